@@ -6,15 +6,50 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes configures the API routes
-func SetupRoutes(router *gin.Engine, productController *controllers.ProductController, healthController *controllers.HealthController) {
+// SetupRoutes configures the API routes; eventMiddleware runs only on the
+// event submission routes, e.g. API key quotas
+func SetupRoutes(router *gin.Engine, productController *controllers.ProductController, healthController *controllers.HealthController, eventMiddleware ...gin.HandlerFunc) {
 	// Health check
 	router.GET("/health", healthController.Health)
+	router.GET("/health/detailed", healthController.DetailedHealth)
+	router.GET("/ready", healthController.Ready)
 
 	// API v1 routes
 	api := router.Group("/api/v1")
 	{
-		api.POST("/events", productController.HandleEvent)
+		events := api.Group("/events", eventMiddleware...)
+		events.POST("", productController.HandleEvent)
+		events.POST("/batch", productController.HandleBatch)
 		api.GET("/products/:id", productController.GetProduct)
 	}
 }
+
+// ConfigureTrustedProxies sets which proxies may supply the client IP via
+// X-Forwarded-For; with none configured the connection's remote address is used
+func ConfigureTrustedProxies(router *gin.Engine, trustedProxies []string) error {
+	return router.SetTrustedProxies(trustedProxies)
+}
+
+// SetupAdminRoutes configures the operational admin routes
+func SetupAdminRoutes(router *gin.Engine, adminController *controllers.AdminController) {
+	admin := router.Group("/admin")
+	{
+		admin.GET("/circuit-breaker", adminController.GetCircuitBreaker)
+		admin.PUT("/circuit-breaker", adminController.UpdateCircuitBreaker)
+		admin.POST("/batch/flush", adminController.FlushBatch)
+		admin.GET("/diagnostics", adminController.Diagnostics)
+		admin.GET("/config", adminController.GetConfig)
+		admin.GET("/workers", adminController.Workers)
+		admin.GET("/queue/dump", adminController.DumpQueue)
+		admin.POST("/queue/purge", adminController.PurgeQueue)
+		admin.GET("/repository/size", adminController.RepositorySize)
+		admin.POST("/products/:id/replay", adminController.ReplayProduct)
+		admin.POST("/wal/replay", adminController.ReplayLog)
+	}
+}
+
+// SetupMetricsRoutes configures the metrics scrape endpoint
+func SetupMetricsRoutes(router *gin.Engine, metricsController *controllers.MetricsController) {
+	router.GET("/metrics", metricsController.Metrics)
+	router.GET("/metrics.json", metricsController.MetricsJSON)
+}
@@ -76,3 +76,79 @@ func TestSetupRoutes_WithRealControllers(t *testing.T) {
 
 	SetupRoutes(router, nil, nil)
 }
+
+func TestSetupRoutes_EventMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	SetupRoutes(router, nil, nil, func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusTooManyRequests)
+	})
+
+	tests := []struct {
+		method  string
+		path    string
+		limited bool
+	}{
+		{"POST", "/api/v1/events", true},
+		{"POST", "/api/v1/events/batch", true},
+		{"GET", "/api/v1/products/test-id", false},
+		{"GET", "/health", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if limited := w.Code == http.StatusTooManyRequests; limited != tt.limited {
+			t.Errorf("Expected event middleware on %s %s: %v, got status %d", tt.method, tt.path, tt.limited, w.Code)
+		}
+	}
+}
+
+func TestConfigureTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resolveIP := func(trustedProxies []string) string {
+		router := gin.New()
+		if err := ConfigureTrustedProxies(router, trustedProxies); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		router.GET("/ip", func(c *gin.Context) {
+			c.String(http.StatusOK, c.ClientIP())
+		})
+
+		// A request from the load balancer on behalf of a real client
+		req, _ := http.NewRequest("GET", "/ip", nil)
+		req.RemoteAddr = "10.0.0.5:43210"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	t.Run("WithoutTrustedProxies", func(t *testing.T) {
+		if ip := resolveIP(nil); ip != "10.0.0.5" {
+			t.Errorf("Expected remote address 10.0.0.5 when no proxies are trusted, got %s", ip)
+		}
+	})
+
+	t.Run("WithTrustedProxyCIDR", func(t *testing.T) {
+		if ip := resolveIP([]string{"10.0.0.0/8"}); ip != "203.0.113.7" {
+			t.Errorf("Expected forwarded client IP 203.0.113.7, got %s", ip)
+		}
+	})
+
+	t.Run("UntrustedProxy", func(t *testing.T) {
+		if ip := resolveIP([]string{"192.168.0.0/16"}); ip != "10.0.0.5" {
+			t.Errorf("Expected remote address 10.0.0.5 from an untrusted proxy, got %s", ip)
+		}
+	})
+
+	t.Run("InvalidCIDR", func(t *testing.T) {
+		if err := ConfigureTrustedProxies(gin.New(), []string{"not-a-cidr"}); err == nil {
+			t.Error("Expected error for an invalid trusted proxy")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -9,9 +10,15 @@ import (
 
 	"product-service/internal/config"
 	"product-service/internal/controllers"
+	"product-service/internal/middleware"
+	"product-service/internal/models"
 	"product-service/internal/repositories"
 	"product-service/internal/services"
+	"product-service/pkg/logging"
+	"product-service/pkg/metrics"
 	"product-service/pkg/queue"
+	"product-service/pkg/ratelimit"
+	"product-service/pkg/tracing"
 
 	v1 "product-service/api/v1"
 
@@ -19,33 +26,332 @@ import (
 )
 
 func main() {
-	// load the config
-	cfg := config.LoadConfig()
-
 	logger := log.New(os.Stdout, "[MAIN] ", log.LstdFlags)
+
+	// load the config, layering the optional config file under the environment
+	configFile := os.Getenv("CONFIG_FILE")
+	if configFile != "" {
+		if err := config.LoadFile(configFile); err != nil {
+			logger.Fatalf("Failed to read config file %s: %v", configFile, err)
+		}
+	}
+	cfg := config.LoadConfig()
 	logger.Printf("Starting application with %d workers, queue size %d", cfg.Workers, cfg.QueueSize)
 
+	logLevel, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	logging.SetLevel(logLevel)
+
+	shutdownTracing, err := tracing.Setup(cfg.TracingExporter)
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+
 	// initialize the dependencies
-	productRepo := repositories.NewInMemoryProductRepository()
-	eventQueue := queue.NewInMemoryEventQueue(cfg.QueueSize)
+	storage, err := repositories.NewProductRepository(cfg)
+	if err != nil {
+		logger.Fatalf("Failed to initialize storage: %v", err)
+	}
+	var productRepo services.ProductRepository = storage
+	registry := metrics.NewRegistry()
+	if cfg.RepositoryMetricsEnabled {
+		productRepo = repositories.NewInstrumentedProductRepository(productRepo, registry)
+	}
+	eventQueue, err := queue.NewEventQueue(cfg)
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
 	productService := services.NewProductService(productRepo, eventQueue, cfg.Workers)
+	productService.SetMetrics(registry)
+	productService.SetDeadLetterQueue(queue.NewDeadLetterQueue(cfg.DeadLetterQueueSize))
+	productService.SetDeadLetterThresholds(services.DeadLetterThresholds{
+		Degraded:  cfg.DLQDegradedDepth,
+		Unhealthy: cfg.DLQUnhealthyDepth,
+	})
+	productService.SetRetryExhaustionThreshold(cfg.RetryExhaustionRate)
+	if err := productService.CircuitBreakers().SetThreshold(cfg.CircuitBreakerThreshold); err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := productService.CircuitBreakers().SetTimeout(cfg.CircuitBreakerTimeout); err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := productService.CircuitBreakers().SetLatencyThreshold(cfg.CircuitBreakerSlowCallThreshold, cfg.CircuitBreakerSlowCallRate, cfg.CircuitBreakerSlowCallWindow); err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := productService.CircuitBreakers().SetSuccessStreak(cfg.CircuitBreakerSuccessStreak); err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.CircuitBreakerForceOpen {
+		productService.CircuitBreakers().ForceOpen()
+		logger.Printf("Circuit breaker forced open; no events are processed until it is released through PUT /admin/circuit-breaker")
+	}
+	enqueueRetry := productService.EnqueueRetry()
+	if cfg.EnqueueRetryAttempts > 0 {
+		enqueueRetry.MaxAttempts = cfg.EnqueueRetryAttempts
+	}
+	enqueueRetry.InitialDelay = cfg.EnqueueRetryDelay
+	productService.SetEnqueueRetry(enqueueRetry)
+	productService.SetStallThreshold(cfg.StallThreshold)
+	productService.SetProcessTimeout(cfg.EventProcessTimeout)
+	productService.SetDrainTimeout(cfg.DrainTimeout)
+	productService.SetAbortRetriesOnStop(cfg.RetryAbortOnStop)
+	if cfg.StrictFIFO {
+		logger.Println("Strict FIFO enabled: using a single worker without load shedding or autoscaling")
+		productService.SetStrictFIFO(true)
+	}
+	if cfg.ProductLockStripes > 0 {
+		productService.SetProductLock(services.NewProductLock(cfg.ProductLockStripes))
+	}
+
+	panicPolicy, err := services.ParsePanicPolicy(cfg.PanicPolicy)
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	productService.SetPanicPolicy(panicPolicy)
+
+	batchPolicy, err := services.ParseBatchConflictPolicy(cfg.BatchConflictPolicy)
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	productService.SetBatchConflictPolicy(batchPolicy)
+
+	var batchProcessor *queue.BatchProcessor
+	if cfg.BatchModeEnabled {
+		batchProcessor = queue.NewBatchProcessor(cfg.BatchSize, cfg.BatchFlushInterval, 10, 1, productService.ApplyBatch)
+		batchProcessor.SetMaxBatchBytes(cfg.BatchMaxBytes)
+		productService.SetBatchProcessor(batchProcessor)
+	}
+
+	if cfg.DedupWindow > 0 {
+		deduplicator := services.NewDeduplicator(cfg.DedupWindow)
+		if cfg.WALPath != "" && cfg.DedupRestoreFromWAL {
+			// A log we cannot read only weakens dedup, so start with an empty window
+			if restored, err := deduplicator.RestoreFromLog(cfg.WALPath); err != nil {
+				logger.Printf("WARNING: %v; duplicates of updates applied before the restart may be re-applied", err)
+			} else {
+				logger.Printf("Restored %d recently applied updates into the deduplication window", restored)
+			}
+		}
+		productService.SetDeduplicator(deduplicator)
+	}
+	if cfg.CoalesceWindow > 0 {
+		productService.SetCoalescer(services.NewCoalescer(cfg.CoalesceWindow))
+	}
+
+	if memoryStore, ok := repositories.Layer[repositories.MemoryStore](storage); ok {
+		productService.SetRepositorySizer(memoryStore)
+	}
+	if sharded, ok := repositories.Layer[*repositories.ShardedProductRepository](storage); ok {
+		productService.SetShardReporter(sharded)
+	}
+
+	var productLimiter *services.ProductLimiter
+	if cfg.MaxProducts > 0 {
+		memoryStore, ok := repositories.Layer[repositories.MemoryStore](storage)
+		if !ok {
+			logger.Fatalf("Invalid configuration: MAX_PRODUCTS is not supported by the %s repository backend", cfg.RepositoryBackend)
+		}
+		productLimiter = services.NewProductLimiter(cfg.MaxProducts, memoryStore)
+		productService.SetProductLimiter(productLimiter)
+	}
+
+	if cfg.MaxInFlight > 0 {
+		productService.SetInFlightLimiter(services.NewInFlightLimiter(cfg.MaxInFlight))
+	}
+
+	if cfg.AdmissionLowPriorityWatermark > 0 {
+		productService.SetAdmissionPolicy(services.NewAdmissionPolicy(cfg.AdmissionLowPriorityWatermark))
+	}
+
+	if cfg.LoadSheddingEnabled {
+		productService.SetLoadShedder(services.NewLoadShedder(cfg.LoadSheddingWatermark, cfg.LoadSheddingProbability))
+	}
+
+	if cfg.TargetEPS != 0 {
+		throttle, err := ratelimit.NewLimiter(cfg.TargetEPS)
+		if err != nil {
+			logger.Fatalf("Invalid configuration: TARGET_EPS %v: %v", cfg.TargetEPS, err)
+		}
+		logger.Printf("Pacing event processing to %.2f events/sec", cfg.TargetEPS)
+		productService.SetThrottle(throttle)
+	}
+
+	if cfg.ChaosFailureRate > 0 || cfg.ChaosLatency > 0 {
+		chaos, err := services.NewChaosInjector(cfg.ChaosFailureRate, cfg.ChaosLatency)
+		if err != nil {
+			logger.Fatalf("Invalid configuration: %v", err)
+		}
+		logger.Printf("WARNING: chaos mode enabled, failing %.0f%% of processing attempts and delaying each by up to %v",
+			cfg.ChaosFailureRate*100, cfg.ChaosLatency)
+		productService.SetChaos(chaos)
+	}
+
+	if cfg.PriceBandEnabled {
+		productService.SetEventValidator(services.PriceBandValidator(cfg.PriceBandMin, cfg.PriceBandMax))
+	}
+
+	var auditLogger *services.AuditLogger
+	if cfg.AuditLogPath != "" {
+		auditLogger, err = services.OpenAuditLog(cfg.AuditLogPath)
+		if err != nil {
+			logger.Fatalf("Invalid configuration: %v", err)
+		}
+		productService.SetAuditLogger(auditLogger)
+	}
+
+	transformers, err := services.ParseEventTransformers(cfg.EventTransforms)
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.PriceMode == models.PriceModeFixed {
+		transformers = append(transformers, services.FixedPricePlaces(cfg.PriceDecimalPlaces))
+	}
+	productService.SetEventTransformers(transformers...)
 
 	// initialize the controllers
 	productController := controllers.NewProductController(productService)
+	productIDRules, err := models.NewProductIDRules(cfg.ProductIDMaxLength, cfg.ProductIDPattern)
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	productController.SetProductIDRules(productIDRules)
+	if err := productController.SetBaseCurrency(cfg.BaseCurrency); err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	switch cfg.PriceMode {
+	case models.PriceModeFloat:
+	case models.PriceModeFixed:
+		if err := productController.SetFixedPricePlaces(cfg.PriceDecimalPlaces); err != nil {
+			logger.Fatalf("Invalid configuration: %v", err)
+		}
+	default:
+		logger.Fatalf("Invalid configuration: unknown price mode %q, expected %q or %q", cfg.PriceMode, models.PriceModeFloat, models.PriceModeFixed)
+	}
 	healthController := controllers.NewHealthController()
+	healthController.SetProductService(productService)
+	adminController := controllers.NewAdminController(productService)
+	adminController.SetConfig(cfg)
+	if cfg.WALPath != "" {
+		adminController.SetLogReplayer(services.NewLogReplayer(cfg.WALPath, productService))
+	}
+	metricsController := controllers.NewMetricsController(productService)
 
 	// setup the gin router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	if err := v1.ConfigureTrustedProxies(router, cfg.TrustedProxies); err != nil {
+		logger.Fatalf("Invalid trusted proxies %v: %v", cfg.TrustedProxies, err)
+	}
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(middleware.Recovery(log.New(os.Stdout, "[RECOVERY] ", log.LstdFlags)))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Metrics(registry))
+	router.Use(middleware.ReadinessGate(productService.Ready))
+	router.Use(middleware.ShedReads(eventQueue.Len, cfg.ReadShedWatermark))
+	router.Use(middleware.Tracing())
+	router.Use(middleware.Timeout(cfg.HandlerTimeout))
+	router.Use(middleware.ResponseEnvelope(cfg.ResponseEnvelope))
 
 	// setup the routes
-	v1.SetupRoutes(router, productController, healthController)
+	var eventMiddleware []gin.HandlerFunc
+	if len(cfg.APIKeyQuotas) > 0 || cfg.APIKeyDefaultQuota >= 0 {
+		quotas, err := middleware.ParseAPIKeyQuotas(cfg.APIKeyQuotas)
+		if err != nil {
+			logger.Fatalf("Invalid configuration: %v", err)
+		}
+		eventMiddleware = append(eventMiddleware, middleware.APIKeyQuota(middleware.NewQuotaLimiter(quotas, cfg.APIKeyDefaultQuota, cfg.QuotaWindow)))
+	}
+	v1.SetupRoutes(router, productController, healthController, eventMiddleware...)
+	v1.SetupAdminRoutes(router, adminController)
+	v1.SetupMetricsRoutes(router, metricsController)
+
+	// verify the dependencies before accepting any traffic
+	selfCheckCtx, cancelSelfCheck := context.WithTimeout(context.Background(), cfg.SelfCheckTimeout)
+	err = productService.SelfCheck(selfCheckCtx)
+	cancelSelfCheck()
+	if err != nil {
+		logger.Fatalf("Startup self-check failed:\n%v", err)
+	}
 
 	// start the product service
 	productService.Start()
 
+	// optionally scale the workers with the queue depth
+	var autoscaler *services.WorkerAutoscaler
+	if cfg.AutoscaleEnabled && !cfg.StrictFIFO {
+		autoscaler = services.NewWorkerAutoscaler(productService.WorkerPool(), eventQueue,
+			cfg.MinWorkers, cfg.MaxWorkers, cfg.WorkerIdleTimeout, cfg.AutoscaleInterval)
+		autoscaler.Start()
+	}
+
+	var auditor *services.ConsistencyAuditor
+	if cfg.AuditInterval > 0 {
+		if memoryStore, ok := repositories.Layer[repositories.MemoryStore](storage); ok {
+			// Evict through the full stack so the cache and write-ahead log see the removal
+			evicter, _ := productRepo.(services.ProductEvicter)
+			if cfg.AuditEvict && evicter == nil {
+				logger.Fatalf("Invalid configuration: AUDIT_EVICT is not supported by the %s repository backend", cfg.RepositoryBackend)
+			}
+			auditor = services.NewConsistencyAuditor(memoryStore, evicter, cfg.AuditInterval, cfg.AuditStaleAfter, cfg.AuditEvict, registry)
+			auditor.SetProductLimiter(productLimiter)
+			auditor.Start()
+		} else {
+			logger.Printf("Consistency audit is not supported by the %s repository backend, skipping", cfg.RepositoryBackend)
+		}
+	}
+
+	var janitor *services.TombstoneJanitor
+	switch cfg.DeleteMode {
+	case "hard":
+	case "soft":
+		memoryStore, ok := repositories.Layer[repositories.MemoryStore](storage)
+		if !ok {
+			logger.Fatalf("Invalid configuration: soft delete is not supported by the %s repository backend", cfg.RepositoryBackend)
+		}
+		memoryStore.SetSoftDelete(true)
+		janitor = services.NewTombstoneJanitor(memoryStore, cfg.TombstoneRetention, cfg.TombstonePurgeInterval)
+		janitor.SetProductLimiter(productLimiter)
+		janitor.Start()
+	default:
+		logger.Fatalf("Invalid configuration: unknown delete mode %q, expected \"hard\" or \"soft\"", cfg.DeleteMode)
+	}
+
+	var backlogMonitor *services.BacklogMonitor
+	if cfg.BacklogAlertEnabled {
+		backlogMonitor = services.NewBacklogMonitor(eventQueue, cfg.BacklogAlertThreshold,
+			cfg.BacklogAlertDuration, cfg.BacklogAlertInterval, registry)
+		productService.SetBacklogMonitor(backlogMonitor)
+		backlogMonitor.Start()
+	}
+
+	var canary *services.Canary
+	if cfg.CanaryEnabled {
+		canary = services.NewCanary(productService, cfg.CanaryInterval, cfg.CanaryTimeout)
+		productService.SetCanary(canary)
+		canary.Start()
+	}
+
+	var metricsLogger *services.MetricsLogger
+	if cfg.MetricsLogInterval > 0 {
+		metricsLogger = services.NewMetricsLogger(productService, cfg.MetricsLogInterval)
+		metricsLogger.Start()
+	}
+
+	// reload the runtime-safe settings on SIGHUP
+	configReloader := newReloader(cfg, configFile, productService, adminController, logger)
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go func() {
+		for range reloadChan {
+			logger.Println("Received reload signal")
+			if _, err := configReloader.Reload(); err != nil {
+				logger.Printf("Reload failed, keeping current settings: %v", err)
+			}
+		}
+	}()
+
 	// setup the graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -53,7 +359,33 @@ func main() {
 	go func() {
 		<-sigChan
 		logger.Println("Received shutdown signal")
+		if autoscaler != nil {
+			autoscaler.Stop()
+		}
+		if auditor != nil {
+			auditor.Stop()
+		}
+		if backlogMonitor != nil {
+			backlogMonitor.Stop()
+		}
+		if canary != nil {
+			canary.Stop()
+		}
+		if janitor != nil {
+			janitor.Stop()
+		}
+		if metricsLogger != nil {
+			metricsLogger.Stop()
+		}
+		// Stop also flushes the batch processor within the drain timeout
 		productService.Stop()
+		if walRepo, ok := repositories.Layer[*repositories.WALProductRepository](storage); ok {
+			walRepo.Close()
+		}
+		if auditLogger != nil {
+			auditLogger.Close()
+		}
+		shutdownTracing(context.Background())
 		os.Exit(0)
 	}()
 
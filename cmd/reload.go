@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"product-service/internal/config"
+	"product-service/internal/controllers"
+	"product-service/internal/services"
+	"product-service/pkg/logging"
+)
+
+// reloader applies the runtime-safe subset of a freshly loaded configuration
+//
+// Only the log level, circuit breaker tuning, stall threshold and event
+// processing timeout are reloaded; every other setting still needs a restart.
+type reloader struct {
+	mu              sync.Mutex
+	current         *config.Config
+	configFile      string
+	productService  *services.ProductService
+	adminController *controllers.AdminController
+	logger          *log.Logger
+}
+
+// newReloader creates a reloader starting from the configuration loaded at startup
+func newReloader(current *config.Config, configFile string, productService *services.ProductService,
+	adminController *controllers.AdminController, logger *log.Logger) *reloader {
+	return &reloader{
+		current:         current,
+		configFile:      configFile,
+		productService:  productService,
+		adminController: adminController,
+		logger:          logger,
+	}
+}
+
+// Reload re-reads the config file and environment and applies the runtime-safe settings
+func (r *reloader) Reload() ([]string, error) {
+	if r.configFile != "" {
+		if err := config.LoadFile(r.configFile); err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", r.configFile, err)
+		}
+	}
+	return r.apply(config.LoadConfig())
+}
+
+// apply updates the components whose settings differ in next and returns a description of each change
+func (r *reloader) apply(next *config.Config) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Validate everything up front so a bad value leaves the running settings untouched
+	level, err := logging.ParseLevel(next.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	if next.CircuitBreakerThreshold <= 0 {
+		return nil, fmt.Errorf("invalid circuit breaker threshold %d", next.CircuitBreakerThreshold)
+	}
+	if next.CircuitBreakerTimeout <= 0 {
+		return nil, fmt.Errorf("invalid circuit breaker timeout %v", next.CircuitBreakerTimeout)
+	}
+
+	updated := *r.current
+	var changes []string
+
+	if next.LogLevel != r.current.LogLevel {
+		logging.SetLevel(level)
+		updated.LogLevel = next.LogLevel
+		changes = append(changes, fmt.Sprintf("LOG_LEVEL: %s -> %s", r.current.LogLevel, next.LogLevel))
+	}
+
+	breaker := r.productService.CircuitBreakers()
+	if next.CircuitBreakerThreshold != r.current.CircuitBreakerThreshold {
+		if err := breaker.SetThreshold(next.CircuitBreakerThreshold); err != nil {
+			return changes, err
+		}
+		updated.CircuitBreakerThreshold = next.CircuitBreakerThreshold
+		changes = append(changes, fmt.Sprintf("CIRCUIT_BREAKER_THRESHOLD: %d -> %d",
+			r.current.CircuitBreakerThreshold, next.CircuitBreakerThreshold))
+	}
+	if next.CircuitBreakerTimeout != r.current.CircuitBreakerTimeout {
+		if err := breaker.SetTimeout(next.CircuitBreakerTimeout); err != nil {
+			return changes, err
+		}
+		updated.CircuitBreakerTimeout = next.CircuitBreakerTimeout
+		changes = append(changes, fmt.Sprintf("CIRCUIT_BREAKER_TIMEOUT: %v -> %v",
+			r.current.CircuitBreakerTimeout, next.CircuitBreakerTimeout))
+	}
+
+	if next.StallThreshold != r.current.StallThreshold {
+		r.productService.SetStallThreshold(next.StallThreshold)
+		updated.StallThreshold = next.StallThreshold
+		changes = append(changes, fmt.Sprintf("STALL_THRESHOLD: %v -> %v", r.current.StallThreshold, next.StallThreshold))
+	}
+	if next.EventProcessTimeout != r.current.EventProcessTimeout {
+		r.productService.SetProcessTimeout(next.EventProcessTimeout)
+		updated.EventProcessTimeout = next.EventProcessTimeout
+		changes = append(changes, fmt.Sprintf("EVENT_PROCESS_TIMEOUT: %v -> %v",
+			r.current.EventProcessTimeout, next.EventProcessTimeout))
+	}
+
+	r.current = &updated
+	if r.adminController != nil {
+		r.adminController.SetConfig(r.current)
+	}
+
+	for _, change := range changes {
+		r.logger.Printf("Reloaded %s", change)
+	}
+	if len(changes) == 0 {
+		r.logger.Println("Reload found no runtime-safe settings to change")
+	}
+	return changes, nil
+}
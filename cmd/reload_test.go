@@ -0,0 +1,98 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"product-service/internal/config"
+	"product-service/internal/repositories"
+	"product-service/internal/services"
+	"product-service/pkg/logging"
+	"product-service/pkg/queue"
+)
+
+func newTestReloader(t *testing.T, configFile string) (*reloader, *services.ProductService) {
+	t.Helper()
+
+	previous := logging.GetLevel()
+	t.Cleanup(func() { logging.SetLevel(previous) })
+	logging.SetLevel(logging.LevelInfo)
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	return newReloader(config.LoadConfig(), configFile, productService, nil, log.New(io.Discard, "", 0)), productService
+}
+
+func TestReloader_Reload(t *testing.T) {
+	t.Run("AppliesChangedLogLevel", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "info")
+		r, _ := newTestReloader(t, "")
+
+		t.Setenv("LOG_LEVEL", "debug")
+		changes, err := r.Reload()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if logging.GetLevel() != logging.LevelDebug {
+			t.Errorf("Expected log level debug after reload, got %s", logging.GetLevel())
+		}
+		if !logging.Enabled(logging.LevelDebug) {
+			t.Error("Expected debug logging to be enabled after reload")
+		}
+		if len(changes) != 1 || changes[0] != "LOG_LEVEL: info -> debug" {
+			t.Errorf("Expected a single log level change, got %v", changes)
+		}
+	})
+
+	t.Run("AppliesCircuitBreakerSettings", func(t *testing.T) {
+		t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "5")
+		t.Setenv("CIRCUIT_BREAKER_TIMEOUT", "60s")
+		r, productService := newTestReloader(t, "")
+
+		t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "9")
+		t.Setenv("CIRCUIT_BREAKER_TIMEOUT", "5s")
+		if _, err := r.Reload(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		breaker := productService.CircuitBreaker()
+		if breaker.GetThreshold() != 9 {
+			t.Errorf("Expected threshold 9, got %d", breaker.GetThreshold())
+		}
+		if breaker.GetTimeout() != 5*time.Second {
+			t.Errorf("Expected timeout 5s, got %v", breaker.GetTimeout())
+		}
+	})
+
+	t.Run("ReadsConfigFile", func(t *testing.T) {
+		// Left empty, as the file never overrides the real environment
+		t.Setenv("LOG_LEVEL", "")
+		path := filepath.Join(t.TempDir(), "service.env")
+		r, _ := newTestReloader(t, path)
+
+		if err := os.WriteFile(path, []byte("# runtime overrides\nLOG_LEVEL=\"warn\"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if _, err := r.Reload(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if logging.GetLevel() != logging.LevelWarn {
+			t.Errorf("Expected log level warn from config file, got %s", logging.GetLevel())
+		}
+	})
+
+	t.Run("RejectsInvalidLevel", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "info")
+		r, _ := newTestReloader(t, "")
+
+		t.Setenv("LOG_LEVEL", "verbose")
+		if _, err := r.Reload(); err == nil {
+			t.Error("Expected an error for an unknown log level")
+		}
+		if logging.GetLevel() != logging.LevelInfo {
+			t.Errorf("Expected log level to stay info, got %s", logging.GetLevel())
+		}
+	})
+}
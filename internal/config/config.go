@@ -1,56 +1,337 @@
 package config
 
 import (
+	"math"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // config holds application configuration
 type Config struct {
-	Workers   int
-	QueueSize int
-	Port      string
+	Workers      int
+	QueueSize    int
+	MaxQueueSize int
+	MaxInFlight  int
+	QueueBackend string
+	Port         string
+	StrictFIFO   bool
+
+	// Same-product serialization
+	ProductLockStripes int
+
+	// Startup and shutdown
+	SelfCheckTimeout time.Duration
+	DrainTimeout     time.Duration
 
 	// High throughput configuration
+	BatchModeEnabled   bool
 	BatchSize          int
 	BatchFlushInterval time.Duration
+	BatchMaxBytes      int
 
 	// Error handling configuration
-	MaxRetryAttempts        int
-	InitialRetryDelay       time.Duration
-	MaxRetryDelay           time.Duration
-	CircuitBreakerThreshold int
-	CircuitBreakerTimeout   time.Duration
+	MaxRetryAttempts                int
+	InitialRetryDelay               time.Duration
+	MaxRetryDelay                   time.Duration
+	RetryAbortOnStop                bool
+	CircuitBreakerThreshold         int
+	CircuitBreakerTimeout           time.Duration
+	CircuitBreakerSlowCallThreshold time.Duration
+	CircuitBreakerSlowCallRate      float64
+	CircuitBreakerSlowCallWindow    int
+	CircuitBreakerForceOpen         bool
+	CircuitBreakerSuccessStreak     int
+	EnqueueRetryAttempts            int
+	EnqueueRetryDelay               time.Duration
+
+	// Processing rate; zero is unlimited
+	TargetEPS float64
 
 	// Memory management
 	MaxMemoryUsage   int64
 	CleanupThreshold float64
 	GCInterval       time.Duration
+
+	// Worker autoscaling
+	AutoscaleEnabled  bool
+	MinWorkers        int
+	MaxWorkers        int
+	WorkerIdleTimeout time.Duration
+	AutoscaleInterval time.Duration
+
+	// Storage
+	RepositoryBackend string
+	RepositoryShards  int
+	WALPath           string
+	MaxProducts       int
+
+	// Deletion
+	DeleteMode             string
+	TombstoneRetention     time.Duration
+	TombstonePurgeInterval time.Duration
+
+	// Read cache
+	CacheSize int
+	CacheTTL  time.Duration
+
+	// Consistency audit
+	AuditInterval   time.Duration
+	AuditStaleAfter time.Duration
+	AuditEvict      bool
+
+	// Mutation audit log
+	AuditLogPath string
+
+	// Slow-consumer alert
+	BacklogAlertEnabled   bool
+	BacklogAlertThreshold int
+	BacklogAlertDuration  time.Duration
+	BacklogAlertInterval  time.Duration
+	StallThreshold        time.Duration
+
+	// Pipeline canary
+	CanaryEnabled  bool
+	CanaryInterval time.Duration
+	CanaryTimeout  time.Duration
+
+	// Observability
+	LogLevel                 string
+	RepositoryMetricsEnabled bool
+	TracingExporter          string
+	MetricsLogInterval       time.Duration
+
+	// Failure handling
+	PanicPolicy         string
+	DeadLetterQueueSize int
+	EventProcessTimeout time.Duration
+	DLQDegradedDepth    int
+	DLQUnhealthyDepth   int
+	RetryExhaustionRate float64
+
+	// Load shedding
+	LoadSheddingEnabled     bool
+	LoadSheddingWatermark   int
+	LoadSheddingProbability float64
+	ReadShedWatermark       int
+
+	// Admission
+	AdmissionLowPriorityWatermark int
+
+	// Chaos mode
+	ChaosFailureRate float64
+	ChaosLatency     time.Duration
+
+	// API responses
+	ResponseEnvelope bool
+
+	// Batch ingestion
+	BatchConflictPolicy string
+
+	// Deduplication
+	DedupWindow         time.Duration
+	DedupRestoreFromWAL bool
+	CoalesceWindow      time.Duration
+
+	// Validation
+	ProductIDMaxLength int
+	ProductIDPattern   string
+	PriceBandEnabled   bool
+	PriceBandMin       float64
+	PriceBandMax       float64
+
+	// Transformation
+	EventTransforms []string
+
+	// Pricing
+	BaseCurrency       string
+	PriceMode          string
+	PriceDecimalPlaces int
+
+	// Networking
+	TrustedProxies []string
+	HandlerTimeout time.Duration
+
+	// API key quotas; the keys themselves are redacted by name
+	APIKeyQuotas       []string
+	APIKeyDefaultQuota int
+	QuotaWindow        time.Duration
+
+	// Outbound HTTP client
+	HTTPClientTimeout               time.Duration
+	HTTPClientDialTimeout           time.Duration
+	HTTPClientTLSHandshakeTimeout   time.Duration
+	HTTPClientResponseHeaderTimeout time.Duration
+	HTTPClientIdleConnTimeout       time.Duration
+	HTTPClientMaxIdleConns          int
+	HTTPClientMaxIdleConnsPerHost   int
+	HTTPClientMaxConnsPerHost       int
 }
 
 // load the config from the environment variables
 func LoadConfig() *Config {
+	workers := getEnvInt("WORKERS", 3)
+	queueSize := getEnvInt("QUEUE_SIZE", 1000)
+
 	return &Config{
-		Workers:   getEnvInt("WORKERS", 3),
-		QueueSize: getEnvInt("QUEUE_SIZE", 1000),
-		Port:      getEnv("PORT", "8080"),
+		Workers:      workers,
+		QueueSize:    queueSize,
+		MaxQueueSize: getEnvInt("MAX_QUEUE_SIZE", 1000000),
+		MaxInFlight:  getEnvInt("MAX_IN_FLIGHT", 0),
+		QueueBackend: getEnv("QUEUE_BACKEND", "memory"),
+		Port:         getEnv("PORT", "8080"),
+		StrictFIFO:   getEnvBool("STRICT_FIFO", false),
+
+		// Same-product serialization
+		ProductLockStripes: getEnvInt("PRODUCT_LOCK_STRIPES", 0),
+
+		// Startup and shutdown
+		SelfCheckTimeout: getEnvDuration("SELF_CHECK_TIMEOUT", 5*time.Second),
+		DrainTimeout:     getEnvDuration("DRAIN_TIMEOUT", 30*time.Second),
 
 		// High throughput configuration
+		BatchModeEnabled:   getEnvBool("BATCH_MODE_ENABLED", false),
 		BatchSize:          getEnvInt("BATCH_SIZE", 100),
 		BatchFlushInterval: getEnvDuration("BATCH_FLUSH_INTERVAL", 1*time.Second),
+		BatchMaxBytes:      getEnvInt("BATCH_MAX_BYTES", 0),
 
 		// Error handling configuration
-		MaxRetryAttempts:        getEnvInt("MAX_RETRY_ATTEMPTS", 3),
-		InitialRetryDelay:       getEnvDuration("INITIAL_RETRY_DELAY", 100*time.Millisecond),
-		MaxRetryDelay:           getEnvDuration("MAX_RETRY_DELAY", 30*time.Second),
-		CircuitBreakerThreshold: getEnvInt("CIRCUIT_BREAKER_THRESHOLD", 5),
-		CircuitBreakerTimeout:   getEnvDuration("CIRCUIT_BREAKER_TIMEOUT", 60*time.Second),
+		MaxRetryAttempts:                getEnvInt("MAX_RETRY_ATTEMPTS", 3),
+		InitialRetryDelay:               getEnvDuration("INITIAL_RETRY_DELAY", 100*time.Millisecond),
+		MaxRetryDelay:                   getEnvDuration("MAX_RETRY_DELAY", 30*time.Second),
+		RetryAbortOnStop:                getEnvBool("RETRY_ABORT_ON_STOP", true),
+		CircuitBreakerThreshold:         getEnvInt("CIRCUIT_BREAKER_THRESHOLD", 5),
+		CircuitBreakerTimeout:           getEnvDuration("CIRCUIT_BREAKER_TIMEOUT", 60*time.Second),
+		CircuitBreakerSlowCallThreshold: getEnvDuration("CIRCUIT_BREAKER_SLOW_CALL_THRESHOLD", 0),
+		CircuitBreakerSlowCallRate:      getEnvFloat64InRange("CIRCUIT_BREAKER_SLOW_CALL_RATE", 0.5, 0, 1),
+		CircuitBreakerSlowCallWindow:    getEnvInt("CIRCUIT_BREAKER_SLOW_CALL_WINDOW", 20),
+		CircuitBreakerForceOpen:         getEnvBool("CIRCUIT_BREAKER_FORCE_OPEN", false),
+		CircuitBreakerSuccessStreak:     getEnvInt("CIRCUIT_BREAKER_SUCCESS_STREAK", 1),
+		EnqueueRetryAttempts:            getEnvInt("ENQUEUE_RETRY_ATTEMPTS", 3),
+		EnqueueRetryDelay:               getEnvDuration("ENQUEUE_RETRY_DELAY", 100*time.Millisecond),
+
+		// Processing rate; zero is unlimited
+		TargetEPS: getEnvFloat64InRange("TARGET_EPS", 0, 0, math.MaxFloat64),
 
 		// Memory management
 		MaxMemoryUsage:   getEnvInt64("MAX_MEMORY_USAGE", 1024*1024*1024), // 1GB
-		CleanupThreshold: getEnvFloat64("CLEANUP_THRESHOLD", 0.8),
+		CleanupThreshold: getEnvFloat64InRange("CLEANUP_THRESHOLD", 0.8, 0, 1),
 		GCInterval:       getEnvDuration("GC_INTERVAL", 30*time.Second),
+
+		// Worker autoscaling
+		AutoscaleEnabled:  getEnvBool("AUTOSCALE_ENABLED", false),
+		MinWorkers:        getEnvInt("MIN_WORKERS", 1),
+		MaxWorkers:        getEnvInt("MAX_WORKERS", workers),
+		WorkerIdleTimeout: getEnvDuration("WORKER_IDLE_TIMEOUT", 30*time.Second),
+		AutoscaleInterval: getEnvDuration("AUTOSCALE_INTERVAL", 1*time.Second),
+
+		// Storage
+		RepositoryBackend: getEnv("REPOSITORY_BACKEND", "memory"),
+		RepositoryShards:  getEnvInt("REPOSITORY_SHARDS", 16),
+		WALPath:           getEnv("WAL_PATH", ""),
+		MaxProducts:       getEnvInt("MAX_PRODUCTS", 0),
+
+		// Deletion
+		DeleteMode:             getEnv("DELETE_MODE", "hard"),
+		TombstoneRetention:     getEnvDuration("TOMBSTONE_RETENTION", 24*time.Hour),
+		TombstonePurgeInterval: getEnvDuration("TOMBSTONE_PURGE_INTERVAL", time.Minute),
+
+		// Read cache
+		CacheSize: getEnvInt("CACHE_SIZE", 0),
+		CacheTTL:  getEnvDuration("CACHE_TTL", 30*time.Second),
+
+		// Consistency audit
+		AuditInterval:   getEnvDuration("AUDIT_INTERVAL", 0),
+		AuditStaleAfter: getEnvDuration("AUDIT_STALE_AFTER", 0),
+		AuditEvict:      getEnvBool("AUDIT_EVICT", false),
+
+		// Mutation audit log
+		AuditLogPath: getEnv("AUDIT_LOG_PATH", ""),
+
+		// Slow-consumer alert
+		BacklogAlertEnabled:   getEnvBool("BACKLOG_ALERT_ENABLED", false),
+		BacklogAlertThreshold: getEnvInt("BACKLOG_ALERT_THRESHOLD", 0),
+		BacklogAlertDuration:  getEnvDuration("BACKLOG_ALERT_DURATION", 30*time.Second),
+		BacklogAlertInterval:  getEnvDuration("BACKLOG_ALERT_INTERVAL", 1*time.Second),
+		StallThreshold:        getEnvDuration("STALL_THRESHOLD", time.Minute),
+
+		// Pipeline canary
+		CanaryEnabled:  getEnvBool("CANARY_ENABLED", false),
+		CanaryInterval: getEnvDuration("CANARY_INTERVAL", 30*time.Second),
+		CanaryTimeout:  getEnvDuration("CANARY_TIMEOUT", 5*time.Second),
+
+		// Observability
+		LogLevel:                 getEnv("LOG_LEVEL", "info"),
+		RepositoryMetricsEnabled: getEnvBool("REPOSITORY_METRICS_ENABLED", false),
+		TracingExporter:          getEnv("TRACING_EXPORTER", ""),
+		MetricsLogInterval:       getEnvDuration("METRICS_LOG_INTERVAL", 0),
+
+		// Failure handling
+		PanicPolicy:         getEnv("PANIC_POLICY", "recover"),
+		DeadLetterQueueSize: getEnvInt("DLQ_SIZE", 1000),
+		EventProcessTimeout: getEnvDuration("EVENT_PROCESS_TIMEOUT", 0),
+		DLQDegradedDepth:    getEnvInt("DLQ_DEGRADED_DEPTH", 0),
+		DLQUnhealthyDepth:   getEnvInt("DLQ_UNHEALTHY_DEPTH", 0),
+		RetryExhaustionRate: getEnvFloat64InRange("RETRY_EXHAUSTION_THRESHOLD", 0, 0, 1),
+
+		// Load shedding
+		LoadSheddingEnabled:     getEnvBool("LOAD_SHEDDING_ENABLED", false),
+		LoadSheddingWatermark:   getEnvInt("LOAD_SHEDDING_WATERMARK", queueSize*8/10),
+		LoadSheddingProbability: getEnvFloat64InRange("LOAD_SHEDDING_PROBABILITY", 0.5, 0, 1),
+		ReadShedWatermark:       getEnvInt("READ_SHED_WATERMARK", 0),
+
+		// Admission
+		AdmissionLowPriorityWatermark: getEnvInt("ADMISSION_LOW_PRIORITY_WATERMARK", 0),
+
+		// Chaos mode
+		ChaosFailureRate: getEnvFloat64InRange("CHAOS_FAILURE_RATE", 0, 0, 1),
+		ChaosLatency:     getEnvDuration("CHAOS_LATENCY", 0),
+
+		// API responses
+		ResponseEnvelope: getEnvBool("RESPONSE_ENVELOPE", false),
+
+		// Batch ingestion
+		BatchConflictPolicy: getEnv("BATCH_CONFLICT_POLICY", "last_wins"),
+
+		// Deduplication
+		DedupWindow:         getEnvDuration("DEDUP_WINDOW", 0),
+		DedupRestoreFromWAL: getEnvBool("DEDUP_RESTORE_FROM_WAL", true),
+		CoalesceWindow:      getEnvDuration("COALESCE_WINDOW", 0),
+
+		// Validation
+		ProductIDMaxLength: getEnvInt("PRODUCT_ID_MAX_LENGTH", 128),
+		ProductIDPattern:   getEnv("PRODUCT_ID_PATTERN", `^[A-Za-z0-9._-]+$`),
+		PriceBandEnabled:   getEnvBool("PRICE_BAND_ENABLED", false),
+		PriceBandMin:       getEnvFloat64("PRICE_BAND_MIN", 0),
+		PriceBandMax:       getEnvFloat64("PRICE_BAND_MAX", 1000000),
+
+		// Transformation
+		EventTransforms: getEnvStringSlice("EVENT_TRANSFORMS", nil),
+
+		// Pricing
+		BaseCurrency:       getEnv("BASE_CURRENCY", "USD"),
+		PriceMode:          getEnv("PRICE_MODE", "float"),
+		PriceDecimalPlaces: getEnvInt("PRICE_DECIMAL_PLACES", 2),
+
+		// Networking
+		TrustedProxies: getEnvStringSlice("TRUSTED_PROXIES", nil),
+		HandlerTimeout: getEnvDuration("HANDLER_TIMEOUT", 30*time.Second),
+
+		// API key quotas
+		APIKeyQuotas:       getEnvStringSlice("API_KEY_QUOTAS", nil),
+		APIKeyDefaultQuota: getEnvInt("API_KEY_DEFAULT_QUOTA", -1),
+		QuotaWindow:        getEnvDuration("API_KEY_QUOTA_WINDOW", 24*time.Hour),
+
+		// Outbound HTTP client
+		HTTPClientTimeout:               getEnvDuration("HTTP_CLIENT_TIMEOUT", 10*time.Second),
+		HTTPClientDialTimeout:           getEnvDuration("HTTP_CLIENT_DIAL_TIMEOUT", 5*time.Second),
+		HTTPClientTLSHandshakeTimeout:   getEnvDuration("HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT", 5*time.Second),
+		HTTPClientResponseHeaderTimeout: getEnvDuration("HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT", 5*time.Second),
+		HTTPClientIdleConnTimeout:       getEnvDuration("HTTP_CLIENT_IDLE_CONN_TIMEOUT", 90*time.Second),
+		HTTPClientMaxIdleConns:          getEnvInt("HTTP_CLIENT_MAX_IDLE_CONNS", 100),
+		HTTPClientMaxIdleConnsPerHost:   getEnvInt("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", 10),
+		HTTPClientMaxConnsPerHost:       getEnvInt("HTTP_CLIENT_MAX_CONNS_PER_HOST", 0),
 	}
 }
 
@@ -80,15 +361,46 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+// getEnvFloat64 rejects NaN and infinities, which strconv.ParseFloat accepts
 func getEnvFloat64(key string, defaultValue float64) float64 {
 	if value := os.Getenv(key); value != "" {
-		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(floatValue) && !math.IsInf(floatValue, 0) {
 			return floatValue
 		}
 	}
 	return defaultValue
 }
 
+// getEnvFloat64InRange falls back to the default for values outside [min, max]
+func getEnvFloat64InRange(key string, defaultValue, min, max float64) float64 {
+	if value := getEnvFloat64(key, defaultValue); value >= min && value <= max {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvStringSlice(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
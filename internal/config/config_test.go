@@ -2,8 +2,11 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
 	"time"
+
+	"product-service/pkg/httpclient"
 )
 
 func TestLoadConfig_DefaultValues(t *testing.T) {
@@ -51,6 +54,12 @@ func TestLoadConfig_DefaultValues(t *testing.T) {
 	if config.GCInterval != 30*time.Second {
 		t.Errorf("Expected GCInterval 30s, got %v", config.GCInterval)
 	}
+	if config.BatchConflictPolicy != "last_wins" {
+		t.Errorf("Expected BatchConflictPolicy 'last_wins', got '%s'", config.BatchConflictPolicy)
+	}
+	if config.ProductIDMaxLength != 128 {
+		t.Errorf("Expected ProductIDMaxLength 128, got %d", config.ProductIDMaxLength)
+	}
 }
 
 func TestLoadConfig_EnvironmentVariables(t *testing.T) {
@@ -279,3 +288,222 @@ func TestGetEnvDuration(t *testing.T) {
 	// Clean up
 	os.Clearenv()
 }
+
+func TestGetEnvBool(t *testing.T) {
+	// Test with valid bool
+	os.Setenv("TEST_BOOL", "true")
+	result := getEnvBool("TEST_BOOL", false)
+	if result != true {
+		t.Errorf("Expected true, got %v", result)
+	}
+
+	// Test with invalid bool
+	os.Setenv("TEST_BOOL", "invalid")
+	result = getEnvBool("TEST_BOOL", false)
+	if result != false {
+		t.Errorf("Expected false, got %v", result)
+	}
+
+	// Clean up
+	os.Clearenv()
+}
+
+func TestLoadConfig_Autoscaling(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("WORKERS", "4")
+
+	config := LoadConfig()
+
+	if config.AutoscaleEnabled {
+		t.Error("Expected autoscaling to be disabled by default")
+	}
+	if config.MinWorkers != 1 {
+		t.Errorf("Expected MinWorkers 1, got %d", config.MinWorkers)
+	}
+	if config.MaxWorkers != 4 {
+		t.Errorf("Expected MaxWorkers to default to Workers (4), got %d", config.MaxWorkers)
+	}
+	if config.WorkerIdleTimeout != 30*time.Second {
+		t.Errorf("Expected WorkerIdleTimeout 30s, got %v", config.WorkerIdleTimeout)
+	}
+
+	os.Setenv("AUTOSCALE_ENABLED", "true")
+	os.Setenv("MIN_WORKERS", "2")
+	os.Setenv("MAX_WORKERS", "8")
+	os.Setenv("WORKER_IDLE_TIMEOUT", "5s")
+
+	config = LoadConfig()
+
+	if !config.AutoscaleEnabled {
+		t.Error("Expected autoscaling to be enabled")
+	}
+	if config.MinWorkers != 2 || config.MaxWorkers != 8 {
+		t.Errorf("Expected workers bounded 2-8, got %d-%d", config.MinWorkers, config.MaxWorkers)
+	}
+	if config.WorkerIdleTimeout != 5*time.Second {
+		t.Errorf("Expected WorkerIdleTimeout 5s, got %v", config.WorkerIdleTimeout)
+	}
+
+	// Clean up
+	os.Clearenv()
+}
+
+func TestLoadConfig_APIKeyDefaultQuota(t *testing.T) {
+	os.Clearenv()
+
+	if quota := LoadConfig().APIKeyDefaultQuota; quota >= 0 {
+		t.Errorf("Expected the default quota to be unlimited unless set, got %d", quota)
+	}
+
+	os.Setenv("API_KEY_DEFAULT_QUOTA", "0")
+	if quota := LoadConfig().APIKeyDefaultQuota; quota != 0 {
+		t.Errorf("Expected an explicit default quota of 0, got %d", quota)
+	}
+
+	// Clean up
+	os.Clearenv()
+}
+
+func TestLoadConfig_HTTPClient(t *testing.T) {
+	os.Clearenv()
+
+	config := LoadConfig()
+	if got := config.HTTPClientConfig(); got != httpclient.DefaultConfig() {
+		t.Errorf("Expected the default client settings, got %+v", got)
+	}
+
+	os.Setenv("HTTP_CLIENT_TIMEOUT", "2s")
+	os.Setenv("HTTP_CLIENT_DIAL_TIMEOUT", "500ms")
+	os.Setenv("HTTP_CLIENT_MAX_CONNS_PER_HOST", "4")
+
+	client := LoadConfig().HTTPClientConfig()
+	if client.Timeout != 2*time.Second {
+		t.Errorf("Expected Timeout 2s, got %v", client.Timeout)
+	}
+	if client.DialTimeout != 500*time.Millisecond {
+		t.Errorf("Expected DialTimeout 500ms, got %v", client.DialTimeout)
+	}
+	if client.MaxConnsPerHost != 4 {
+		t.Errorf("Expected MaxConnsPerHost 4, got %d", client.MaxConnsPerHost)
+	}
+
+	// Clean up
+	os.Clearenv()
+}
+
+func TestGetEnvStringSlice(t *testing.T) {
+	os.Setenv("TEST_SLICE", "10.0.0.0/8, 192.168.1.1 ,,")
+	result := getEnvStringSlice("TEST_SLICE", nil)
+	if len(result) != 2 || result[0] != "10.0.0.0/8" || result[1] != "192.168.1.1" {
+		t.Errorf("Expected [10.0.0.0/8 192.168.1.1], got %v", result)
+	}
+
+	os.Unsetenv("TEST_SLICE")
+	result = getEnvStringSlice("TEST_SLICE", []string{"default"})
+	if len(result) != 1 || result[0] != "default" {
+		t.Errorf("Expected [default], got %v", result)
+	}
+
+	// Clean up
+	os.Clearenv()
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	os.Setenv("WORKERS", "7")
+	os.Setenv("BATCH_FLUSH_INTERVAL", "250ms")
+	defer os.Unsetenv("WORKERS")
+	defer os.Unsetenv("BATCH_FLUSH_INTERVAL")
+
+	redacted := LoadConfig().Redacted()
+
+	if redacted["Workers"] != 7 {
+		t.Errorf("Expected Workers 7, got %v", redacted["Workers"])
+	}
+	if redacted["BatchFlushInterval"] != "250ms" {
+		t.Errorf("Expected BatchFlushInterval '250ms', got %v", redacted["BatchFlushInterval"])
+	}
+	if redacted["BaseCurrency"] != "USD" {
+		t.Errorf("Expected BaseCurrency 'USD', got %v", redacted["BaseCurrency"])
+	}
+}
+
+func TestRedact_SensitiveFields(t *testing.T) {
+	settings := struct {
+		Name        string
+		APIKey      string
+		AdminToken  string
+		DatabaseURL string `secret:"true"`
+		hidden      string
+	}{"service", "key-123", "token-456", "postgres://user:pass@db", "unexported"}
+
+	redacted := redact(reflect.ValueOf(settings))
+
+	if redacted["Name"] != "service" {
+		t.Errorf("Expected Name 'service', got %v", redacted["Name"])
+	}
+	for _, field := range []string{"APIKey", "AdminToken", "DatabaseURL"} {
+		if redacted[field] != RedactedValue {
+			t.Errorf("Expected %s to be redacted, got %v", field, redacted[field])
+		}
+	}
+	if _, ok := redacted["hidden"]; ok {
+		t.Error("Expected unexported fields to be omitted")
+	}
+}
+
+func TestGetEnvFloat64_NonFinite(t *testing.T) {
+	for _, value := range []string{"NaN", "nan", "Inf", "+Inf", "-Inf", "infinity"} {
+		os.Setenv("TEST_FLOAT", value)
+		if result := getEnvFloat64("TEST_FLOAT", 1.0); result != 1.0 {
+			t.Errorf("Expected %q to fall back to 1.0, got %f", value, result)
+		}
+	}
+
+	os.Clearenv()
+}
+
+func TestLoadConfig_BoundedFloats(t *testing.T) {
+	defaults := LoadConfig()
+
+	for _, value := range []string{"NaN", "Inf", "-0.1", "1.5"} {
+		t.Run(value, func(t *testing.T) {
+			os.Setenv("CLEANUP_THRESHOLD", value)
+			os.Setenv("LOAD_SHEDDING_PROBABILITY", value)
+			os.Setenv("CHAOS_FAILURE_RATE", value)
+			os.Setenv("CIRCUIT_BREAKER_SLOW_CALL_RATE", value)
+			defer os.Clearenv()
+
+			config := LoadConfig()
+			if config.CleanupThreshold != defaults.CleanupThreshold {
+				t.Errorf("Expected CleanupThreshold %f, got %f", defaults.CleanupThreshold, config.CleanupThreshold)
+			}
+			if config.LoadSheddingProbability != defaults.LoadSheddingProbability {
+				t.Errorf("Expected LoadSheddingProbability %f, got %f", defaults.LoadSheddingProbability, config.LoadSheddingProbability)
+			}
+			if config.ChaosFailureRate != defaults.ChaosFailureRate {
+				t.Errorf("Expected ChaosFailureRate %f, got %f", defaults.ChaosFailureRate, config.ChaosFailureRate)
+			}
+			if config.CircuitBreakerSlowCallRate != defaults.CircuitBreakerSlowCallRate {
+				t.Errorf("Expected CircuitBreakerSlowCallRate %f, got %f", defaults.CircuitBreakerSlowCallRate, config.CircuitBreakerSlowCallRate)
+			}
+		})
+	}
+
+	t.Run("TargetEPS", func(t *testing.T) {
+		for _, value := range []string{"-5", "Inf"} {
+			os.Setenv("TARGET_EPS", value)
+			if config := LoadConfig(); config.TargetEPS != 0 {
+				t.Errorf("Expected TARGET_EPS=%s to fall back to 0, got %f", value, config.TargetEPS)
+			}
+		}
+		os.Clearenv()
+	})
+
+	t.Run("InRange", func(t *testing.T) {
+		os.Setenv("CLEANUP_THRESHOLD", "0.6")
+		defer os.Clearenv()
+		if config := LoadConfig(); config.CleanupThreshold != 0.6 {
+			t.Errorf("Expected CleanupThreshold 0.6, got %f", config.CleanupThreshold)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	fileMu sync.Mutex
+	// fileKeys are the keys the config file last set in the environment
+	fileKeys = make(map[string]bool)
+)
+
+// LoadFile reads KEY=VALUE settings from path into the environment so that
+// LoadConfig picks them up. Blank lines and lines starting with # are
+// ignored, and values may be wrapped in single or double quotes.
+//
+// Variables set to a non-empty value in the real environment take
+// precedence and are never overwritten. Loading the file again, e.g. on SIGHUP, unsets the keys an
+// earlier load set that the file no longer has. A file that fails to parse
+// leaves the environment untouched.
+func LoadFile(path string) error {
+	settings, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	for key := range fileKeys {
+		if _, ok := settings[key]; !ok {
+			if err := os.Unsetenv(key); err != nil {
+				return err
+			}
+			delete(fileKeys, key)
+		}
+	}
+	for key, value := range settings {
+		// Empty counts as unset, as it does for LoadConfig
+		if os.Getenv(key) != "" && !fileKeys[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+		fileKeys[key] = true
+	}
+	return nil
+}
+
+// readFile parses the KEY=VALUE settings in path
+func readFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		settings[key] = value
+	}
+	return settings, scanner.Err()
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	os.Clearenv()
+	fileKeys = make(map[string]bool)
+	defer os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "service.env")
+	writeFile := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	os.Setenv("WORKERS", "8")
+	writeFile("# workers\nWORKERS=2\nQUEUE_SIZE = \"500\"\n\nLOG_LEVEL='debug'\n")
+	if err := LoadFile(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("EnvironmentTakesPrecedence", func(t *testing.T) {
+		config := LoadConfig()
+		if config.Workers != 8 {
+			t.Errorf("Expected Workers 8 from the environment, got %d", config.Workers)
+		}
+		if config.QueueSize != 500 {
+			t.Errorf("Expected QueueSize 500 from the file, got %d", config.QueueSize)
+		}
+		if config.LogLevel != "debug" {
+			t.Errorf("Expected LogLevel debug from the file, got %q", config.LogLevel)
+		}
+	})
+
+	t.Run("ReloadUnsetsRemovedKeys", func(t *testing.T) {
+		writeFile("WORKERS=2\nQUEUE_SIZE=750\n")
+		if err := LoadFile(path); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if value := os.Getenv("QUEUE_SIZE"); value != "750" {
+			t.Errorf("Expected the changed QUEUE_SIZE 750, got %q", value)
+		}
+		if _, ok := os.LookupEnv("LOG_LEVEL"); ok {
+			t.Error("Expected LOG_LEVEL removed from the file to be unset")
+		}
+		if value := os.Getenv("WORKERS"); value != "8" {
+			t.Errorf("Expected WORKERS to keep its environment value 8, got %q", value)
+		}
+	})
+
+	t.Run("InvalidFileLeavesEnvironment", func(t *testing.T) {
+		writeFile("QUEUE_SIZE=1\nnot a setting\n")
+		if err := LoadFile(path); err == nil {
+			t.Fatal("Expected an error for a line without =")
+		}
+		if value := os.Getenv("QUEUE_SIZE"); value != "750" {
+			t.Errorf("Expected QUEUE_SIZE to stay 750, got %q", value)
+		}
+	})
+}
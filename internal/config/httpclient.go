@@ -0,0 +1,18 @@
+package config
+
+import "product-service/pkg/httpclient"
+
+// HTTPClientConfig returns the outbound HTTP client settings, for building
+// clients with httpclient.New
+func (c *Config) HTTPClientConfig() httpclient.Config {
+	return httpclient.Config{
+		Timeout:               c.HTTPClientTimeout,
+		DialTimeout:           c.HTTPClientDialTimeout,
+		TLSHandshakeTimeout:   c.HTTPClientTLSHandshakeTimeout,
+		ResponseHeaderTimeout: c.HTTPClientResponseHeaderTimeout,
+		IdleConnTimeout:       c.HTTPClientIdleConnTimeout,
+		MaxIdleConns:          c.HTTPClientMaxIdleConns,
+		MaxIdleConnsPerHost:   c.HTTPClientMaxIdleConnsPerHost,
+		MaxConnsPerHost:       c.HTTPClientMaxConnsPerHost,
+	}
+}
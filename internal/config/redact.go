@@ -0,0 +1,56 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces the value of a sensitive setting when the config is displayed
+const RedactedValue = "[REDACTED]"
+
+// sensitiveNameParts mark a setting as sensitive when they appear in its field name
+var sensitiveNameParts = []string{"secret", "password", "token", "apikey", "credential"}
+
+// Redacted returns the config as a map from field name to value, suitable for
+// display. Fields tagged `secret:"true"` or named like a credential are
+// replaced with RedactedValue, and durations are rendered as strings.
+func (c *Config) Redacted() map[string]interface{} {
+	return redact(reflect.ValueOf(c).Elem())
+}
+
+// redact renders every exported field of a struct value, hiding sensitive ones
+func redact(v reflect.Value) map[string]interface{} {
+	fields := make(map[string]interface{}, v.NumField())
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value := v.Field(i).Interface()
+		switch {
+		case isSensitive(field):
+			value = RedactedValue
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			value = value.(time.Duration).String()
+		}
+		fields[field.Name] = value
+	}
+	return fields
+}
+
+// isSensitive reports whether a field holds a secret
+func isSensitive(field reflect.StructField) bool {
+	if field.Tag.Get("secret") == "true" {
+		return true
+	}
+	name := strings.ToLower(field.Name)
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
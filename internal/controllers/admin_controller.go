@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"product-service/internal/config"
+	"product-service/internal/models"
+	"product-service/internal/services"
+	"product-service/pkg/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxQueueDumpEvents bounds how many events GET /admin/queue/dump returns
+const maxQueueDumpEvents = 1000
+
+// AdminController handles operational requests for tuning the service at runtime
+type AdminController struct {
+	productService *services.ProductService
+	logReplayer    *services.LogReplayer
+	configMu       sync.RWMutex
+	config         *config.Config
+}
+
+// NewAdminController creates a new admin controller
+func NewAdminController(productService *services.ProductService) *AdminController {
+	return &AdminController{
+		productService: productService,
+	}
+}
+
+// SetConfig sets the loaded configuration reported by GetConfig; it may be called again after a reload
+func (ac *AdminController) SetConfig(cfg *config.Config) {
+	ac.configMu.Lock()
+	defer ac.configMu.Unlock()
+	ac.config = cfg
+}
+
+// GetConfig handles GET /admin/config, returning the effective configuration with secrets redacted
+func (ac *AdminController) GetConfig(c *gin.Context) {
+	ac.configMu.RLock()
+	cfg := ac.config
+	ac.configMu.RUnlock()
+
+	if cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Configuration is not available"})
+		return
+	}
+	writeSuccess(c, http.StatusOK, cfg.Redacted())
+}
+
+// GetCircuitBreaker handles GET /admin/circuit-breaker
+func (ac *AdminController) GetCircuitBreaker(c *gin.Context) {
+	writeSuccess(c, http.StatusOK, ac.circuitBreakerResponse())
+}
+
+// Values of the force field of a circuit breaker update
+const (
+	ForceOpen    = "open"
+	ForceClosed  = "closed"
+	ForceRelease = "release"
+)
+
+// UpdateCircuitBreaker handles PUT /admin/circuit-breaker
+func (ac *AdminController) UpdateCircuitBreaker(c *gin.Context) {
+	var req models.CircuitBreakerSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+
+	if req.Threshold == nil && req.Timeout == "" && req.Force == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "threshold, timeout or force is required"})
+		return
+	}
+	switch req.Force {
+	case "", ForceOpen, ForceClosed, ForceRelease:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "force must be open, closed or release"})
+		return
+	}
+
+	// Validate everything before applying so a bad field doesn't leave a partial update
+	if req.Threshold != nil && *req.Threshold <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "threshold must be positive"})
+		return
+	}
+
+	var timeout time.Duration
+	if req.Timeout != "" {
+		parsed, err := time.ParseDuration(req.Timeout)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "timeout must be a positive duration"})
+			return
+		}
+		timeout = parsed
+	}
+
+	cb := ac.productService.CircuitBreakers()
+	if req.Threshold != nil {
+		if err := cb.SetThreshold(*req.Threshold); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if timeout > 0 {
+		if err := cb.SetTimeout(timeout); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	switch req.Force {
+	case ForceOpen:
+		cb.ForceOpen()
+	case ForceClosed:
+		cb.ForceClosed()
+	case ForceRelease:
+		cb.Release()
+	}
+
+	writeSuccess(c, http.StatusOK, ac.circuitBreakerResponse())
+}
+
+// circuitBreakerResponse builds the response describing the current breaker
+func (ac *AdminController) circuitBreakerResponse() models.CircuitBreakerResponse {
+	cb := ac.productService.CircuitBreaker()
+	response := models.CircuitBreakerResponse{
+		State:     cb.GetState().String(),
+		Failures:  cb.GetFailureCount(),
+		Threshold: cb.GetThreshold(),
+		Timeout:   cb.GetTimeout().String(),
+		Forced:    cb.IsForced(),
+	}
+
+	group := ac.productService.CircuitBreakers()
+	for _, category := range group.Keys() {
+		breaker := group.Get(category)
+		response.Categories = append(response.Categories, models.CategoryCircuitBreaker{
+			Category: category,
+			State:    breaker.GetState().String(),
+			Failures: breaker.GetFailureCount(),
+		})
+	}
+	return response
+}
+
+// FlushBatch handles POST /admin/batch/flush
+func (ac *AdminController) FlushBatch(c *gin.Context) {
+	bp := ac.productService.BatchProcessor()
+	if bp == nil {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Batch processing is not enabled"})
+		return
+	}
+
+	if err := bp.Flush(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Batch flush failed", Details: err.Error()})
+		return
+	}
+
+	writeSuccess(c, http.StatusOK, models.BatchFlushResponse{Message: "Batch processor flushed"})
+}
+
+// ReplayProduct handles POST /admin/products/:id/replay, re-enqueueing the
+// product's most recently dead-lettered event
+func (ac *AdminController) ReplayProduct(c *gin.Context) {
+	productID := c.Param("id")
+
+	failed, ticket, err := ac.productService.ReplayDeadLetter(productID)
+	if err != nil {
+		if errors.Is(err, services.ErrNothingToReplay) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "No dead-lettered event for product"})
+			return
+		}
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Replay failed", Details: err.Error()})
+		return
+	}
+
+	writeSuccess(c, http.StatusAccepted, models.ReplayResponse{
+		Message:   "Event requeued for processing",
+		ProductID: productID,
+		Ticket:    ticket,
+		Replayed:  failed,
+	})
+}
+
+// SetLogReplayer enables replaying the write-ahead log through ReplayLog
+func (ac *AdminController) SetLogReplayer(replayer *services.LogReplayer) {
+	ac.logReplayer = replayer
+}
+
+// ReplayLog handles POST /admin/wal/replay, re-enqueueing the write-ahead log
+// entries from the sequence number in ?from= or the RFC 3339 time in ?since=
+func (ac *AdminController) ReplayLog(c *gin.Context) {
+	if ac.logReplayer == nil {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{Error: "Write-ahead log is not enabled"})
+		return
+	}
+
+	from, hasFrom := c.GetQuery("from")
+	since, hasSince := c.GetQuery("since")
+	var err error
+	switch {
+	case hasFrom == hasSince:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Exactly one of from or since is required"})
+		return
+	case hasFrom:
+		offset, parseErr := strconv.ParseInt(from, 10, 64)
+		if parseErr != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid from", Details: "expected a non-negative sequence number"})
+			return
+		}
+		err = ac.logReplayer.ReplayFrom(offset)
+	default:
+		t, parseErr := time.Parse(time.RFC3339, since)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid since", Details: "expected an RFC 3339 time"})
+			return
+		}
+		err = ac.logReplayer.ReplayFromTime(t)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Replay failed", Details: err.Error()})
+		return
+	}
+	writeSuccess(c, http.StatusAccepted, models.LogReplayResponse{Message: "Write-ahead log entries requeued for processing"})
+}
+
+// Diagnostics handles GET /admin/diagnostics
+func (ac *AdminController) Diagnostics(c *gin.Context) {
+	eventQueue := ac.productService.Queue()
+	pool := ac.productService.WorkerPool()
+	stats := pool.Stats()
+	rc := ac.productService.RetryConfig()
+
+	response := models.DiagnosticsResponse{
+		Queue: models.QueueDiagnostics{Depth: eventQueue.Len(), Capacity: eventQueue.Cap()},
+		Workers: models.WorkerDiagnostics{
+			Count:     pool.WorkerCount(),
+			Alive:     pool.AliveWorkers(),
+			Processed: stats.Processed,
+			Failed:    stats.Failed,
+		},
+		CircuitBreaker: ac.circuitBreakerResponse(),
+		Retry: models.RetryDiagnostics{
+			MaxAttempts:  rc.MaxAttempts,
+			InitialDelay: rc.InitialDelay.String(),
+			MaxDelay:     rc.MaxDelay.String(),
+			Multiplier:   rc.Multiplier,
+			Retries:      stats.Retries,
+			Exhausted:    stats.Exhausted,
+		},
+		LastFailures: pool.RecentFailures(),
+	}
+	if dlq := ac.productService.DeadLetterQueue(); dlq != nil {
+		response.DeadLetterQueue = &models.QueueDiagnostics{Depth: dlq.Len(), Capacity: dlq.Cap()}
+	}
+
+	writeSuccess(c, http.StatusOK, response)
+}
+
+// DumpQueue handles GET /admin/queue/dump, listing the buffered events
+// without consuming them. At most maxQueueDumpEvents are returned, fewer
+// with ?limit=N.
+func (ac *AdminController) DumpQueue(c *gin.Context) {
+	limit := maxQueueDumpEvents
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer"})
+			return
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	eventQueue := ac.productService.Queue()
+	peeker, ok := eventQueue.(queue.Peeker)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{Error: "Queue backend does not support dumping"})
+		return
+	}
+
+	depth := eventQueue.Len()
+	events := peeker.Peek(limit)
+	writeSuccess(c, http.StatusOK, models.QueueDumpResponse{
+		Depth:     depth,
+		Capacity:  eventQueue.Cap(),
+		Truncated: len(events) < depth,
+		Events:    events,
+	})
+}
+
+// PurgeConfirmation must be passed as ?confirm= for a queue purge to proceed
+const PurgeConfirmation = "purge"
+
+// PurgeQueue handles POST /admin/queue/purge, discarding every queued event.
+// It refuses unless confirmed, since the events cannot be recovered.
+func (ac *AdminController) PurgeQueue(c *gin.Context) {
+	if c.Query("confirm") != PurgeConfirmation {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Purge not confirmed",
+			Details: "pass ?confirm=" + PurgeConfirmation + " to discard every queued event",
+		})
+		return
+	}
+
+	writeSuccess(c, http.StatusOK, models.QueuePurgeResponse{Purged: ac.productService.PurgeQueue()})
+}
+
+// RepositorySize handles GET /admin/repository/size, estimating the memory held by stored products
+func (ac *AdminController) RepositorySize(c *gin.Context) {
+	size := ac.productService.RepositorySize()
+	if size < 0 {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{Error: "Repository backend does not report its size"})
+		return
+	}
+	writeSuccess(c, http.StatusOK, models.RepositorySizeResponse{
+		Products:  ac.productService.RepositoryProducts(),
+		SizeBytes: size,
+	})
+}
+
+// Workers handles GET /admin/workers, reporting what each running worker is doing
+func (ac *AdminController) Workers(c *gin.Context) {
+	activity := ac.productService.WorkerPool().Activity()
+
+	response := models.WorkersResponse{Workers: make([]models.WorkerState, 0, len(activity))}
+	for _, worker := range activity {
+		response.Workers = append(response.Workers, models.WorkerState{
+			ID:               worker.ID,
+			Status:           worker.Status,
+			Processed:        worker.Processed,
+			CurrentProductID: worker.CurrentProductID,
+			CurrentSequence:  worker.CurrentSequence,
+			LastActivity:     worker.LastActivity,
+		})
+	}
+
+	writeSuccess(c, http.StatusOK, response)
+}
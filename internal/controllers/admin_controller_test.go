@@ -0,0 +1,651 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"product-service/internal/config"
+	"product-service/internal/models"
+	"product-service/internal/repositories"
+	"product-service/internal/services"
+	"product-service/pkg/queue"
+	"product-service/pkg/wal"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminController_CircuitBreaker(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repo, eventQueue, 1)
+
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.GET("/admin/circuit-breaker", controller.GetCircuitBreaker)
+	router.PUT("/admin/circuit-breaker", controller.UpdateCircuitBreaker)
+
+	t.Run("GetCircuitBreaker", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/admin/circuit-breaker", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+
+		var resp models.CircuitBreakerResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if resp.State != "closed" {
+			t.Errorf("Expected state 'closed', got '%s'", resp.State)
+		}
+	})
+
+	t.Run("UpdateCircuitBreaker", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"threshold": 10, "timeout": "2m"}`)
+		req, _ := http.NewRequest("PUT", "/admin/circuit-breaker", body)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+
+		cb := productService.CircuitBreaker()
+		if cb.GetThreshold() != 10 {
+			t.Errorf("Expected threshold 10, got %d", cb.GetThreshold())
+		}
+		if cb.GetTimeout() != 2*time.Minute {
+			t.Errorf("Expected timeout 2m, got %v", cb.GetTimeout())
+		}
+	})
+
+	t.Run("UpdateCircuitBreaker_InvalidValues", func(t *testing.T) {
+		payloads := []string{
+			`{"threshold": 0}`,
+			`{"threshold": -3}`,
+			`{"timeout": "-1s"}`,
+			`{"timeout": "soon"}`,
+			`{"threshold": 4, "timeout": "0s"}`,
+			`{"threshold": 4, "force": "sideways"}`,
+			`{}`,
+		}
+
+		for _, payload := range payloads {
+			req, _ := http.NewRequest("PUT", "/admin/circuit-breaker", bytes.NewBufferString(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", payload, w.Code)
+			}
+		}
+
+		// Rejected updates must not partially apply
+		cb := productService.CircuitBreaker()
+		if cb.GetThreshold() != 10 {
+			t.Errorf("Expected threshold to remain 10, got %d", cb.GetThreshold())
+		}
+	})
+
+	t.Run("UpdateCircuitBreaker_Force", func(t *testing.T) {
+		force := func(value string) models.CircuitBreakerResponse {
+			req, _ := http.NewRequest("PUT", "/admin/circuit-breaker", bytes.NewBufferString(`{"force": "`+value+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200 forcing %s, got %d", value, w.Code)
+			}
+			var resp models.CircuitBreakerResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			return resp
+		}
+
+		if resp := force(ForceOpen); resp.State != "open" || !resp.Forced {
+			t.Errorf("Expected breaker forced open, got %+v", resp)
+		}
+		if err := productService.CircuitBreaker().Execute(func() error { return nil }); err == nil {
+			t.Error("Expected a forced-open breaker to reject operations")
+		}
+		if resp := force(ForceClosed); resp.State != "closed" || !resp.Forced {
+			t.Errorf("Expected breaker forced closed, got %+v", resp)
+		}
+		if resp := force(ForceRelease); resp.State != "closed" || resp.Forced {
+			t.Errorf("Expected breaker released, got %+v", resp)
+		}
+	})
+}
+
+func TestAdminController_FlushBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.POST("/admin/batch/flush", controller.FlushBatch)
+
+	t.Run("NotEnabled", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/admin/batch/flush", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", w.Code)
+		}
+	})
+
+	t.Run("FlushesBufferedEvents", func(t *testing.T) {
+		bp := queue.NewBatchProcessor(10, time.Hour, 10, 1, productService.ApplyBatch)
+		defer bp.Stop()
+		productService.SetBatchProcessor(bp)
+
+		bp.AddEvent(models.ProductEvent{ProductID: "batched-1", Price: 1.0, Stock: 1})
+		bp.AddEvent(models.ProductEvent{ProductID: "batched-2", Price: 2.0, Stock: 2})
+
+		req, _ := http.NewRequest("POST", "/admin/batch/flush", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		for _, id := range []string{"batched-1", "batched-2"} {
+			if _, exists := repo.Get(id); !exists {
+				t.Errorf("Expected %s to be applied by the flush", id)
+			}
+		}
+	})
+}
+
+// explodingProductRepository panics when updating one specific product
+type explodingProductRepository struct {
+	*repositories.InMemoryProductRepository
+	explodeID string
+}
+
+func (r *explodingProductRepository) Update(id string, price float64, stock int, currency string) {
+	if id == r.explodeID {
+		panic("repository exploded")
+	}
+	r.InMemoryProductRepository.Update(id, price, stock, currency)
+}
+
+func TestAdminController_Diagnostics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &explodingProductRepository{repositories.NewInMemoryProductRepository(), "broken"}
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 2)
+	productService.SetDeadLetterQueue(queue.NewDeadLetterQueue(10))
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.GET("/admin/diagnostics", controller.Diagnostics)
+
+	productService.Start()
+	defer productService.Stop()
+
+	productService.ProcessEvent(models.ProductEvent{ProductID: "fine", Price: 1.0, Stock: 1})
+	productService.ProcessEvent(models.ProductEvent{ProductID: "broken", Price: 2.0, Stock: 2})
+	time.Sleep(100 * time.Millisecond)
+
+	req, _ := http.NewRequest("GET", "/admin/diagnostics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &sections); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	for _, section := range []string{"queue", "workers", "circuit_breaker", "retry", "dead_letter_queue", "last_failures"} {
+		raw, ok := sections[section]
+		if !ok || len(raw) == 0 || string(raw) == "null" || string(raw) == "{}" || string(raw) == "[]" {
+			t.Errorf("Expected non-empty %s section, got %s", section, raw)
+		}
+	}
+
+	var response models.DiagnosticsResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.Queue.Capacity != 10 {
+		t.Errorf("Expected queue capacity 10, got %d", response.Queue.Capacity)
+	}
+	if response.Workers.Count != 2 || response.Workers.Alive != 2 {
+		t.Errorf("Expected 2 workers alive, got count=%d alive=%d", response.Workers.Count, response.Workers.Alive)
+	}
+	if response.Workers.Processed != 1 || response.Workers.Failed != 1 {
+		t.Errorf("Expected 1 processed and 1 failed, got %+v", response.Workers)
+	}
+	if response.Retry.MaxAttempts == 0 {
+		t.Error("Expected retry settings to be reported")
+	}
+	if response.DeadLetterQueue.Depth != 1 {
+		t.Errorf("Expected 1 dead-lettered event, got %d", response.DeadLetterQueue.Depth)
+	}
+	if len(response.LastFailures) != 1 || response.LastFailures[0].Event.ProductID != "broken" {
+		t.Errorf("Expected last failure for broken, got %+v", response.LastFailures)
+	}
+}
+
+func TestAdminController_GetConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.GET("/admin/config", controller.GetConfig)
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/admin/config", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", w.Code)
+		}
+	})
+
+	t.Run("ReturnsLoadedValues", func(t *testing.T) {
+		cfg := config.LoadConfig()
+		cfg.Workers = 12
+		cfg.CacheTTL = 45 * time.Second
+		controller.SetConfig(cfg)
+
+		req, _ := http.NewRequest("GET", "/admin/config", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["Workers"] != float64(12) {
+			t.Errorf("Expected Workers 12, got %v", response["Workers"])
+		}
+		if response["CacheTTL"] != "45s" {
+			t.Errorf("Expected CacheTTL '45s', got %v", response["CacheTTL"])
+		}
+	})
+}
+
+func TestAdminController_Workers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(50), 3)
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.GET("/admin/workers", controller.Workers)
+
+	productService.Start()
+	defer productService.Stop()
+
+	for i := 0; i < 20; i++ {
+		productService.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("p%d", i), Price: 1.0, Stock: i})
+	}
+	productService.WorkerPool().Drain()
+	time.Sleep(50 * time.Millisecond)
+
+	req, _ := http.NewRequest("GET", "/admin/workers", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response models.WorkersResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Workers) != 3 {
+		t.Fatalf("Expected 3 workers, got %d", len(response.Workers))
+	}
+
+	var sum int64
+	for _, worker := range response.Workers {
+		sum += worker.Processed
+		if worker.Status != services.WorkerStatusIdle {
+			t.Errorf("Expected worker %d to be idle, got %s", worker.ID, worker.Status)
+		}
+		if worker.LastActivity.IsZero() {
+			t.Errorf("Expected worker %d to report its last activity", worker.ID)
+		}
+	}
+	total := productService.WorkerPool().Stats().Processed
+	if total != 20 {
+		t.Errorf("Expected 20 processed events, got %d", total)
+	}
+	if sum != total {
+		t.Errorf("Expected per-worker counts to sum to %d, got %d", total, sum)
+	}
+}
+
+// flakyProductRepository fails updates while broken is set
+type flakyProductRepository struct {
+	*repositories.InMemoryProductRepository
+	broken atomic.Bool
+}
+
+func (r *flakyProductRepository) Update(id string, price float64, stock int, currency string) {
+	if r.broken.Load() {
+		panic("repository unavailable")
+	}
+	r.InMemoryProductRepository.Update(id, price, stock, currency)
+}
+
+func TestAdminController_ReplayProduct(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &flakyProductRepository{InMemoryProductRepository: repositories.NewInMemoryProductRepository()}
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	productService.SetDeadLetterQueue(queue.NewDeadLetterQueue(10))
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.POST("/admin/products/:id/replay", controller.ReplayProduct)
+
+	productService.Start()
+	defer productService.Stop()
+
+	replay := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/admin/products/"+id+"/replay", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("NothingToReplay", func(t *testing.T) {
+		if w := replay("unknown"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("ReprocessesDeadLetteredEvent", func(t *testing.T) {
+		repo.broken.Store(true)
+		productService.ProcessEvent(models.ProductEvent{ProductID: "flaky", Price: 7.5, Stock: 3})
+		productService.WorkerPool().Drain()
+		time.Sleep(50 * time.Millisecond)
+
+		if productService.DeadLetterQueue().Len() != 1 {
+			t.Fatalf("Expected the event to be dead-lettered, got %d", productService.DeadLetterQueue().Len())
+		}
+		if _, exists := repo.Get("flaky"); exists {
+			t.Fatal("Expected the product not to be stored while the repository is broken")
+		}
+
+		repo.broken.Store(false)
+		w := replay("flaky")
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+
+		var response models.ReplayResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Replayed.Event.ProductID != "flaky" || response.Replayed.Error == "" {
+			t.Errorf("Expected the failed event for flaky to be reported, got %+v", response.Replayed)
+		}
+
+		productService.WorkerPool().Drain()
+		time.Sleep(50 * time.Millisecond)
+
+		product, exists := repo.Get("flaky")
+		if !exists {
+			t.Fatal("Expected the replayed event to be processed")
+		}
+		if product.Price != 7.5 || product.Stock != 3 {
+			t.Errorf("Expected price 7.5 and stock 3, got %v and %d", product.Price, product.Stock)
+		}
+		if productService.DeadLetterQueue().Len() != 0 {
+			t.Errorf("Expected the dead letter queue to be empty, got %d", productService.DeadLetterQueue().Len())
+		}
+		if w := replay("flaky"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 once replayed, got %d", w.Code)
+		}
+	})
+}
+
+func TestAdminController_DumpQueue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The service is not started, so every event stays queued
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.GET("/admin/queue/dump", controller.DumpQueue)
+
+	for i := 0; i < 4; i++ {
+		productService.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("queued-%d", i), Price: 1.0, Stock: i})
+	}
+
+	dump := func(query string) (*httptest.ResponseRecorder, models.QueueDumpResponse) {
+		req, _ := http.NewRequest("GET", "/admin/queue/dump"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response models.QueueDumpResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("ListsInOrder", func(t *testing.T) {
+		w, response := dump("")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if response.Depth != 4 || response.Capacity != 10 || response.Truncated {
+			t.Errorf("Expected depth 4 of 10 untruncated, got %+v", response)
+		}
+		if len(response.Events) != 4 {
+			t.Fatalf("Expected 4 events, got %d", len(response.Events))
+		}
+		for i, event := range response.Events {
+			if expected := fmt.Sprintf("queued-%d", i); event.ProductID != expected || event.Stock != i {
+				t.Errorf("Expected event %d to be %s, got %+v", i, expected, event)
+			}
+		}
+		if eventQueue.Len() != 4 {
+			t.Errorf("Expected the dump not to consume events, got depth %d", eventQueue.Len())
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		_, response := dump("?limit=2")
+		if len(response.Events) != 2 || !response.Truncated {
+			t.Errorf("Expected 2 events marked truncated, got %d (truncated=%v)", len(response.Events), response.Truncated)
+		}
+		if response.Events[0].ProductID != "queued-0" {
+			t.Errorf("Expected the limit to keep the oldest events, got %s", response.Events[0].ProductID)
+		}
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		if w, _ := dump("?limit=zero"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	// The queue still processes normally afterwards
+	event, ok := eventQueue.Dequeue()
+	if !ok || event.ProductID != "queued-0" {
+		t.Errorf("Expected queued-0 to be dequeued first, got %+v (ok=%v)", event, ok)
+	}
+}
+
+func TestAdminController_PurgeQueue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The service is not started, so every event stays queued until purged
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.POST("/admin/queue/purge", controller.PurgeQueue)
+
+	for i := 0; i < 3; i++ {
+		productService.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("poisoned-%d", i), Price: 1.0, Stock: i})
+	}
+
+	purge := func(query string) (*httptest.ResponseRecorder, models.QueuePurgeResponse) {
+		req, _ := http.NewRequest("POST", "/admin/queue/purge"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response models.QueuePurgeResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("RefusesWithoutConfirmation", func(t *testing.T) {
+		for _, query := range []string{"", "?confirm=yes"} {
+			w, _ := purge(query)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+			}
+		}
+		if eventQueue.Len() != 3 {
+			t.Errorf("Expected queue to be untouched, got depth %d", eventQueue.Len())
+		}
+	})
+
+	t.Run("Purges", func(t *testing.T) {
+		w, response := purge("?confirm=" + PurgeConfirmation)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if response.Purged != 3 {
+			t.Errorf("Expected 3 events purged, got %d", response.Purged)
+		}
+		if eventQueue.Len() != 0 {
+			t.Errorf("Expected queue to be empty, got depth %d", eventQueue.Len())
+		}
+		purged := productService.Metrics().Counter("dropped_total", "", map[string]string{"reason": services.DropReasonPurged}).Value()
+		if purged != 3 {
+			t.Errorf("Expected 3 purged drops recorded, got %d", purged)
+		}
+	})
+
+	t.Run("EmptyQueue", func(t *testing.T) {
+		w, response := purge("?confirm=" + PurgeConfirmation)
+		if w.Code != http.StatusOK || response.Purged != 0 {
+			t.Errorf("Expected 200 with nothing purged, got %d and %d", w.Code, response.Purged)
+		}
+	})
+}
+func TestAdminController_RepositorySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.GET("/admin/repository/size", controller.RepositorySize)
+
+	get := func() (*httptest.ResponseRecorder, models.RepositorySizeResponse) {
+		req, _ := http.NewRequest("GET", "/admin/repository/size", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response models.RepositorySizeResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		if w, _ := get(); w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", w.Code)
+		}
+	})
+
+	t.Run("Reported", func(t *testing.T) {
+		productService.SetRepositorySizer(repo)
+		repo.Update("sized", 1.0, 1, "")
+
+		w, response := get()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if response.Products != 1 || response.SizeBytes != repo.SizeBytes() || response.SizeBytes <= 0 {
+			t.Errorf("Expected 1 product of %d bytes, got %+v", repo.SizeBytes(), response)
+		}
+	})
+}
+
+func TestAdminController_ReplayLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	path := filepath.Join(t.TempDir(), "products.wal")
+	log, err := wal.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	log.Append("a", 1.0, 1)
+	log.Append("b", 2.0, 2)
+	log.Append("c", 3.0, 3)
+	log.Close()
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	controller := NewAdminController(productService)
+
+	router := gin.New()
+	router.POST("/admin/wal/replay", controller.ReplayLog)
+
+	post := func(query string) int {
+		req, _ := http.NewRequest("POST", "/admin/wal/replay"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("?from=2"); code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a write-ahead log, got %d", code)
+	}
+
+	controller.SetLogReplayer(services.NewLogReplayer(path, productService))
+
+	for _, query := range []string{"", "?from=2&since=2024-01-01T00:00:00Z", "?from=-1", "?since=yesterday"} {
+		if code := post(query); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, code)
+		}
+	}
+
+	if code := post("?from=2"); code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", code)
+	}
+	if depth := productService.Queue().Len(); depth != 2 {
+		t.Errorf("Expected 2 events requeued from sequence 2, got %d", depth)
+	}
+}
@@ -2,21 +2,86 @@ package controllers
 
 import (
 	"net/http"
+	"time"
 
 	"product-service/internal/models"
+	"product-service/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 // HealthController handles health check requests
-type HealthController struct{}
+type HealthController struct {
+	productService *services.ProductService
+}
 
 // NewHealthController creates a new health controller
 func NewHealthController() *HealthController {
 	return &HealthController{}
 }
 
-// Health handles GET /health
+// Health handles GET /health, reporting degraded while queued events make no progress.
+// Clients accepting text/plain but not JSON get just the status as plain text.
 func (hc *HealthController) Health(c *gin.Context) {
-	c.JSON(http.StatusOK, models.HealthResponse{Status: "healthy"})
+	response := models.HealthResponse{Status: models.HealthStatusHealthy}
+	if hc.productService != nil {
+		if last := hc.productService.LastProcessedAt(); !last.IsZero() {
+			since := time.Since(last).Seconds()
+			response.LastProcessedAt = &last
+			response.SecondsSinceLastProcessed = &since
+		}
+		if hc.productService.Stalled() {
+			response.Status = models.HealthStatusDegraded
+		}
+	}
+
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEPlain) == gin.MIMEPlain {
+		c.String(http.StatusOK, response.Status)
+		return
+	}
+	writeSuccess(c, http.StatusOK, response)
+}
+
+// Ready handles GET /ready, returning 503 until the product service has finished starting.
+// A dead letter queue past its thresholds reports the service degraded, or not ready.
+func (hc *HealthController) Ready(c *gin.Context) {
+	if hc.productService == nil || !hc.productService.Ready() {
+		c.JSON(http.StatusServiceUnavailable, models.ReadinessResponse{Status: models.ReadinessNotReady})
+		return
+	}
+
+	switch status, details := hc.productService.CheckDeadLetter(); status {
+	case models.HealthStatusUnhealthy:
+		c.JSON(http.StatusServiceUnavailable, models.ReadinessResponse{
+			Status: models.ReadinessNotReady,
+			Reason: "dead letter queue backlog: " + details,
+		})
+	case models.HealthStatusDegraded:
+		writeSuccess(c, http.StatusOK, models.ReadinessResponse{
+			Status: models.ReadinessDegraded,
+			Reason: "dead letter queue backlog: " + details,
+		})
+	default:
+		writeSuccess(c, http.StatusOK, models.ReadinessResponse{Status: models.ReadinessReady})
+	}
+}
+
+// SetProductService sets the service whose subsystems DetailedHealth checks
+func (hc *HealthController) SetProductService(productService *services.ProductService) {
+	hc.productService = productService
+}
+
+// DetailedHealth handles GET /health/detailed
+func (hc *HealthController) DetailedHealth(c *gin.Context) {
+	if hc.productService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Detailed health checks are not configured"})
+		return
+	}
+
+	health := hc.productService.CheckHealth()
+	status := http.StatusOK
+	if health.Status == models.HealthStatusUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+	writeSuccess(c, status, health)
 }
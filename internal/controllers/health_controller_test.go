@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"product-service/internal/middleware"
+	"product-service/internal/models"
+	"product-service/internal/repositories"
+	"product-service/internal/services"
+	"product-service/pkg/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthController_DetailedHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repo, eventQueue, 1)
+	productService.Start()
+	defer productService.Stop()
+	time.Sleep(20 * time.Millisecond)
+
+	controller := NewHealthController()
+	controller.SetProductService(productService)
+
+	router := gin.New()
+	router.GET("/health/detailed", controller.DetailedHealth)
+
+	t.Run("Healthy", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/health/detailed", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+
+		var health models.DetailedHealthResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if health.Status != models.HealthStatusHealthy {
+			t.Errorf("Expected status healthy, got %s", health.Status)
+		}
+		if len(health.Checks) != 9 {
+			t.Errorf("Expected 9 checks, got %d", len(health.Checks))
+		}
+	})
+
+	t.Run("UnhealthyReturns503", func(t *testing.T) {
+		productService.CircuitBreaker().SetThreshold(1)
+		productService.CircuitBreaker().Execute(func() error { return http.ErrHandlerTimeout })
+		defer productService.CircuitBreaker().Reset()
+
+		req, _ := http.NewRequest("GET", "/health/detailed", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", w.Code)
+		}
+	})
+}
+
+func TestHealthController_Health_LastProcessed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+
+	controller := NewHealthController()
+	controller.SetProductService(productService)
+
+	router := gin.New()
+	router.GET("/health", controller.Health)
+
+	get := func() models.HealthResponse {
+		req, _ := http.NewRequest("GET", "/health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		var response models.HealthResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	t.Run("NothingProcessed", func(t *testing.T) {
+		response := get()
+		if response.Status != models.HealthStatusHealthy {
+			t.Errorf("Expected healthy, got %s", response.Status)
+		}
+		if response.LastProcessedAt != nil || response.SecondsSinceLastProcessed != nil {
+			t.Errorf("Expected no last processed fields, got %+v", response)
+		}
+	})
+
+	t.Run("Processed", func(t *testing.T) {
+		productService.Start()
+		defer productService.Stop()
+		productService.ProcessEvent(models.ProductEvent{ProductID: "health", Price: 1.0, Stock: 1})
+		time.Sleep(50 * time.Millisecond)
+
+		response := get()
+		if response.LastProcessedAt == nil || response.SecondsSinceLastProcessed == nil {
+			t.Fatalf("Expected last processed fields, got %+v", response)
+		}
+		if *response.SecondsSinceLastProcessed < 0 || *response.SecondsSinceLastProcessed > 5 {
+			t.Errorf("Expected a recent last processed time, got %f seconds ago", *response.SecondsSinceLastProcessed)
+		}
+	})
+
+	t.Run("Stalled", func(t *testing.T) {
+		stalled := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+		stalled.SetStallThreshold(time.Millisecond)
+		stalled.WorkerPool().Start()
+		stalled.WorkerPool().Resize(0)
+		time.Sleep(20 * time.Millisecond)
+		stalled.ProcessEvent(models.ProductEvent{ProductID: "stuck", Price: 1.0, Stock: 1})
+		time.Sleep(20 * time.Millisecond)
+
+		controller.SetProductService(stalled)
+		if response := get(); response.Status != models.HealthStatusDegraded {
+			t.Errorf("Expected degraded while queued events make no progress, got %s", response.Status)
+		}
+	})
+}
+
+func TestHealthController_Ready(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	healthController := NewHealthController()
+	healthController.SetProductService(productService)
+	productController := NewProductController(productService)
+
+	router := gin.New()
+	router.Use(middleware.ReadinessGate(productService.Ready))
+	router.GET("/ready", healthController.Ready)
+	router.GET("/health", healthController.Health)
+	router.POST("/events", productController.HandleEvent)
+
+	request := func(method, path, body string) int {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	event := `{"product_id": "abc", "price": 1.0, "stock": 1}`
+
+	t.Run("BeforeStart", func(t *testing.T) {
+		if code := request("GET", "/ready", ""); code != http.StatusServiceUnavailable {
+			t.Errorf("Expected /ready to return 503, got %d", code)
+		}
+		if code := request("POST", "/events", event); code != http.StatusServiceUnavailable {
+			t.Errorf("Expected events to be rejected with 503, got %d", code)
+		}
+		if code := request("GET", "/health", ""); code != http.StatusOK {
+			t.Errorf("Expected /health to be served, got %d", code)
+		}
+		if productService.Queue().Len() != 0 {
+			t.Errorf("Expected nothing enqueued before start, got %d", productService.Queue().Len())
+		}
+	})
+
+	productService.Start()
+
+	t.Run("AfterStart", func(t *testing.T) {
+		if code := request("GET", "/ready", ""); code != http.StatusOK {
+			t.Errorf("Expected /ready to return 200, got %d", code)
+		}
+		if code := request("POST", "/events", event); code != http.StatusAccepted {
+			t.Errorf("Expected events to be accepted, got %d", code)
+		}
+	})
+
+	productService.Stop()
+
+	t.Run("AfterStop", func(t *testing.T) {
+		if code := request("GET", "/ready", ""); code != http.StatusServiceUnavailable {
+			t.Errorf("Expected /ready to return 503 once stopping, got %d", code)
+		}
+	})
+}
+
+func TestHealthController_Ready_DeadLetterBacklog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	productService.SetDeadLetterThresholds(services.DeadLetterThresholds{Degraded: 2, Unhealthy: 4})
+	productService.Start()
+	defer productService.Stop()
+
+	healthController := NewHealthController()
+	healthController.SetProductService(productService)
+	router := gin.New()
+	router.GET("/ready", healthController.Ready)
+	router.GET("/health/detailed", healthController.DetailedHealth)
+
+	ready := func() (int, models.ReadinessResponse) {
+		req, _ := http.NewRequest("GET", "/ready", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response models.ReadinessResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	deadLetter := func(n int) {
+		for i := 0; i < n; i++ {
+			event := models.ProductEvent{ProductID: fmt.Sprintf("failed-%d", i), Price: 1.0, Stock: 1}
+			productService.DeadLetterQueue().Add(event, errors.New("dependency down"), 3)
+		}
+	}
+
+	if code, response := ready(); code != http.StatusOK || response.Status != models.ReadinessReady {
+		t.Errorf("Expected 200 ready with an empty DLQ, got %d %s", code, response.Status)
+	}
+
+	deadLetter(2)
+	code, response := ready()
+	if code != http.StatusOK || response.Status != models.ReadinessDegraded {
+		t.Errorf("Expected 200 degraded at the degraded depth, got %d %s", code, response.Status)
+	}
+	if !strings.Contains(response.Reason, "dead letter queue") {
+		t.Errorf("Expected the reason to name the dead letter queue, got %q", response.Reason)
+	}
+
+	deadLetter(2)
+	if code, response := ready(); code != http.StatusServiceUnavailable || response.Status != models.ReadinessNotReady {
+		t.Errorf("Expected 503 not_ready at the unhealthy depth, got %d %s", code, response.Status)
+	}
+	if check := productService.CheckHealth().Checks[services.HealthCheckDeadLetter]; check.Status != models.HealthStatusUnhealthy {
+		t.Errorf("Expected the dead letter check unhealthy, got %s", check.Status)
+	}
+
+	if _, _, err := productService.ReplayDeadLetter("failed-0"); err != nil {
+		t.Fatalf("Expected replay to succeed, got %v", err)
+	}
+	if code, response := ready(); code != http.StatusOK || response.Status != models.ReadinessDegraded {
+		t.Errorf("Expected 200 degraded after replaying below the unhealthy depth, got %d %s", code, response.Status)
+	}
+
+	productService.DeadLetterQueue().Drain()
+	if code, response := ready(); code != http.StatusOK || response.Status != models.ReadinessReady {
+		t.Errorf("Expected 200 ready once the DLQ is drained, got %d %s", code, response.Status)
+	}
+}
+
+func TestHealthController_Health_Accept(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewHealthController()
+	router := gin.New()
+	router.GET("/health", controller.Health)
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/health", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, accept := range []string{"", "application/json", "*/*", "text/html"} {
+		t.Run("JSON "+accept, func(t *testing.T) {
+			w := get(accept)
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+				t.Errorf("Expected a JSON content type, got %q", contentType)
+			}
+			var response models.HealthResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Status != models.HealthStatusHealthy {
+				t.Errorf("Expected a JSON healthy status, got %q", w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		w := get("text/plain")
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("Expected a plain text content type, got %q", contentType)
+		}
+		if body := w.Body.String(); body != models.HealthStatusHealthy {
+			t.Errorf("Expected body %q, got %q", models.HealthStatusHealthy, body)
+		}
+	})
+}
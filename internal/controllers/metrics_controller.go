@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+
+	"product-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsController exposes service metrics for scraping
+type MetricsController struct {
+	productService *services.ProductService
+}
+
+// NewMetricsController creates a new metrics controller
+func NewMetricsController(productService *services.ProductService) *MetricsController {
+	return &MetricsController{
+		productService: productService,
+	}
+}
+
+// Metrics handles GET /metrics
+func (mc *MetricsController) Metrics(c *gin.Context) {
+	mc.refresh()
+
+	c.Header("Content-Type", "text/plain; version=0.0.4")
+	c.Status(http.StatusOK)
+	mc.productService.Metrics().WritePrometheus(c.Writer)
+}
+
+// MetricsJSON handles GET /metrics.json, exporting the same metrics as JSON
+func (mc *MetricsController) MetricsJSON(c *gin.Context) {
+	mc.refresh()
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	mc.productService.Metrics().WriteJSON(c.Writer)
+}
+
+// refresh updates the gauges computed on demand; rates decay while idle, so
+// they are refreshed on every scrape
+func (mc *MetricsController) refresh() {
+	mc.productService.Throughput()
+	mc.productService.RetryExhaustionRate()
+	mc.productService.InFlight()
+	mc.productService.RepositorySize()
+	mc.productService.ShardStats()
+}
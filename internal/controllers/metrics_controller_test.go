@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"product-service/internal/repositories"
+	"product-service/internal/services"
+	"product-service/pkg/metrics"
+	"product-service/pkg/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsController(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repo, eventQueue, 1)
+
+	productController := NewProductController(productService)
+	metricsController := NewMetricsController(productService)
+
+	router := gin.New()
+	router.POST("/events", productController.HandleEvent)
+	router.GET("/metrics", metricsController.Metrics)
+
+	// Trigger a validation drop
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"price": 1.0}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected text/plain content type, got '%s'", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `dropped_total{reason="validation"} 1`) {
+		t.Errorf("Expected validation drop counter in metrics, got:\n%s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `events_processed_per_second{window="1m"} 0`) {
+		t.Errorf("Expected throughput gauge in metrics, got:\n%s", w.Body.String())
+	}
+}
+
+func TestMetricsController_JSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	productController := NewProductController(productService)
+	metricsController := NewMetricsController(productService)
+
+	router := gin.New()
+	router.POST("/events", productController.HandleEvent)
+	router.GET("/metrics.json", metricsController.MetricsJSON)
+
+	scrape := func() map[string]metrics.FamilySnapshot {
+		req, _ := http.NewRequest("GET", "/metrics.json", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			t.Errorf("Expected application/json content type, got '%s'", w.Header().Get("Content-Type"))
+		}
+		var document struct {
+			Metrics []metrics.FamilySnapshot `json:"metrics"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		families := make(map[string]metrics.FamilySnapshot)
+		for _, family := range document.Metrics {
+			families[family.Name] = family
+		}
+		return families
+	}
+
+	// validationDrops returns the validation drop count in families, or -1 if absent
+	validationDrops := func(families map[string]metrics.FamilySnapshot) float64 {
+		for _, series := range families["dropped_total"].Series {
+			if series.Labels["reason"] == services.DropReasonValidation && series.Value != nil {
+				return *series.Value
+			}
+		}
+		return -1
+	}
+
+	families := scrape()
+	if _, ok := families["events_processed_per_second"]; !ok {
+		t.Error("Expected the throughput gauge in the JSON metrics")
+	}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"price": 1.0}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if drops := validationDrops(scrape()); drops != 2 {
+		t.Errorf("Expected 2 validation drops after invalid events, got %v", drops)
+	}
+}
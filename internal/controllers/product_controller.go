@@ -1,61 +1,519 @@
 package controllers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
 
+	"product-service/internal/middleware"
 	"product-service/internal/models"
 	"product-service/internal/services"
+	"product-service/pkg/circuitbreaker"
+	"product-service/pkg/queue"
+	"product-service/pkg/retry"
+	"product-service/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 )
 
+// lookupRetryAfter is suggested to clients whose product lookup timed out
+const lookupRetryAfter = time.Second
+
+// StatusClientClosedRequest is the non-standard status recorded when the client goes away before a response
+const StatusClientClosedRequest = 499
+
 // ProductController handles HTTP requests for products
 type ProductController struct {
 	productService *services.ProductService
+	idRules        models.ProductIDRules
+	baseCurrency   string
+	logger         *log.Logger
+
+	// Fixed price places mode rejects prices written with more than pricePlaces
+	// decimal places and renders prices rounded to exactly that many
+	fixedPricePlaces bool
+	pricePlaces      int
 }
 
 // NewProductController creates a new product controller
 func NewProductController(productService *services.ProductService) *ProductController {
 	return &ProductController{
 		productService: productService,
+		idRules:        models.DefaultProductIDRules(),
+		baseCurrency:   models.DefaultBaseCurrency,
+		logger:         log.New(os.Stdout, "[CONTROLLER] ", log.LstdFlags),
 	}
 }
 
 // HandleEvent handles POST /events
 func (pc *ProductController) HandleEvent(c *gin.Context) {
-	var event models.ProductEvent
-	if err := c.ShouldBindJSON(&event); err != nil {
+	body, err := c.GetRawData()
+	if err != nil {
+		pc.productService.RecordDrop(services.DropReasonValidation)
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid JSON payload"})
 		return
 	}
 
-	// Validate required fields
-	if event.ProductID == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "product_id is required"})
+	event, err := models.DecodeProductEvent(body)
+	if err != nil {
+		pc.productService.RecordDrop(services.DropReasonValidation)
+		if errors.Is(err, models.ErrUnsupportedSchemaVersion) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid JSON payload", Details: describeJSONError(err)})
+		return
+	}
+
+	if pc.fixedPricePlaces {
+		if event.Price, err = pc.fixedPlacesPrice(body); err != nil {
+			pc.productService.RecordDrop(services.DropReasonValidation)
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	if event.Currency == "" {
+		event.Currency = pc.baseCurrency
+	}
+
+	// Validate required fields, then any deployment-specific rules
+	err = event.ValidateWith(pc.idRules)
+	if err == nil {
+		err = pc.productService.ValidateEvent(event)
+	}
+	if err != nil {
+		pc.productService.RecordDrop(services.DropReasonValidation)
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// Process the event
-	if err := pc.productService.ProcessEvent(event); err != nil {
+	tracing.InjectEvent(c.Request.Context(), &event)
+	event.RequestID = middleware.GetRequestID(c)
+	refundQuota, ok := middleware.ChargeQuota(c, 1)
+	if !ok {
+		return
+	}
+	if sync, _ := strconv.ParseBool(c.Query("sync")); sync {
+		pc.processSync(c, event, refundQuota)
+		return
+	}
+	ticket, err := pc.productService.ProcessEventWithTicket(event)
+	if errors.Is(err, services.ErrDuplicate) {
+		writeSuccess(c, http.StatusOK, models.EventResponse{
+			Message:   "Duplicate of the last accepted event for this product, skipped",
+			ProductID: event.ProductID,
+			Duplicate: true,
+		})
+		return
+	}
+	if err != nil {
+		refundQuota(1)
+		if errors.Is(err, queue.ErrQueueClosed) {
+			pc.logger.Printf("Rejected event for product %s: %v", event.ProductID, err)
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Service is shutting down"})
+			return
+		}
+		setRetryAfter(c, pc.productService.RetryAfter(event.Category, err))
+		if errors.Is(err, services.ErrProductLimitReached) {
+			pc.logger.Printf("Rejected event for new product %s: %v", event.ProductID, err)
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Product limit reached"})
+			return
+		}
+		if errors.Is(err, services.ErrNotAdmitted) {
+			pc.logger.Printf("Rejected event for product %s: %v", event.ProductID, err)
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Code:    models.ErrorCodeNotAdmitted,
+				Error:   "Queue is busy",
+				Details: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInFlightLimitReached) {
+			pc.logger.Printf("Rejected event for product %s: %v", event.ProductID, err)
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Too many events in flight"})
+			return
+		}
+
+		var fullErr *queue.QueueFullError
+		if errors.As(err, &fullErr) {
+			pc.logger.Printf("Rejected event for product %s: queue depth %d of capacity %d",
+				event.ProductID, fullErr.Depth, fullErr.Capacity)
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:   "Queue is full",
+				Details: fmt.Sprintf("queue depth %d of capacity %d", fullErr.Depth, fullErr.Capacity),
+			})
+			return
+		}
+
+		pc.logger.Printf("Rejected event for product %s: %v", event.ProductID, err)
 		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Queue is full"})
 		return
 	}
 
-	c.JSON(http.StatusAccepted, models.EventResponse{
+	writeSuccess(c, http.StatusAccepted, models.EventResponse{
 		Message:   "Event accepted for processing",
 		ProductID: event.ProductID,
+		Ticket:    ticket,
 	})
 }
 
+// processSync applies an event before responding with the resulting product,
+// refunding its quota charge if it is not applied
+func (pc *ProductController) processSync(c *gin.Context, event models.ProductEvent, refundQuota func(n int)) {
+	product, err := pc.productService.ProcessEventSync(c.Request.Context(), event)
+	if err == nil {
+		pc.writeProduct(c, http.StatusOK, product)
+		return
+	}
+	refundQuota(1)
+
+	pc.logger.Printf("Synchronous processing failed for product %s: %v", event.ProductID, err)
+	switch {
+	case errors.Is(err, services.ErrProductLimitReached):
+		setRetryAfter(c, pc.productService.RetryAfter(event.Category, err))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Product limit reached"})
+	case errors.Is(err, services.ErrInFlightLimitReached):
+		setRetryAfter(c, pc.productService.RetryAfter(event.Category, err))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Too many events in flight"})
+	case errors.Is(err, queue.ErrQueueClosed):
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Service is shutting down"})
+	case errors.Is(err, circuitbreaker.ErrOpen):
+		setRetryAfter(c, pc.productService.RetryAfter(event.Category, err))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Circuit breaker is open"})
+	case errors.Is(err, context.Canceled):
+		c.JSON(StatusClientClosedRequest, models.ErrorResponse{Error: "Client closed request"})
+	default:
+		var exhausted *retry.ExhaustedError
+		if errors.As(err, &exhausted) && exhausted.LastErr != nil {
+			err = fmt.Errorf("%v: %v", exhausted, exhausted.LastErr)
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Event processing failed", Details: err.Error()})
+	}
+}
+
+// HandleBatch handles POST /events/batch
+func (pc *ProductController) HandleBatch(c *gin.Context) {
+	var req models.BatchEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.productService.RecordDrop(services.DropReasonValidation)
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid JSON payload", Details: describeJSONError(err)})
+		return
+	}
+
+	if len(req.Events) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "events must not be empty"})
+		return
+	}
+
+	// Decode and validate every event, setting aside the invalid ones
+	results := make([]models.BatchItemOutcome, len(req.Events))
+	events := make([]models.ProductEvent, 0, len(req.Events))
+	indexes := make([]int, 0, len(req.Events))
+	var firstInvalid *models.ErrorResponse
+	for i, raw := range req.Events {
+		event, err := models.DecodeProductEvent(raw)
+		if err == nil {
+			err = pc.prepareBatchEvent(raw, &event)
+		}
+		results[i] = models.BatchItemOutcome{Index: i, ProductID: event.ProductID}
+		if err != nil {
+			pc.productService.RecordDrop(services.DropReasonValidation)
+			results[i].Status = models.BatchItemRejected
+			results[i].Code = models.ErrorCodeInvalidEvent
+			results[i].Error = describeJSONError(err)
+			if firstInvalid == nil {
+				firstInvalid = &models.ErrorResponse{
+					Error:   "Invalid event in batch",
+					Details: fmt.Sprintf("events[%d]: %s", i, results[i].Error),
+				}
+			}
+			continue
+		}
+		tracing.InjectEvent(c.Request.Context(), &event)
+		event.RequestID = middleware.GetRequestID(c)
+		events = append(events, event)
+		indexes = append(indexes, i)
+	}
+
+	if len(events) == 0 {
+		c.JSON(http.StatusBadRequest, firstInvalid)
+		return
+	}
+
+	refundQuota, ok := middleware.ChargeQuota(c, len(events))
+	if !ok {
+		return
+	}
+	outcomes, err := pc.productService.EnqueueBatch(events)
+	if err != nil {
+		refundQuota(len(events))
+		var conflictErr *services.BatchConflictError
+		if errors.As(err, &conflictErr) {
+			c.JSON(http.StatusConflict, models.BatchConflictResponse{
+				Error:      "Duplicate product IDs in batch",
+				ProductIDs: conflictErr.ProductIDs,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Batch processing failed", Details: err.Error()})
+		return
+	}
+
+	items := make([]models.BatchItemResult, 0, len(events))
+	var firstErr error
+	for j, outcome := range outcomes {
+		result := &results[indexes[j]]
+		switch {
+		case outcome.Superseded:
+			result.Status = models.BatchItemAccepted
+			result.Code = models.ErrorCodeSuperseded
+		case outcome.Duplicate:
+			result.Status = models.BatchItemAccepted
+			result.Code = models.ErrorCodeDuplicate
+		case outcome.Err != nil:
+			refundQuota(1)
+			result.Status = models.BatchItemRejected
+			result.Code = batchErrorCode(outcome.Err)
+			result.Error = lastAttemptError(outcome.Err).Error()
+			if firstErr == nil {
+				firstErr = outcome.Err
+			}
+		default:
+			result.Status = models.BatchItemAccepted
+			items = append(items, models.BatchItemResult{ProductID: events[j].ProductID, Status: outcome.Status})
+		}
+	}
+
+	received, accepted := len(req.Events), len(items)
+	// A batch of nothing but duplicates was still handled, so only failures reject it
+	if accepted == 0 && firstErr != nil && firstInvalid == nil {
+		pc.logger.Printf("Rejected batch of %d events: %v", received, firstErr)
+		pc.writeBatchRejection(c, firstErr, received)
+		return
+	}
+
+	status, message := http.StatusAccepted, "Batch accepted for processing"
+	if firstInvalid != nil || firstErr != nil {
+		pc.logger.Printf("Accepted %d of %d events in batch", accepted, received)
+		status, message = http.StatusMultiStatus, "Batch partially accepted for processing"
+	}
+	writeSuccess(c, status, models.BatchEventResponse{
+		Message:  message,
+		Received: received,
+		Accepted: accepted,
+		Items:    items,
+		Results:  results,
+	})
+}
+
+// prepareBatchEvent fills in defaults and validates a decoded batch event
+func (pc *ProductController) prepareBatchEvent(raw json.RawMessage, event *models.ProductEvent) error {
+	if event.Currency == "" {
+		event.Currency = pc.baseCurrency
+	}
+	if pc.fixedPricePlaces {
+		price, err := pc.fixedPlacesPrice(raw)
+		if err != nil {
+			return err
+		}
+		event.Price = price
+	}
+	if err := event.ValidateWith(pc.idRules); err != nil {
+		return err
+	}
+	return pc.productService.ValidateEvent(*event)
+}
+
+// writeBatchRejection responds to a batch none of whose events could be enqueued
+func (pc *ProductController) writeBatchRejection(c *gin.Context, err error, received int) {
+	details := fmt.Sprintf("accepted 0 of %d events", received)
+	switch {
+	case errors.Is(err, services.ErrProductLimitReached):
+		setRetryAfter(c, pc.productService.RetryAfter("", err))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Product limit reached", Details: details})
+	case errors.Is(err, services.ErrInFlightLimitReached):
+		setRetryAfter(c, pc.productService.RetryAfter("", err))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Too many events in flight", Details: details})
+	case errors.Is(err, services.ErrNotAdmitted):
+		setRetryAfter(c, pc.productService.RetryAfter("", err))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Code: models.ErrorCodeNotAdmitted, Error: "Queue is busy", Details: details + ": " + err.Error()})
+	default:
+		setRetryAfter(c, pc.productService.RetryAfter("", err))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Queue is full", Details: details})
+	}
+}
+
+// lastAttemptError unwraps the error of the final attempt from a retried operation
+func lastAttemptError(err error) error {
+	var exhausted *retry.ExhaustedError
+	if errors.As(err, &exhausted) && exhausted.LastErr != nil {
+		return exhausted.LastErr
+	}
+	return err
+}
+
+// batchErrorCode maps an enqueue error to the code reported for a batch item
+func batchErrorCode(err error) string {
+	switch {
+	case errors.Is(err, services.ErrProductLimitReached):
+		return models.ErrorCodeProductLimit
+	case errors.Is(err, services.ErrInFlightLimitReached):
+		return models.ErrorCodeInFlightLimit
+	case errors.Is(err, services.ErrNotAdmitted):
+		return models.ErrorCodeNotAdmitted
+	case errors.Is(err, queue.ErrQueueClosed):
+		return models.ErrorCodeShuttingDown
+	case errors.Is(err, queue.ErrQueueFull):
+		return models.ErrorCodeQueueFull
+	default:
+		return models.ErrorCodeInternal
+	}
+}
+
+// SetProductIDRules sets the length and charset rules product IDs are validated against
+func (pc *ProductController) SetProductIDRules(rules models.ProductIDRules) {
+	pc.idRules = rules
+}
+
 // GetProduct handles GET /products/{id}
 func (pc *ProductController) GetProduct(c *gin.Context) {
 	productID := c.Param("id")
 
-	product, exists := pc.productService.GetProduct(productID)
+	product, exists, err := pc.productService.GetProductContext(c.Request.Context(), productID)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			setRetryAfter(c, lookupRetryAfter)
+			c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{Error: "Request timed out"})
+			return
+		}
+		pc.logger.Printf("Lookup of product %s abandoned: %v", productID, err)
+		c.JSON(StatusClientClosedRequest, models.ErrorResponse{Error: "Client closed request"})
+		return
+	}
 	if !exists {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Product not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	pc.writeProduct(c, http.StatusOK, product)
+}
+
+// writeProduct renders a product, with its exact price in fixed price places mode
+func (pc *ProductController) writeProduct(c *gin.Context, status int, product *models.Product) {
+	if pc.fixedPricePlaces {
+		if product.Amount != nil {
+			writeSuccess(c, status, decimalProduct{Product: product, Price: *product.Amount})
+			return
+		}
+		// Stored before fixed mode was enabled, so round it to the fixed places
+		if price, err := models.DecimalFromFloat(product.Price, pc.pricePlaces); err == nil {
+			writeSuccess(c, status, decimalProduct{Product: product, Price: price})
+			return
+		}
+	}
+	writeSuccess(c, status, product)
+}
+
+// decimalProduct renders a product with its price as a fixed-precision decimal
+type decimalProduct struct {
+	*models.Product
+	Price models.Decimal `json:"price"`
+}
+
+// SetBaseCurrency sets the currency assumed for events that omit one
+func (pc *ProductController) SetBaseCurrency(currency string) error {
+	if !models.IsValidCurrency(currency) {
+		return fmt.Errorf("unsupported base currency %q", currency)
+	}
+	pc.baseCurrency = currency
+	return nil
+}
+
+// SetFixedPricePlaces limits prices to the given number of decimal places. Prices
+// written with more are rejected, and products are rendered with exactly that
+// many. Prices are still stored as floats, so this checks and formats them
+// rather than making arithmetic on them exact.
+func (pc *ProductController) SetFixedPricePlaces(places int) error {
+	if places < 0 || places > models.MaxDecimalPlaces {
+		return fmt.Errorf("price decimal places must be between 0 and %d, got %d", models.MaxDecimalPlaces, places)
+	}
+	pc.fixedPricePlaces = true
+	pc.pricePlaces = places
+	return nil
+}
+
+// fixedPlacesPrice re-reads the price from the raw event as written, so its
+// decimal places are checked exactly rather than after rounding to a float
+func (pc *ProductController) fixedPlacesPrice(raw []byte) (float64, error) {
+	var fields struct {
+		Price json.Number `json:"price"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return 0, fmt.Errorf("price must be a number: %v", err)
+	}
+	if fields.Price == "" {
+		return 0, nil
+	}
+
+	price, err := models.ParseDecimal(string(fields.Price), pc.pricePlaces)
+	if err != nil {
+		return 0, fmt.Errorf("price %v", err)
+	}
+	return price.Float64(), nil
+}
+
+// describeJSONError explains a decode failure in terms of the payload, naming
+// the offending field and its expected type where the decoder reports one
+func describeJSONError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field == "" {
+			return fmt.Sprintf("payload must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value)
+		}
+		return fmt.Sprintf("field %q must be %s, got %s", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Value)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("malformed JSON at byte %d: %v", syntaxErr.Offset, syntaxErr)
+	}
+
+	if errors.Is(err, io.EOF) {
+		return "request body is empty"
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return "request body ends before the JSON is complete"
+	}
+	return err.Error()
+}
+
+// jsonTypeName describes a Go type as the JSON value expected for it
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	default:
+		return t.String()
+	}
 }
@@ -2,16 +2,28 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"product-service/internal/middleware"
 	"product-service/internal/models"
 	"product-service/internal/repositories"
 	"product-service/internal/services"
 	"product-service/pkg/queue"
+	"product-service/pkg/retry"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,6 +58,12 @@ func TestProductController(t *testing.T) {
 		if w.Code != http.StatusAccepted {
 			t.Errorf("Expected status 202, got %d", w.Code)
 		}
+
+		var resp models.EventResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Ticket <= 0 {
+			t.Errorf("Expected a positive ticket, got %d", resp.Ticket)
+		}
 	})
 
 	// Test invalid JSON
@@ -77,6 +95,71 @@ func TestProductController(t *testing.T) {
 		}
 	})
 
+	// Test unsupported schema version
+	t.Run("HandleEvent_UnsupportedSchemaVersion", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"schema_version": 2, "product_id": "v2", "price": 1.0, "stock": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+
+		var errorResp models.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &errorResp)
+		if errorResp.Error != "unsupported schema_version 2, supported versions: [1]" {
+			t.Errorf("Expected unsupported schema version error, got '%s'", errorResp.Error)
+		}
+	})
+
+	// Test unknown priority
+	t.Run("HandleEvent_InvalidPriority", func(t *testing.T) {
+		event := models.ProductEvent{ProductID: "priority-test", Price: 10.0, Stock: 5, Priority: "urgent"}
+		eventJSON, _ := json.Marshal(event)
+
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(eventJSON))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	// Test product ID length and charset validation
+	t.Run("HandleEvent_ProductIDValidation", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			productID string
+			expected  int
+		}{
+			{"OverLength", strings.Repeat("x", models.DefaultProductIDMaxLength+1), http.StatusBadRequest},
+			{"ControlCharacter", "bad\tid", http.StatusBadRequest},
+			{"Valid", "valid-id_1.0", http.StatusAccepted},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				event := models.ProductEvent{ProductID: test.productID, Price: 1.0, Stock: 1}
+				eventJSON, _ := json.Marshal(event)
+
+				req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(eventJSON))
+				req.Header.Set("Content-Type", "application/json")
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != test.expected {
+					t.Errorf("Expected status %d, got %d", test.expected, w.Code)
+				}
+			})
+		}
+	})
+
 	// Test GET /products/{id} - product exists
 	t.Run("GetProduct_Exists", func(t *testing.T) {
 		// First create a product by processing an event
@@ -168,5 +251,1016 @@ func TestProductController(t *testing.T) {
 		if w.Code != http.StatusServiceUnavailable {
 			t.Errorf("Expected status 503 for queue full, got %d", w.Code)
 		}
+
+		var errorResp models.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+			t.Errorf("Failed to unmarshal error response: %v", err)
+		}
+		if errorResp.Details != "queue depth 1 of capacity 1" {
+			t.Errorf("Expected queue depth details, got '%s'", errorResp.Details)
+		}
 	})
 }
+
+func TestProductController_HandleBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	batch := `{"events": [
+		{"product_id": "dup", "price": 1.0, "stock": 1},
+		{"product_id": "other", "price": 2.0, "stock": 2},
+		{"product_id": "dup", "price": 3.0, "stock": 3}
+	]}`
+
+	newRouter := func(policy services.BatchConflictPolicy) (*gin.Engine, queue.EventQueue) {
+		eventQueue := queue.NewInMemoryEventQueue(10)
+		productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+		productService.SetBatchConflictPolicy(policy)
+		controller := NewProductController(productService)
+
+		router := gin.New()
+		router.POST("/events/batch", controller.HandleBatch)
+		return router, eventQueue
+	}
+
+	t.Run("LastWins", func(t *testing.T) {
+		router, eventQueue := newRouter(services.BatchConflictLastWins)
+
+		req, _ := http.NewRequest("POST", "/events/batch", bytes.NewBufferString(batch))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+
+		var resp models.BatchEventResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Received != 3 || resp.Accepted != 2 {
+			t.Errorf("Expected received=3, accepted=2, got received=%d, accepted=%d", resp.Received, resp.Accepted)
+		}
+
+		eventQueue.Dequeue()
+		last, _ := eventQueue.Dequeue()
+		if last.ProductID != "dup" || last.Price != 3.0 {
+			t.Errorf("Expected last dup event with price 3.0 to win, got %s with price %.2f", last.ProductID, last.Price)
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		router, eventQueue := newRouter(services.BatchConflictReject)
+
+		req, _ := http.NewRequest("POST", "/events/batch", bytes.NewBufferString(batch))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Fatalf("Expected status 409, got %d", w.Code)
+		}
+
+		var resp models.BatchConflictResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.ProductIDs) != 1 || resp.ProductIDs[0] != "dup" {
+			t.Errorf("Expected duplicate IDs [dup], got %v", resp.ProductIDs)
+		}
+		if eventQueue.Len() != 0 {
+			t.Errorf("Expected nothing enqueued, got queue length %d", eventQueue.Len())
+		}
+	})
+
+	t.Run("InvalidEvent", func(t *testing.T) {
+		router, _ := newRouter(services.BatchConflictLastWins)
+
+		req, _ := http.NewRequest("POST", "/events/batch", bytes.NewBufferString(`{"events": [{"price": 1.0}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestProductController_HandleBatch_PartialFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	eventQueue := queue.NewInMemoryEventQueue(2)
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+	productService.SetEnqueueRetry(&retry.RetryConfig{MaxAttempts: 1, Multiplier: 1})
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events/batch", controller.HandleBatch)
+
+	post := func(body string) (*httptest.ResponseRecorder, models.BatchEventResponse) {
+		req, _ := http.NewRequest("POST", "/events/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp models.BatchEventResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	t.Run("MixedOutcomes", func(t *testing.T) {
+		// The queue holds two events, so the fourth item finds it full
+		w, resp := post(`{"events": [
+			{"product_id": "first", "price": 1.0, "stock": 1},
+			{"product_id": "negative", "price": -5.0, "stock": 1},
+			{"product_id": "third", "price": 3.0, "stock": 3},
+			{"product_id": "fourth", "price": 4.0, "stock": 4}
+		]}`)
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("Expected status 207, got %d: %s", w.Code, w.Body.String())
+		}
+		if resp.Received != 4 || resp.Accepted != 2 {
+			t.Errorf("Expected received=4, accepted=2, got received=%d, accepted=%d", resp.Received, resp.Accepted)
+		}
+
+		expected := []models.BatchItemOutcome{
+			{Index: 0, ProductID: "first", Status: models.BatchItemAccepted},
+			{Index: 1, ProductID: "negative", Status: models.BatchItemRejected, Code: models.ErrorCodeInvalidEvent, Error: "price must not be negative"},
+			{Index: 2, ProductID: "third", Status: models.BatchItemAccepted},
+			{Index: 3, ProductID: "fourth", Status: models.BatchItemRejected, Code: models.ErrorCodeQueueFull, Error: "queue is full"},
+		}
+		if len(resp.Results) != len(expected) {
+			t.Fatalf("Expected %d results, got %+v", len(expected), resp.Results)
+		}
+		for i := range expected {
+			if resp.Results[i] != expected[i] {
+				t.Errorf("Expected result %d to be %+v, got %+v", i, expected[i], resp.Results[i])
+			}
+		}
+
+		first, _ := eventQueue.Dequeue()
+		second, _ := eventQueue.Dequeue()
+		if first.ProductID != "first" || second.ProductID != "third" {
+			t.Errorf("Expected first and third to be enqueued, got %s and %s", first.ProductID, second.ProductID)
+		}
+	})
+
+	t.Run("Superseded", func(t *testing.T) {
+		w, resp := post(`{"events": [
+			{"product_id": "dup", "price": 1.0, "stock": 1},
+			{"product_id": "dup", "price": 2.0, "stock": 2}
+		]}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		if resp.Results[0].Code != models.ErrorCodeSuperseded || resp.Results[1].Status != models.BatchItemAccepted {
+			t.Errorf("Expected the earlier duplicate to be superseded by the later one, got %+v", resp.Results)
+		}
+		eventQueue.Dequeue()
+	})
+
+	t.Run("NothingAccepted", func(t *testing.T) {
+		eventQueue.Enqueue(models.ProductEvent{ProductID: "filler-1"})
+		eventQueue.Enqueue(models.ProductEvent{ProductID: "filler-2"})
+
+		w, _ := post(`{"events": [{"product_id": "late", "price": 1.0, "stock": 1}]}`)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", w.Code)
+		}
+	})
+}
+
+func TestProductController_APIKeyQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	eventQueue := queue.NewInMemoryEventQueue(2)
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+	productService.SetEnqueueRetry(&retry.RetryConfig{MaxAttempts: 1, Multiplier: 1})
+	controller := NewProductController(productService)
+	router := gin.New()
+	events := router.Group("/events", middleware.APIKeyQuota(middleware.NewQuotaLimiter(nil, 3, time.Minute)))
+	events.POST("", controller.HandleEvent)
+	events.POST("/batch", controller.HandleBatch)
+
+	post := func(path, body string) int {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Of the three valid events, the queue only holds two, so two are charged
+	code := post("/events/batch", `{"events": [
+		{"product_id": "a", "price": 1.0, "stock": 1},
+		{"product_id": "negative", "price": -5.0, "stock": 1},
+		{"product_id": "b", "price": 2.0, "stock": 2},
+		{"product_id": "c", "price": 3.0, "stock": 3}
+	]}`)
+	if code != http.StatusMultiStatus {
+		t.Fatalf("Expected status 207, got %d", code)
+	}
+	if code := post("/events", `{"product_id": "d", "price": 4.0, "stock": 4}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 with the queue full, got %d", code)
+	}
+	eventQueue.Dequeue()
+	eventQueue.Dequeue()
+
+	if code := post("/events/batch", `{"events": [{"product_id": "d", "price": 4.0, "stock": 4}, {"product_id": "e", "price": 5.0, "stock": 5}]}`); code != http.StatusTooManyRequests {
+		t.Errorf("Expected a batch larger than the remaining quota to get 429, got %d", code)
+	}
+	if code := post("/events", `{"product_id": "d", "price": 4.0, "stock": 4}`); code != http.StatusAccepted {
+		t.Errorf("Expected the last event of the quota to be accepted, got %d", code)
+	}
+	if code := post("/events", `{"product_id": "e", "price": 5.0, "stock": 5}`); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 once the quota is used up, got %d", code)
+	}
+}
+
+// slowProductRepository simulates a backend that takes a long time to answer lookups
+type slowProductRepository struct {
+	delay time.Duration
+}
+
+func (r *slowProductRepository) Get(id string) (*models.Product, bool) {
+	time.Sleep(r.delay)
+	return nil, false
+}
+
+func (r *slowProductRepository) Update(id string, price float64, stock int, currency string) {}
+
+func TestProductController_HandleBatch_Upsert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	repo.Update("existing", 1.0, 1, "")
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	controller := NewProductController(productService)
+
+	router := gin.New()
+	router.POST("/events/batch", controller.HandleBatch)
+
+	batch := `{"events": [
+		{"product_id": "new", "price": 2.0, "stock": 2},
+		{"product_id": "existing", "price": 3.0, "stock": 3}
+	]}`
+	req, _ := http.NewRequest("POST", "/events/batch", bytes.NewBufferString(batch))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+
+	var resp models.BatchEventResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	expected := map[string]string{"new": models.UpsertCreated, "existing": models.UpsertUpdated}
+	if len(resp.Items) != len(expected) {
+		t.Fatalf("Expected %d items, got %+v", len(expected), resp.Items)
+	}
+	for _, item := range resp.Items {
+		if item.Status != expected[item.ProductID] {
+			t.Errorf("Expected %s to be %s, got %s", item.ProductID, expected[item.ProductID], item.Status)
+		}
+	}
+}
+
+func TestProductController_GetProduct_ContextDone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	productService := services.NewProductService(&slowProductRepository{delay: 2 * time.Second}, queue.NewInMemoryEventQueue(1), 1)
+	controller := NewProductController(productService)
+
+	router := gin.New()
+	router.GET("/products/:id", controller.GetProduct)
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/products/slow", nil)
+		w := httptest.NewRecorder()
+
+		start := time.Now()
+		router.ServeHTTP(w, req)
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected handler to return promptly after cancellation, took %v", elapsed)
+		}
+		if w.Code != StatusClientClosedRequest {
+			t.Errorf("Expected status 499, got %d", w.Code)
+		}
+	})
+
+	t.Run("DeadlineExceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/products/slow", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected status 504, got %d", w.Code)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != "1" {
+			t.Errorf("Expected Retry-After 1, got %q", retryAfter)
+		}
+	})
+}
+
+func TestProductController_RetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	eventQueue := queue.NewInMemoryEventQueue(1)
+	productService := services.NewProductService(repo, eventQueue, 1)
+	productService.RetryConfig().InitialDelay = time.Millisecond
+	productService.SetEnqueueRetry(&retry.RetryConfig{MaxAttempts: 1})
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.POST("/events/batch", controller.HandleBatch)
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	retryAfter := func(t *testing.T, w *httptest.ResponseRecorder) int {
+		t.Helper()
+		seconds, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		if err != nil {
+			t.Fatalf("Expected a Retry-After in seconds, got %q", w.Header().Get("Retry-After"))
+		}
+		return seconds
+	}
+
+	t.Run("QueueFull", func(t *testing.T) {
+		eventQueue.Enqueue(models.ProductEvent{ProductID: "filler"})
+		defer eventQueue.Dequeue()
+
+		w := send("/events", `{"product_id": "full", "price": 1.0, "stock": 1}`)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", w.Code)
+		}
+		if seconds := retryAfter(t, w); seconds < 1 || seconds > 60 {
+			t.Errorf("Expected Retry-After between 1 and 60 seconds, got %d", seconds)
+		}
+	})
+
+	t.Run("BatchQueueFull", func(t *testing.T) {
+		eventQueue.Enqueue(models.ProductEvent{ProductID: "filler"})
+		defer eventQueue.Dequeue()
+
+		w := send("/events/batch", `{"events": [{"product_id": "full", "price": 1.0, "stock": 1}]}`)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", w.Code)
+		}
+		if seconds := retryAfter(t, w); seconds < 1 || seconds > 60 {
+			t.Errorf("Expected Retry-After between 1 and 60 seconds, got %d", seconds)
+		}
+	})
+
+	t.Run("InFlightLimit", func(t *testing.T) {
+		limiter := services.NewInFlightLimiter(1)
+		limiter.Acquire()
+		productService.SetInFlightLimiter(limiter)
+		defer productService.SetInFlightLimiter(nil)
+
+		w := send("/events", `{"product_id": "busy", "price": 1.0, "stock": 1}`)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", w.Code)
+		}
+		if seconds := retryAfter(t, w); seconds < 1 || seconds > 60 {
+			t.Errorf("Expected Retry-After between 1 and 60 seconds, got %d", seconds)
+		}
+	})
+
+	t.Run("ProductLimit", func(t *testing.T) {
+		repo.Update("stored", 1.0, 1, "")
+		defer repo.Delete("stored")
+		productService.SetProductLimiter(services.NewProductLimiter(1, repo))
+		defer productService.SetProductLimiter(nil)
+
+		for _, path := range []string{"/events", "/events?sync=true"} {
+			w := send(path, `{"product_id": "new", "price": 1.0, "stock": 1}`)
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("Expected status 503 from %s, got %d", path, w.Code)
+			}
+			if seconds := retryAfter(t, w); seconds != 60 {
+				t.Errorf("Expected Retry-After of 60 seconds from %s, got %d", path, seconds)
+			}
+		}
+	})
+
+	t.Run("CircuitOpen", func(t *testing.T) {
+		breaker := productService.CircuitBreakers().Get("flaky-feed")
+		for i := 0; i < breaker.GetThreshold(); i++ {
+			breaker.Execute(func() error { return errors.New("downstream failure") })
+		}
+
+		w := send("/events?sync=true", `{"product_id": "flaky", "price": 1.0, "stock": 1, "category": "flaky-feed"}`)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", w.Code)
+		}
+		// The breaker half-opens after its 60s timeout
+		if seconds := retryAfter(t, w); seconds < 59 || seconds > 60 {
+			t.Errorf("Expected Retry-After close to the breaker timeout, got %d", seconds)
+		}
+	})
+}
+
+func TestProductController_ShuttingDown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	productService.Stop()
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"product_id": "late", "price": 1.0, "stock": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", w.Code)
+	}
+	var response models.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Error != "Service is shutting down" {
+		t.Errorf("Expected error 'Service is shutting down', got %q", response.Error)
+	}
+	if w.Header().Get("Retry-After") != "" {
+		t.Errorf("Expected no Retry-After while shutting down, got %q", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestProductController_FixedPricePlaces(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	controller := NewProductController(productService)
+	if err := controller.SetFixedPricePlaces(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.GET("/products/:id", controller.GetProduct)
+
+	t.Run("RenderedWithFixedPlaces", func(t *testing.T) {
+		// 0.1 + 0.2 as a float is 0.30000000000000004
+		repo.Update("decimal", 0.1+0.2, 1, "")
+
+		req, _ := http.NewRequest("GET", "/products/decimal", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if !strings.Contains(w.Body.String(), `"price":0.30`) {
+			t.Errorf("Expected price rendered as 0.30, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("TooManyPlacesRejected", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"product_id": "decimal", "price": 19.999, "stock": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+
+		var errorResp models.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &errorResp)
+		if errorResp.Error != "price must have at most 2 decimal places" {
+			t.Errorf("Expected decimal places error, got '%s'", errorResp.Error)
+		}
+	})
+
+	t.Run("ValidPriceAccepted", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"product_id": "decimal", "price": 19.99, "stock": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusAccepted {
+			t.Errorf("Expected status 202, got %d", w.Code)
+		}
+	})
+
+	t.Run("NonNumericPriceRejected", func(t *testing.T) {
+		if _, err := controller.fixedPlacesPrice([]byte(`{"product_id": "decimal", "price": true}`)); err == nil {
+			t.Error("Expected error for a non-numeric price")
+		}
+	})
+
+	t.Run("InvalidPlaces", func(t *testing.T) {
+		if err := controller.SetFixedPricePlaces(models.MaxDecimalPlaces + 1); err == nil {
+			t.Error("Expected error for too many decimal places")
+		}
+	})
+}
+
+func TestProductController_FixedPriceRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	if err := repo.SetPricePlaces(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	productService.SetEventTransformers(services.ApplyMarkup(10), services.FixedPricePlaces(2))
+	productService.Start()
+	defer productService.Stop()
+
+	controller := NewProductController(productService)
+	if err := controller.SetFixedPricePlaces(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.GET("/products/:id", controller.GetProduct)
+
+	postEvent := func(body string) int {
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		id    string
+		price string
+		want  string
+	}{
+		// 0.1 with a 10% markup is 0.11000000000000001 as a float
+		{"cents", "0.10", `"price":0.11`},
+		{"rounded", "19.99", `"price":21.99`},
+		{"largest", "909090909090.90", `"price":999999999999.99`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			body := fmt.Sprintf(`{"product_id": "%s", "price": %s, "stock": 1}`, tt.id, tt.price)
+			if code := postEvent(body); code != http.StatusAccepted {
+				t.Fatalf("Expected status 202, got %d", code)
+			}
+			time.Sleep(50 * time.Millisecond)
+
+			req, _ := http.NewRequest("GET", "/products/"+tt.id, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("Expected %s, got %s", tt.want, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("OverflowRejected", func(t *testing.T) {
+		if code := postEvent(`{"product_id": "overflow", "price": 10000000000000, "stock": 1}`); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", code)
+		}
+	})
+
+	t.Run("OverflowAfterMarkupRejected", func(t *testing.T) {
+		postEvent(`{"product_id": "marked-up", "price": 9999999999999.99, "stock": 1}`)
+		time.Sleep(50 * time.Millisecond)
+
+		if _, exists := repo.Get("marked-up"); exists {
+			t.Error("Expected product with an overflowing price not to be stored")
+		}
+	})
+}
+
+func TestProductController_Currency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	productService.Start()
+	defer productService.Stop()
+
+	controller := NewProductController(productService)
+	if err := controller.SetBaseCurrency("GBP"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.GET("/products/:id", controller.GetProduct)
+
+	postEvent := func(body string) int {
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	getProduct := func(id string) models.Product {
+		req, _ := http.NewRequest("GET", "/products/"+id, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var product models.Product
+		json.Unmarshal(w.Body.Bytes(), &product)
+		return product
+	}
+
+	t.Run("ValidCurrency", func(t *testing.T) {
+		if code := postEvent(`{"product_id": "eur-product", "price": 5.0, "stock": 1, "currency": "EUR"}`); code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", code)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		if product := getProduct("eur-product"); product.Currency != "EUR" {
+			t.Errorf("Expected currency EUR, got '%s'", product.Currency)
+		}
+	})
+
+	t.Run("InvalidCurrency", func(t *testing.T) {
+		if code := postEvent(`{"product_id": "bad-currency", "price": 5.0, "stock": 1, "currency": "XYZ"}`); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", code)
+		}
+	})
+
+	t.Run("DefaultWhenOmitted", func(t *testing.T) {
+		if code := postEvent(`{"product_id": "default-currency", "price": 5.0, "stock": 1}`); code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", code)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		if product := getProduct("default-currency"); product.Currency != "GBP" {
+			t.Errorf("Expected base currency GBP, got '%s'", product.Currency)
+		}
+	})
+
+	t.Run("InvalidBaseCurrency", func(t *testing.T) {
+		if err := controller.SetBaseCurrency("XYZ"); err == nil {
+			t.Error("Expected error for unsupported base currency")
+		}
+	})
+}
+
+func TestProductController_EventValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repo, eventQueue, 1)
+	productService.SetEventValidator(services.PriceBandValidator(1.0, 100.0))
+
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.POST("/events/batch", controller.HandleBatch)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		price    string
+		expected int
+	}{
+		{"BelowBand", "0.5", http.StatusBadRequest},
+		{"AboveBand", "100.01", http.StatusBadRequest},
+		{"InBand", "50", http.StatusAccepted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := post("/events", `{"product_id": "band", "price": `+test.price+`, "stock": 1}`)
+			if w.Code != test.expected {
+				t.Errorf("Expected status %d, got %d", test.expected, w.Code)
+			}
+			if test.expected == http.StatusBadRequest && !strings.Contains(w.Body.String(), "outside the allowed range") {
+				t.Errorf("Expected validator message in response, got %s", w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		depth := eventQueue.Len()
+		w := post("/events/batch", `{"events": [{"product_id": "ok", "price": 5, "stock": 1}, {"product_id": "bad", "price": 500, "stock": 1}]}`)
+		if w.Code != http.StatusMultiStatus {
+			t.Errorf("Expected status 207, got %d", w.Code)
+		}
+
+		var resp models.BatchEventResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Results) != 2 || resp.Results[1].Status != models.BatchItemRejected ||
+			!strings.Contains(resp.Results[1].Error, "outside the allowed range") {
+			t.Errorf("Expected the second event rejected by the validator, got %+v", resp.Results)
+		}
+		if eventQueue.Len() != depth+1 {
+			t.Error("Expected only the valid event of the batch to be enqueued")
+		}
+	})
+
+	dropped := productService.Metrics().Counter("dropped_total", "", map[string]string{"reason": services.DropReasonValidation}).Value()
+	if dropped != 3 {
+		t.Errorf("Expected 3 validation drops, got %d", dropped)
+	}
+}
+
+func TestProductController_Duplicate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+	productService.SetDeduplicator(services.NewDeduplicator(time.Minute))
+
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.POST("/events/batch", controller.HandleBatch)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	event := `{"product_id": "dup", "price": 5, "stock": 1}`
+	if w := post("/events", event); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+
+	w := post("/events", event)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for a duplicate, got %d", w.Code)
+	}
+	var resp models.EventResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.Duplicate || resp.Ticket != 0 || strings.Contains(w.Body.String(), "ticket") {
+		t.Errorf("Expected a duplicate response without a ticket, got %s", w.Body.String())
+	}
+
+	w = post("/events/batch", `{"events": [`+event+`]}`)
+	var batch models.BatchEventResponse
+	json.Unmarshal(w.Body.Bytes(), &batch)
+	if len(batch.Results) != 1 || batch.Results[0].Code != models.ErrorCodeDuplicate {
+		t.Errorf("Expected the batch event marked %s, got %+v", models.ErrorCodeDuplicate, batch.Results)
+	}
+	if eventQueue.Len() != 1 {
+		t.Errorf("Expected only the first event enqueued, got %d", eventQueue.Len())
+	}
+}
+
+func TestProductController_SyncProcessing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &explodingProductRepository{repositories.NewInMemoryProductRepository(), "broken"}
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	productService.RetryConfig().InitialDelay = time.Millisecond
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.GET("/products/:id", controller.GetProduct)
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	get := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/products/"+id, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Sync", func(t *testing.T) {
+		w := send("/events?sync=true", `{"product_id": "sync-product", "price": 12.5, "stock": 4}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var product models.Product
+		if err := json.Unmarshal(w.Body.Bytes(), &product); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if product.ID != "sync-product" || product.Price != 12.5 || product.Stock != 4 {
+			t.Errorf("Expected the applied product in the response, got %+v", product)
+		}
+
+		// No workers are running, so only the synchronous path could have applied it
+		if w := get("sync-product"); w.Code != http.StatusOK {
+			t.Errorf("Expected the product to be readable right after the response, got %d", w.Code)
+		}
+	})
+
+	t.Run("ProcessingError", func(t *testing.T) {
+		w := send("/events?sync=true", `{"product_id": "broken", "price": 1, "stock": 1}`)
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, got %d", w.Code)
+		}
+
+		var response models.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Error != "Event processing failed" || !strings.Contains(response.Details, "repository exploded") {
+			t.Errorf("Expected the processing error in the response, got %+v", response)
+		}
+		if productService.DeadLetterQueue().Len() != 0 {
+			t.Errorf("Expected a synchronous failure not to be dead-lettered, got %d", productService.DeadLetterQueue().Len())
+		}
+	})
+
+	t.Run("AsyncByDefault", func(t *testing.T) {
+		w := send("/events", `{"product_id": "async-product", "price": 12.5, "stock": 4}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		if w := get("async-product"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected the queued product not to be applied yet, got %d", w.Code)
+		}
+
+		productService.Start()
+		defer productService.Stop()
+		productService.WorkerPool().Drain()
+		time.Sleep(50 * time.Millisecond)
+		if w := get("async-product"); w.Code != http.StatusOK {
+			t.Errorf("Expected the product to be readable once processed, got %d", w.Code)
+		}
+	})
+}
+
+func TestProductController_JSONErrorDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+	router.POST("/events/batch", controller.HandleBatch)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		error   string
+		details string
+	}{
+		{"TypeMismatch", "/events", `{"product_id": "abc", "price": "abc", "stock": 1}`, "Invalid JSON payload", `field "price" must be a number, got string`},
+		{"IntegerField", "/events", `{"product_id": "abc", "price": 1.5, "stock": 2.5}`, "Invalid JSON payload", `field "stock" must be an integer, got number 2.5`},
+		{"SyntaxError", "/events", `{"product_id": "abc", "price": 1.5,}`, "Invalid JSON payload", `malformed JSON at byte 36: invalid character '}' looking for beginning of object key string`},
+		{"NotAnObject", "/events", `[1, 2]`, "Invalid JSON payload", `payload must be an object, got array`},
+		{"BatchTypeMismatch", "/events/batch", `{"events": [{"product_id": "abc", "price": true, "stock": 1}]}`, "Invalid event in batch", `events[0]: field "price" must be a number, got bool`},
+		{"BatchNotAnObject", "/events/batch", `{"events": "abc"}`, "Invalid JSON payload", `field "events" must be an array, got string`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", test.path, bytes.NewBufferString(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.Error != test.error {
+				t.Errorf("Expected error '%s', got '%s'", test.error, response.Error)
+			}
+			if response.Details != test.details {
+				t.Errorf("Expected details '%s', got '%s'", test.details, response.Details)
+			}
+		})
+	}
+}
+
+func TestProductController_TracePropagation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	repo := repositories.NewInMemoryProductRepository()
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	productService.Start()
+	controller := NewProductController(productService)
+
+	router := gin.New()
+	router.Use(middleware.Tracing())
+	router.POST("/events", controller.HandleEvent)
+
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"product_id": "traced", "price": 1.0, "stock": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Stop drains the queue, so the worker span has ended by the time it returns
+	productService.Stop()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	parent, ok := spans["POST /events"]
+	if !ok {
+		t.Fatalf("Expected an HTTP server span, got %v", spans)
+	}
+	child, ok := spans["processEvent"]
+	if !ok {
+		t.Fatalf("Expected a processEvent span, got %v", spans)
+	}
+
+	if child.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("Expected worker span in trace %s, got %s", parent.SpanContext().TraceID(), child.SpanContext().TraceID())
+	}
+	if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Expected worker span parent %s, got %s", parent.SpanContext().SpanID(), child.Parent().SpanID())
+	}
+}
+
+func TestProductController_RequestIDOnEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.POST("/events", controller.HandleEvent)
+	router.POST("/events/batch", controller.HandleBatch)
+
+	for path, body := range map[string]string{
+		"/events":       `{"product_id": "single", "price": 1.0, "stock": 1}`,
+		"/events/batch": `{"events": [{"product_id": "batched", "price": 1.0, "stock": 1}]}`,
+	} {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.RequestIDHeader, "audit-"+path)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		event, ok := eventQueue.Dequeue()
+		if !ok {
+			t.Fatalf("Expected an event enqueued by %s", path)
+		}
+		if event.RequestID != "audit-"+path {
+			t.Errorf("Expected request ID %q on the event, got %q", "audit-"+path, event.RequestID)
+		}
+	}
+}
+
+func TestProductController_AdmissionPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	eventQueue := queue.NewInMemoryEventQueue(10)
+	productService := services.NewProductService(repositories.NewInMemoryProductRepository(), eventQueue, 1)
+	productService.SetAdmissionPolicy(services.NewAdmissionPolicy(1))
+	controller := NewProductController(productService)
+	router := gin.New()
+	router.POST("/events", controller.HandleEvent)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(`{"product_id": "first", "price": 1.0, "stock": 1, "priority": "low"}`); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202 below the watermark, got %d", w.Code)
+	}
+
+	w := send(`{"product_id": "bulk", "price": 1.0, "stock": 1, "priority": "low"}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 for a low-priority event at the watermark, got %d", w.Code)
+	}
+	var errorResp models.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &errorResp)
+	if errorResp.Code != models.ErrorCodeNotAdmitted || !strings.Contains(errorResp.Details, "low-priority") {
+		t.Errorf("Expected a NOT_ADMITTED rejection with a reason, got %+v", errorResp)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+
+	if w := send(`{"product_id": "urgent", "price": 1.0, "stock": 1, "priority": "high"}`); w.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202 for a high-priority event, got %d", w.Code)
+	}
+}
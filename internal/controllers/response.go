@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"product-service/internal/middleware"
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeSuccess writes a successful JSON payload, enveloping it when the envelope mode is enabled
+func writeSuccess(c *gin.Context, status int, payload interface{}) {
+	if !middleware.EnvelopeEnabled(c) {
+		c.JSON(status, payload)
+		return
+	}
+
+	c.JSON(status, models.Envelope{
+		Data: payload,
+		Meta: models.EnvelopeMeta{
+			RequestID: middleware.GetRequestID(c),
+			Timestamp: time.Now().UTC(),
+		},
+	})
+}
+
+// setRetryAfter advertises when a rejected client should retry, in whole seconds rounded up
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"product-service/internal/middleware"
+	"product-service/internal/models"
+	"product-service/internal/repositories"
+	"product-service/internal/services"
+	"product-service/pkg/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResponseEnvelope_GetProduct(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+
+	repo := repositories.NewInMemoryProductRepository()
+	repo.Update("envelope-test", 12.5, 3, "")
+	productService := services.NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	controller := NewProductController(productService)
+
+	newRouter := func(envelope bool) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.RequestID())
+		router.Use(middleware.ResponseEnvelope(envelope))
+		router.GET("/products/:id", controller.GetProduct)
+		return router
+	}
+
+	t.Run("Bare", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/products/envelope-test", nil)
+		w := httptest.NewRecorder()
+		newRouter(false).ServeHTTP(w, req)
+
+		var product models.Product
+		if err := json.Unmarshal(w.Body.Bytes(), &product); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if product.ID != "envelope-test" || product.Price != 12.5 || product.Stock != 3 {
+			t.Errorf("Expected bare product, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Enveloped", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/products/envelope-test", nil)
+		req.Header.Set(middleware.RequestIDHeader, "req-42")
+		w := httptest.NewRecorder()
+		newRouter(true).ServeHTTP(w, req)
+
+		var envelope struct {
+			Data models.Product      `json:"data"`
+			Meta models.EnvelopeMeta `json:"meta"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if envelope.Data.ID != "envelope-test" || envelope.Data.Price != 12.5 {
+			t.Errorf("Expected product under data, got %s", w.Body.String())
+		}
+		if envelope.Meta.RequestID != "req-42" {
+			t.Errorf("Expected request ID 'req-42' in meta, got '%s'", envelope.Meta.RequestID)
+		}
+		if time.Since(envelope.Meta.Timestamp) > time.Minute {
+			t.Errorf("Expected a current server timestamp in meta, got %v", envelope.Meta.Timestamp)
+		}
+	})
+
+	t.Run("ErrorsNotEnveloped", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/products/missing", nil)
+		w := httptest.NewRecorder()
+		newRouter(true).ServeHTTP(w, req)
+
+		var errorResp models.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &errorResp)
+		if w.Code != http.StatusNotFound || errorResp.Error != "Product not found" {
+			t.Errorf("Expected bare 404 error response, got %d %s", w.Code, w.Body.String())
+		}
+	})
+}
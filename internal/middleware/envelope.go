@@ -0,0 +1,19 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// EnvelopeKey is the gin context key signalling that success payloads should be enveloped
+const EnvelopeKey = "response_envelope"
+
+// ResponseEnvelope marks requests so handlers wrap successful payloads in a data/meta envelope
+func ResponseEnvelope(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(EnvelopeKey, enabled)
+		c.Next()
+	}
+}
+
+// EnvelopeEnabled returns true if successful payloads should be enveloped
+func EnvelopeEnabled(c *gin.Context) bool {
+	return c.GetBool(EnvelopeKey)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"product-service/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metric names recorded for every HTTP request
+const (
+	metricHTTPRequests        = "http_requests_total"
+	metricHTTPRequestDuration = "http_request_duration_seconds"
+)
+
+// UnmatchedRoute is the path label recorded for requests that match no route
+const UnmatchedRoute = "unmatched"
+
+// Metrics counts requests and records their latency by method, route template
+// and status. Routes are labelled with gin's FullPath, e.g. /api/v1/products/:id,
+// so the number of series stays bounded however many IDs are requested.
+func Metrics(registry *metrics.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = UnmatchedRoute
+		}
+		labels := metrics.Labels{
+			"method": c.Request.Method,
+			"path":   path,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+
+		registry.Counter(metricHTTPRequests, "HTTP requests, by method, route and status", labels).Inc()
+		registry.Histogram(metricHTTPRequestDuration, "HTTP request latency in seconds, by method, route and status",
+			metrics.DefaultBuckets, labels).Observe(time.Since(start).Seconds())
+	}
+}
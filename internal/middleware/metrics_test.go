@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"product-service/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := metrics.NewRegistry()
+	router := gin.New()
+	router.Use(Metrics(registry))
+	router.GET("/products/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	router.POST("/events", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	for _, request := range []struct{ method, path string }{
+		{"GET", "/products/a"},
+		{"GET", "/products/b"},
+		{"GET", "/products/missing"},
+		{"POST", "/events"},
+		{"GET", "/nowhere"},
+	} {
+		req, _ := http.NewRequest(request.method, request.path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	counter := func(method, path, status string) uint64 {
+		return registry.Counter(metricHTTPRequests, "", metrics.Labels{"method": method, "path": path, "status": status}).Value()
+	}
+	tests := []struct {
+		method, path, status string
+		expected             uint64
+	}{
+		{"GET", "/products/:id", "200", 2},
+		{"GET", "/products/:id", "404", 1},
+		{"POST", "/events", "202", 1},
+		{"GET", UnmatchedRoute, "404", 1},
+	}
+	for _, test := range tests {
+		if got := counter(test.method, test.path, test.status); got != test.expected {
+			t.Errorf("Expected %d requests for %s %s %s, got %d", test.expected, test.method, test.path, test.status, got)
+		}
+	}
+
+	histogram := registry.Histogram(metricHTTPRequestDuration, "", metrics.DefaultBuckets,
+		metrics.Labels{"method": "GET", "path": "/products/:id", "status": "200"})
+	if histogram.Count() != 2 {
+		t.Errorf("Expected 2 latency observations, got %d", histogram.Count())
+	}
+
+	var output strings.Builder
+	registry.WritePrometheus(&output)
+	if strings.Contains(output.String(), "/products/a") {
+		t.Errorf("Expected raw paths not to be used as labels, got:\n%s", output.String())
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, GetRequestID(c))
+	})
+
+	t.Run("Generated", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		requestID := w.Header().Get(RequestIDHeader)
+		if len(requestID) != 32 {
+			t.Errorf("Expected a 32 character request ID, got '%s'", requestID)
+		}
+		if w.Body.String() != requestID {
+			t.Errorf("Expected handler to see request ID '%s', got '%s'", requestID, w.Body.String())
+		}
+	})
+
+	t.Run("Propagated", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, "client-id-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Header().Get(RequestIDHeader) != "client-id-123" {
+			t.Errorf("Expected client request ID to be reused, got '%s'", w.Header().Get(RequestIDHeader))
+		}
+	})
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, enabled := range []bool{true, false} {
+		router := gin.New()
+		router.Use(ResponseEnvelope(enabled))
+
+		var seen bool
+		router.GET("/", func(c *gin.Context) {
+			seen = EnvelopeEnabled(c)
+		})
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if seen != enabled {
+			t.Errorf("Expected envelope enabled=%v, got %v", enabled, seen)
+		}
+	}
+}
+
+func TestReadinessGate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ready := false
+	router := gin.New()
+	router.Use(ReadinessGate(func() bool { return ready }))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/", func(c *gin.Context) { c.Status(http.StatusAccepted) })
+
+	serve := func(method string) int {
+		req, _ := http.NewRequest(method, "/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve("POST"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected writes to be rejected while not ready, got %d", code)
+	}
+	if code := serve("GET"); code != http.StatusOK {
+		t.Errorf("Expected reads to be served while not ready, got %d", code)
+	}
+
+	ready = true
+	if code := serve("POST"); code != http.StatusAccepted {
+		t.Errorf("Expected writes to be accepted once ready, got %d", code)
+	}
+}
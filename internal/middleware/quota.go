@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the header identifying the client's API key
+const APIKeyHeader = "X-API-Key"
+
+// DefaultQuotaWindow replaces a non-positive quota window
+const DefaultQuotaWindow = 24 * time.Hour
+
+// defaultQuotaBucket is the counter shared by requests without a configured key
+const defaultQuotaBucket = ""
+
+// quotaChargeKey is the context key holding the quota charge of a request
+const quotaChargeKey = "quota_charge"
+
+// QuotaLimiter counts accepted events per API key over fixed windows
+//
+// Every key's counter resets when a window ends. Requests with no key or a
+// key without a configured quota share the default quota, so rotating made-up
+// keys does not escape limiting.
+type QuotaLimiter struct {
+	mu           sync.Mutex
+	quotas       map[string]int
+	defaultQuota int
+	window       time.Duration
+	counts       map[string]int
+	windowStart  time.Time
+	now          func() time.Time
+}
+
+// NewQuotaLimiter creates a limiter allowing each key in quotas that many events
+// per window. Other keys, and requests without one, share defaultQuota events
+// per window; a negative defaultQuota leaves them unlimited.
+func NewQuotaLimiter(quotas map[string]int, defaultQuota int, window time.Duration) *QuotaLimiter {
+	if window <= 0 {
+		window = DefaultQuotaWindow
+	}
+	limiter := &QuotaLimiter{
+		quotas:       quotas,
+		defaultQuota: defaultQuota,
+		window:       window,
+		counts:       make(map[string]int),
+		now:          time.Now,
+	}
+	limiter.windowStart = limiter.now()
+	return limiter
+}
+
+// Allow counts n events for key if all of them are within quota, along with
+// how long until the current window resets. Calling refund takes that many
+// events back out of the count, unless the window has since reset.
+func (q *QuotaLimiter) Allow(key string, n int) (allowed bool, resetIn time.Duration, refund func(n int)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	resetIn = q.advance()
+	bucket, quota := q.bucket(key)
+	if quota < 0 {
+		return true, resetIn, func(int) {}
+	}
+	if q.counts[bucket]+n > quota {
+		return false, resetIn, func(int) {}
+	}
+	q.counts[bucket] += n
+
+	windowStart := q.windowStart
+	refund = func(n int) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.windowStart.Equal(windowStart) {
+			q.counts[bucket] = max(0, q.counts[bucket]-n)
+		}
+	}
+	return true, resetIn, refund
+}
+
+// Remaining returns how many more events key may send in the current window,
+// negative if it is unlimited, along with how long until the window resets
+func (q *QuotaLimiter) Remaining(key string) (remaining int, resetIn time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	resetIn = q.advance()
+	bucket, quota := q.bucket(key)
+	if quota < 0 {
+		return -1, resetIn
+	}
+	return max(0, quota-q.counts[bucket]), resetIn
+}
+
+// Quota returns the quota applied to key, negative if it is unlimited
+func (q *QuotaLimiter) Quota(key string) int {
+	_, quota := q.bucket(key)
+	return quota
+}
+
+// advance starts a new window once the current one has ended, returning how
+// long until the current window resets. Callers must hold q.mu.
+func (q *QuotaLimiter) advance() time.Duration {
+	now := q.now()
+	if elapsed := now.Sub(q.windowStart); elapsed >= q.window {
+		q.windowStart = q.windowStart.Add(elapsed / q.window * q.window)
+		q.counts = make(map[string]int)
+	}
+	return q.windowStart.Add(q.window).Sub(now)
+}
+
+// bucket returns the counter key is charged to and its quota
+func (q *QuotaLimiter) bucket(key string) (string, int) {
+	if quota, ok := q.quotas[key]; ok && key != defaultQuotaBucket {
+		return key, quota
+	}
+	return defaultQuotaBucket, q.defaultQuota
+}
+
+// quotaCharge charges the events a request submits to its API key
+type quotaCharge struct {
+	limiter *QuotaLimiter
+	key     string
+}
+
+// APIKeyQuota limits the events each API key may submit per window. Requests
+// from a key that has used up its quota are rejected with 429 up front; the
+// handler charges the rest with ChargeQuota, so each accepted event counts once
+// however many a request carries. Mount it on the event routes only.
+func APIKeyQuota(limiter *QuotaLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if remaining, resetIn := limiter.Remaining(key); remaining == 0 {
+			rejectOverQuota(c, limiter, key, resetIn)
+			return
+		}
+
+		c.Set(quotaChargeKey, &quotaCharge{limiter: limiter, key: key})
+		c.Next()
+	}
+}
+
+// ChargeQuota charges n events to the request's API key before they are
+// queued, responding with 429 and returning false if they do not all fit in
+// its quota. Calling refund takes back events that were not accepted after
+// all. Requests on routes without APIKeyQuota are never charged.
+func ChargeQuota(c *gin.Context, n int) (refund func(n int), ok bool) {
+	value, exists := c.Get(quotaChargeKey)
+	if !exists {
+		return func(int) {}, true
+	}
+	charge := value.(*quotaCharge)
+
+	allowed, resetIn, refund := charge.limiter.Allow(charge.key, n)
+	if !allowed {
+		rejectOverQuota(c, charge.limiter, charge.key, resetIn)
+		return refund, false
+	}
+	return refund, true
+}
+
+// rejectOverQuota responds 429 with when the key's quota resets
+func rejectOverQuota(c *gin.Context, limiter *QuotaLimiter, key string, resetIn time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(resetIn.Seconds())))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Code:    models.ErrorCodeQuotaExceeded,
+		Error:   "API key quota exceeded",
+		Details: fmt.Sprintf("quota of %d events per %v exceeded; resets in %v", limiter.Quota(key), limiter.window, resetIn.Round(time.Second)),
+	})
+}
+
+// ParseAPIKeyQuotas converts specs such as "key:100" into a quota per key.
+// Errors name the position of a bad spec rather than echoing the key.
+func ParseAPIKeyQuotas(specs []string) (map[string]int, error) {
+	quotas := make(map[string]int, len(specs))
+	for i, spec := range specs {
+		key, limit, ok := strings.Cut(spec, ":")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid API key quota #%d, expected key:limit", i+1)
+		}
+		quota, err := strconv.Atoi(limit)
+		if err != nil || quota < 0 {
+			return nil, fmt.Errorf("invalid limit in API key quota #%d, expected a non-negative integer", i+1)
+		}
+		quotas[key] = quota
+	}
+	return quotas, nil
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewQuotaLimiter(map[string]int{"tenant-a": 2, "tenant-b": 5}, 1, time.Minute)
+	now := limiter.windowStart
+	limiter.now = func() time.Time { return now }
+
+	// Each request submits the number of events in its "events" query, and
+	// "rejected" of them are refunded as not accepted
+	router := gin.New()
+	router.POST("/", APIKeyQuota(limiter), func(c *gin.Context) {
+		events, _ := strconv.Atoi(c.DefaultQuery("events", "1"))
+		rejected, _ := strconv.Atoi(c.DefaultQuery("rejected", "0"))
+		refund, ok := ChargeQuota(c, events)
+		if !ok {
+			return
+		}
+		refund(rejected)
+		c.Status(http.StatusAccepted)
+	})
+	router.PUT("/admin", func(c *gin.Context) {
+		if _, ok := ChargeQuota(c, 1); ok {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	serveAt := func(method, path, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	serve := func(key string) *httptest.ResponseRecorder {
+		return serveAt("POST", "/", key)
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := serve("tenant-a"); w.Code != http.StatusAccepted {
+			t.Fatalf("Expected event %d within quota to be accepted, got %d", i+1, w.Code)
+		}
+	}
+
+	w := serve("tenant-a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 over quota, got %d", w.Code)
+	}
+	var response models.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Code != models.ErrorCodeQuotaExceeded {
+		t.Errorf("Expected code %s, got %q", models.ErrorCodeQuotaExceeded, response.Code)
+	}
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", retryAfter)
+	}
+
+	t.Run("OtherKeysUnaffected", func(t *testing.T) {
+		if w := serve("tenant-b"); w.Code != http.StatusAccepted {
+			t.Errorf("Expected tenant-b to be accepted, got %d", w.Code)
+		}
+		if w := serveAt("PUT", "/admin", "tenant-a"); w.Code != http.StatusOK {
+			t.Errorf("Expected routes without the quota to be served over quota, got %d", w.Code)
+		}
+	})
+
+	t.Run("UnknownAndMissingKeysShareDefault", func(t *testing.T) {
+		if w := serve("unknown"); w.Code != http.StatusAccepted {
+			t.Errorf("Expected a key without a quota to use the default quota, got %d", w.Code)
+		}
+		if w := serve("other-unknown"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected another unknown key to share the used up default quota, got %d", w.Code)
+		}
+		if w := serve(""); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected a request without a key to share the used up default quota, got %d", w.Code)
+		}
+	})
+
+	t.Run("EachEventCounts", func(t *testing.T) {
+		// tenant-b used 1 of 5 above, so a batch of 5 no longer fits
+		if w := serveAt("POST", "/?events=5", "tenant-b"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected a batch over the remaining quota to be rejected, got %d", w.Code)
+		}
+		// Rejected events are refunded, so only 2 of these 4 count
+		if w := serveAt("POST", "/?events=4&rejected=2", "tenant-b"); w.Code != http.StatusAccepted {
+			t.Fatalf("Expected a batch within quota to be accepted, got %d", w.Code)
+		}
+		if w := serveAt("POST", "/?events=2", "tenant-b"); w.Code != http.StatusAccepted {
+			t.Errorf("Expected the last 2 events of the quota to be accepted, got %d", w.Code)
+		}
+		if w := serve("tenant-b"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected tenant-b to be over quota, got %d", w.Code)
+		}
+	})
+
+	t.Run("ResetsAfterWindow", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if w := serve("tenant-a"); w.Code != http.StatusAccepted {
+			t.Errorf("Expected tenant-a to be accepted in the next window, got %d", w.Code)
+		}
+	})
+}
+
+func TestChargeQuota_WithoutLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if _, ok := ChargeQuota(c, 1000); !ok {
+		t.Error("Expected a request without a quota to never be charged")
+	}
+}
+
+func TestAPIKeyQuota_UnlimitedDefault(t *testing.T) {
+	limiter := NewQuotaLimiter(map[string]int{"tenant-a": 0}, -1, time.Minute)
+	for _, key := range []string{"", "unknown"} {
+		if allowed, _, _ := limiter.Allow(key, 1000); !allowed {
+			t.Errorf("Expected key %q to be unlimited", key)
+		}
+		if remaining, _ := limiter.Remaining(key); remaining >= 0 {
+			t.Errorf("Expected no remaining count for unlimited key %q, got %d", key, remaining)
+		}
+	}
+	if allowed, _, _ := limiter.Allow("tenant-a", 1); allowed {
+		t.Error("Expected a configured quota of 0 to still apply")
+	}
+}
+
+func TestParseAPIKeyQuotas(t *testing.T) {
+	quotas, err := ParseAPIKeyQuotas([]string{"tenant-a:100", "tenant-b:0"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if quotas["tenant-a"] != 100 || quotas["tenant-b"] != 0 || len(quotas) != 2 {
+		t.Errorf("Expected tenant-a:100 and tenant-b:0, got %v", quotas)
+	}
+
+	for _, specs := range [][]string{{"tenant-a"}, {":10"}, {"tenant-a:many"}, {"tenant-a:-1"}} {
+		if _, err := ParseAPIKeyQuotas(specs); err == nil {
+			t.Errorf("Expected an error for %v", specs)
+		}
+	}
+}
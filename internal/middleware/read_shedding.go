@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// criticalReadPrefixes are the read paths served even while reads are shed,
+// so operators can still observe and manage an overloaded service
+var criticalReadPrefixes = []string{"/health", "/ready", "/metrics", "/admin"}
+
+// ShedReads rejects non-critical read requests with 503 while more than
+// watermark events are pending, so an overloaded service spends its capacity
+// draining writes instead of contending for the repository with lookups.
+// Writes, health checks, metrics and admin endpoints are never shed. A
+// non-positive watermark disables the middleware.
+func ShedReads(pending func() int, watermark int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if watermark <= 0 || isWrite(c.Request.Method) || isCriticalRead(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		if depth := pending(); depth > watermark {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Code:    models.ErrorCodeReadShed,
+				Error:   "Reads are temporarily shed",
+				Details: fmt.Sprintf("%d events pending, above the read shedding watermark of %d", depth, watermark),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// isCriticalRead reports whether path is exempt from read shedding
+func isCriticalRead(path string) bool {
+	for _, prefix := range criticalReadPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
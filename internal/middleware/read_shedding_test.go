@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShedReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var pending int64
+	router := gin.New()
+	router.Use(ShedReads(func() int { return int(atomic.LoadInt64(&pending)) }, 5))
+	router.GET("/api/v1/products/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/v1/events", func(c *gin.Context) {
+		atomic.AddInt64(&pending, 1)
+		c.Status(http.StatusAccepted)
+	})
+	for _, path := range []string{"/health", "/ready", "/metrics", "/admin/diagnostics"} {
+		router.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Writes keep being accepted as the backlog crosses the watermark
+	for i := 0; i < 5; i++ {
+		if w := serve("GET", "/api/v1/products/p1"); w.Code != http.StatusOK {
+			t.Fatalf("Expected read at depth %d to be served, got %d", pending, w.Code)
+		}
+		serve("POST", "/api/v1/events")
+	}
+
+	t.Run("AboveWatermark", func(t *testing.T) {
+		if w := serve("POST", "/api/v1/events"); w.Code != http.StatusAccepted {
+			t.Errorf("Expected writes to continue above the watermark, got %d", w.Code)
+		}
+
+		w := serve("GET", "/api/v1/products/p1")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected read to be shed with 503, got %d", w.Code)
+		}
+		var response models.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Code != models.ErrorCodeReadShed {
+			t.Errorf("Expected code %s, got %q", models.ErrorCodeReadShed, response.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("Expected a Retry-After header")
+		}
+
+		for _, path := range []string{"/health", "/ready", "/metrics", "/admin/diagnostics"} {
+			if w := serve("GET", path); w.Code != http.StatusOK {
+				t.Errorf("Expected critical read %s to be served, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("DrainedBelowWatermark", func(t *testing.T) {
+		atomic.StoreInt64(&pending, 5)
+		if w := serve("GET", "/api/v1/products/p1"); w.Code != http.StatusOK {
+			t.Errorf("Expected reads to resume once drained to the watermark, got %d", w.Code)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		disabled := gin.New()
+		disabled.Use(ShedReads(func() int { return 1000 }, 0))
+		disabled.GET("/api/v1/products/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req, _ := http.NewRequest("GET", "/api/v1/products/p1", nil)
+		w := httptest.NewRecorder()
+		disabled.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected no shedding with a zero watermark, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessGate rejects write requests with 503 while ready reports false, so
+// nothing is accepted into a queue that is not being drained yet. Reads such as
+// health checks and metrics are always served.
+func ReadinessGate(ready func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isWrite(c.Request.Method) && !ready() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Service is not ready"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// isWrite reports whether method can change state
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
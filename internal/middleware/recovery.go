@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a handler into a JSON 500 carrying the request ID,
+// logging the panic and its stack trace with the same ID so the two can be
+// correlated. A nil logger logs to stdout.
+func Recovery(logger *log.Logger) gin.HandlerFunc {
+	if logger == nil {
+		logger = log.New(os.Stdout, "[RECOVERY] ", log.LstdFlags)
+	}
+
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			stack := debug.Stack()
+			if stacked, ok := r.(*stackedPanic); ok {
+				r, stack = stacked.value, stacked.stack
+			}
+			if err, ok := r.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				// The server deliberately aborts the response; let it
+				panic(r)
+			}
+
+			requestID := GetRequestID(c)
+			if requestID == "" {
+				requestID = newRequestID()
+				c.Header(RequestIDHeader, requestID)
+			}
+			logger.Printf("panic recovered request_id=%s method=%s path=%s panic=%q stack=%q",
+				requestID, c.Request.Method, c.Request.URL.Path, r, stack)
+
+			if c.Writer.Written() {
+				// Part of the response is already out, so a JSON body would only corrupt it
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+				Code:      models.ErrorCodeInternal,
+				Error:     "Internal server error",
+				RequestID: requestID,
+			})
+		}()
+		c.Next()
+	}
+}
+
+// stackedPanic is a panic re-raised on another goroutine than the one it
+// happened on, carrying the stack trace of the original goroutine
+type stackedPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// String formats the panic value followed by its original stack trace
+func (p *stackedPanic) String() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
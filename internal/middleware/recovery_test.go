@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(Recovery(log.New(&logs, "", 0)))
+	router.Use(RequestID())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("handler exploded")
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		c.String(http.StatusOK, "fine")
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/panic", nil)
+		req.Header.Set(RequestIDHeader, "req-42")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, got %d", w.Code)
+		}
+		var response models.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Expected a JSON body, got %q: %v", w.Body.String(), err)
+		}
+		if response.Code != models.ErrorCodeInternal || response.RequestID != "req-42" {
+			t.Errorf("Expected code INTERNAL with request ID req-42, got %+v", response)
+		}
+		if strings.Contains(w.Body.String(), "handler exploded") {
+			t.Error("Expected the panic value not to leak to the client")
+		}
+
+		logged := logs.String()
+		for _, want := range []string{"request_id=req-42", `panic="handler exploded"`, "path=/panic", "stack=", "recovery_test.go"} {
+			if !strings.Contains(logged, want) {
+				t.Errorf("Expected the log to contain %q, got %q", want, logged)
+			}
+		}
+	})
+
+	t.Run("NoPanic", func(t *testing.T) {
+		logs.Reset()
+		req, _ := http.NewRequest("GET", "/ok", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Body.String() != "fine" {
+			t.Errorf("Expected the handler response to pass through, got %d %q", w.Code, w.Body.String())
+		}
+		if logs.Len() != 0 {
+			t.Errorf("Expected nothing logged, got %q", logs.String())
+		}
+	})
+}
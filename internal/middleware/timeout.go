@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout bounds how long the rest of the chain may take to respond. The
+// request context is cancelled after timeout and the client gets a JSON 504
+// straight away; whatever the handler writes afterwards is discarded. The
+// middleware still waits for the handler to return before handing the
+// context back to gin, so handlers should honour the context to free up
+// quickly. A non-positive timeout disables the middleware.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		original := c.Writer
+		buffered := newTimeoutWriter(original)
+		c.Writer = buffered
+
+		done := make(chan struct{})
+		var panicked interface{}
+		go func() {
+			defer func() {
+				// The stack is only available here, on the goroutine that panicked
+				if r := recover(); r != nil {
+					panicked = &stackedPanic{value: r, stack: debug.Stack()}
+				}
+				close(done)
+			}()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+		// A handler finishing only as the deadline passes has usually given up on it
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			buffered.timeOut()
+			writeTimeout(original, timeout)
+		}
+		<-done
+
+		c.Writer = original
+		if panicked != nil {
+			// Re-raise on the request goroutine so Recovery can answer it,
+			// carrying the handler's stack trace along
+			panic(panicked)
+		}
+		buffered.flushTo(original)
+	}
+}
+
+// timeoutRetryAfter is the Retry-After, in seconds, sent with a handler timeout
+const timeoutRetryAfter = "1"
+
+// writeTimeout answers a request whose handler ran past timeout
+func writeTimeout(w gin.ResponseWriter, timeout time.Duration) {
+	body, _ := json.Marshal(models.ErrorResponse{
+		Code:    models.ErrorCodeHandlerTimeout,
+		Error:   "Request timed out",
+		Details: fmt.Sprintf("handler did not complete within %v", timeout),
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Retry-After", timeoutRetryAfter)
+	w.WriteHeader(http.StatusGatewayTimeout)
+	w.Write(body)
+	w.Flush()
+}
+
+// timeoutWriter buffers a handler's response until it completes in time; once
+// the request has timed out every further write is dropped
+type timeoutWriter struct {
+	gin.ResponseWriter
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	written  bool
+	timedOut bool
+}
+
+// newTimeoutWriter creates a buffer in front of w, starting from w's headers
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{ResponseWriter: w, header: w.Header().Clone(), status: http.StatusOK}
+}
+
+// timeOut marks the response as timed out, so it is never sent
+func (w *timeoutWriter) timeOut() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+}
+
+// flushTo writes the buffered response to dst unless the request timed out
+func (w *timeoutWriter) flushTo(dst gin.ResponseWriter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+
+	for key, values := range w.header {
+		dst.Header()[key] = values
+	}
+	dst.WriteHeader(w.status)
+	if w.written {
+		dst.WriteHeaderNow()
+		dst.Write(w.body.Bytes())
+	}
+}
+
+// Header returns the buffered headers
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the status code
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written && code > 0 {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow marks the headers as written
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+// Write buffers body bytes, dropping them once the request has timed out
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.body.Write(data)
+}
+
+// WriteString buffers body text
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Status returns the buffered status code
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// Size returns the number of buffered body bytes, or -1 if nothing was written
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written reports whether the handler has started its response
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush is a no-op: the response is only sent once the handler completes
+func (w *timeoutWriter) Flush() {}
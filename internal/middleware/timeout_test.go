@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cancelled := make(chan bool, 1)
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(Recovery(log.New(&logs, "", 0)))
+	router.Use(Timeout(20 * time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			cancelled <- true
+		case <-time.After(time.Second):
+			cancelled <- false
+		}
+		c.JSON(http.StatusOK, gin.H{"late": true})
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Handler", "fast")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	router.GET("/panic", explodingHandler)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("SlowHandler", func(t *testing.T) {
+		start := time.Now()
+		w := serve("/slow")
+
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected status 504, got %d", w.Code)
+		}
+		var response models.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Expected a JSON body, got %q", w.Body.String())
+		}
+		if response.Code != models.ErrorCodeHandlerTimeout {
+			t.Errorf("Expected code %s, got %q", models.ErrorCodeHandlerTimeout, response.Code)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != "1" {
+			t.Errorf("Expected Retry-After 1, got %q", retryAfter)
+		}
+		if !<-cancelled {
+			t.Error("Expected the handler's context to be cancelled")
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected the timeout to fire promptly, took %v", elapsed)
+		}
+	})
+
+	t.Run("FastHandler", func(t *testing.T) {
+		w := serve("/fast")
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected status 201, got %d", w.Code)
+		}
+		if w.Header().Get("X-Handler") != "fast" {
+			t.Errorf("Expected the handler's headers to be kept, got %v", w.Header())
+		}
+		if w.Body.String() != `{"ok":true}` {
+			t.Errorf("Expected the handler's body, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("PanickingHandler", func(t *testing.T) {
+		if w := serve("/panic"); w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected the panic to reach Recovery as a 500, got %d", w.Code)
+		}
+		// The logged stack is the handler's, not the one re-raising the panic
+		for _, want := range []string{`panic="boom"`, "explodingHandler"} {
+			if !strings.Contains(logs.String(), want) {
+				t.Errorf("Expected the log to contain %q, got %q", want, logs.String())
+			}
+		}
+	})
+}
+
+// explodingHandler panics, to check its frame survives the timeout goroutine
+func explodingHandler(c *gin.Context) {
+	panic("boom")
+}
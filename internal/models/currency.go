@@ -0,0 +1,21 @@
+package models
+
+// DefaultBaseCurrency is the currency assumed for events that omit one
+const DefaultBaseCurrency = "USD"
+
+// knownCurrencies are the ISO 4217 codes accepted on events
+var knownCurrencies = map[string]bool{
+	"AED": true, "ARS": true, "AUD": true, "BRL": true, "CAD": true,
+	"CHF": true, "CLP": true, "CNY": true, "COP": true, "CZK": true,
+	"DKK": true, "EGP": true, "EUR": true, "GBP": true, "HKD": true,
+	"HUF": true, "IDR": true, "ILS": true, "INR": true, "JPY": true,
+	"KRW": true, "MXN": true, "MYR": true, "NGN": true, "NOK": true,
+	"NZD": true, "PHP": true, "PKR": true, "PLN": true, "RON": true,
+	"SAR": true, "SEK": true, "SGD": true, "THB": true, "TRY": true,
+	"TWD": true, "UAH": true, "USD": true, "VND": true, "ZAR": true,
+}
+
+// IsValidCurrency returns true if code is a known ISO 4217 currency code
+func IsValidCurrency(code string) bool {
+	return knownCurrencies[code]
+}
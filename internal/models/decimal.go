@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Price modes selectable with PRICE_MODE
+const (
+	PriceModeFloat = "float"
+	PriceModeFixed = "fixed"
+)
+
+// MaxDecimalPlaces bounds the precision of a Decimal so its units fit in an int64
+const MaxDecimalPlaces = 9
+
+// MaxDecimalUnits bounds the magnitude of a Decimal to 15 significant digits,
+// the most a float64 carries without loss, so a decimal converted to a float
+// and back is always the same decimal
+const MaxDecimalUnits = 999_999_999_999_999
+
+// Decimal errors
+var (
+	ErrInvalidDecimal  = errors.New("invalid decimal number")
+	ErrDecimalOverflow = errors.New("decimal exceeds 15 significant digits")
+)
+
+// Decimal is a fixed-precision decimal stored as an integer number of minor units,
+// e.g. 1999 units with 2 places is 19.99
+type Decimal struct {
+	Units  int64
+	Places int
+}
+
+// ParseDecimal parses a decimal number exactly, failing if it has more than places decimal places
+func ParseDecimal(value string, places int) (Decimal, error) {
+	if places < 0 || places > MaxDecimalPlaces {
+		return Decimal{}, fmt.Errorf("decimal places must be between 0 and %d, got %d", MaxDecimalPlaces, places)
+	}
+
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Decimal{}, ErrInvalidDecimal
+	}
+
+	scaled := rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)))
+	if !scaled.IsInt() {
+		return Decimal{}, fmt.Errorf("must have at most %d decimal places", places)
+	}
+	units := scaled.Num()
+	if units.CmpAbs(big.NewInt(MaxDecimalUnits)) > 0 {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{Units: units.Int64(), Places: places}, nil
+}
+
+// DecimalFromFloat rounds a float to the nearest decimal with the given places,
+// failing if the result exceeds MaxDecimalUnits or the float is not finite
+func DecimalFromFloat(value float64, places int) (Decimal, error) {
+	if places < 0 || places > MaxDecimalPlaces {
+		return Decimal{}, fmt.Errorf("decimal places must be between 0 and %d, got %d", MaxDecimalPlaces, places)
+	}
+	units := math.Round(value * math.Pow10(places))
+	if math.IsNaN(units) || math.Abs(units) > MaxDecimalUnits {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{Units: int64(units), Places: places}, nil
+}
+
+// Float64 returns the float closest to the decimal
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats the decimal with exactly Places decimal places
+func (d Decimal) String() string {
+	if d.Places == 0 {
+		return strconv.FormatInt(d.Units, 10)
+	}
+
+	// Negated as unsigned so the most negative int64 has a magnitude too
+	sign := ""
+	units := uint64(d.Units)
+	if d.Units < 0 {
+		sign = "-"
+		units = -units
+	}
+	digits := fmt.Sprintf("%0*d", d.Places+1, units)
+	split := len(digits) - d.Places
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// MarshalJSON encodes the decimal as a JSON number with exactly Places decimal places
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
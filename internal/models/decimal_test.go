@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDecimal_JSON(t *testing.T) {
+	d, _ := ParseDecimal("0.3", 2)
+	data, err := json.Marshal(map[string]Decimal{"price": d})
+	if err != nil {
+		t.Fatalf("Failed to marshal decimal: %v", err)
+	}
+	if string(data) != `{"price":0.30}` {
+		t.Errorf("Expected exact JSON representation, got %s", data)
+	}
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	// Every decimal within range survives the trip through a float
+	for _, value := range []string{"0.30", "19.99", "-0.01", "9999999999999.99", "-9999999999999.99"} {
+		d, _ := ParseDecimal(value, 2)
+		back, err := DecimalFromFloat(d.Float64(), 2)
+		if err != nil || back != d {
+			t.Errorf("Expected %s to survive a float round trip, got %s (%v)", value, back, err)
+		}
+	}
+
+	for _, value := range []float64{1e13, -1e13, math.Inf(1), math.NaN()} {
+		if _, err := DecimalFromFloat(value, 2); !errors.Is(err, ErrDecimalOverflow) {
+			t.Errorf("Expected ErrDecimalOverflow for %v, got %v", value, err)
+		}
+	}
+}
+
+func TestDecimal_StringMinInt64(t *testing.T) {
+	d := Decimal{Units: math.MinInt64, Places: 2}
+	if d.String() != "-92233720368547758.08" {
+		t.Errorf("Expected -92233720368547758.08, got %s", d.String())
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		places   int
+		expected string
+		valid    bool
+	}{
+		{"19.99", 2, "19.99", true},
+		{"20", 2, "20.00", true},
+		{"-0.5", 2, "-0.50", true},
+		{"1.5e-1", 2, "0.15", true},
+		{"1000", 0, "1000", true},
+		{"19.999", 2, "", false},
+		{"0.30000000000000004", 2, "", false},
+		{"abc", 2, "", false},
+		{"1", 10, "", false},
+		{"9999999999999.99", 2, "9999999999999.99", true},
+		{"10000000000000", 2, "", false},
+		{"99999999999999999999", 0, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			d, err := ParseDecimal(test.input, test.places)
+			if !test.valid {
+				if err == nil {
+					t.Errorf("Expected error parsing %q with %d places, got %s", test.input, test.places, d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if d.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, d.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// CurrentSchemaVersion is the ProductEvent schema version produced by decoding
+const CurrentSchemaVersion = 1
+
+// ErrUnsupportedSchemaVersion is returned for payloads with an unknown schema version
+var ErrUnsupportedSchemaVersion = errors.New("unsupported schema_version")
+
+// eventDecoder maps a payload of a specific schema version into the current ProductEvent
+type eventDecoder func(data []byte) (ProductEvent, error)
+
+// eventDecoders holds a decoder for every supported schema version
+var eventDecoders = map[int]eventDecoder{
+	1: decodeEventV1,
+}
+
+// DecodeProductEvent decodes a JSON payload of any supported schema version into a ProductEvent
+func DecodeProductEvent(data []byte) (ProductEvent, error) {
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return ProductEvent{}, err
+	}
+
+	version := header.SchemaVersion
+	if version == 0 {
+		version = 1
+	}
+
+	decode, ok := eventDecoders[version]
+	if !ok {
+		return ProductEvent{}, fmt.Errorf("%w %d, supported versions: %v", ErrUnsupportedSchemaVersion, version, SupportedSchemaVersions())
+	}
+
+	event, err := decode(data)
+	if err != nil {
+		return ProductEvent{}, err
+	}
+	event.SchemaVersion = CurrentSchemaVersion
+	return event, nil
+}
+
+// SupportedSchemaVersions returns the schema versions that can be decoded, in ascending order
+func SupportedSchemaVersions() []int {
+	versions := make([]int, 0, len(eventDecoders))
+	for version := range eventDecoders {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// decodeEventV1 decodes the original product_id/price/stock payload
+func decodeEventV1(data []byte) (ProductEvent, error) {
+	var event ProductEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
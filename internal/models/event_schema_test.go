@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeProductEvent_Unversioned(t *testing.T) {
+	event, err := DecodeProductEvent([]byte(`{"product_id": "abc", "price": 9.5, "stock": 3}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if event.ProductID != "abc" || event.Price != 9.5 || event.Stock != 3 {
+		t.Errorf("Expected {abc 9.5 3}, got %+v", event)
+	}
+	if event.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", CurrentSchemaVersion, event.SchemaVersion)
+	}
+}
+
+func TestDecodeProductEvent_ExplicitVersion(t *testing.T) {
+	event, err := DecodeProductEvent([]byte(`{"schema_version": 1, "product_id": "abc", "price": 9.5, "stock": 3}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if event.ProductID != "abc" || event.Price != 9.5 || event.Stock != 3 {
+		t.Errorf("Expected {abc 9.5 3}, got %+v", event)
+	}
+}
+
+func TestDecodeProductEvent_UnsupportedVersion(t *testing.T) {
+	_, err := DecodeProductEvent([]byte(`{"schema_version": 99, "product_id": "abc"}`))
+	if !errors.Is(err, ErrUnsupportedSchemaVersion) {
+		t.Fatalf("Expected ErrUnsupportedSchemaVersion, got %v", err)
+	}
+
+	if err.Error() != "unsupported schema_version 99, supported versions: [1]" {
+		t.Errorf("Expected a clear unsupported version message, got '%s'", err.Error())
+	}
+}
+
+func TestDecodeProductEvent_InvalidJSON(t *testing.T) {
+	if _, err := DecodeProductEvent([]byte(`not json`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
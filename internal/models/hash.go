@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// HashID returns the 64-bit FNV-1a hash of a product ID. It depends only on
+// id, so it is stable across runs and processes and safe to persist or use
+// for routing; it is not suitable where collisions must be hard to forge.
+func HashID(id string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return h.Sum64()
+}
+
+// Hash returns a 64-bit FNV-1a hash of the state the event sets: its product
+// ID, price, stock and currency. Events setting the same state hash equal
+// whatever their priority, category or request metadata, and the hash is
+// stable across runs and processes.
+func (e ProductEvent) Hash() uint64 {
+	var buf [8]byte
+	h := fnv.New64a()
+	h.Write([]byte(e.ProductID))
+	// Separators keep field boundaries unambiguous
+	h.Write([]byte{0})
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(e.Price))
+	h.Write(buf[:])
+	binary.LittleEndian.PutUint64(buf[:], uint64(int64(e.Stock)))
+	h.Write(buf[:])
+	h.Write([]byte(e.Currency))
+	return h.Sum64()
+}
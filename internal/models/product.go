@@ -1,31 +1,345 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Product represents a product with its current state
 type Product struct {
-	ID    string  `json:"id"`
-	Price float64 `json:"price"`
-	Stock int     `json:"stock"`
+	ID       string  `json:"id"`
+	Price    float64 `json:"price"`
+	Stock    int     `json:"stock"`
+	Currency string  `json:"currency,omitempty"`
+
+	// Amount is the exact price kept by stores in fixed price places mode;
+	// Price is then its float value. It is nil in float mode.
+	Amount *Decimal `json:"-"`
+
+	// DeletedAt is set when the product has been soft-deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
+// Event priorities; an empty priority is treated as normal
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
 // ProductEvent represents an incoming product update event
 type ProductEvent struct {
 	ProductID string  `json:"product_id"`
 	Price     float64 `json:"price"`
 	Stock     int     `json:"stock"`
+	Currency  string  `json:"currency,omitempty"`
+	Priority  string  `json:"priority,omitempty"`
+
+	// Category groups products that share a downstream dependency, such as a
+	// supplier feed; each category is processed behind its own circuit breaker
+	Category string `json:"category,omitempty"`
+
+	// SchemaVersion identifies the payload shape; unversioned payloads are version 1
+	SchemaVersion int `json:"schema_version,omitempty"`
+
+	// MaxAttempts overrides how many times processing is attempted; zero uses the service default
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Sequence is assigned by the service at enqueue time and is not part of the API
+	Sequence uint64 `json:"-"`
+
+	// TraceParent and TraceState carry the W3C trace context of the request
+	// that accepted the event, so processing joins the same trace
+	TraceParent string `json:"-"`
+	TraceState  string `json:"-"`
+
+	// RequestID identifies the request that accepted the event, for the audit log
+	RequestID string `json:"-"`
+}
+
+// IsValidPriority returns true if priority is empty or a known priority
+func IsValidPriority(priority string) bool {
+	switch priority {
+	case "", PriorityLow, PriorityNormal, PriorityHigh:
+		return true
+	default:
+		return false
+	}
+}
+
+// FailedEvent represents an event that could not be processed and was dead-lettered
+type FailedEvent struct {
+	Event      ProductEvent `json:"event"`
+	Error      string       `json:"error"`
+	Timestamp  time.Time    `json:"timestamp"`
+	RetryCount int          `json:"retry_count"`
+}
+
+// Envelope wraps a successful response payload with request metadata
+type Envelope struct {
+	Data interface{}  `json:"data"`
+	Meta EnvelopeMeta `json:"meta"`
+}
+
+// EnvelopeMeta carries metadata about the request that produced an enveloped response
+type EnvelopeMeta struct {
+	RequestID string    `json:"request_id"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
+	Status                    string     `json:"status"`
+	LastProcessedAt           *time.Time `json:"last_processed_at,omitempty"`
+	SecondsSinceLastProcessed *float64   `json:"seconds_since_last_processed,omitempty"`
+}
+
+// Health statuses, ordered from best to worst
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// ReadinessResponse represents whether the service is ready to accept traffic
+type ReadinessResponse struct {
 	Status string `json:"status"`
+	// Reason explains a degraded or not ready status caused by a dependency
+	Reason string `json:"reason,omitempty"`
+}
+
+// Readiness statuses
+const (
+	ReadinessReady    = "ready"
+	ReadinessNotReady = "not_ready"
+	ReadinessDegraded = "degraded"
+)
+
+// HealthCheck represents the result of a single subsystem health check
+type HealthCheck struct {
+	Status     string  `json:"status"`
+	Details    string  `json:"details,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// DetailedHealthResponse represents the result of every subsystem health check
+type DetailedHealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error"`
+	Details string `json:"details,omitempty"`
+	// RequestID correlates the response with the server logs
+	RequestID string `json:"request_id,omitempty"`
 }
 
+// ErrorCodeInternal marks an unexpected server failure, such as a recovered panic
+const ErrorCodeInternal = "INTERNAL"
+
 // EventResponse represents the response after accepting an event
 type EventResponse struct {
 	Message   string `json:"message"`
 	ProductID string `json:"product_id"`
+	Ticket    int64  `json:"ticket,omitempty"`
+	// Duplicate is set, without a ticket, when the event repeats the product's
+	// last accepted event and was skipped
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// BatchEventRequest represents a batch of product update events
+type BatchEventRequest struct {
+	Events []json.RawMessage `json:"events"`
+}
+
+// BatchEventResponse represents the response after accepting a batch of events
+type BatchEventResponse struct {
+	Message  string            `json:"message"`
+	Received int               `json:"received"`
+	Accepted int               `json:"accepted"`
+	Items    []BatchItemResult `json:"items"`
+	// Results holds one outcome per submitted event, in request order
+	Results []BatchItemOutcome `json:"results"`
+}
+
+// Upsert outcomes reported for each product in a batch
+const (
+	UpsertCreated = "created"
+	UpsertUpdated = "updated"
+)
+
+// BatchItemResult reports whether a batch event creates a new product or updates an existing one
+type BatchItemResult struct {
+	ProductID string `json:"product_id"`
+	Status    string `json:"status"`
+}
+
+// Outcomes reported for each event of a batch
+const (
+	BatchItemAccepted = "accepted"
+	BatchItemRejected = "rejected"
+)
+
+// Codes explaining a batch item outcome
+const (
+	ErrorCodeInvalidEvent  = "INVALID_EVENT"
+	ErrorCodeQueueFull     = "QUEUE_FULL"
+	ErrorCodeProductLimit  = "PRODUCT_LIMIT"
+	ErrorCodeInFlightLimit = "IN_FLIGHT_LIMIT"
+	ErrorCodeShuttingDown  = "SHUTTING_DOWN"
+	ErrorCodeSuperseded    = "SUPERSEDED"
+	ErrorCodeNotAdmitted   = "NOT_ADMITTED"
+	ErrorCodeDuplicate     = "DUPLICATE"
+)
+
+// ErrorCodeHandlerTimeout marks a request whose handler did not complete within HANDLER_TIMEOUT
+const ErrorCodeHandlerTimeout = "HANDLER_TIMEOUT"
+
+// ErrorCodeQuotaExceeded marks a request rejected because its API key used up its quota
+const ErrorCodeQuotaExceeded = "QUOTA_EXCEEDED"
+
+// ErrorCodeReadShed marks a read rejected because too many events are pending
+const ErrorCodeReadShed = "READ_SHED"
+
+// BatchItemOutcome reports what happened to one event of a batch, by its index in the request
+type BatchItemOutcome struct {
+	Index     int    `json:"index"`
+	ProductID string `json:"product_id"`
+	Status    string `json:"status"`
+	Code      string `json:"code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BatchFlushResponse represents the response after flushing the batch processor
+type BatchFlushResponse struct {
+	Message string `json:"message"`
+}
+
+// ReplayResponse represents the response after re-enqueueing a dead-lettered event
+type ReplayResponse struct {
+	Message   string      `json:"message"`
+	ProductID string      `json:"product_id"`
+	Ticket    int64       `json:"ticket"`
+	Replayed  FailedEvent `json:"replayed"`
+}
+
+// LogReplayResponse represents the result of replaying the write-ahead log
+type LogReplayResponse struct {
+	Message string `json:"message"`
+}
+
+// BatchConflictResponse represents a batch rejected for containing duplicate products
+type BatchConflictResponse struct {
+	Error      string   `json:"error"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+// CircuitBreakerSettingsRequest represents a runtime update to the circuit breaker
+type CircuitBreakerSettingsRequest struct {
+	Threshold *int   `json:"threshold,omitempty"`
+	Timeout   string `json:"timeout,omitempty"`
+	// Force pins the breakers "open" or "closed", or "release"s a pinned state
+	Force string `json:"force,omitempty"`
+}
+
+// CircuitBreakerResponse represents the current circuit breaker settings and state
+type CircuitBreakerResponse struct {
+	State     string `json:"state"`
+	Failures  int    `json:"failures"`
+	Threshold int    `json:"threshold"`
+	Timeout   string `json:"timeout"`
+	// Forced is set while the state is pinned by a force request
+	Forced bool `json:"forced"`
+
+	// Categories lists the per-category breakers created so far
+	Categories []CategoryCircuitBreaker `json:"categories,omitempty"`
+}
+
+// CategoryCircuitBreaker represents the state of the breaker for one event category
+type CategoryCircuitBreaker struct {
+	Category string `json:"category"`
+	State    string `json:"state"`
+	Failures int    `json:"failures"`
+}
+
+// DiagnosticsResponse gathers the state of every processing subsystem in one document
+type DiagnosticsResponse struct {
+	Queue           QueueDiagnostics       `json:"queue"`
+	Workers         WorkerDiagnostics      `json:"workers"`
+	CircuitBreaker  CircuitBreakerResponse `json:"circuit_breaker"`
+	Retry           RetryDiagnostics       `json:"retry"`
+	DeadLetterQueue *QueueDiagnostics      `json:"dead_letter_queue,omitempty"`
+	LastFailures    []FailedEvent          `json:"last_failures"`
+}
+
+// QueueDiagnostics describes how full a queue is
+type QueueDiagnostics struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+}
+
+// WorkerDiagnostics describes the worker pool and the events it has handled
+type WorkerDiagnostics struct {
+	Count     int   `json:"count"`
+	Alive     int   `json:"alive"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+}
+
+// WorkersResponse lists the activity of every running worker
+type WorkersResponse struct {
+	Workers []WorkerState `json:"workers"`
+}
+
+// WorkerState describes what a single worker is doing
+type WorkerState struct {
+	ID               int       `json:"id"`
+	Status           string    `json:"status"`
+	Processed        int64     `json:"processed"`
+	CurrentProductID string    `json:"current_product_id,omitempty"`
+	CurrentSequence  uint64    `json:"current_sequence,omitempty"`
+	LastActivity     time.Time `json:"last_activity"`
+}
+
+// QueueDumpResponse lists the events buffered in the queue in the order they will be processed
+type QueueDumpResponse struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+	// Truncated is set when more events were queued than returned
+	Truncated bool           `json:"truncated"`
+	Events    []ProductEvent `json:"events"`
+}
+
+// QueuePurgeResponse reports how many queued events a purge discarded
+type QueuePurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// RepositorySizeResponse reports how many products the repository holds and their approximate memory footprint
+type RepositorySizeResponse struct {
+	Products  int   `json:"products"`
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// ShardStats describes how many products one repository shard holds and how
+// many operations it has served, so a hot shard stands out
+type ShardStats struct {
+	Shard    int    `json:"shard"`
+	Products int    `json:"products"`
+	Gets     uint64 `json:"gets"`
+	Updates  uint64 `json:"updates"`
+	Deletes  uint64 `json:"deletes"`
+}
+
+// RetryDiagnostics describes the retry settings and how often retries were needed
+type RetryDiagnostics struct {
+	MaxAttempts  int     `json:"max_attempts"`
+	InitialDelay string  `json:"initial_delay"`
+	MaxDelay     string  `json:"max_delay"`
+	Multiplier   float64 `json:"multiplier"`
+	Retries      int64   `json:"retries"`
+	Exhausted    int64   `json:"exhausted"`
 }
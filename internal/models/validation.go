@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Defaults for product ID validation
+const (
+	DefaultProductIDMaxLength = 128
+	DefaultProductIDPattern   = `^[A-Za-z0-9._-]+$`
+)
+
+// MaxEventAttempts is the most processing attempts an event may request
+const MaxEventAttempts = 10
+
+// Validation errors returned by ProductEvent.Validate
+var (
+	ErrProductIDRequired = errors.New("product_id is required")
+	ErrProductIDCharset  = errors.New("product_id contains characters that are not allowed")
+	ErrInvalidPriority   = errors.New("priority must be one of low, normal, high")
+	ErrInvalidCurrency   = errors.New("currency must be a supported ISO 4217 code")
+	ErrNegativePrice     = errors.New("price must not be negative")
+	ErrInvalidAttempts   = fmt.Errorf("max_attempts must be between 1 and %d", MaxEventAttempts)
+)
+
+// defaultProductIDPattern is compiled once, since Validate runs for every event
+var defaultProductIDPattern = regexp.MustCompile(DefaultProductIDPattern)
+
+// ProductIDRules constrain which product IDs are accepted
+type ProductIDRules struct {
+	MaxLength int
+	Pattern   *regexp.Regexp
+}
+
+// DefaultProductIDRules returns the rules used by Validate
+func DefaultProductIDRules() ProductIDRules {
+	return ProductIDRules{
+		MaxLength: DefaultProductIDMaxLength,
+		Pattern:   defaultProductIDPattern,
+	}
+}
+
+// NewProductIDRules builds product ID rules from a max length in bytes and a regular expression
+func NewProductIDRules(maxLength int, pattern string) (ProductIDRules, error) {
+	if maxLength <= 0 {
+		return ProductIDRules{}, fmt.Errorf("product ID max length must be positive, got %d", maxLength)
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return ProductIDRules{}, fmt.Errorf("invalid product ID pattern %q: %w", pattern, err)
+	}
+	return ProductIDRules{MaxLength: maxLength, Pattern: compiled}, nil
+}
+
+// Validate checks the event against the default product ID rules
+func (e ProductEvent) Validate() error {
+	return e.ValidateWith(DefaultProductIDRules())
+}
+
+// ValidateWith checks the event's required fields, price, priority, currency, attempts and product ID against rules
+func (e ProductEvent) ValidateWith(rules ProductIDRules) error {
+	if e.ProductID == "" {
+		return ErrProductIDRequired
+	}
+	if len(e.ProductID) > rules.MaxLength {
+		return fmt.Errorf("product_id must be at most %d characters", rules.MaxLength)
+	}
+	if rules.Pattern != nil && !rules.Pattern.MatchString(e.ProductID) {
+		return ErrProductIDCharset
+	}
+	if e.Price < 0 {
+		return ErrNegativePrice
+	}
+	if !IsValidPriority(e.Priority) {
+		return ErrInvalidPriority
+	}
+	if e.Currency != "" && !IsValidCurrency(e.Currency) {
+		return ErrInvalidCurrency
+	}
+	if e.MaxAttempts < 0 || e.MaxAttempts > MaxEventAttempts {
+		return ErrInvalidAttempts
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProductEvent_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		productID string
+		valid     bool
+	}{
+		{"ValidID", "sku-123_v2.0", true},
+		{"MaxLengthID", strings.Repeat("a", DefaultProductIDMaxLength), true},
+		{"OverLengthID", strings.Repeat("a", DefaultProductIDMaxLength+1), false},
+		{"ControlCharacterID", "sku\n123", false},
+		{"NullByteID", "sku\x00123", false},
+		{"UnicodeID", "skü-123", false},
+		{"EmptyID", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ProductEvent{ProductID: test.productID, Price: 1.0, Stock: 1}.Validate()
+			if test.valid && err != nil {
+				t.Errorf("Expected %q to be valid, got %v", test.productID, err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("Expected %q to be invalid", test.productID)
+			}
+		})
+	}
+}
+
+func TestProductEvent_ValidateWith(t *testing.T) {
+	rules, err := NewProductIDRules(4, `^[0-9]+$`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := (ProductEvent{ProductID: "1234"}).ValidateWith(rules); err != nil {
+		t.Errorf("Expected valid ID, got %v", err)
+	}
+	if err := (ProductEvent{ProductID: "12345"}).ValidateWith(rules); err == nil || err.Error() != "product_id must be at most 4 characters" {
+		t.Errorf("Expected max length error, got %v", err)
+	}
+	if err := (ProductEvent{ProductID: "abc"}).ValidateWith(rules); err != ErrProductIDCharset {
+		t.Errorf("Expected ErrProductIDCharset, got %v", err)
+	}
+	if err := (ProductEvent{ProductID: "1", Priority: "urgent"}).ValidateWith(rules); err != ErrInvalidPriority {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+}
+
+func TestNewProductIDRules_Invalid(t *testing.T) {
+	if _, err := NewProductIDRules(0, DefaultProductIDPattern); err == nil {
+		t.Error("Expected error for non-positive max length")
+	}
+	if _, err := NewProductIDRules(10, "[unclosed"); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestProductEvent_Validate_Currency(t *testing.T) {
+	if err := (ProductEvent{ProductID: "fx", Currency: "EUR"}).Validate(); err != nil {
+		t.Errorf("Expected EUR to be valid, got %v", err)
+	}
+	if err := (ProductEvent{ProductID: "fx"}).Validate(); err != nil {
+		t.Errorf("Expected omitted currency to be valid, got %v", err)
+	}
+	for _, code := range []string{"XYZ", "eur", "EURO"} {
+		if err := (ProductEvent{ProductID: "fx", Currency: code}).Validate(); err != ErrInvalidCurrency {
+			t.Errorf("Expected ErrInvalidCurrency for %q, got %v", code, err)
+		}
+	}
+}
+
+func TestProductEvent_Validate_Price(t *testing.T) {
+	if err := (ProductEvent{ProductID: "free", Price: 0, Stock: 1}).Validate(); err != nil {
+		t.Errorf("Expected a zero price to be valid, got %v", err)
+	}
+	if err := (ProductEvent{ProductID: "negative", Price: -0.01, Stock: 1}).Validate(); err != ErrNegativePrice {
+		t.Errorf("Expected ErrNegativePrice, got %v", err)
+	}
+}
+
+func TestProductEvent_ValidateMaxAttempts(t *testing.T) {
+	for _, attempts := range []int{0, 1, MaxEventAttempts} {
+		if err := (ProductEvent{ProductID: "retry", MaxAttempts: attempts}).Validate(); err != nil {
+			t.Errorf("Expected max_attempts %d to be valid, got %v", attempts, err)
+		}
+	}
+	for _, attempts := range []int{-1, MaxEventAttempts + 1} {
+		if err := (ProductEvent{ProductID: "retry", MaxAttempts: attempts}).Validate(); err != ErrInvalidAttempts {
+			t.Errorf("Expected ErrInvalidAttempts for %d, got %v", attempts, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// CachingProductRepository serves hot reads from a bounded LRU cache in front of another repository
+//
+// Reads populate the cache and writes evict the entry, so the next read goes
+// back to the wrapped repository. A cache fill racing with a write is
+// discarded rather than risk caching the value the write replaced.
+type CachingProductRepository struct {
+	inner    ProductRepository
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	writes  uint64
+	hits    uint64
+	misses  uint64
+}
+
+// cacheEntry is a cached product and when it stops being served
+type cacheEntry struct {
+	id        string
+	product   *models.Product
+	expiresAt time.Time
+}
+
+// NewCachingProductRepository wraps a repository with an LRU cache holding up to
+// capacity products, each for at most ttl; a ttl of zero never expires entries
+func NewCachingProductRepository(inner ProductRepository, capacity int, ttl time.Duration) *CachingProductRepository {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &CachingProductRepository{
+		inner:    inner,
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Unwrap returns the wrapped repository
+func (r *CachingProductRepository) Unwrap() ProductRepository {
+	return r.inner
+}
+
+// SelfCheck checks the wrapped repository
+func (r *CachingProductRepository) SelfCheck(ctx context.Context) error {
+	return selfCheck(ctx, r.inner)
+}
+
+// Get retrieves a product by ID, from the cache when possible
+func (r *CachingProductRepository) Get(id string) (*models.Product, bool) {
+	if product, ok := r.lookup(id); ok {
+		return product, true
+	}
+
+	writes := r.writeCount()
+	product, exists := r.inner.Get(id)
+	if exists {
+		r.store(id, product, writes)
+	}
+	return product, exists
+}
+
+// GetContext retrieves a product by ID, honouring cancellation of ctx on a cache miss
+func (r *CachingProductRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	if product, ok := r.lookup(id); ok {
+		return product, true, nil
+	}
+
+	writes := r.writeCount()
+	product, exists, err := getContext(ctx, r.inner, id)
+	if err == nil && exists {
+		r.store(id, product, writes)
+	}
+	return product, exists, err
+}
+
+// Update updates a product's state including its currency and evicts it from the cache
+func (r *CachingProductRepository) Update(id string, price float64, stock int, currency string) {
+	r.inner.Update(id, price, stock, currency)
+	r.evict(id)
+}
+
+// Delete removes a product from the wrapped repository and evicts it from the cache
+func (r *CachingProductRepository) Delete(id string) bool {
+	deleted := deleteFrom(r.inner, id)
+	r.evict(id)
+	return deleted
+}
+
+// evict drops a written product from the cache. Evicting after the write
+// means any fill that read the old value is either removed here or rejected by store
+func (r *CachingProductRepository) evict(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.writes++
+	if element, ok := r.entries[id]; ok {
+		r.removeLocked(element)
+	}
+}
+
+// Stats returns the number of cache hits and misses so far
+func (r *CachingProductRepository) Stats() (hits, misses uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hits, r.misses
+}
+
+// Len returns the number of cached products
+func (r *CachingProductRepository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.order.Len()
+}
+
+// SetLockWaitObserver forwards the observer to the wrapped repository if it reports lock waits
+func (r *CachingProductRepository) SetLockWaitObserver(observer func(operation string, wait time.Duration)) {
+	if reporter, ok := r.inner.(LockWaitReporter); ok {
+		reporter.SetLockWaitObserver(observer)
+	}
+}
+
+// lookup returns a live cached product, dropping it if it has expired
+func (r *CachingProductRepository) lookup(id string) (*models.Product, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element, ok := r.entries[id]
+	if !ok {
+		r.misses++
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if r.ttl > 0 && !r.now().Before(entry.expiresAt) {
+		r.removeLocked(element)
+		r.misses++
+		return nil, false
+	}
+
+	r.order.MoveToFront(element)
+	r.hits++
+	copied := *entry.product
+	return &copied, true
+}
+
+// writeCount returns the number of writes seen, used to detect fills racing a write
+func (r *CachingProductRepository) writeCount() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writes
+}
+
+// store caches a product read from the wrapped repository, unless a write
+// happened since the read started
+func (r *CachingProductRepository) store(id string, product *models.Product, writesBefore uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.writes != writesBefore {
+		return
+	}
+
+	// Cache a private copy so a caller mutating its result cannot change what later reads see
+	copied := *product
+	entry := &cacheEntry{id: id, product: &copied, expiresAt: r.now().Add(r.ttl)}
+	if element, ok := r.entries[id]; ok {
+		element.Value = entry
+		r.order.MoveToFront(element)
+		return
+	}
+
+	r.entries[id] = r.order.PushFront(entry)
+	if r.order.Len() > r.capacity {
+		r.removeLocked(r.order.Back())
+	}
+}
+
+// removeLocked drops an element from the cache; the caller must hold r.mu
+func (r *CachingProductRepository) removeLocked(element *list.Element) {
+	r.order.Remove(element)
+	delete(r.entries, element.Value.(*cacheEntry).id)
+}
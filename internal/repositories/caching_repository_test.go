@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// countingProductRepository counts lookups that reach the backend
+type countingProductRepository struct {
+	*InMemoryProductRepository
+	gets int
+}
+
+func (r *countingProductRepository) Get(id string) (*models.Product, bool) {
+	r.gets++
+	return r.InMemoryProductRepository.Get(id)
+}
+
+func newCountingRepository() *countingProductRepository {
+	return &countingProductRepository{InMemoryProductRepository: NewInMemoryProductRepository()}
+}
+
+func TestCachingProductRepository(t *testing.T) {
+	t.Run("SecondGetHitsCache", func(t *testing.T) {
+		backend := newCountingRepository()
+		backend.Update("cached", 10.0, 1, "")
+		repo := NewCachingProductRepository(backend, 10, time.Minute)
+
+		repo.Get("cached")
+		product, exists := repo.Get("cached")
+
+		if !exists || product.Price != 10.0 {
+			t.Errorf("Expected cached product with price 10.0, got %+v", product)
+		}
+		if backend.gets != 1 {
+			t.Errorf("Expected backend to be called once, got %d", backend.gets)
+		}
+		if hits, misses := repo.Stats(); hits != 1 || misses != 1 {
+			t.Errorf("Expected 1 hit and 1 miss, got %d hits and %d misses", hits, misses)
+		}
+	})
+
+	t.Run("UpdateInvalidates", func(t *testing.T) {
+		backend := newCountingRepository()
+		repo := NewCachingProductRepository(backend, 10, time.Minute)
+
+		repo.Update("updated", 1.0, 1, "")
+		repo.Get("updated")
+		repo.Update("updated", 2.0, 2, "")
+
+		product, _ := repo.Get("updated")
+		if product.Price != 2.0 || product.Stock != 2 {
+			t.Errorf("Expected updated product with price 2.0, got %+v", product)
+		}
+		if backend.gets != 2 {
+			t.Errorf("Expected backend to be called again after update, got %d calls", backend.gets)
+		}
+	})
+
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		backend := newCountingRepository()
+		for _, id := range []string{"a", "b", "c"} {
+			backend.Update(id, 1.0, 1, "")
+		}
+		repo := NewCachingProductRepository(backend, 2, time.Minute)
+
+		repo.Get("a")
+		repo.Get("b")
+		repo.Get("a") // a is now most recently used
+		repo.Get("c") // evicts b
+
+		if repo.Len() != 2 {
+			t.Errorf("Expected 2 cached products, got %d", repo.Len())
+		}
+
+		gets := backend.gets
+		repo.Get("a")
+		if backend.gets != gets {
+			t.Error("Expected a to still be cached")
+		}
+		repo.Get("b")
+		if backend.gets != gets+1 {
+			t.Error("Expected b to have been evicted")
+		}
+	})
+
+	t.Run("ExpiresAfterTTL", func(t *testing.T) {
+		backend := newCountingRepository()
+		backend.Update("ttl", 1.0, 1, "")
+		repo := NewCachingProductRepository(backend, 10, time.Second)
+
+		now := time.Now()
+		repo.now = func() time.Time { return now }
+		repo.Get("ttl")
+
+		now = now.Add(2 * time.Second)
+		repo.Get("ttl")
+
+		if backend.gets != 2 {
+			t.Errorf("Expected expired entry to be re-read, got %d backend calls", backend.gets)
+		}
+	})
+
+	t.Run("MissesAreNotCached", func(t *testing.T) {
+		backend := newCountingRepository()
+		repo := NewCachingProductRepository(backend, 10, time.Minute)
+
+		repo.Get("missing")
+		repo.Get("missing")
+
+		if backend.gets != 2 {
+			t.Errorf("Expected every miss to reach the backend, got %d calls", backend.gets)
+		}
+	})
+}
+
+func TestCachingProductRepository_GetReturnsCopy(t *testing.T) {
+	repo := NewCachingProductRepository(NewInMemoryProductRepository(), 10, time.Minute)
+	repo.Update("copy-product", 10.0, 5, "")
+
+	first, _ := repo.Get("copy-product")
+	first.Price = 0
+
+	cached, _ := repo.Get("copy-product")
+	cached.Stock = -1
+
+	stored, _ := repo.Get("copy-product")
+	if stored.Price != 10.0 || stored.Stock != 5 {
+		t.Errorf("Expected cached product to be unchanged, got price=%.2f, stock=%d", stored.Price, stored.Stock)
+	}
+}
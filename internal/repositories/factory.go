@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"product-service/internal/config"
+	"product-service/internal/models"
+)
+
+// Storage backends selectable with REPOSITORY_BACKEND
+const (
+	BackendMemory  = "memory"
+	BackendSharded = "sharded"
+)
+
+// ErrUnknownBackend is returned by NewProductRepository for an unsupported backend
+var ErrUnknownBackend = errors.New("unknown repository backend")
+
+// MemoryStore is implemented by the in-process stores at the bottom of a
+// storage stack, which support deletion, auditing and counting directly
+type MemoryStore interface {
+	ProductRepository
+	Delete(id string) bool
+	SetSoftDelete(enabled bool)
+	SetPricePlaces(places int) error
+	PurgeTombstones(cutoff time.Time) int
+	Tombstones() int
+	ProductCount() int
+	SizeBytes() int64
+	Range(fn func(product models.Product, updatedAt time.Time) bool)
+	Snapshot() []models.Product
+}
+
+// Unwrapper is implemented by repositories that decorate another repository
+type Unwrapper interface {
+	Unwrap() ProductRepository
+}
+
+// Deleter is implemented by repositories that can remove a product
+type Deleter interface {
+	Delete(id string) bool
+}
+
+// deleteFrom removes a product from repo, returning false if it cannot delete or the product did not exist
+func deleteFrom(repo ProductRepository, id string) bool {
+	if deleter, ok := repo.(Deleter); ok {
+		return deleter.Delete(id)
+	}
+	return false
+}
+
+// SelfChecker is implemented by repositories that can verify their backing storage is usable
+type SelfChecker interface {
+	SelfCheck(ctx context.Context) error
+}
+
+// selfCheck runs the self-check of repo if it has one
+func selfCheck(ctx context.Context, repo ProductRepository) error {
+	if checker, ok := repo.(SelfChecker); ok {
+		return checker.SelfCheck(ctx)
+	}
+	return nil
+}
+
+// NewProductRepository builds the storage stack described by cfg: the store
+// selected by cfg.RepositoryBackend, a write-ahead log replayed from
+// cfg.WALPath if set, and an LRU read cache on top if cfg.CacheSize is positive.
+// The returned repository is the outermost layer; use Layer to reach the others.
+func NewProductRepository(cfg *config.Config) (ProductRepository, error) {
+	var repo ProductRepository
+	switch strings.ToLower(strings.TrimSpace(cfg.RepositoryBackend)) {
+	case "", BackendMemory:
+		repo = NewInMemoryProductRepository()
+	case BackendSharded:
+		repo = NewShardedProductRepository(cfg.RepositoryShards)
+	default:
+		return nil, fmt.Errorf("%w %q, expected %q or %q", ErrUnknownBackend, cfg.RepositoryBackend, BackendMemory, BackendSharded)
+	}
+
+	// Set before replay, so replayed prices are stored exactly too
+	if cfg.PriceMode == models.PriceModeFixed {
+		if err := repo.(MemoryStore).SetPricePlaces(cfg.PriceDecimalPlaces); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.WALPath != "" {
+		walRepo, err := NewWALProductRepository(repo, cfg.WALPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open write-ahead log: %w", err)
+		}
+		if err := walRepo.Replay(cfg.WALPath); err != nil {
+			walRepo.Close()
+			return nil, fmt.Errorf("failed to replay write-ahead log: %w", err)
+		}
+		repo = walRepo
+	}
+
+	if cfg.CacheSize > 0 {
+		repo = NewCachingProductRepository(repo, cfg.CacheSize, cfg.CacheTTL)
+	}
+	return repo, nil
+}
+
+// Layer returns the first repository of type T found by unwrapping repo,
+// starting with repo itself
+func Layer[T ProductRepository](repo ProductRepository) (T, bool) {
+	for repo != nil {
+		if layer, ok := repo.(T); ok {
+			return layer, true
+		}
+		unwrapper, ok := repo.(Unwrapper)
+		if !ok {
+			break
+		}
+		repo = unwrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
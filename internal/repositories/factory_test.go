@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"product-service/internal/config"
+)
+
+func TestNewProductRepository(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		for _, backend := range []string{"memory", "MEMORY", ""} {
+			repo, err := NewProductRepository(&config.Config{RepositoryBackend: backend})
+			if err != nil {
+				t.Fatalf("Expected no error for backend %q, got %v", backend, err)
+			}
+			if _, ok := repo.(*InMemoryProductRepository); !ok {
+				t.Errorf("Expected *InMemoryProductRepository for backend %q, got %T", backend, repo)
+			}
+		}
+	})
+
+	t.Run("Sharded", func(t *testing.T) {
+		repo, err := NewProductRepository(&config.Config{RepositoryBackend: "sharded", RepositoryShards: 4})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		sharded, ok := repo.(*ShardedProductRepository)
+		if !ok {
+			t.Fatalf("Expected *ShardedProductRepository, got %T", repo)
+		}
+		if sharded.ShardCount() != 4 {
+			t.Errorf("Expected 4 shards, got %d", sharded.ShardCount())
+		}
+		if _, ok := Layer[MemoryStore](repo); !ok {
+			t.Error("Expected the sharded repository to be a memory store")
+		}
+	})
+
+	t.Run("Caching", func(t *testing.T) {
+		repo, err := NewProductRepository(&config.Config{RepositoryBackend: BackendMemory, CacheSize: 10, CacheTTL: time.Second})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, ok := repo.(*CachingProductRepository); !ok {
+			t.Errorf("Expected *CachingProductRepository, got %T", repo)
+		}
+		if _, ok := Layer[*InMemoryProductRepository](repo); !ok {
+			t.Error("Expected the in-memory store beneath the cache")
+		}
+	})
+
+	t.Run("WriteAheadLog", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "products.wal")
+		repo, err := NewProductRepository(&config.Config{RepositoryBackend: BackendMemory, WALPath: path})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		walRepo, ok := repo.(*WALProductRepository)
+		if !ok {
+			t.Fatalf("Expected *WALProductRepository, got %T", repo)
+		}
+		repo.Update("abc", 1.5, 3, "")
+		walRepo.Close()
+
+		// A second stack over the same log replays the update
+		repo, err = NewProductRepository(&config.Config{RepositoryBackend: BackendMemory, WALPath: path, CacheSize: 10})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, ok := repo.(*CachingProductRepository); !ok {
+			t.Errorf("Expected *CachingProductRepository outermost, got %T", repo)
+		}
+		walRepo, ok = Layer[*WALProductRepository](repo)
+		if !ok {
+			t.Fatal("Expected a write-ahead log layer")
+		}
+		defer walRepo.Close()
+
+		if product, exists := repo.Get("abc"); !exists || product.Price != 1.5 || product.Stock != 3 {
+			t.Errorf("Expected replayed product, got %+v", product)
+		}
+	})
+
+	t.Run("UnknownBackend", func(t *testing.T) {
+		repo, err := NewProductRepository(&config.Config{RepositoryBackend: "floppy"})
+		if !errors.Is(err, ErrUnknownBackend) {
+			t.Errorf("Expected ErrUnknownBackend, got %v", err)
+		}
+		if repo != nil {
+			t.Errorf("Expected no repository, got %T", repo)
+		}
+	})
+}
+
+func TestLayer(t *testing.T) {
+	memory := NewInMemoryProductRepository()
+	repo := NewCachingProductRepository(memory, 10, 0)
+
+	if found, ok := Layer[*InMemoryProductRepository](repo); !ok || found != memory {
+		t.Errorf("Expected to find the wrapped in-memory repository, got %v", found)
+	}
+	if _, ok := Layer[*WALProductRepository](repo); ok {
+		t.Error("Expected no write-ahead log layer")
+	}
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+)
+
+// Metric names recorded by InstrumentedProductRepository
+const (
+	metricOperationDuration = "repository_operation_duration_seconds"
+	metricLockWait          = "repository_lock_wait_seconds"
+)
+
+// repositoryBuckets extend down to a microsecond, since uncontended map and lock operations are that fast
+var repositoryBuckets = []float64{0.000001, 0.00001, 0.0001, 0.001, 0.01, 0.1, 1}
+
+// LockWaitReporter is implemented by repositories that can report how long operations waited for their lock
+type LockWaitReporter interface {
+	SetLockWaitObserver(observer func(operation string, wait time.Duration))
+}
+
+// InstrumentedProductRepository records the latency of every call to the wrapped repository
+//
+// If the wrapped repository implements LockWaitReporter, the time spent waiting
+// for its lock is recorded separately so lock contention can be told apart
+// from slow storage.
+type InstrumentedProductRepository struct {
+	inner    ProductRepository
+	registry *metrics.Registry
+}
+
+// NewInstrumentedProductRepository wraps a repository, recording timings into registry
+func NewInstrumentedProductRepository(inner ProductRepository, registry *metrics.Registry) *InstrumentedProductRepository {
+	r := &InstrumentedProductRepository{
+		inner:    inner,
+		registry: registry,
+	}
+
+	if reporter, ok := inner.(LockWaitReporter); ok {
+		reporter.SetLockWaitObserver(r.observeLockWait)
+	}
+	return r
+}
+
+// Unwrap returns the wrapped repository
+func (r *InstrumentedProductRepository) Unwrap() ProductRepository {
+	return r.inner
+}
+
+// SelfCheck checks the wrapped repository
+func (r *InstrumentedProductRepository) SelfCheck(ctx context.Context) error {
+	return selfCheck(ctx, r.inner)
+}
+
+// Get retrieves a product by ID
+func (r *InstrumentedProductRepository) Get(id string) (*models.Product, bool) {
+	start := time.Now()
+	product, exists := r.inner.Get(id)
+	r.observeDuration("get", time.Since(start))
+	return product, exists
+}
+
+// GetContext retrieves a product by ID, honouring cancellation of ctx
+func (r *InstrumentedProductRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	start := time.Now()
+	product, exists, err := getContext(ctx, r.inner, id)
+	r.observeDuration("get", time.Since(start))
+	return product, exists, err
+}
+
+// Update updates a product's state including the currency of its price
+func (r *InstrumentedProductRepository) Update(id string, price float64, stock int, currency string) {
+	start := time.Now()
+	r.inner.Update(id, price, stock, currency)
+	r.observeDuration("update", time.Since(start))
+}
+
+// Delete removes a product, returning false if it did not exist
+func (r *InstrumentedProductRepository) Delete(id string) bool {
+	start := time.Now()
+	deleted := deleteFrom(r.inner, id)
+	r.observeDuration("delete", time.Since(start))
+	return deleted
+}
+
+// observeDuration records the total latency of an operation
+func (r *InstrumentedProductRepository) observeDuration(operation string, d time.Duration) {
+	r.registry.Histogram(metricOperationDuration, "Repository operation latency in seconds, by operation",
+		repositoryBuckets, metrics.Labels{"operation": operation}).Observe(d.Seconds())
+}
+
+// observeLockWait records the time an operation spent waiting for the repository lock
+func (r *InstrumentedProductRepository) observeLockWait(operation string, wait time.Duration) {
+	r.registry.Histogram(metricLockWait, "Time spent waiting for the repository lock in seconds, by operation",
+		repositoryBuckets, metrics.Labels{"operation": operation}).Observe(wait.Seconds())
+}
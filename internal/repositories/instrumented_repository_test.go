@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"testing"
+
+	"product-service/pkg/metrics"
+)
+
+func TestInstrumentedProductRepository(t *testing.T) {
+	registry := metrics.NewRegistry()
+	repo := NewInstrumentedProductRepository(NewInMemoryProductRepository(), registry)
+
+	t.Run("PassesThroughResults", func(t *testing.T) {
+		repo.Update("instrumented", 12.5, 7, "")
+
+		product, exists := repo.Get("instrumented")
+		if !exists {
+			t.Fatal("Expected product to exist after update")
+		}
+		if product.ID != "instrumented" || product.Price != 12.5 || product.Stock != 7 {
+			t.Errorf("Expected product{ID: instrumented, Price: 12.5, Stock: 7}, got %+v", product)
+		}
+
+		if _, exists := repo.Get("missing"); exists {
+			t.Error("Expected missing product to not exist")
+		}
+	})
+
+	t.Run("RecordsTimings", func(t *testing.T) {
+		for _, tc := range []struct {
+			operation string
+			count     uint64
+		}{
+			{"get", 2},
+			{"update", 1},
+		} {
+			labels := metrics.Labels{"operation": tc.operation}
+
+			duration := registry.Histogram(metricOperationDuration, "", repositoryBuckets, labels)
+			if duration.Count() != tc.count {
+				t.Errorf("Expected %d %s duration observations, got %d", tc.count, tc.operation, duration.Count())
+			}
+			if duration.Sum() <= 0 {
+				t.Errorf("Expected non-zero %s duration, got %f", tc.operation, duration.Sum())
+			}
+
+			lockWait := registry.Histogram(metricLockWait, "", repositoryBuckets, labels)
+			if lockWait.Count() != tc.count {
+				t.Errorf("Expected %d %s lock wait observations, got %d", tc.count, tc.operation, lockWait.Count())
+			}
+			if lockWait.Sum() <= 0 {
+				t.Errorf("Expected non-zero %s lock wait, got %f", tc.operation, lockWait.Sum())
+			}
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"product-service/internal/models"
+)
+
+// FanOutPolicy controls how a MultiRepository handles a write that fails on some repositories
+type FanOutPolicy string
+
+const (
+	// FanOutBestEffort applies every write to all repositories it can, logging failures
+	FanOutBestEffort FanOutPolicy = "best_effort"
+	// FanOutAllOrNothing undoes a write on every repository once any of them fails it
+	FanOutAllOrNothing FanOutPolicy = "all_or_nothing"
+)
+
+// ParseFanOutPolicy parses a fan-out policy from its configuration value
+func ParseFanOutPolicy(value string) (FanOutPolicy, error) {
+	switch FanOutPolicy(value) {
+	case FanOutBestEffort, FanOutAllOrNothing:
+		return FanOutPolicy(value), nil
+	default:
+		return "", fmt.Errorf("unknown fan-out policy %q, expected %q or %q", value, FanOutBestEffort, FanOutAllOrNothing)
+	}
+}
+
+// ErrRolledBack is returned when an all-or-nothing write failed and was undone
+var ErrRolledBack = errors.New("write rolled back on every repository")
+
+// Sink is implemented by repositories whose writes can fail, such as an
+// external search index. MultiRepository relies on it to notice a failed
+// write; writes to repositories without it are assumed to succeed.
+type Sink interface {
+	TryUpdate(id string, price float64, stock int, currency string) error
+	TryDelete(id string) error
+}
+
+// MultiRepository mirrors every write to a primary repository and any number
+// of secondaries, while reads are served by the primary alone
+//
+// Writes are serialized and applied to the secondaries before the primary.
+// Under FanOutAllOrNothing a failure stops the write and restores the
+// product's previous state, taken from the primary, on every repository
+// already written, so the primary never holds an update a sink refused.
+type MultiRepository struct {
+	mu          sync.Mutex
+	primary     ProductRepository
+	secondaries []ProductRepository
+	policy      FanOutPolicy
+	failures    uint64
+	logger      *log.Logger
+}
+
+// NewMultiRepository creates a repository fanning writes out to primary and secondaries
+func NewMultiRepository(policy FanOutPolicy, primary ProductRepository, secondaries ...ProductRepository) *MultiRepository {
+	return &MultiRepository{
+		primary:     primary,
+		secondaries: secondaries,
+		policy:      policy,
+		logger:      log.New(os.Stdout, "[FANOUT] ", log.LstdFlags),
+	}
+}
+
+// Unwrap returns the primary repository
+func (r *MultiRepository) Unwrap() ProductRepository {
+	return r.primary
+}
+
+// Get retrieves a product by ID from the primary
+func (r *MultiRepository) Get(id string) (*models.Product, bool) {
+	return r.primary.Get(id)
+}
+
+// GetContext retrieves a product by ID from the primary, honouring cancellation of ctx
+func (r *MultiRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	return getContext(ctx, r.primary, id)
+}
+
+// Update applies an update, including its currency, to every repository
+func (r *MultiRepository) Update(id string, price float64, stock int, currency string) {
+	r.TryUpdate(id, price, stock, currency)
+}
+
+// TryUpdate applies an update to every repository, returning the failures.
+// Under FanOutAllOrNothing a failure means no repository kept the update.
+func (r *MultiRepository) TryUpdate(id string, price float64, stock int, currency string) error {
+	return r.write(id, "update", func(repo ProductRepository) error {
+		if sink, ok := repo.(Sink); ok {
+			return sink.TryUpdate(id, price, stock, currency)
+		}
+		repo.Update(id, price, stock, currency)
+		return nil
+	})
+}
+
+// Delete removes a product from every repository, reporting whether the primary held it
+func (r *MultiRepository) Delete(id string) bool {
+	_, existed := r.primary.Get(id)
+	if err := r.TryDelete(id); err != nil && r.policy == FanOutAllOrNothing {
+		return false
+	}
+	return existed
+}
+
+// TryDelete removes a product from every repository that supports deletion,
+// returning the failures. Under FanOutAllOrNothing a failure means the
+// product is still present everywhere it was.
+func (r *MultiRepository) TryDelete(id string) error {
+	return r.write(id, "delete", func(repo ProductRepository) error {
+		if sink, ok := repo.(Sink); ok {
+			return sink.TryDelete(id)
+		}
+		if deleter, ok := repo.(interface{ Delete(id string) bool }); ok {
+			deleter.Delete(id)
+		}
+		return nil
+	})
+}
+
+// Failures returns how many repository writes have failed
+func (r *MultiRepository) Failures() uint64 {
+	return atomic.LoadUint64(&r.failures)
+}
+
+// write applies op to the secondaries and then the primary, following the policy on failure
+func (r *MultiRepository) write(id, operation string, op func(repo ProductRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, existed := r.primary.Get(id)
+	repos := append(append([]ProductRepository{}, r.secondaries...), r.primary)
+
+	var errs []error
+	for i, repo := range repos {
+		err := op(repo)
+		if err == nil {
+			continue
+		}
+
+		atomic.AddUint64(&r.failures, 1)
+		err = fmt.Errorf("%s of product %s failed on %s: %w", operation, id, r.name(i), err)
+		errs = append(errs, err)
+		if r.policy == FanOutAllOrNothing {
+			r.logger.Printf("%v; rolling back", err)
+			r.restore(repos[:i], id, previous, existed)
+			return fmt.Errorf("%w: %w", ErrRolledBack, err)
+		}
+		r.logger.Print(err)
+	}
+	return errors.Join(errs...)
+}
+
+// restore puts the product's previous state back on repos; rollback failures are only logged
+func (r *MultiRepository) restore(repos []ProductRepository, id string, previous *models.Product, existed bool) {
+	for i, repo := range repos {
+		var err error
+		if sink, ok := repo.(Sink); ok {
+			if existed {
+				err = sink.TryUpdate(id, previous.Price, previous.Stock, previous.Currency)
+			} else {
+				err = sink.TryDelete(id)
+			}
+		} else if existed {
+			repo.Update(id, previous.Price, previous.Stock, previous.Currency)
+		} else if deleter, ok := repo.(interface{ Delete(id string) bool }); ok {
+			deleter.Delete(id)
+		}
+		if err != nil {
+			r.logger.Printf("Rollback failed on %s, it may now diverge from the primary: %v", r.name(i), err)
+		}
+	}
+}
+
+// name describes the repository at index i of the write order
+func (r *MultiRepository) name(i int) string {
+	if i == len(r.secondaries) {
+		return "primary"
+	}
+	return fmt.Sprintf("secondary #%d", i+1)
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+)
+
+// failingSink is an in-memory sink that refuses writes while down
+type failingSink struct {
+	*InMemoryProductRepository
+	down bool
+}
+
+var errSinkDown = errors.New("sink unavailable")
+
+func (s *failingSink) TryUpdate(id string, price float64, stock int, currency string) error {
+	if s.down {
+		return errSinkDown
+	}
+	s.Update(id, price, stock, currency)
+	return nil
+}
+
+func (s *failingSink) TryDelete(id string) error {
+	if s.down {
+		return errSinkDown
+	}
+	s.Delete(id)
+	return nil
+}
+
+func TestMultiRepository_FansOut(t *testing.T) {
+	primary := NewInMemoryProductRepository()
+	secondary := NewInMemoryProductRepository()
+	repo := NewMultiRepository(FanOutBestEffort, primary, secondary)
+
+	repo.Update("mirrored", 12.5, 3, "EUR")
+	for name, backing := range map[string]*InMemoryProductRepository{"primary": primary, "secondary": secondary} {
+		product, exists := backing.Get("mirrored")
+		if !exists {
+			t.Fatalf("Expected %s to receive the update", name)
+		}
+		if product.Price != 12.5 || product.Stock != 3 || product.Currency != "EUR" {
+			t.Errorf("Expected %s to hold 12.5 EUR x3, got %+v", name, product)
+		}
+	}
+
+	if !repo.Delete("mirrored") {
+		t.Error("Expected delete to report the product existed")
+	}
+	if _, exists := secondary.Get("mirrored"); exists {
+		t.Error("Expected secondary to receive the delete")
+	}
+	if _, exists := repo.Get("mirrored"); exists {
+		t.Error("Expected primary to receive the delete")
+	}
+	if repo.Failures() != 0 {
+		t.Errorf("Expected no failures, got %d", repo.Failures())
+	}
+}
+
+func TestMultiRepository_PartialFailure(t *testing.T) {
+	setup := func(policy FanOutPolicy) (*MultiRepository, *InMemoryProductRepository, *InMemoryProductRepository, *failingSink) {
+		primary := NewInMemoryProductRepository()
+		mirror := NewInMemoryProductRepository()
+		sink := &failingSink{InMemoryProductRepository: NewInMemoryProductRepository()}
+		repo := NewMultiRepository(policy, primary, mirror, sink)
+		repo.Update("widget", 10.0, 1, "")
+		sink.down = true
+		return repo, primary, mirror, sink
+	}
+
+	t.Run("BestEffort", func(t *testing.T) {
+		repo, primary, mirror, sink := setup(FanOutBestEffort)
+
+		err := repo.TryUpdate("widget", 20.0, 2, "")
+		if !errors.Is(err, errSinkDown) {
+			t.Errorf("Expected the sink failure to be reported, got %v", err)
+		}
+		for name, backing := range map[string]*InMemoryProductRepository{"primary": primary, "mirror": mirror} {
+			if product, _ := backing.Get("widget"); product.Price != 20.0 {
+				t.Errorf("Expected %s to apply the update despite the failing sink, got %+v", name, product)
+			}
+		}
+		if product, _ := sink.Get("widget"); product.Price != 10.0 {
+			t.Errorf("Expected the failing sink to keep its old state, got %+v", product)
+		}
+		if repo.Failures() != 1 {
+			t.Errorf("Expected 1 failure counted, got %d", repo.Failures())
+		}
+	})
+
+	t.Run("AllOrNothing", func(t *testing.T) {
+		repo, primary, mirror, sink := setup(FanOutAllOrNothing)
+
+		err := repo.TryUpdate("widget", 20.0, 2, "")
+		if !errors.Is(err, ErrRolledBack) || !errors.Is(err, errSinkDown) {
+			t.Errorf("Expected a rolled back sink failure, got %v", err)
+		}
+		for name, backing := range map[string]*InMemoryProductRepository{"primary": primary, "mirror": mirror, "sink": sink.InMemoryProductRepository} {
+			if product, _ := backing.Get("widget"); product.Price != 10.0 || product.Stock != 1 {
+				t.Errorf("Expected %s to keep the previous state, got %+v", name, product)
+			}
+		}
+
+		t.Run("NewProduct", func(t *testing.T) {
+			repo.Update("fresh", 5.0, 5, "")
+			for name, backing := range map[string]*InMemoryProductRepository{"primary": primary, "mirror": mirror} {
+				if _, exists := backing.Get("fresh"); exists {
+					t.Errorf("Expected the rolled back product to be absent from %s", name)
+				}
+			}
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			if repo.Delete("widget") {
+				t.Error("Expected a rolled back delete to report failure")
+			}
+			for name, backing := range map[string]*InMemoryProductRepository{"primary": primary, "mirror": mirror} {
+				if _, exists := backing.Get("widget"); !exists {
+					t.Errorf("Expected the rolled back delete to leave the product in %s", name)
+				}
+			}
+		})
+	})
+}
+
+func TestParseFanOutPolicy(t *testing.T) {
+	for _, value := range []string{"best_effort", "all_or_nothing"} {
+		if policy, err := ParseFanOutPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("Expected %q to parse, got %q, %v", value, policy, err)
+		}
+	}
+	if _, err := ParseFanOutPolicy("sometimes"); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
@@ -1,7 +1,12 @@
 package repositories
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
+	"unsafe"
 
 	"product-service/internal/models"
 )
@@ -9,37 +14,339 @@ import (
 // ProductRepository interface defines the contract for product storage
 type ProductRepository interface {
 	Get(id string) (*models.Product, bool)
-	Update(id string, price float64, stock int)
+	// Update stores a product's state; currency is that of its price, empty if unknown
+	Update(id string, price float64, stock int, currency string)
+}
+
+// ContextProductRepository is implemented by repositories whose lookups honour context cancellation
+type ContextProductRepository interface {
+	GetContext(ctx context.Context, id string) (*models.Product, bool, error)
+}
+
+// getContext looks up id in repo, using its context-aware lookup when it has one
+func getContext(ctx context.Context, repo ProductRepository, id string) (*models.Product, bool, error) {
+	if contextRepo, ok := repo.(ContextProductRepository); ok {
+		return contextRepo.GetContext(ctx, id)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	product, exists := repo.Get(id)
+	return product, exists, nil
 }
 
 // InMemoryProductRepository implements ProductRepository using in-memory storage
 type InMemoryProductRepository struct {
-	mu   sync.RWMutex
-	data map[string]*models.Product
+	mu        sync.RWMutex
+	data      map[string]*models.Product
+	updatedAt map[string]time.Time
+	lockWait  func(operation string, wait time.Duration)
+	now       func() time.Time
+
+	// sizeBytes is the running estimate of the memory held by stored products
+	sizeBytes int64
+
+	// softDelete makes Delete leave a tombstone instead of removing the product
+	softDelete bool
+
+	// fixedPrices stores each price as an exact decimal with pricePlaces places
+	fixedPrices bool
+	pricePlaces int
 }
 
 // NewInMemoryProductRepository creates a new in-memory product repository
 func NewInMemoryProductRepository() *InMemoryProductRepository {
 	return &InMemoryProductRepository{
-		data: make(map[string]*models.Product),
+		data:      make(map[string]*models.Product),
+		updatedAt: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// productEntryOverhead approximates the fixed memory cost of one stored
+// product: the struct, its pointer and string key in data, and its updatedAt entry
+const productEntryOverhead = int64(unsafe.Sizeof(models.Product{}) + unsafe.Sizeof(&models.Product{}) +
+	2*unsafe.Sizeof("") + unsafe.Sizeof(time.Time{}))
+
+// estimateEntrySize approximates the memory held by one stored product, ignoring map bucket overhead
+func estimateEntrySize(product *models.Product) int64 {
+	size := productEntryOverhead + int64(len(product.ID)+len(product.Currency))
+	if product.DeletedAt != nil {
+		size += int64(unsafe.Sizeof(time.Time{}))
+	}
+	if product.Amount != nil {
+		size += int64(unsafe.Sizeof(models.Decimal{}))
+	}
+	return size
+}
+
+// SetPricePlaces stores prices written from now on as exact decimals with the
+// given number of places, rounding them half away from zero
+func (r *InMemoryProductRepository) SetPricePlaces(places int) error {
+	if places < 0 || places > models.MaxDecimalPlaces {
+		return fmt.Errorf("price decimal places must be between 0 and %d, got %d", models.MaxDecimalPlaces, places)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixedPrices = true
+	r.pricePlaces = places
+	return nil
+}
+
+// newProduct builds the product to store, with an exact amount in fixed price
+// places mode. A price out of decimal range, which the service rejects before
+// it gets here, is kept as a float only.
+func (r *InMemoryProductRepository) newProduct(id string, price float64, stock int, currency string) *models.Product {
+	product := &models.Product{ID: id, Price: price, Stock: stock, Currency: currency}
+	if r.fixedPrices {
+		if amount, err := models.DecimalFromFloat(price, r.pricePlaces); err == nil {
+			product.Amount = &amount
+			product.Price = amount.Float64()
+		}
+	}
+	return product
+}
+
+// store replaces the product stored under its ID, keeping the size estimate
+// current; the caller must hold the write lock
+func (r *InMemoryProductRepository) store(product *models.Product, updatedAt time.Time) {
+	if existing, exists := r.data[product.ID]; exists {
+		r.sizeBytes -= estimateEntrySize(existing)
 	}
+	r.data[product.ID] = product
+	r.updatedAt[product.ID] = updatedAt
+	r.sizeBytes += estimateEntrySize(product)
 }
 
-// Get retrieves a product by ID
+// remove deletes a stored product, keeping the size estimate current; the
+// caller must hold the write lock
+func (r *InMemoryProductRepository) remove(id string) {
+	if existing, exists := r.data[id]; exists {
+		r.sizeBytes -= estimateEntrySize(existing)
+	}
+	delete(r.data, id)
+	delete(r.updatedAt, id)
+}
+
+// Get retrieves a copy of a product by ID, so callers cannot mutate stored state
 func (r *InMemoryProductRepository) Get(id string) (*models.Product, bool) {
+	start := time.Now()
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	r.observeLockWait("get", start)
+
+	product, exists := r.data[id]
+	if !exists || product.DeletedAt != nil {
+		return nil, false
+	}
+	copied := *product
+	return &copied, true
+}
+
+// GetContext retrieves a product by ID unless ctx is already done
+func (r *InMemoryProductRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	product, exists := r.Get(id)
+	return product, exists, nil
+}
+
+// Update updates a product's state including the currency of its price
+func (r *InMemoryProductRepository) Update(id string, price float64, stock int, currency string) {
+	start := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observeLockWait("update", start)
+
+	r.store(r.newProduct(id, price, stock, currency), r.now())
+}
+
+// UpdateBatch upserts every event under a single lock, returning for each one
+// whether it created the product or updated an existing one
+func (r *InMemoryProductRepository) UpdateBatch(events []models.ProductEvent) []string {
+	start := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observeLockWait("update", start)
+
+	now := r.now()
+	statuses := make([]string, len(events))
+	for i, event := range events {
+		statuses[i] = models.UpsertCreated
+		if existing, exists := r.data[event.ProductID]; exists && existing.DeletedAt == nil {
+			statuses[i] = models.UpsertUpdated
+		}
+		r.store(r.newProduct(event.ProductID, event.Price, event.Stock, event.Currency), now)
+	}
+	return statuses
+}
+
+// dataset is a complete set of stored products, built off-lock so it can be swapped in at once
+type dataset struct {
+	data      map[string]*models.Product
+	updatedAt map[string]time.Time
+	sizeBytes int64
+}
+
+// newDataset copies products into a dataset updated at now; nil products are
+// skipped and a later duplicate ID replaces an earlier one
+func newDataset(products []*models.Product, now time.Time) dataset {
+	d := dataset{
+		data:      make(map[string]*models.Product, len(products)),
+		updatedAt: make(map[string]time.Time, len(products)),
+	}
+	for _, product := range products {
+		if product == nil {
+			continue
+		}
+		copied := *product
+		if existing, exists := d.data[copied.ID]; exists {
+			d.sizeBytes -= estimateEntrySize(existing)
+		}
+		d.data[copied.ID] = &copied
+		d.updatedAt[copied.ID] = now
+		d.sizeBytes += estimateEntrySize(&copied)
+	}
+	return d
+}
+
+// swapLocked replaces the stored products with d; the caller must hold the write lock
+func (r *InMemoryProductRepository) swapLocked(d dataset) {
+	r.data = d.data
+	r.updatedAt = d.updatedAt
+	r.sizeBytes = d.sizeBytes
+}
+
+// ReplaceAll atomically replaces every stored product, including tombstones,
+// with copies of products. The new dataset is built before the write lock is
+// taken, so readers only ever see the complete old or complete new catalog.
+func (r *InMemoryProductRepository) ReplaceAll(products []*models.Product) {
+	d := newDataset(products, r.now())
+
+	start := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observeLockWait("replace", start)
+	r.swapLocked(d)
+}
+
+// SetSoftDelete chooses whether Delete leaves a tombstone that hides the
+// product until PurgeTombstones removes it, or removes the product at once
+func (r *InMemoryProductRepository) SetSoftDelete(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.softDelete = enabled
+}
+
+// Delete removes a product, or tombstones it in soft-delete mode, returning
+// false if it did not exist or was already deleted
+func (r *InMemoryProductRepository) Delete(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	product, exists := r.data[id]
-	return product, exists
+	if !exists || product.DeletedAt != nil {
+		return false
+	}
+	if r.softDelete {
+		deletedAt := r.now()
+		product.DeletedAt = &deletedAt
+		r.sizeBytes += int64(unsafe.Sizeof(deletedAt))
+		return true
+	}
+	r.remove(id)
+	return true
+}
+
+// PurgeTombstones permanently removes products soft-deleted before cutoff and returns how many were removed
+func (r *InMemoryProductRepository) PurgeTombstones(cutoff time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for id, product := range r.data {
+		if product.DeletedAt != nil && product.DeletedAt.Before(cutoff) {
+			r.remove(id)
+			purged++
+		}
+	}
+	return purged
+}
+
+// Tombstones returns the number of soft-deleted products awaiting purge
+func (r *InMemoryProductRepository) Tombstones() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, product := range r.data {
+		if product.DeletedAt != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// ProductCount returns the number of stored products, including tombstones awaiting purge
+func (r *InMemoryProductRepository) ProductCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.data)
+}
+
+// SizeBytes returns an approximate count of the bytes held by stored
+// products, including tombstones awaiting purge
+func (r *InMemoryProductRepository) SizeBytes() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sizeBytes
+}
+
+// Range calls fn with a copy of every live product and the time it was last updated,
+// stopping early if fn returns false. fn must not call back into the repository.
+func (r *InMemoryProductRepository) Range(fn func(product models.Product, updatedAt time.Time) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, product := range r.data {
+		if product.DeletedAt != nil {
+			continue
+		}
+		if !fn(*product, r.updatedAt[id]) {
+			return
+		}
+	}
 }
 
-// Update updates a product's state
-func (r *InMemoryProductRepository) Update(id string, price float64, stock int) {
+// Snapshot returns a copy of every live product ordered by ID
+func (r *InMemoryProductRepository) Snapshot() []models.Product {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]models.Product, 0, len(r.data))
+	for _, product := range r.data {
+		if product.DeletedAt == nil {
+			products = append(products, *product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID < products[j].ID
+	})
+	return products
+}
+
+// SetLockWaitObserver registers a callback receiving the time each operation waited for the lock
+func (r *InMemoryProductRepository) SetLockWaitObserver(observer func(operation string, wait time.Duration)) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.data[id] = &models.Product{
-		ID:    id,
-		Price: price,
-		Stock: stock,
+	r.lockWait = observer
+}
+
+// observeLockWait reports the lock wait since start; callers must hold the lock
+func (r *InMemoryProductRepository) observeLockWait(operation string, start time.Time) {
+	if r.lockWait != nil {
+		r.lockWait(operation, time.Since(start))
 	}
 }
@@ -1,8 +1,13 @@
 package repositories
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"testing"
+	"time"
+
+	"product-service/internal/models"
 )
 
 func TestInMemoryProductRepository(t *testing.T) {
@@ -15,7 +20,7 @@ func TestInMemoryProductRepository(t *testing.T) {
 	}
 
 	// Test update
-	repo.Update("test-product", 99.99, 50)
+	repo.Update("test-product", 99.99, 50, "")
 	product, exists := repo.Get("test-product")
 	if !exists {
 		t.Error("Expected product to exist after update")
@@ -30,7 +35,7 @@ func TestInMemoryProductRepository(t *testing.T) {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
-			repo.Update("product-"+string(rune(id)), float64(id), id*10)
+			repo.Update("product-"+string(rune(id)), float64(id), id*10, "")
 		}(i)
 	}
 	wg.Wait()
@@ -44,3 +49,282 @@ func TestInMemoryProductRepository(t *testing.T) {
 		t.Errorf("Expected original product to have price=99.99, stock=50, got price=%.2f, stock=%d", originalProduct.Price, originalProduct.Stock)
 	}
 }
+
+func TestInMemoryProductRepository_GetContext(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	repo.Update("ctx-product", 5.0, 2, "")
+
+	product, exists, err := repo.GetContext(context.Background(), "ctx-product")
+	if err != nil || !exists || product.Price != 5.0 {
+		t.Errorf("Expected product with price 5.0, got %+v (exists %v, err %v)", product, exists, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := repo.GetContext(ctx, "ctx-product"); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestInMemoryProductRepository_GetReturnsCopy(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	repo.Update("copy-product", 10.0, 5, "")
+
+	product, _ := repo.Get("copy-product")
+	product.Price = 0
+	product.Stock = -1
+
+	stored, _ := repo.Get("copy-product")
+	if stored.Price != 10.0 || stored.Stock != 5 {
+		t.Errorf("Expected stored product to be unchanged, got price=%.2f, stock=%d", stored.Price, stored.Stock)
+	}
+}
+
+func TestInMemoryProductRepository_RangeDelete(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	repo.Update("a", 1.0, 1, "")
+	repo.Update("b", 2.0, 2, "")
+
+	seen := 0
+	repo.Range(func(product models.Product, updatedAt time.Time) bool {
+		if updatedAt.IsZero() {
+			t.Errorf("Expected update time for %s", product.ID)
+		}
+		seen++
+		return true
+	})
+	if seen != 2 {
+		t.Errorf("Expected 2 products, got %d", seen)
+	}
+
+	if !repo.Delete("a") {
+		t.Error("Expected delete of existing product to succeed")
+	}
+	if repo.Delete("a") {
+		t.Error("Expected second delete to report missing product")
+	}
+	if _, exists := repo.Get("a"); exists {
+		t.Error("Expected deleted product to be gone")
+	}
+}
+
+func TestInMemoryProductRepository_SoftDelete(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewInMemoryProductRepository()
+	repo.now = func() time.Time { return now }
+	repo.SetSoftDelete(true)
+
+	repo.Update("gone", 1.0, 1, "")
+	repo.Update("kept", 2.0, 2, "")
+
+	if !repo.Delete("gone") {
+		t.Fatal("Expected Delete to report the product existed")
+	}
+	if repo.Delete("gone") {
+		t.Error("Expected a second Delete to report the product was already deleted")
+	}
+	if _, exists := repo.Get("gone"); exists {
+		t.Error("Expected a soft-deleted product not to be returned by Get")
+	}
+	if products := repo.Snapshot(); len(products) != 1 || products[0].ID != "kept" {
+		t.Errorf("Expected only the live product in the snapshot, got %+v", products)
+	}
+	if repo.Tombstones() != 1 {
+		t.Errorf("Expected 1 tombstone, got %d", repo.Tombstones())
+	}
+
+	// Still inside the retention period
+	if purged := repo.PurgeTombstones(now); purged != 0 {
+		t.Errorf("Expected nothing purged before retention, got %d", purged)
+	}
+
+	if purged := repo.PurgeTombstones(now.Add(time.Hour)); purged != 1 {
+		t.Errorf("Expected 1 product purged after retention, got %d", purged)
+	}
+	if repo.Tombstones() != 0 {
+		t.Errorf("Expected no tombstones after purge, got %d", repo.Tombstones())
+	}
+	if _, exists := repo.Get("kept"); !exists {
+		t.Error("Expected the live product to survive the purge")
+	}
+}
+
+func TestInMemoryProductRepository_SoftDeleteRevivedByUpdate(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	repo.SetSoftDelete(true)
+
+	repo.Update("abc", 1.0, 1, "")
+	repo.Delete("abc")
+	repo.Update("abc", 3.0, 3, "")
+
+	product, exists := repo.Get("abc")
+	if !exists || product.Price != 3.0 || product.DeletedAt != nil {
+		t.Errorf("Expected an update to replace the tombstone, got %+v", product)
+	}
+}
+
+func TestInMemoryProductRepository_UpdateBatch(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	repo.SetSoftDelete(true)
+	repo.Update("existing", 1.0, 1, "")
+	repo.Update("deleted", 1.0, 1, "")
+	repo.Delete("deleted")
+
+	statuses := repo.UpdateBatch([]models.ProductEvent{
+		{ProductID: "new", Price: 2.0, Stock: 2},
+		{ProductID: "existing", Price: 3.0, Stock: 3},
+		{ProductID: "deleted", Price: 4.0, Stock: 4},
+	})
+
+	expected := []string{models.UpsertCreated, models.UpsertUpdated, models.UpsertCreated}
+	for i, status := range expected {
+		if statuses[i] != status {
+			t.Errorf("Expected status %s for item %d, got %s", status, i, statuses[i])
+		}
+	}
+	for _, id := range []string{"new", "existing", "deleted"} {
+		if _, exists := repo.Get(id); !exists {
+			t.Errorf("Expected product %s to be stored", id)
+		}
+	}
+}
+
+func TestInMemoryProductRepository_PricePlaces(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	if err := repo.SetPricePlaces(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 0.1 + 0.2 as a float is 0.30000000000000004
+	repo.Update("single", 0.1+0.2, 1, "")
+	repo.UpdateBatch([]models.ProductEvent{{ProductID: "batch", Price: 19.999, Stock: 1}})
+
+	for id, want := range map[string]string{"single": "0.30", "batch": "20.00"} {
+		product, _ := repo.Get(id)
+		if product.Amount == nil || product.Amount.String() != want {
+			t.Errorf("Expected amount %s for %s, got %v", want, id, product.Amount)
+		}
+		if product.Price != product.Amount.Float64() {
+			t.Errorf("Expected price %v to match the amount, got %v", product.Amount.Float64(), product.Price)
+		}
+	}
+
+	if err := repo.SetPricePlaces(models.MaxDecimalPlaces + 1); err == nil {
+		t.Error("Expected error for too many decimal places")
+	}
+}
+
+func TestInMemoryProductRepository_SizeBytes(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	if size := repo.SizeBytes(); size != 0 {
+		t.Fatalf("Expected an empty repository to hold 0 bytes, got %d", size)
+	}
+
+	repo.Update("size-1", 1.0, 1, "")
+	one := repo.SizeBytes()
+	if one <= 0 {
+		t.Fatalf("Expected a positive size after adding a product, got %d", one)
+	}
+
+	repo.UpdateBatch([]models.ProductEvent{{ProductID: "size-2"}, {ProductID: "size-3"}})
+	three := repo.SizeBytes()
+	if three <= one {
+		t.Errorf("Expected the size to grow as products are added, got %d then %d", one, three)
+	}
+
+	repo.Update("size-1", 2.0, 2, "")
+	if size := repo.SizeBytes(); size != three {
+		t.Errorf("Expected updating a product not to change the size, got %d then %d", three, size)
+	}
+
+	repo.Delete("size-3")
+	if size := repo.SizeBytes(); size >= three {
+		t.Errorf("Expected the size to shrink after a delete, got %d then %d", three, size)
+	}
+
+	repo.SetSoftDelete(true)
+	repo.Delete("size-2")
+	repo.Delete("size-1")
+	repo.PurgeTombstones(time.Now().Add(time.Second))
+	if size := repo.SizeBytes(); size != 0 {
+		t.Errorf("Expected purging every tombstone to free the whole estimate, got %d", size)
+	}
+}
+
+// catalog builds n products all carrying the given price
+func catalog(n int, price float64) []*models.Product {
+	products := make([]*models.Product, n)
+	for i := range products {
+		products[i] = &models.Product{ID: fmt.Sprintf("p%03d", i), Price: price, Stock: i}
+	}
+	return products
+}
+
+func TestInMemoryProductRepository_ReplaceAll(t *testing.T) {
+	repo := NewInMemoryProductRepository()
+	repo.Update("stale", 9.0, 9, "")
+	repo.ReplaceAll(catalog(3, 1.0))
+
+	if count := repo.ProductCount(); count != 3 {
+		t.Errorf("Expected 3 products after ReplaceAll, got %d", count)
+	}
+	if _, exists := repo.Get("stale"); exists {
+		t.Error("Expected products missing from the new dataset to be gone")
+	}
+	if product, exists := repo.Get("p002"); !exists || product.Price != 1.0 || product.Stock != 2 {
+		t.Errorf("Expected p002 {1.0 2}, got %+v", product)
+	}
+
+	fresh := NewInMemoryProductRepository()
+	fresh.ReplaceAll(catalog(3, 1.0))
+	if repo.SizeBytes() != fresh.SizeBytes() {
+		t.Errorf("Expected size %d to match a fresh dataset, got %d", fresh.SizeBytes(), repo.SizeBytes())
+	}
+
+	products := catalog(1, 1.0)
+	repo.ReplaceAll(products)
+	products[0].Price = 100
+	if product, _ := repo.Get("p000"); product.Price != 1.0 {
+		t.Errorf("Expected the stored product to be a copy, got price %.2f", product.Price)
+	}
+}
+
+func TestInMemoryProductRepository_ReplaceAllIsAtomic(t *testing.T) {
+	const size = 200
+	repo := NewInMemoryProductRepository()
+	repo.ReplaceAll(catalog(size, 1.0))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				snapshot := repo.Snapshot()
+				if len(snapshot) != size {
+					t.Errorf("Expected %d products in every snapshot, got %d", size, len(snapshot))
+					return
+				}
+				for _, product := range snapshot {
+					if product.Price != snapshot[0].Price {
+						t.Errorf("Expected a snapshot from a single dataset, got prices %.0f and %.0f", snapshot[0].Price, product.Price)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		repo.ReplaceAll(catalog(size, float64(i%2+1)))
+	}
+	close(stop)
+	wg.Wait()
+}
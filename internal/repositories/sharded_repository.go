@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// DefaultShardCount is the number of shards used when none is configured
+const DefaultShardCount = 16
+
+// ShardedProductRepository spreads products across independently locked
+// in-memory shards by a hash of their ID
+//
+// Operations on different products only contend when they hash to the same
+// shard, so concurrent readers and writers scale with the shard count rather
+// than serializing on one lock. Whole-store operations visit every shard in
+// turn and are not atomic across shards. Each shard counts the operations it
+// serves, so a skewed ID distribution shows up in ShardStats.
+type ShardedProductRepository struct {
+	shards []*InMemoryProductRepository
+	ops    [][shardOperations]uint64
+}
+
+// Operations counted per shard
+const (
+	shardGet = iota
+	shardUpdate
+	shardDelete
+	shardOperations
+)
+
+// NewShardedProductRepository creates a repository with the given number of shards;
+// a count below one selects DefaultShardCount
+func NewShardedProductRepository(shards int) *ShardedProductRepository {
+	if shards < 1 {
+		shards = DefaultShardCount
+	}
+
+	r := &ShardedProductRepository{
+		shards: make([]*InMemoryProductRepository, shards),
+		ops:    make([][shardOperations]uint64, shards),
+	}
+	for i := range r.shards {
+		r.shards[i] = NewInMemoryProductRepository()
+	}
+	return r
+}
+
+// SetPricePlaces stores prices in every shard as exact decimals with the given number of places
+func (r *ShardedProductRepository) SetPricePlaces(places int) error {
+	for _, shard := range r.shards {
+		if err := shard.SetPricePlaces(places); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShardCount returns the number of shards
+func (r *ShardedProductRepository) ShardCount() int {
+	return len(r.shards)
+}
+
+// ShardIndex returns the index of the shard holding id
+func (r *ShardedProductRepository) ShardIndex(id string) int {
+	return int(models.HashID(id) % uint64(len(r.shards)))
+}
+
+// shard returns the shard holding id
+func (r *ShardedProductRepository) shard(id string) *InMemoryProductRepository {
+	return r.shards[r.ShardIndex(id)]
+}
+
+// count returns the shard holding id after counting operation against it
+func (r *ShardedProductRepository) count(id string, operation int) *InMemoryProductRepository {
+	index := r.ShardIndex(id)
+	atomic.AddUint64(&r.ops[index][operation], 1)
+	return r.shards[index]
+}
+
+// Get retrieves a copy of a product by ID
+func (r *ShardedProductRepository) Get(id string) (*models.Product, bool) {
+	return r.count(id, shardGet).Get(id)
+}
+
+// GetContext retrieves a product by ID unless ctx is already done
+func (r *ShardedProductRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	return r.count(id, shardGet).GetContext(ctx, id)
+}
+
+// Update updates a product's state including the currency of its price
+func (r *ShardedProductRepository) Update(id string, price float64, stock int, currency string) {
+	r.count(id, shardUpdate).Update(id, price, stock, currency)
+}
+
+// UpdateBatch upserts every event, taking each shard's lock once for all of
+// its events, and returns whether each event created or updated its product
+func (r *ShardedProductRepository) UpdateBatch(events []models.ProductEvent) []string {
+	byShard := make(map[int][]int)
+	for i, event := range events {
+		index := r.ShardIndex(event.ProductID)
+		byShard[index] = append(byShard[index], i)
+	}
+
+	statuses := make([]string, len(events))
+	for index, positions := range byShard {
+		shardEvents := make([]models.ProductEvent, len(positions))
+		for j, position := range positions {
+			shardEvents[j] = events[position]
+		}
+		atomic.AddUint64(&r.ops[index][shardUpdate], uint64(len(positions)))
+		for j, status := range r.shards[index].UpdateBatch(shardEvents) {
+			statuses[positions[j]] = status
+		}
+	}
+	return statuses
+}
+
+// ReplaceAll replaces every stored product with copies of products. Each
+// shard's new dataset is built first, then all shards are swapped while
+// holding every shard lock, so no lookup sees a mix of old and new shards.
+func (r *ShardedProductRepository) ReplaceAll(products []*models.Product) {
+	partitions := make([][]*models.Product, len(r.shards))
+	for _, product := range products {
+		if product == nil {
+			continue
+		}
+		index := r.ShardIndex(product.ID)
+		partitions[index] = append(partitions[index], product)
+	}
+
+	now := time.Now()
+	datasets := make([]dataset, len(r.shards))
+	for i, partition := range partitions {
+		datasets[i] = newDataset(partition, now)
+	}
+
+	// Shards are always locked in index order, so concurrent swaps cannot deadlock
+	for _, shard := range r.shards {
+		shard.mu.Lock()
+	}
+	for i, shard := range r.shards {
+		shard.swapLocked(datasets[i])
+	}
+	for _, shard := range r.shards {
+		shard.mu.Unlock()
+	}
+}
+
+// SetSoftDelete chooses whether Delete leaves a tombstone or removes the product at once
+func (r *ShardedProductRepository) SetSoftDelete(enabled bool) {
+	for _, shard := range r.shards {
+		shard.SetSoftDelete(enabled)
+	}
+}
+
+// Delete removes or tombstones a product, returning false if it did not exist or was already deleted
+func (r *ShardedProductRepository) Delete(id string) bool {
+	return r.count(id, shardDelete).Delete(id)
+}
+
+// PurgeTombstones permanently removes products soft-deleted before cutoff and returns how many were removed
+func (r *ShardedProductRepository) PurgeTombstones(cutoff time.Time) int {
+	purged := 0
+	for _, shard := range r.shards {
+		purged += shard.PurgeTombstones(cutoff)
+	}
+	return purged
+}
+
+// Tombstones returns the number of soft-deleted products awaiting purge
+func (r *ShardedProductRepository) Tombstones() int {
+	count := 0
+	for _, shard := range r.shards {
+		count += shard.Tombstones()
+	}
+	return count
+}
+
+// ProductCount returns the number of stored products, including tombstones awaiting purge
+func (r *ShardedProductRepository) ProductCount() int {
+	count := 0
+	for _, shard := range r.shards {
+		count += shard.ProductCount()
+	}
+	return count
+}
+
+// ShardStats returns the products held by each shard, including tombstones,
+// and the operations each has served since the repository was created
+func (r *ShardedProductRepository) ShardStats() []models.ShardStats {
+	stats := make([]models.ShardStats, len(r.shards))
+	for i, shard := range r.shards {
+		stats[i] = models.ShardStats{
+			Shard:    i,
+			Products: shard.ProductCount(),
+			Gets:     atomic.LoadUint64(&r.ops[i][shardGet]),
+			Updates:  atomic.LoadUint64(&r.ops[i][shardUpdate]),
+			Deletes:  atomic.LoadUint64(&r.ops[i][shardDelete]),
+		}
+	}
+	return stats
+}
+
+// SizeBytes returns an approximate count of the bytes held by stored
+// products across all shards, including tombstones awaiting purge
+func (r *ShardedProductRepository) SizeBytes() int64 {
+	var size int64
+	for _, shard := range r.shards {
+		size += shard.SizeBytes()
+	}
+	return size
+}
+
+// Range calls fn with a copy of every live product and the time it was last updated,
+// shard by shard, stopping early if fn returns false. fn must not call back into the repository.
+func (r *ShardedProductRepository) Range(fn func(product models.Product, updatedAt time.Time) bool) {
+	stopped := false
+	for _, shard := range r.shards {
+		shard.Range(func(product models.Product, updatedAt time.Time) bool {
+			if !fn(product, updatedAt) {
+				stopped = true
+			}
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// Snapshot returns a copy of every live product ordered by ID
+func (r *ShardedProductRepository) Snapshot() []models.Product {
+	products := make([]models.Product, 0)
+	for _, shard := range r.shards {
+		products = append(products, shard.Snapshot()...)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID < products[j].ID
+	})
+	return products
+}
+
+// SetLockWaitObserver registers a callback receiving the time each operation waited for its shard's lock
+func (r *ShardedProductRepository) SetLockWaitObserver(observer func(operation string, wait time.Duration)) {
+	for _, shard := range r.shards {
+		shard.SetLockWaitObserver(observer)
+	}
+}
@@ -0,0 +1,219 @@
+package repositories
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestShardedProductRepository_Shards(t *testing.T) {
+	repo := NewShardedProductRepository(8)
+
+	for i := 0; i < 1000; i++ {
+		repo.Update(fmt.Sprintf("product-%d", i), float64(i), i, "EUR")
+	}
+
+	used := 0
+	total := 0
+	for index, shard := range repo.shards {
+		count := shard.ProductCount()
+		total += count
+		if count > 0 {
+			used++
+		}
+		for _, product := range shard.Snapshot() {
+			if repo.ShardIndex(product.ID) != index {
+				t.Errorf("Expected %s in shard %d, found it in shard %d", product.ID, repo.ShardIndex(product.ID), index)
+			}
+		}
+	}
+	if total != 1000 || repo.ProductCount() != 1000 {
+		t.Errorf("Expected 1000 products across shards, got %d (reported %d)", total, repo.ProductCount())
+	}
+	if used != 8 {
+		t.Errorf("Expected products in all 8 shards, got %d", used)
+	}
+
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("product-%d", i)
+		product, exists := repo.Get(id)
+		if !exists {
+			t.Fatalf("Expected %s to be retrievable", id)
+		}
+		if product.Price != float64(i) || product.Stock != i || product.Currency != "EUR" {
+			t.Errorf("Expected %s with price %d, stock %d and currency EUR, got %+v", id, i, i, product)
+		}
+	}
+}
+
+func TestShardedProductRepository_DefaultShards(t *testing.T) {
+	if count := NewShardedProductRepository(0).ShardCount(); count != DefaultShardCount {
+		t.Errorf("Expected %d shards, got %d", DefaultShardCount, count)
+	}
+}
+
+func TestShardedProductRepository_UpdateBatch(t *testing.T) {
+	repo := NewShardedProductRepository(4)
+	repo.Update("b", 1.0, 1, "")
+
+	statuses := repo.UpdateBatch([]models.ProductEvent{
+		{ProductID: "a", Price: 1.0, Stock: 1},
+		{ProductID: "b", Price: 2.0, Stock: 2},
+		{ProductID: "c", Price: 3.0, Stock: 3},
+	})
+
+	expected := []string{models.UpsertCreated, models.UpsertUpdated, models.UpsertCreated}
+	for i, status := range statuses {
+		if status != expected[i] {
+			t.Errorf("Expected status %s for event %d, got %s", expected[i], i, status)
+		}
+	}
+	if snapshot := repo.Snapshot(); len(snapshot) != 3 || snapshot[0].ID != "a" || snapshot[2].ID != "c" {
+		t.Errorf("Expected a sorted snapshot of a, b and c, got %+v", snapshot)
+	}
+}
+
+func TestShardedProductRepository_SoftDelete(t *testing.T) {
+	repo := NewShardedProductRepository(4)
+	repo.SetSoftDelete(true)
+	repo.Update("gone", 1.0, 1, "")
+	repo.Update("kept", 1.0, 1, "")
+
+	if !repo.Delete("gone") {
+		t.Fatal("Expected the product to be deleted")
+	}
+	if _, exists := repo.Get("gone"); exists {
+		t.Error("Expected the deleted product to be hidden")
+	}
+	if repo.Tombstones() != 1 {
+		t.Errorf("Expected 1 tombstone, got %d", repo.Tombstones())
+	}
+	if purged := repo.PurgeTombstones(time.Now().Add(time.Second)); purged != 1 {
+		t.Errorf("Expected 1 purged tombstone, got %d", purged)
+	}
+
+	visited := 0
+	repo.Range(func(product models.Product, updatedAt time.Time) bool {
+		visited++
+		return true
+	})
+	if visited != 1 {
+		t.Errorf("Expected 1 live product, got %d", visited)
+	}
+}
+
+// benchmarkConcurrentAccess runs a read-heavy mix of lookups and updates over 1000 products from parallel goroutines
+func benchmarkConcurrentAccess(b *testing.B, repo ProductRepository) {
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("product-%d", i)
+		repo.Update(ids[i], 1.0, 1, "")
+	}
+
+	// Each goroutine starts at a different product so they don't move in lockstep
+	var next uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(atomic.AddUint64(&next, 1)) * 7919
+
+		for pb.Next() {
+			id := ids[i%len(ids)]
+			if i%4 == 0 {
+				repo.Update(id, float64(i), i, "")
+			} else {
+				repo.Get(id)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkInMemoryProductRepository_Concurrent(b *testing.B) {
+	benchmarkConcurrentAccess(b, NewInMemoryProductRepository())
+}
+
+func BenchmarkShardedProductRepository_Concurrent(b *testing.B) {
+	benchmarkConcurrentAccess(b, NewShardedProductRepository(DefaultShardCount))
+}
+
+func TestShardedProductRepository_SizeBytes(t *testing.T) {
+	repo := NewShardedProductRepository(4)
+	single := NewInMemoryProductRepository()
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("sized-%d", i)
+		repo.Update(id, 1.0, i, "")
+		single.Update(id, 1.0, i, "")
+	}
+
+	if repo.SizeBytes() != single.SizeBytes() {
+		t.Errorf("Expected the shards to sum to %d bytes, got %d", single.SizeBytes(), repo.SizeBytes())
+	}
+}
+
+func TestShardedProductRepository_ReplaceAll(t *testing.T) {
+	repo := NewShardedProductRepository(4)
+	repo.Update("stale", 9.0, 9, "")
+	repo.ReplaceAll(catalog(20, 2.0))
+
+	if count := repo.ProductCount(); count != 20 {
+		t.Errorf("Expected 20 products after ReplaceAll, got %d", count)
+	}
+	if _, exists := repo.Get("stale"); exists {
+		t.Error("Expected products missing from the new dataset to be gone")
+	}
+	for _, product := range repo.Snapshot() {
+		if product.Price != 2.0 {
+			t.Errorf("Expected every product from the new dataset, got %+v", product)
+		}
+	}
+}
+
+func TestShardedProductRepository_ShardStats(t *testing.T) {
+	repo := NewShardedProductRepository(4)
+
+	// One hot product receives most of the traffic
+	for i := 0; i < 100; i++ {
+		repo.Update("hot", float64(i), i, "")
+		repo.Get("hot")
+	}
+	for i := 0; i < 20; i++ {
+		repo.Update(fmt.Sprintf("cold-%d", i), 1.0, 1, "")
+	}
+	repo.UpdateBatch([]models.ProductEvent{{ProductID: "hot", Price: 1.0}, {ProductID: "hot", Price: 2.0}})
+	repo.Delete("cold-0")
+
+	stats := repo.ShardStats()
+	if len(stats) != 4 {
+		t.Fatalf("Expected stats for 4 shards, got %d", len(stats))
+	}
+	hot := repo.ShardIndex("hot")
+	var updates, gets, deletes uint64
+	products := 0
+	for i, shard := range stats {
+		if shard.Shard != i {
+			t.Errorf("Expected shard %d at index %d, got %d", i, i, shard.Shard)
+		}
+		updates += shard.Updates
+		gets += shard.Gets
+		deletes += shard.Deletes
+		products += shard.Products
+		if i != hot && shard.Updates >= stats[hot].Updates {
+			t.Errorf("Expected the hot shard %d to lead on updates, shard %d has %d vs %d", hot, i, shard.Updates, stats[hot].Updates)
+		}
+	}
+	if updates != 122 || gets != 100 || deletes != 1 {
+		t.Errorf("Expected 122 updates, 100 gets and 1 delete in total, got %d, %d and %d", updates, gets, deletes)
+	}
+	if stats[hot].Gets != 100 {
+		t.Errorf("Expected every get on the hot shard, got %d", stats[hot].Gets)
+	}
+	if stats[hot].Updates < 102 {
+		t.Errorf("Expected at least 102 updates on the hot shard, got %d", stats[hot].Updates)
+	}
+	if products != 20 {
+		t.Errorf("Expected 20 products across shards, got %d", products)
+	}
+}
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/wal"
+)
+
+// WALProductRepository records every update in a write-ahead log before applying it
+//
+// Updates are serialized so the log order is exactly the apply order, which
+// lets Replay rebuild an identical repository from the log.
+type WALProductRepository struct {
+	mu     sync.Mutex
+	inner  ProductRepository
+	log    *wal.WriteAheadLog
+	logger *log.Logger
+}
+
+// NewWALProductRepository wraps a repository with a write-ahead log stored at path
+func NewWALProductRepository(inner ProductRepository, path string) (*WALProductRepository, error) {
+	writeAheadLog, err := wal.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WALProductRepository{
+		inner:  inner,
+		log:    writeAheadLog,
+		logger: log.New(os.Stdout, "[WAL] ", log.LstdFlags),
+	}, nil
+}
+
+// Unwrap returns the wrapped repository
+func (r *WALProductRepository) Unwrap() ProductRepository {
+	return r.inner
+}
+
+// SelfCheck verifies the log can still be synced to disk, then checks the wrapped repository
+func (r *WALProductRepository) SelfCheck(ctx context.Context) error {
+	if err := r.log.Sync(); err != nil {
+		return err
+	}
+	return selfCheck(ctx, r.inner)
+}
+
+// Get retrieves a product by ID
+func (r *WALProductRepository) Get(id string) (*models.Product, bool) {
+	return r.inner.Get(id)
+}
+
+// GetContext retrieves a product by ID, honouring cancellation of ctx
+func (r *WALProductRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	return getContext(ctx, r.inner, id)
+}
+
+// Update durably logs the update, including its currency, and then applies it
+func (r *WALProductRepository) Update(id string, price float64, stock int, currency string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.log.AppendWithCurrency(id, price, stock, currency); err != nil {
+		// Never apply what isn't logged, otherwise a replay would diverge
+		r.logger.Printf("Failed to log update for product %s, update not applied: %v", id, err)
+		return
+	}
+
+	r.inner.Update(id, price, stock, currency)
+}
+
+// Delete durably logs the removal of an existing product and then removes it
+// from the wrapped repository, returning false if it did not exist
+func (r *WALProductRepository) Delete(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.inner.Get(id); !exists {
+		return false
+	}
+	if _, err := r.log.AppendDelete(id); err != nil {
+		r.logger.Printf("Failed to log delete of product %s, product not deleted: %v", id, err)
+		return false
+	}
+
+	return deleteFrom(r.inner, id)
+}
+
+// SetLockWaitObserver forwards the observer to the wrapped repository if it reports lock waits
+func (r *WALProductRepository) SetLockWaitObserver(observer func(operation string, wait time.Duration)) {
+	if reporter, ok := r.inner.(LockWaitReporter); ok {
+		reporter.SetLockWaitObserver(observer)
+	}
+}
+
+// Replay reconstructs the wrapped repository by applying the log at path in order
+func (r *WALProductRepository) Replay(path string) error {
+	entries, err := wal.ReadAll(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range entries {
+		if entry.Deleted {
+			deleteFrom(r.inner, entry.ProductID)
+			continue
+		}
+		r.inner.Update(entry.ProductID, entry.Price, entry.Stock, entry.Currency)
+	}
+
+	r.logger.Printf("Replayed %d entries from %s", len(entries), path)
+	return nil
+}
+
+// Sequence returns the sequence number of the last logged update
+func (r *WALProductRepository) Sequence() uint64 {
+	return r.log.Sequence()
+}
+
+// Close closes the write-ahead log
+func (r *WALProductRepository) Close() error {
+	return r.log.Close()
+}
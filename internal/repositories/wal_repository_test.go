@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"product-service/pkg/metrics"
+	"product-service/pkg/wal"
+)
+
+func TestWALProductRepository_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.wal")
+
+	original := NewInMemoryProductRepository()
+	repo, err := NewWALProductRepository(original, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Apply a sequence with overwrites so ordering matters
+	for i := 0; i < 50; i++ {
+		repo.Update(fmt.Sprintf("product-%d", i%7), float64(i)+0.99, i*3, "")
+	}
+	if repo.Sequence() != 50 {
+		t.Errorf("Expected sequence 50, got %d", repo.Sequence())
+	}
+	repo.Close()
+
+	// Replay into a fresh repository
+	restored := NewInMemoryProductRepository()
+	replayRepo, err := NewWALProductRepository(restored, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer replayRepo.Close()
+
+	if err := replayRepo.Replay(path); err != nil {
+		t.Fatalf("Expected no error replaying, got %v", err)
+	}
+
+	want, _ := json.Marshal(original.Snapshot())
+	got, _ := json.Marshal(restored.Snapshot())
+	if !bytes.Equal(want, got) {
+		t.Errorf("Expected replayed snapshot to match original\nwant: %s\ngot:  %s", want, got)
+	}
+
+	// Replay must not append to the log
+	if replayRepo.Sequence() != 50 {
+		t.Errorf("Expected sequence to remain 50 after replay, got %d", replayRepo.Sequence())
+	}
+}
+
+func TestWALProductRepository_LogsBeforeApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.wal")
+
+	inner := NewInMemoryProductRepository()
+	repo, _ := NewWALProductRepository(inner, path)
+
+	// Once the log can't be written, updates must not reach the repository
+	repo.Close()
+	repo.Update("unlogged", 1.0, 1, "")
+
+	if _, exists := inner.Get("unlogged"); exists {
+		t.Error("Expected update to be skipped when it could not be logged")
+	}
+}
+
+func TestWALProductRepository_ReplayMissingFile(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, _ := NewWALProductRepository(NewInMemoryProductRepository(), filepath.Join(dir, "products.wal"))
+	defer repo.Close()
+
+	if err := repo.Replay(filepath.Join(dir, "missing.wal")); err != nil {
+		t.Errorf("Expected no error replaying a missing log, got %v", err)
+	}
+}
+
+func TestWALProductRepository_ReplayCurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.wal")
+
+	repo, err := NewWALProductRepository(NewInMemoryProductRepository(), path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	repo.Update("fx-product", 12.5, 3, "EUR")
+	repo.Close()
+
+	restored := NewInMemoryProductRepository()
+	replayRepo, err := NewWALProductRepository(restored, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer replayRepo.Close()
+	replayRepo.Replay(path)
+
+	product, exists := restored.Get("fx-product")
+	if !exists || product.Currency != "EUR" {
+		t.Errorf("Expected replayed product with currency EUR, got %+v", product)
+	}
+}
+
+func TestWALProductRepository_SelfCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.wal")
+
+	repo, _ := NewWALProductRepository(NewInMemoryProductRepository(), path)
+	cached := NewCachingProductRepository(repo, 10, 0)
+
+	if err := cached.SelfCheck(context.Background()); err != nil {
+		t.Errorf("Expected an open log to pass, got %v", err)
+	}
+
+	repo.Close()
+	if err := cached.SelfCheck(context.Background()); !errors.Is(err, wal.ErrClosed) {
+		t.Errorf("Expected wal.ErrClosed through the cache layer, got %v", err)
+	}
+}
+
+func TestWALProductRepository_Delete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.wal")
+
+	repo, err := NewWALProductRepository(NewInMemoryProductRepository(), path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Delete through the full stack, as the consistency audit does
+	cached := NewCachingProductRepository(repo, 10, 0)
+	stack := NewInstrumentedProductRepository(cached, metrics.NewRegistry())
+	stack.Update("kept", 1.0, 1, "")
+	stack.Update("evicted", 2.0, 2, "")
+	stack.Get("evicted")
+
+	if !stack.Delete("evicted") {
+		t.Fatal("Expected delete to succeed")
+	}
+	if stack.Delete("missing") {
+		t.Error("Expected deleting a missing product to fail")
+	}
+	if _, exists := stack.Get("evicted"); exists {
+		t.Error("Expected deleted product not to be served from the cache")
+	}
+	if repo.Sequence() != 3 {
+		t.Errorf("Expected the delete to be logged as sequence 3, got %d", repo.Sequence())
+	}
+	repo.Close()
+
+	entries, _ := wal.ReadAll(path)
+	if last := entries[len(entries)-1]; !last.Deleted || last.ProductID != "evicted" {
+		t.Errorf("Expected a delete record for evicted, got %+v", last)
+	}
+
+	// Replaying must not resurrect the deleted product
+	restored := NewInMemoryProductRepository()
+	replayRepo, _ := NewWALProductRepository(restored, path)
+	defer replayRepo.Close()
+	if err := replayRepo.Replay(path); err != nil {
+		t.Fatalf("Expected no error replaying, got %v", err)
+	}
+	if _, exists := restored.Get("evicted"); exists {
+		t.Error("Expected deleted product to stay deleted after replay")
+	}
+	if _, exists := restored.Get("kept"); !exists {
+		t.Error("Expected kept product to be replayed")
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"product-service/internal/models"
+)
+
+// DropReasonAdmission is recorded when a low-priority event is refused by the admission policy
+const DropReasonAdmission = "admission"
+
+// ErrNotAdmitted is matched by errors for events refused by the admission policy
+var ErrNotAdmitted = errors.New("event not admitted while the queue is busy")
+
+// AdmissionError is returned for a low-priority event arriving while the queue depth is at or above the watermark
+type AdmissionError struct {
+	Priority  string
+	Depth     int
+	Watermark int
+}
+
+// Error implements the error interface
+func (e *AdmissionError) Error() string {
+	return fmt.Sprintf("%s-priority events are not admitted while the queue depth (%d) is at or above %d", e.Priority, e.Depth, e.Watermark)
+}
+
+// Is reports whether the target is ErrNotAdmitted so callers can match the sentinel
+func (e *AdmissionError) Is(target error) bool {
+	return target == ErrNotAdmitted
+}
+
+// AdmissionPolicy turns away low-priority events while the queue is busy
+//
+// Once the queue depth reaches the watermark, low-priority events are refused
+// so producers of bulk updates back off, while normal and high-priority events
+// are still admitted for as long as the queue has room.
+type AdmissionPolicy struct {
+	lowWatermark int
+}
+
+// NewAdmissionPolicy creates a policy refusing low-priority events at or above lowWatermark queued events
+func NewAdmissionPolicy(lowWatermark int) *AdmissionPolicy {
+	return &AdmissionPolicy{lowWatermark: lowWatermark}
+}
+
+// Admit decides whether an event may be enqueued at the current queue depth,
+// returning an *AdmissionError if it may not
+func (p *AdmissionPolicy) Admit(event models.ProductEvent, depth int) error {
+	if event.Priority == models.PriorityLow && depth >= p.lowWatermark {
+		return &AdmissionError{Priority: event.Priority, Depth: depth, Watermark: p.lowWatermark}
+	}
+	return nil
+}
+
+// LowWatermark returns the queue depth at which low-priority events are refused
+func (p *AdmissionPolicy) LowWatermark() int {
+	return p.lowWatermark
+}
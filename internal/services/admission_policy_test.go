@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+)
+
+func TestAdmissionPolicy_Admit(t *testing.T) {
+	policy := NewAdmissionPolicy(5)
+
+	tests := []struct {
+		priority string
+		depth    int
+		admitted bool
+	}{
+		{models.PriorityLow, 0, true},
+		{models.PriorityLow, 4, true},
+		{models.PriorityLow, 5, false},
+		{models.PriorityLow, 50, false},
+		{"", 5, true},
+		{models.PriorityNormal, 50, true},
+		{models.PriorityHigh, 0, true},
+		{models.PriorityHigh, 50, true},
+	}
+
+	for _, tt := range tests {
+		err := policy.Admit(models.ProductEvent{ProductID: "admitted", Priority: tt.priority}, tt.depth)
+		if admitted := err == nil; admitted != tt.admitted {
+			t.Errorf("Expected admitted=%v for priority %q at depth %d, got error %v", tt.admitted, tt.priority, tt.depth, err)
+		}
+		if err != nil && !errors.Is(err, ErrNotAdmitted) {
+			t.Errorf("Expected ErrNotAdmitted, got %v", err)
+		}
+	}
+}
+
+func TestProductService_AdmissionPolicy(t *testing.T) {
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+	service.SetAdmissionPolicy(NewAdmissionPolicy(3))
+
+	// The service is not started, so every admitted event stays queued
+	for i := 0; i < 3; i++ {
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: "low", Price: 1.0, Stock: i, Priority: models.PriorityLow}); err != nil {
+			t.Fatalf("Expected low-priority event %d to be admitted below the watermark, got %v", i, err)
+		}
+	}
+
+	var admissionErr *AdmissionError
+	err := service.ProcessEvent(models.ProductEvent{ProductID: "low", Price: 1.0, Stock: 3, Priority: models.PriorityLow})
+	if !errors.As(err, &admissionErr) || admissionErr.Depth != 3 || admissionErr.Watermark != 3 {
+		t.Fatalf("Expected an admission error at depth 3, got %v", err)
+	}
+
+	if err := service.ProcessEvent(models.ProductEvent{ProductID: "high", Price: 1.0, Stock: 1, Priority: models.PriorityHigh}); err != nil {
+		t.Errorf("Expected a high-priority event to be admitted, got %v", err)
+	}
+	if depth := eventQueue.Len(); depth != 4 {
+		t.Errorf("Expected 4 queued events, got %d", depth)
+	}
+
+	dropped := service.Metrics().Counter(metricDroppedTotal, "", metrics.Labels{"reason": DropReasonAdmission}).Value()
+	if dropped != 1 {
+		t.Errorf("Expected 1 admission drop, got %v", dropped)
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// AuditState is the state of a product recorded in the audit log
+type AuditState struct {
+	Price    float64 `json:"price"`
+	Stock    int     `json:"stock"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// AuditEntry records one applied mutation: who made it, what changed and when
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	ProductID string    `json:"product_id"`
+	RequestID string    `json:"request_id,omitempty"`
+	// Before is nil when the mutation created the product
+	Before *AuditState `json:"before"`
+	After  AuditState  `json:"after"`
+}
+
+// AuditLogger appends a JSON line to a writer for every mutation applied to the repository
+//
+// The before state is read just ahead of each write, so concurrent updates to
+// the same product by different workers may each report the same before state.
+type AuditLogger struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+// NewAuditLogger creates an audit logger writing entries to w
+func NewAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{
+		encoder: json.NewEncoder(w),
+		now:     time.Now,
+		logger:  log.New(os.Stdout, "[AUDIT] ", log.LstdFlags),
+	}
+}
+
+// OpenAuditLog creates an audit logger appending to the file at path, creating it if needed
+func OpenAuditLog(path string) (*AuditLogger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	logger := NewAuditLogger(file)
+	logger.closer = file
+	return logger, nil
+}
+
+// Record appends an entry for event having been applied over before, which is nil for a new product
+func (a *AuditLogger) Record(event models.ProductEvent, before *models.Product) {
+	entry := AuditEntry{
+		ProductID: event.ProductID,
+		RequestID: event.RequestID,
+		After:     AuditState{Price: event.Price, Stock: event.Stock, Currency: event.Currency},
+	}
+	if before != nil {
+		entry.Before = &AuditState{Price: before.Price, Stock: before.Stock, Currency: before.Currency}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry.Timestamp = a.now().UTC()
+	if err := a.encoder.Encode(entry); err != nil {
+		a.logger.Printf("WARNING: failed to record mutation of product %s: %v", event.ProductID, err)
+	}
+}
+
+// Close closes the audit log file, if the logger opened one
+func (a *AuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
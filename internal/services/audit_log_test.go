@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// readAuditEntries decodes every JSON line written to the audit log
+func readAuditEntries(t *testing.T, data []byte) []AuditEntry {
+	t.Helper()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected a JSON audit entry, got %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestProductService_AuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	auditLogger := NewAuditLogger(&buf)
+	fixed := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	auditLogger.now = func() time.Time { return fixed }
+
+	repo := NewMockProductRepository()
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+	service.SetAuditLogger(auditLogger)
+	service.Start()
+
+	service.ProcessEvent(models.ProductEvent{ProductID: "audited", Price: 10.0, Stock: 5, RequestID: "req-1"})
+	time.Sleep(50 * time.Millisecond)
+	service.ProcessEvent(models.ProductEvent{ProductID: "audited", Price: 12.5, Stock: 3, RequestID: "req-2"})
+	service.Stop()
+
+	entries := readAuditEntries(t, buf.Bytes())
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+
+	created := entries[0]
+	if created.ProductID != "audited" || created.RequestID != "req-1" || !created.Timestamp.Equal(fixed) {
+		t.Errorf("Expected metadata for req-1, got %+v", created)
+	}
+	if created.Before != nil {
+		t.Errorf("Expected no before state for a new product, got %+v", created.Before)
+	}
+	if created.After.Price != 10.0 || created.After.Stock != 5 {
+		t.Errorf("Expected after state 10.00/5, got %+v", created.After)
+	}
+
+	updated := entries[1]
+	if updated.RequestID != "req-2" {
+		t.Errorf("Expected request ID req-2, got %q", updated.RequestID)
+	}
+	if updated.Before == nil || updated.Before.Price != 10.0 || updated.Before.Stock != 5 {
+		t.Errorf("Expected before state 10.00/5, got %+v", updated.Before)
+	}
+	if updated.After.Price != 12.5 || updated.After.Stock != 3 {
+		t.Errorf("Expected after state 12.50/3, got %+v", updated.After)
+	}
+}
+
+func TestProductService_AuditLog_BatchMode(t *testing.T) {
+	var buf bytes.Buffer
+	repo := NewMockProductRepository()
+	repo.Update("batched", 1.0, 1, "")
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+	service.SetAuditLogger(NewAuditLogger(&buf))
+
+	service.ApplyBatch([]models.ProductEvent{
+		{ProductID: "batched", Price: 2.0, Stock: 2},
+		{ProductID: "batched", Price: 3.0, Stock: 3},
+	})
+
+	entries := readAuditEntries(t, buf.Bytes())
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+	if entries[0].Before == nil || entries[0].Before.Price != 1.0 {
+		t.Errorf("Expected the first write to overwrite the stored state, got %+v", entries[0].Before)
+	}
+	if entries[1].Before == nil || entries[1].Before.Price != 2.0 {
+		t.Errorf("Expected the second write to overwrite the first, got %+v", entries[1].Before)
+	}
+}
+
+func TestOpenAuditLog_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		auditLogger, err := OpenAuditLog(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		auditLogger.Record(models.ProductEvent{ProductID: "appended", Price: 1.0, Stock: i}, nil)
+		auditLogger.Close()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries := readAuditEntries(t, data); len(entries) != 2 {
+		t.Errorf("Expected reopening the log to append, got %d entries", len(entries))
+	}
+}
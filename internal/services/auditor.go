@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+)
+
+// Kinds of anomaly the consistency audit looks for
+const (
+	AnomalyNegativeStock = "negative_stock"
+	AnomalyInvalidPrice  = "invalid_price"
+	AnomalyStale         = "stale"
+)
+
+// metricAuditAnomalies counts anomalies found by the consistency audit
+const metricAuditAnomalies = "audit_anomalies_total"
+
+// AuditableRepository is a repository the consistency audit can scan
+type AuditableRepository interface {
+	Range(fn func(product models.Product, updatedAt time.Time) bool)
+}
+
+// ProductEvicter removes products found by the consistency audit
+type ProductEvicter interface {
+	Delete(id string) bool
+}
+
+// Anomaly describes a stored product that fails a consistency check
+type Anomaly struct {
+	ProductID string
+	Kind      string
+}
+
+// ConsistencyAuditor periodically scans the repository for corrupt or stale products
+//
+// Anomalies are always logged and counted; with eviction enabled the
+// offending products are also removed so they stop being served.
+type ConsistencyAuditor struct {
+	repository AuditableRepository
+	evicter    ProductEvicter
+	interval   time.Duration
+	staleAfter time.Duration
+	evict      bool
+	metrics    *metrics.Registry
+	now        func() time.Time
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	logger     *log.Logger
+	limiter    *ProductLimiter
+}
+
+// NewConsistencyAuditor creates an auditor scanning repo every interval. Products not
+// updated for staleAfter are reported as stale; a zero staleAfter disables that check.
+// Evictions go through evicter, normally the top of the repository stack so
+// caches and the write-ahead log see them too; it may be nil unless evict is set.
+func NewConsistencyAuditor(repo AuditableRepository, evicter ProductEvicter, interval, staleAfter time.Duration, evict bool, registry *metrics.Registry) *ConsistencyAuditor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConsistencyAuditor{
+		repository: repo,
+		evicter:    evicter,
+		interval:   interval,
+		staleAfter: staleAfter,
+		evict:      evict,
+		metrics:    registry,
+		now:        time.Now,
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     log.New(os.Stdout, "[AUDIT] ", log.LstdFlags),
+	}
+}
+
+// SetProductLimiter sets the product limit recounted after products are evicted
+func (a *ConsistencyAuditor) SetProductLimiter(limiter *ProductLimiter) {
+	a.limiter = limiter
+}
+
+// Start starts the periodic audit
+func (a *ConsistencyAuditor) Start() {
+	a.wg.Add(1)
+	go a.run()
+	a.logger.Printf("Started consistency audit every %v", a.interval)
+}
+
+// Stop stops the periodic audit
+func (a *ConsistencyAuditor) Stop() {
+	a.cancel()
+	a.wg.Wait()
+}
+
+// run audits the repository on every interval until stopped
+func (a *ConsistencyAuditor) run() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.Audit()
+		}
+	}
+}
+
+// Audit scans the repository once, reporting and optionally evicting every anomaly found
+func (a *ConsistencyAuditor) Audit() []Anomaly {
+	now := a.now()
+
+	var anomalies []Anomaly
+	a.repository.Range(func(product models.Product, updatedAt time.Time) bool {
+		if kind, ok := a.check(product, updatedAt, now); ok {
+			anomalies = append(anomalies, Anomaly{ProductID: product.ID, Kind: kind})
+		}
+		return true
+	})
+
+	evicted := 0
+	for _, anomaly := range anomalies {
+		a.metrics.Counter(metricAuditAnomalies, "Products failing the consistency audit, by anomaly",
+			metrics.Labels{"anomaly": anomaly.Kind}).Inc()
+
+		if a.evict && a.evicter.Delete(anomaly.ProductID) {
+			a.logger.Printf("Evicted product %s: %s", anomaly.ProductID, anomaly.Kind)
+			evicted++
+			continue
+		}
+		a.logger.Printf("Found anomaly in product %s: %s", anomaly.ProductID, anomaly.Kind)
+	}
+	if evicted > 0 && a.limiter != nil {
+		a.limiter.Recount()
+	}
+	return anomalies
+}
+
+// check returns the first anomaly a product exhibits, if any
+func (a *ConsistencyAuditor) check(product models.Product, updatedAt, now time.Time) (string, bool) {
+	switch {
+	case math.IsNaN(product.Price) || math.IsInf(product.Price, 0):
+		return AnomalyInvalidPrice, true
+	case product.Stock < 0:
+		return AnomalyNegativeStock, true
+	case a.staleAfter > 0 && !updatedAt.IsZero() && now.Sub(updatedAt) > a.staleAfter:
+		return AnomalyStale, true
+	default:
+		return "", false
+	}
+}
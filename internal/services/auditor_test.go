@@ -0,0 +1,114 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+)
+
+// auditRecord is a product stored in a fakeAuditableRepository
+type auditRecord struct {
+	product   models.Product
+	updatedAt time.Time
+}
+
+// fakeAuditableRepository holds seeded records, including ones the real repository would never produce
+type fakeAuditableRepository struct {
+	records map[string]auditRecord
+}
+
+func (r *fakeAuditableRepository) Range(fn func(product models.Product, updatedAt time.Time) bool) {
+	for _, record := range r.records {
+		if !fn(record.product, record.updatedAt) {
+			return
+		}
+	}
+}
+
+func (r *fakeAuditableRepository) Delete(id string) bool {
+	if _, exists := r.records[id]; !exists {
+		return false
+	}
+	delete(r.records, id)
+	return true
+}
+
+func seededAuditRepository(now time.Time) *fakeAuditableRepository {
+	return &fakeAuditableRepository{records: map[string]auditRecord{
+		"healthy":        {models.Product{ID: "healthy", Price: 1.0, Stock: 1}, now},
+		"negative-stock": {models.Product{ID: "negative-stock", Price: 1.0, Stock: -5}, now},
+		"nan-price":      {models.Product{ID: "nan-price", Price: math.NaN(), Stock: 1}, now},
+		"stale":          {models.Product{ID: "stale", Price: 1.0, Stock: 1}, now.Add(-2 * time.Hour)},
+	}}
+}
+
+func TestConsistencyAuditor_DetectsAnomalies(t *testing.T) {
+	now := time.Now()
+	repo := seededAuditRepository(now)
+	registry := metrics.NewRegistry()
+
+	auditor := NewConsistencyAuditor(repo, repo, time.Minute, time.Hour, false, registry)
+	auditor.now = func() time.Time { return now }
+
+	found := make(map[string]string)
+	for _, anomaly := range auditor.Audit() {
+		found[anomaly.ProductID] = anomaly.Kind
+	}
+
+	expected := map[string]string{
+		"negative-stock": AnomalyNegativeStock,
+		"nan-price":      AnomalyInvalidPrice,
+		"stale":          AnomalyStale,
+	}
+	if len(found) != len(expected) {
+		t.Errorf("Expected %d anomalies, got %v", len(expected), found)
+	}
+	for id, kind := range expected {
+		if found[id] != kind {
+			t.Errorf("Expected %s to be reported as %s, got '%s'", id, kind, found[id])
+		}
+	}
+
+	for _, kind := range []string{AnomalyNegativeStock, AnomalyInvalidPrice, AnomalyStale} {
+		count := registry.Counter(metricAuditAnomalies, "", metrics.Labels{"anomaly": kind}).Value()
+		if count != 1 {
+			t.Errorf("Expected %s counter 1, got %d", kind, count)
+		}
+	}
+
+	// Without eviction every record is left in place
+	if len(repo.records) != 4 {
+		t.Errorf("Expected no records evicted, got %d remaining", len(repo.records))
+	}
+}
+
+func TestConsistencyAuditor_Evict(t *testing.T) {
+	now := time.Now()
+	repo := seededAuditRepository(now)
+
+	auditor := NewConsistencyAuditor(repo, repo, time.Minute, time.Hour, true, metrics.NewRegistry())
+	auditor.now = func() time.Time { return now }
+	auditor.Audit()
+
+	if len(repo.records) != 1 {
+		t.Errorf("Expected only the healthy record to remain, got %d records", len(repo.records))
+	}
+	if _, exists := repo.records["healthy"]; !exists {
+		t.Error("Expected healthy record to survive the audit")
+	}
+}
+
+func TestConsistencyAuditor_StaleCheckDisabled(t *testing.T) {
+	now := time.Now()
+	repo := &fakeAuditableRepository{records: map[string]auditRecord{
+		"old": {models.Product{ID: "old", Price: 1.0, Stock: 1}, now.Add(-24 * time.Hour)},
+	}}
+
+	auditor := NewConsistencyAuditor(repo, repo, time.Minute, 0, false, metrics.NewRegistry())
+	if anomalies := auditor.Audit(); len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies with the stale check disabled, got %v", anomalies)
+	}
+}
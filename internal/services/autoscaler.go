@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/pkg/queue"
+)
+
+// WorkerAutoscaler resizes a worker pool based on queue depth
+//
+// Workers are removed one at a time toward minWorkers once the queue has been
+// empty for idleTimeout, and added one at a time toward maxWorkers while the
+// backlog exceeds the number of running workers.
+type WorkerAutoscaler struct {
+	pool          *WorkerPool
+	queue         queue.EventQueue
+	minWorkers    int
+	maxWorkers    int
+	idleTimeout   time.Duration
+	checkInterval time.Duration
+	idleSince     time.Time
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	logger        *log.Logger
+}
+
+// DefaultAutoscaleInterval replaces a non-positive check interval, which time.NewTicker rejects
+const DefaultAutoscaleInterval = time.Second
+
+// NewWorkerAutoscaler creates a new worker autoscaler
+func NewWorkerAutoscaler(pool *WorkerPool, eventQueue queue.EventQueue, minWorkers, maxWorkers int, idleTimeout, checkInterval time.Duration) *WorkerAutoscaler {
+	if minWorkers < 1 {
+		minWorkers = 1
+	}
+	if maxWorkers < minWorkers {
+		maxWorkers = minWorkers
+	}
+	if checkInterval <= 0 {
+		checkInterval = DefaultAutoscaleInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WorkerAutoscaler{
+		pool:          pool,
+		queue:         eventQueue,
+		minWorkers:    minWorkers,
+		maxWorkers:    maxWorkers,
+		idleTimeout:   idleTimeout,
+		checkInterval: checkInterval,
+		ctx:           ctx,
+		cancel:        cancel,
+		logger:        log.New(os.Stdout, "[AUTOSCALER] ", log.LstdFlags),
+	}
+}
+
+// Start starts the autoscaler control loop
+func (as *WorkerAutoscaler) Start() {
+	as.wg.Add(1)
+	go as.run()
+	as.logger.Printf("Started autoscaler with %d-%d workers, idle timeout %v",
+		as.minWorkers, as.maxWorkers, as.idleTimeout)
+}
+
+// Stop stops the autoscaler control loop
+func (as *WorkerAutoscaler) Stop() {
+	as.cancel()
+	as.wg.Wait()
+}
+
+// run evaluates the queue on every check interval until stopped
+func (as *WorkerAutoscaler) run() {
+	defer as.wg.Done()
+
+	ticker := time.NewTicker(as.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-as.ctx.Done():
+			return
+		case now := <-ticker.C:
+			as.evaluate(now)
+		}
+	}
+}
+
+// evaluate makes a single scaling decision based on the current queue depth
+func (as *WorkerAutoscaler) evaluate(now time.Time) {
+	depth := as.queue.Len()
+	workers := as.pool.WorkerCount()
+
+	if depth == 0 {
+		if as.idleSince.IsZero() {
+			as.idleSince = now
+			return
+		}
+		if now.Sub(as.idleSince) >= as.idleTimeout && workers > as.minWorkers {
+			as.logger.Printf("Queue idle for %v, scaling down to %d workers", now.Sub(as.idleSince), workers-1)
+			as.pool.Resize(workers - 1)
+			// Require another full idle period before removing the next worker
+			as.idleSince = now
+		}
+		return
+	}
+
+	as.idleSince = time.Time{}
+	if depth > workers && workers < as.maxWorkers {
+		as.logger.Printf("Queue depth %d exceeds %d workers, scaling up", depth, workers)
+		as.pool.Resize(workers + 1)
+	}
+}
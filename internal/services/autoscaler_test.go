@@ -0,0 +1,123 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestWorkerPool_Resize(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 2)
+
+	service.Start()
+	defer service.Stop()
+
+	pool := service.WorkerPool()
+	if pool.WorkerCount() != 2 {
+		t.Errorf("Expected 2 workers, got %d", pool.WorkerCount())
+	}
+
+	pool.Resize(5)
+	if pool.WorkerCount() != 5 {
+		t.Errorf("Expected 5 workers after scaling up, got %d", pool.WorkerCount())
+	}
+
+	pool.Resize(1)
+	if pool.WorkerCount() != 1 {
+		t.Errorf("Expected 1 worker after scaling down, got %d", pool.WorkerCount())
+	}
+
+	// The remaining worker still processes events
+	if err := service.ProcessEvent(models.ProductEvent{ProductID: "resize-test", Price: 5.0, Stock: 2}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if _, exists := service.GetProduct("resize-test"); !exists {
+		t.Error("Expected product to be processed after resize")
+	}
+}
+
+func TestWorkerAutoscaler_ScaleDownWhenIdle(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 4)
+
+	service.Start()
+	defer service.Stop()
+
+	autoscaler := NewWorkerAutoscaler(service.WorkerPool(), eventQueue, 1, 4, 30*time.Millisecond, 5*time.Millisecond)
+	autoscaler.Start()
+	defer autoscaler.Stop()
+
+	// Simulate a quiet period long enough to step down to the minimum
+	deadline := time.Now().Add(1 * time.Second)
+	for time.Now().Before(deadline) && service.WorkerPool().WorkerCount() > 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if count := service.WorkerPool().WorkerCount(); count != 1 {
+		t.Errorf("Expected workers to scale down to 1, got %d", count)
+	}
+}
+
+func TestWorkerAutoscaler_ScaleUpOnBurst(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(500)
+	service := NewProductService(repo, eventQueue, 1)
+
+	service.Start()
+	defer service.Stop()
+
+	autoscaler := NewWorkerAutoscaler(service.WorkerPool(), eventQueue, 1, 4, 1*time.Second, 5*time.Millisecond)
+	autoscaler.Start()
+	defer autoscaler.Stop()
+
+	// Simulate a burst that outpaces a single worker
+	for i := 0; i < 200; i++ {
+		eventQueue.Enqueue(models.ProductEvent{ProductID: "burst", Price: float64(i), Stock: i})
+	}
+
+	deadline := time.Now().Add(1 * time.Second)
+	for time.Now().Before(deadline) && service.WorkerPool().WorkerCount() < 4 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if count := service.WorkerPool().WorkerCount(); count != 4 {
+		t.Errorf("Expected workers to scale up to 4, got %d", count)
+	}
+}
+
+func TestWorkerAutoscaler_Bounds(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 2)
+
+	autoscaler := NewWorkerAutoscaler(service.WorkerPool(), eventQueue, 0, 0, time.Second, time.Second)
+
+	if autoscaler.minWorkers != 1 {
+		t.Errorf("Expected minimum clamped to 1, got %d", autoscaler.minWorkers)
+	}
+	if autoscaler.maxWorkers != 1 {
+		t.Errorf("Expected maximum clamped to minimum, got %d", autoscaler.maxWorkers)
+	}
+}
+
+func TestWorkerAutoscaler_NonPositiveInterval(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 2)
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		autoscaler := NewWorkerAutoscaler(service.WorkerPool(), eventQueue, 1, 2, time.Second, interval)
+		if autoscaler.checkInterval != DefaultAutoscaleInterval {
+			t.Errorf("Expected interval %v to fall back to %v, got %v", interval, DefaultAutoscaleInterval, autoscaler.checkInterval)
+		}
+		// Would panic in time.NewTicker without the fallback
+		autoscaler.Start()
+		autoscaler.Stop()
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/pkg/metrics"
+	"product-service/pkg/queue"
+)
+
+// metricBacklogAlert is 1 while the slow-consumer alert is firing and 0 otherwise
+const metricBacklogAlert = "backlog_alert"
+
+// BacklogMonitor raises an alert when workers fall behind the incoming events
+//
+// The alert fires once the queue depth has stayed above threshold, or has
+// grown on every sample, for at least sustain. It clears as soon as neither
+// condition holds. A non-positive threshold disables the depth condition.
+type BacklogMonitor struct {
+	mu            sync.Mutex
+	queue         queue.EventQueue
+	threshold     int
+	sustain       time.Duration
+	checkInterval time.Duration
+	aboveSince    time.Time
+	growingSince  time.Time
+	lastDepth     int
+	alerting      bool
+	metrics       *metrics.Registry
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	logger        *log.Logger
+}
+
+// DefaultBacklogCheckInterval replaces a non-positive check interval, which time.NewTicker rejects
+const DefaultBacklogCheckInterval = time.Second
+
+// NewBacklogMonitor creates a monitor sampling the queue depth every checkInterval
+func NewBacklogMonitor(eventQueue queue.EventQueue, threshold int, sustain, checkInterval time.Duration, registry *metrics.Registry) *BacklogMonitor {
+	if checkInterval <= 0 {
+		checkInterval = DefaultBacklogCheckInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	monitor := &BacklogMonitor{
+		queue:         eventQueue,
+		threshold:     threshold,
+		sustain:       sustain,
+		checkInterval: checkInterval,
+		metrics:       registry,
+		ctx:           ctx,
+		cancel:        cancel,
+		logger:        log.New(os.Stdout, "[BACKLOG] ", log.LstdFlags),
+	}
+	monitor.gauge().Set(0)
+	return monitor
+}
+
+// Start starts sampling the queue depth
+func (bm *BacklogMonitor) Start() {
+	bm.wg.Add(1)
+	go bm.run()
+	bm.logger.Printf("Started backlog monitor with threshold %d sustained for %v", bm.threshold, bm.sustain)
+}
+
+// Stop stops sampling the queue depth
+func (bm *BacklogMonitor) Stop() {
+	bm.cancel()
+	bm.wg.Wait()
+}
+
+// Alerting reports whether the slow-consumer alert is currently firing
+func (bm *BacklogMonitor) Alerting() bool {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	return bm.alerting
+}
+
+// run samples the queue on every check interval until stopped
+func (bm *BacklogMonitor) run() {
+	defer bm.wg.Done()
+
+	ticker := time.NewTicker(bm.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-bm.ctx.Done():
+			return
+		case now := <-ticker.C:
+			bm.evaluate(now)
+		}
+	}
+}
+
+// evaluate takes a single depth sample and updates the alert state
+func (bm *BacklogMonitor) evaluate(now time.Time) {
+	depth := bm.queue.Len()
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if bm.threshold > 0 && depth > bm.threshold {
+		if bm.aboveSince.IsZero() {
+			bm.aboveSince = now
+		}
+	} else {
+		bm.aboveSince = time.Time{}
+	}
+
+	if depth > bm.lastDepth {
+		if bm.growingSince.IsZero() {
+			bm.growingSince = now
+		}
+	} else {
+		bm.growingSince = time.Time{}
+	}
+	bm.lastDepth = depth
+
+	sustainedAbove := !bm.aboveSince.IsZero() && now.Sub(bm.aboveSince) >= bm.sustain
+	sustainedGrowth := !bm.growingSince.IsZero() && now.Sub(bm.growingSince) >= bm.sustain
+	firing := sustainedAbove || sustainedGrowth
+
+	switch {
+	case firing && !bm.alerting:
+		bm.logger.Printf("WARNING: consumers falling behind, queue depth %d of capacity %d", depth, bm.queue.Cap())
+		bm.gauge().Set(1)
+	case !firing && bm.alerting:
+		bm.logger.Printf("Backlog recovered, queue depth %d", depth)
+		bm.gauge().Set(0)
+	}
+	bm.alerting = firing
+}
+
+// gauge returns the alert gauge
+func (bm *BacklogMonitor) gauge() *metrics.Gauge {
+	return bm.metrics.Gauge(metricBacklogAlert, "Whether the slow-consumer alert is firing", nil)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+)
+
+// fillQueue enqueues n events into the mock queue
+func fillQueue(q *MockEventQueue, n int) {
+	for i := 0; i < n; i++ {
+		q.Enqueue(models.ProductEvent{ProductID: "backlog", Price: 1.0, Stock: i})
+	}
+}
+
+// drainQueue dequeues n events from the mock queue
+func drainQueue(q *MockEventQueue, n int) {
+	for i := 0; i < n; i++ {
+		q.Dequeue()
+	}
+}
+
+func TestBacklogMonitor_SustainedDepth(t *testing.T) {
+	eventQueue := NewMockEventQueue(100)
+	registry := metrics.NewRegistry()
+	monitor := NewBacklogMonitor(eventQueue, 10, 30*time.Second, time.Second, registry)
+	gauge := registry.Gauge(metricBacklogAlert, "", nil)
+
+	fillQueue(eventQueue, 20)
+	start := time.Now()
+
+	monitor.evaluate(start)
+	monitor.evaluate(start.Add(10 * time.Second))
+	if monitor.Alerting() {
+		t.Error("Expected no alert before the backlog is sustained")
+	}
+
+	monitor.evaluate(start.Add(30 * time.Second))
+	if !monitor.Alerting() {
+		t.Error("Expected alert once depth stayed above threshold for the sustain period")
+	}
+	if gauge.Value() != 1 {
+		t.Errorf("Expected backlog_alert gauge 1, got %f", gauge.Value())
+	}
+
+	drainQueue(eventQueue, 15)
+	monitor.evaluate(start.Add(31 * time.Second))
+	if monitor.Alerting() {
+		t.Error("Expected alert to clear once depth recovered")
+	}
+	if gauge.Value() != 0 {
+		t.Errorf("Expected backlog_alert gauge 0, got %f", gauge.Value())
+	}
+}
+
+func TestBacklogMonitor_SustainedGrowth(t *testing.T) {
+	eventQueue := NewMockEventQueue(100)
+	// The threshold is never reached, so only growth can fire the alert
+	monitor := NewBacklogMonitor(eventQueue, 0, 3*time.Second, time.Second, metrics.NewRegistry())
+
+	start := time.Now()
+	for i := 0; i <= 3; i++ {
+		fillQueue(eventQueue, 2)
+		monitor.evaluate(start.Add(time.Duration(i) * time.Second))
+	}
+	if !monitor.Alerting() {
+		t.Error("Expected alert after depth grew on every sample for the sustain period")
+	}
+
+	// A sample without growth clears it
+	monitor.evaluate(start.Add(4 * time.Second))
+	if monitor.Alerting() {
+		t.Error("Expected alert to clear once depth stopped growing")
+	}
+}
+
+func TestBacklogMonitor_NonPositiveInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		monitor := NewBacklogMonitor(NewMockEventQueue(10), 0, time.Second, interval, metrics.NewRegistry())
+		if monitor.checkInterval != DefaultBacklogCheckInterval {
+			t.Errorf("Expected interval %v to fall back to %v, got %v", interval, DefaultBacklogCheckInterval, monitor.checkInterval)
+		}
+		// Would panic in time.NewTicker without the fallback
+		monitor.Start()
+		monitor.Stop()
+	}
+}
+
+func TestProductService_CheckHealth_Backlog(t *testing.T) {
+	eventQueue := NewMockEventQueue(100)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+	monitor := NewBacklogMonitor(eventQueue, 5, time.Second, time.Second, metrics.NewRegistry())
+	service.SetBacklogMonitor(monitor)
+
+	fillQueue(eventQueue, 10)
+	start := time.Now()
+	monitor.evaluate(start)
+	monitor.evaluate(start.Add(time.Second))
+
+	if status := service.CheckHealth().Checks[HealthCheckBacklog].Status; status != models.HealthStatusDegraded {
+		t.Errorf("Expected backlog check degraded while alerting, got %s", status)
+	}
+}
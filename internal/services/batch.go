@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"product-service/internal/models"
+)
+
+// BatchConflictPolicy controls how a batch containing the same product more than once is handled
+type BatchConflictPolicy string
+
+const (
+	// BatchConflictLastWins coalesces duplicates, keeping the last event for each product
+	BatchConflictLastWins BatchConflictPolicy = "last_wins"
+	// BatchConflictReject rejects the whole batch if any product appears more than once
+	BatchConflictReject BatchConflictPolicy = "reject"
+)
+
+// ParseBatchConflictPolicy parses a batch conflict policy from its configuration value
+func ParseBatchConflictPolicy(value string) (BatchConflictPolicy, error) {
+	switch BatchConflictPolicy(value) {
+	case BatchConflictLastWins, BatchConflictReject:
+		return BatchConflictPolicy(value), nil
+	default:
+		return "", fmt.Errorf("unknown batch conflict policy %q, expected %q or %q", value, BatchConflictLastWins, BatchConflictReject)
+	}
+}
+
+// BatchConflictError is returned when a batch is rejected for containing duplicate products
+type BatchConflictError struct {
+	ProductIDs []string
+}
+
+// Error implements the error interface
+func (e *BatchConflictError) Error() string {
+	return fmt.Sprintf("duplicate product IDs in batch: %s", strings.Join(e.ProductIDs, ", "))
+}
+
+// findDuplicates returns each product ID appearing more than once, in first-seen order
+func findDuplicates(events []models.ProductEvent) []string {
+	counts := make(map[string]int, len(events))
+	var duplicates []string
+	for _, event := range events {
+		counts[event.ProductID]++
+		if counts[event.ProductID] == 2 {
+			duplicates = append(duplicates, event.ProductID)
+		}
+	}
+	return duplicates
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"product-service/internal/models"
+	"product-service/internal/repositories"
+	"product-service/pkg/queue"
+	"product-service/pkg/retry"
+)
+
+func duplicateBatch() []models.ProductEvent {
+	return []models.ProductEvent{
+		{ProductID: "a", Price: 1.0, Stock: 1},
+		{ProductID: "b", Price: 2.0, Stock: 2},
+		{ProductID: "a", Price: 3.0, Stock: 3},
+	}
+}
+
+func TestParseBatchConflictPolicy(t *testing.T) {
+	for _, value := range []string{"last_wins", "reject"} {
+		if policy, err := ParseBatchConflictPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("Expected policy %s, got %s (err %v)", value, policy, err)
+		}
+	}
+
+	if _, err := ParseBatchConflictPolicy("first_wins"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestProductService_EnqueueBatch_LastWins(t *testing.T) {
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+
+	outcomes, err := service.EnqueueBatch(duplicateBatch())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !outcomes[0].Superseded || outcomes[1].Superseded || outcomes[2].Superseded {
+		t.Errorf("Expected only the first event for a to be superseded, got %+v", outcomes)
+	}
+	if eventQueue.Len() != 2 {
+		t.Errorf("Expected 2 events enqueued, got %d", eventQueue.Len())
+	}
+
+	// The kept events preserve order, and the duplicate is the last one sent
+	first, _ := eventQueue.Dequeue()
+	second, _ := eventQueue.Dequeue()
+	if first.ProductID != "b" {
+		t.Errorf("Expected first event for b, got %s", first.ProductID)
+	}
+	if second.ProductID != "a" || second.Price != 3.0 {
+		t.Errorf("Expected last event for a with price 3.0, got %s with price %.2f", second.ProductID, second.Price)
+	}
+}
+
+func TestProductService_EnqueueBatch_Reject(t *testing.T) {
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+	service.SetBatchConflictPolicy(BatchConflictReject)
+
+	outcomes, err := service.EnqueueBatch(duplicateBatch())
+
+	var conflictErr *BatchConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected BatchConflictError, got %v", err)
+	}
+	if len(conflictErr.ProductIDs) != 1 || conflictErr.ProductIDs[0] != "a" {
+		t.Errorf("Expected duplicate IDs [a], got %v", conflictErr.ProductIDs)
+	}
+	if outcomes != nil || eventQueue.Len() != 0 {
+		t.Errorf("Expected nothing enqueued, got outcomes %+v and queue length %d", outcomes, eventQueue.Len())
+	}
+}
+
+func TestProductService_EnqueueBatch_Upsert(t *testing.T) {
+	repo := NewMockProductRepository()
+	repo.Update("existing", 1.0, 1, "")
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+
+	outcomes, err := service.EnqueueBatch([]models.ProductEvent{
+		{ProductID: "new", Price: 2.0, Stock: 2},
+		{ProductID: "existing", Price: 3.0, Stock: 3},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{models.UpsertCreated, models.UpsertUpdated}
+	if len(outcomes) != len(expected) {
+		t.Fatalf("Expected %d outcomes, got %+v", len(expected), outcomes)
+	}
+	for i := range expected {
+		if outcomes[i].Err != nil || outcomes[i].Status != expected[i] {
+			t.Errorf("Expected outcome %d to be %s, got %+v", i, expected[i], outcomes[i])
+		}
+	}
+}
+
+func TestProductService_ApplyBatch_UsesBatchUpdate(t *testing.T) {
+	repo := repositories.NewInMemoryProductRepository()
+	repo.Update("existing", 1.0, 1, "")
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+
+	if err := service.ApplyBatch([]models.ProductEvent{
+		{ProductID: "new", Price: 2.0, Stock: 2, Currency: "EUR"},
+		{ProductID: "existing", Price: 3.0, Stock: 3},
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if product, exists := repo.Get("new"); !exists || product.Currency != "EUR" {
+		t.Errorf("Expected new product with currency EUR, got %+v", product)
+	}
+	if product, _ := repo.Get("existing"); product.Price != 3.0 {
+		t.Errorf("Expected existing product updated to 3.0, got %.2f", product.Price)
+	}
+	if service.WorkerPool().Stats().Processed != 2 {
+		t.Errorf("Expected 2 processed events, got %d", service.WorkerPool().Stats().Processed)
+	}
+}
+
+func TestProductService_EnqueueBatch(t *testing.T) {
+	eventQueue := NewMockEventQueue(2)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+	service.SetEnqueueRetry(&retry.RetryConfig{MaxAttempts: 1, Multiplier: 1})
+
+	outcomes, err := service.EnqueueBatch([]models.ProductEvent{
+		{ProductID: "a", Price: 1.0, Stock: 1},
+		{ProductID: "b", Price: 2.0, Stock: 2},
+		{ProductID: "a", Price: 3.0, Stock: 3},
+		{ProductID: "c", Price: 4.0, Stock: 4},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(outcomes) != 4 {
+		t.Fatalf("Expected 4 outcomes, got %d", len(outcomes))
+	}
+	if !outcomes[0].Superseded {
+		t.Errorf("Expected the first event for a to be superseded, got %+v", outcomes[0])
+	}
+	for _, i := range []int{1, 2} {
+		if outcomes[i].Err != nil || outcomes[i].Status != models.UpsertCreated {
+			t.Errorf("Expected outcome %d to be created, got %+v", i, outcomes[i])
+		}
+	}
+	if !errors.Is(outcomes[3].Err, queue.ErrQueueFull) {
+		t.Errorf("Expected the last event to find the queue full, got %v", outcomes[3].Err)
+	}
+}
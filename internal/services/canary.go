@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// CanaryProductID is the reserved product the canary writes its synthetic events to
+const CanaryProductID = "__canary__"
+
+// Metric names recorded by the canary
+const (
+	metricCanaryRoundTrip = "canary_round_trip_seconds"
+	metricCanaryFailures  = "canary_failures_total"
+)
+
+// canaryPollInterval is how often a probe checks whether its event has been applied
+const canaryPollInterval = 10 * time.Millisecond
+
+// canaryBuckets cover round trips from a few milliseconds up to a stalled pipeline
+var canaryBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Canary periodically sends a synthetic event through the whole pipeline and
+// waits for it to be applied, so breakage shows up before real traffic notices
+//
+// Each probe enqueues an event for CanaryProductID carrying a fresh sequence
+// number as its stock, then polls the repository until that stock appears or
+// the timeout passes.
+type Canary struct {
+	mu          sync.Mutex
+	service     *ProductService
+	interval    time.Duration
+	timeout     time.Duration
+	sequence    int
+	lastSuccess time.Time
+	lastErr     error
+	lastRTT     time.Duration
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	logger      *log.Logger
+}
+
+// DefaultCanaryInterval replaces a non-positive probe interval, which time.NewTicker rejects
+const DefaultCanaryInterval = 30 * time.Second
+
+// NewCanary creates a canary probing service every interval, failing probes not applied within timeout
+func NewCanary(service *ProductService, interval, timeout time.Duration) *Canary {
+	if interval <= 0 {
+		interval = DefaultCanaryInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Canary{
+		service:  service,
+		interval: interval,
+		timeout:  timeout,
+		ctx:      ctx,
+		cancel:   cancel,
+		logger:   log.New(os.Stdout, "[CANARY] ", log.LstdFlags),
+	}
+}
+
+// Start starts probing the pipeline
+func (c *Canary) Start() {
+	c.wg.Add(1)
+	go c.run()
+	c.logger.Printf("Started canary every %v with timeout %v", c.interval, c.timeout)
+}
+
+// Stop stops probing the pipeline, abandoning a probe in progress
+func (c *Canary) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// run probes on every interval until stopped
+func (c *Canary) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.Probe()
+		}
+	}
+}
+
+// Probe sends one synthetic event and waits for it to be applied, returning the round-trip time
+func (c *Canary) Probe() (time.Duration, error) {
+	c.mu.Lock()
+	c.sequence++
+	sequence := c.sequence
+	c.mu.Unlock()
+
+	start := time.Now()
+	rtt, err := c.roundTrip(sequence, start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if c.lastErr == nil {
+			c.logger.Printf("WARNING: canary probe %d failed: %v", sequence, err)
+		}
+		c.lastErr = err
+		c.service.metrics.Counter(metricCanaryFailures, "Canary probes that were not applied in time", nil).Inc()
+		return rtt, err
+	}
+	if c.lastErr != nil {
+		c.logger.Printf("Canary recovered, probe %d applied in %v", sequence, rtt)
+	}
+	c.lastErr = nil
+	c.lastSuccess = start.Add(rtt)
+	c.lastRTT = rtt
+	c.service.metrics.Histogram(metricCanaryRoundTrip, "Time for a canary event to be applied after enqueueing",
+		canaryBuckets, nil).Observe(rtt.Seconds())
+	return rtt, nil
+}
+
+// roundTrip enqueues the probe event and polls until it is applied or the timeout passes
+func (c *Canary) roundTrip(sequence int, start time.Time) (time.Duration, error) {
+	event := models.ProductEvent{ProductID: CanaryProductID, Stock: sequence}
+	if _, err := c.service.ProcessEventWithTicket(event); err != nil {
+		return time.Since(start), fmt.Errorf("enqueue failed: %w", err)
+	}
+
+	deadline := time.NewTimer(c.timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(canaryPollInterval)
+	defer poll.Stop()
+
+	for {
+		if product, exists := c.service.repository.Get(CanaryProductID); exists && product.Stock >= sequence {
+			return time.Since(start), nil
+		}
+		select {
+		case <-poll.C:
+		case <-deadline.C:
+			return time.Since(start), fmt.Errorf("not applied within %v", c.timeout)
+		case <-c.ctx.Done():
+			return time.Since(start), c.ctx.Err()
+		}
+	}
+}
+
+// Status reports whether the last probe completed, with a human readable detail
+func (c *Canary) Status() (bool, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.lastErr != nil:
+		return false, fmt.Sprintf("last probe failed: %v", c.lastErr)
+	case c.lastSuccess.IsZero():
+		return true, "no probe completed yet"
+	default:
+		return true, fmt.Sprintf("last round trip %v at %s", c.lastRTT, c.lastSuccess.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestCanary_Probe(t *testing.T) {
+	t.Run("CompletesUnderNormalOperation", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		service.Start()
+		defer service.Stop()
+
+		canary := NewCanary(service, time.Hour, time.Second)
+		service.SetCanary(canary)
+
+		for i := 0; i < 2; i++ {
+			rtt, err := canary.Probe()
+			if err != nil {
+				t.Fatalf("Expected probe %d to complete, got %v", i, err)
+			}
+			if rtt <= 0 || rtt >= time.Second {
+				t.Errorf("Expected a round trip within the timeout, got %v", rtt)
+			}
+		}
+
+		if check := service.CheckHealth().Checks[HealthCheckCanary]; check.Status != models.HealthStatusHealthy {
+			t.Errorf("Expected canary check healthy, got %s (%s)", check.Status, check.Details)
+		}
+		if count := service.Metrics().Histogram(metricCanaryRoundTrip, "", canaryBuckets, nil).Count(); count != 2 {
+			t.Errorf("Expected 2 round trips recorded, got %d", count)
+		}
+	})
+
+	t.Run("DegradesHealthWhenStalled", func(t *testing.T) {
+		// Without started workers nothing is ever applied
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		canary := NewCanary(service, time.Hour, 50*time.Millisecond)
+		service.SetCanary(canary)
+
+		if _, err := canary.Probe(); err == nil {
+			t.Fatal("Expected the probe to time out")
+		}
+
+		check := service.CheckHealth().Checks[HealthCheckCanary]
+		if check.Status != models.HealthStatusDegraded {
+			t.Errorf("Expected canary check degraded, got %s (%s)", check.Status, check.Details)
+		}
+		if failures := service.Metrics().Counter(metricCanaryFailures, "", nil).Value(); failures != 1 {
+			t.Errorf("Expected 1 canary failure, got %d", failures)
+		}
+
+		// Once processing resumes the next probe clears the degradation
+		service.Start()
+		defer service.Stop()
+		if _, err := canary.Probe(); err != nil {
+			t.Fatalf("Expected the probe to complete after workers started, got %v", err)
+		}
+		if check := service.CheckHealth().Checks[HealthCheckCanary]; check.Status != models.HealthStatusHealthy {
+			t.Errorf("Expected canary check healthy again, got %s (%s)", check.Status, check.Details)
+		}
+	})
+}
+
+func TestCanary_NonPositiveInterval(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		canary := NewCanary(service, interval, time.Second)
+		if canary.interval != DefaultCanaryInterval {
+			t.Errorf("Expected interval %v to fall back to %v, got %v", interval, DefaultCanaryInterval, canary.interval)
+		}
+		// Would panic in time.NewTicker without the fallback
+		canary.Start()
+		canary.Stop()
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// ErrChaosInjected is returned for a processing attempt failed on purpose by chaos mode
+var ErrChaosInjected = errors.New("chaos: injected processing failure")
+
+// ChaosInjector deliberately delays and fails processing attempts so retries,
+// the circuit breaker and the dead letter queue can be exercised outside production
+type ChaosInjector struct {
+	failureRate float64
+	latency     time.Duration
+	random      func() float64
+	sleep       func(time.Duration)
+}
+
+// NewChaosInjector creates an injector failing the given fraction of attempts
+// and delaying each attempt by a random duration up to latency
+func NewChaosInjector(failureRate float64, latency time.Duration) (*ChaosInjector, error) {
+	if failureRate < 0 || failureRate > 1 {
+		return nil, fmt.Errorf("chaos failure rate must be between 0 and 1, got %v", failureRate)
+	}
+	if latency < 0 {
+		return nil, fmt.Errorf("chaos latency must not be negative, got %v", latency)
+	}
+	return &ChaosInjector{
+		failureRate: failureRate,
+		latency:     latency,
+		random:      rand.Float64,
+		sleep:       time.Sleep,
+	}, nil
+}
+
+// Inject delays one processing attempt for event and reports whether it should fail
+func (c *ChaosInjector) Inject(event models.ProductEvent) error {
+	if c.latency > 0 {
+		c.sleep(time.Duration(c.random() * float64(c.latency)))
+	}
+	if c.failureRate > 0 && c.random() < c.failureRate {
+		return fmt.Errorf("%w for product %s", ErrChaosInjected, event.ProductID)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/queue"
+)
+
+func newChaosTestService(t *testing.T, failureRate float64) (*ProductService, *MockProductRepository, *queue.DeadLetterQueue) {
+	t.Helper()
+
+	repo := NewMockProductRepository()
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+	service.retryConfig.InitialDelay = time.Millisecond
+	dlq := queue.NewDeadLetterQueue(10)
+	service.SetDeadLetterQueue(dlq)
+
+	chaos, err := NewChaosInjector(failureRate, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service.SetChaos(chaos)
+	return service, repo, dlq
+}
+
+func TestChaosInjector_FullFailureRate(t *testing.T) {
+	service, repo, dlq := newChaosTestService(t, 1)
+	pool := service.WorkerPool()
+
+	pool.processEvent(models.ProductEvent{ProductID: "chaos", Price: 1.0, Stock: 1}, 0)
+
+	if _, exists := repo.Get("chaos"); exists {
+		t.Error("Expected the product not to be stored")
+	}
+	failed := dlq.List()
+	if len(failed) != 1 {
+		t.Fatalf("Expected the event to be dead-lettered, got %d entries", len(failed))
+	}
+	if failed[0].RetryCount != service.retryConfig.MaxAttempts {
+		t.Errorf("Expected %d attempts before dead-lettering, got %d", service.retryConfig.MaxAttempts, failed[0].RetryCount)
+	}
+	if stats := pool.Stats(); stats.Exhausted != 1 || stats.Retries != int64(service.retryConfig.MaxAttempts-1) {
+		t.Errorf("Expected every retry to be used, got %+v", stats)
+	}
+}
+
+func TestChaosInjector_ZeroFailureRate(t *testing.T) {
+	service, repo, dlq := newChaosTestService(t, 0)
+	pool := service.WorkerPool()
+
+	pool.processEvent(models.ProductEvent{ProductID: "calm", Price: 1.0, Stock: 1}, 0)
+
+	if _, exists := repo.Get("calm"); !exists {
+		t.Error("Expected the product to be stored")
+	}
+	if dlq.Len() != 0 {
+		t.Errorf("Expected nothing dead-lettered, got %d", dlq.Len())
+	}
+	if stats := pool.Stats(); stats.Processed != 1 {
+		t.Errorf("Expected 1 processed event, got %d", stats.Processed)
+	}
+}
+
+func TestChaosInjector_Inject(t *testing.T) {
+	t.Run("RejectsInvalidSettings", func(t *testing.T) {
+		if _, err := NewChaosInjector(1.5, 0); err == nil {
+			t.Error("Expected an error for a failure rate above 1")
+		}
+		if _, err := NewChaosInjector(0, -time.Second); err == nil {
+			t.Error("Expected an error for negative latency")
+		}
+	})
+
+	t.Run("DelaysUpToLatency", func(t *testing.T) {
+		chaos, _ := NewChaosInjector(0.5, 100*time.Millisecond)
+		chaos.random = func() float64 { return 0.75 }
+		var slept time.Duration
+		chaos.sleep = func(d time.Duration) { slept = d }
+
+		if err := chaos.Inject(models.ProductEvent{ProductID: "slow"}); err != nil {
+			t.Errorf("Expected no failure above the rate, got %v", err)
+		}
+		if slept != 75*time.Millisecond {
+			t.Errorf("Expected a 75ms delay, got %v", slept)
+		}
+
+		chaos.random = func() float64 { return 0.25 }
+		if err := chaos.Inject(models.ProductEvent{ProductID: "slow"}); !errors.Is(err, ErrChaosInjected) {
+			t.Errorf("Expected ErrChaosInjected below the rate, got %v", err)
+		}
+	})
+}
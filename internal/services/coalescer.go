@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// DropReasonCoalesced is recorded when an event is skipped because a newer event for its product is pending
+const DropReasonCoalesced = "coalesced"
+
+// Coalescer collapses bursts of updates to the same product
+//
+// Workers hold each event until it has been pending for the window, then skip
+// it if a newer event for the same product was enqueued meanwhile. The newest
+// event per product is always applied, in the order it was enqueued, so
+// coalescing only removes writes that would have been overwritten.
+type Coalescer struct {
+	mu       sync.Mutex
+	window   time.Duration
+	sequence uint64
+	latest   map[string]coalescedEvent
+	now      func() time.Time
+}
+
+// coalescedEvent identifies the newest pending event for a product
+type coalescedEvent struct {
+	sequence   uint64
+	enqueuedAt time.Time
+}
+
+// NewCoalescer creates a coalescer holding events for window before they are processed
+func NewCoalescer(window time.Duration) *Coalescer {
+	return &Coalescer{
+		window: window,
+		latest: make(map[string]coalescedEvent),
+		now:    time.Now,
+	}
+}
+
+// Track records an event being enqueued as the newest for its product,
+// assigning it a sequence number unless one was already assigned
+func (c *Coalescer) Track(event *models.ProductEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if event.Sequence == 0 {
+		c.sequence++
+		event.Sequence = c.sequence
+	}
+	c.latest[event.ProductID] = coalescedEvent{sequence: event.Sequence, enqueuedAt: c.now()}
+}
+
+// Untrack forgets an event that was never enqueued
+func (c *Coalescer) Untrack(event models.ProductEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.latest[event.ProductID].sequence == event.Sequence {
+		delete(c.latest, event.ProductID)
+	}
+}
+
+// Superseded waits until a dequeued event has been pending for the window and
+// reports whether a newer event for its product arrived in the meantime. If
+// ctx is done first the event is treated as current, so it is still applied.
+func (c *Coalescer) Superseded(ctx context.Context, event models.ProductEvent) bool {
+	c.mu.Lock()
+	latest, tracked := c.latest[event.ProductID]
+	c.mu.Unlock()
+	if !tracked || latest.sequence < event.Sequence {
+		return false
+	}
+	if latest.sequence > event.Sequence {
+		return true
+	}
+
+	if wait := c.window - c.now().Sub(latest.enqueuedAt); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	latest = c.latest[event.ProductID]
+	if latest.sequence > event.Sequence {
+		return true
+	}
+	delete(c.latest, event.ProductID)
+	return false
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+)
+
+func TestCoalescer_Superseded(t *testing.T) {
+	coalescer := NewCoalescer(20 * time.Millisecond)
+
+	first := models.ProductEvent{ProductID: "burst", Price: 1.0}
+	second := models.ProductEvent{ProductID: "burst", Price: 2.0}
+	other := models.ProductEvent{ProductID: "other", Price: 3.0}
+	coalescer.Track(&first)
+	coalescer.Track(&second)
+	coalescer.Track(&other)
+
+	if !coalescer.Superseded(context.Background(), first) {
+		t.Error("Expected the older event to be superseded")
+	}
+
+	start := time.Now()
+	if coalescer.Superseded(context.Background(), second) {
+		t.Error("Expected the newest event not to be superseded")
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected the newest event to be held for the window, took %v", elapsed)
+	}
+
+	t.Run("CancelledWaitApplies", func(t *testing.T) {
+		coalescer := NewCoalescer(time.Hour)
+		event := models.ProductEvent{ProductID: "stopping"}
+		coalescer.Track(&event)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if coalescer.Superseded(ctx, event) {
+			t.Error("Expected an event held when the worker stops to be applied")
+		}
+	})
+
+	t.Run("Untracked", func(t *testing.T) {
+		event := models.ProductEvent{ProductID: "never-tracked", Sequence: 99}
+		if coalescer.Superseded(context.Background(), event) {
+			t.Error("Expected an untracked event not to be superseded")
+		}
+	})
+}
+
+func TestProductService_Coalescing(t *testing.T) {
+	repo := &orderRecordingRepository{MockProductRepository: NewMockProductRepository()}
+	service := NewProductService(repo, NewMockEventQueue(100), 2)
+	service.SetCoalescer(NewCoalescer(50 * time.Millisecond))
+	service.Start()
+	defer service.Stop()
+
+	const updates = 20
+	for i := 1; i <= updates; i++ {
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: "hot", Price: float64(i), Stock: i}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	product, exists := repo.Get("hot")
+	if !exists {
+		t.Fatal("Expected the product to be applied")
+	}
+	if product.Stock != updates || product.Price != updates {
+		t.Errorf("Expected the final update to win, got price %v stock %d", product.Price, product.Stock)
+	}
+
+	repo.orderMu.Lock()
+	writes := len(repo.order)
+	repo.orderMu.Unlock()
+	if writes >= updates {
+		t.Errorf("Expected fewer than %d writes, got %d", updates, writes)
+	}
+
+	coalesced := service.Metrics().Counter(metricDroppedTotal, "", metrics.Labels{"reason": DropReasonCoalesced}).Value()
+	if int(coalesced)+writes != updates {
+		t.Errorf("Expected every update to be applied or coalesced, got %d writes and %v coalesced", writes, coalesced)
+	}
+}
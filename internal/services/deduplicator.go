@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/wal"
+)
+
+// ErrDuplicate is returned for an event identical to the last one accepted for its product
+var ErrDuplicate = errors.New("duplicate of the last accepted event for this product")
+
+// Deduplicator collapses repeats of a product's last accepted event arriving within a short window
+//
+// Each product remembers only the hash of its last accepted event, covering
+// price, stock and currency. An event is a duplicate only if it matches that
+// hash, so it would leave the product exactly as the accepted one does; an
+// update back to an earlier state, as in A, B, A, is always applied.
+type Deduplicator struct {
+	mu        sync.Mutex
+	window    time.Duration
+	last      map[string]acceptedEvent
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// acceptedEvent is the content hash of a product's last accepted event and when it was accepted
+type acceptedEvent struct {
+	hash uint64
+	at   time.Time
+}
+
+// NewDeduplicator creates a deduplicator skipping repeats within window
+func NewDeduplicator(window time.Duration) *Deduplicator {
+	return &Deduplicator{
+		window: window,
+		last:   make(map[string]acceptedEvent),
+		now:    time.Now,
+	}
+}
+
+// Reserve records an event as its product's last accepted one and reports
+// whether it is new; false means it repeats the last event accepted for the
+// product within the window
+func (d *Deduplicator) Reserve(event models.ProductEvent) bool {
+	hash := contentHash(event)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.sweepLocked(now)
+
+	if last, ok := d.last[event.ProductID]; ok && last.hash == hash && now.Sub(last.at) < d.window {
+		return false
+	}
+	d.last[event.ProductID] = acceptedEvent{hash: hash, at: now}
+	return true
+}
+
+// Release forgets a reserved event that was never enqueued, or was dropped or
+// dead-lettered without being applied, so a retry is not treated as a duplicate. The product then has no last event until the next
+// one is accepted, so its next event is never skipped.
+func (d *Deduplicator) Release(event models.ProductEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.last[event.ProductID]; ok && last.hash == contentHash(event) {
+		delete(d.last, event.ProductID)
+	}
+}
+
+// RestoreFromLog records the last update of each product in the write-ahead
+// log at path applied within the window, so dedup survives a restart. Returns how many
+// entries were restored; a log that does not exist yet restores none.
+func (d *Deduplicator) RestoreFromLog(path string) (int, error) {
+	entries, err := wal.ReadSince(path, d.now().Add(-d.window))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore deduplication window: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	restored := 0
+	for _, entry := range entries {
+		if entry.ProductID == CanaryProductID {
+			continue
+		}
+		last, ok := d.last[entry.ProductID]
+		if ok && entry.Time.Before(last.at) {
+			restored++
+			continue
+		}
+		// An update re-creating a deleted product is not a duplicate of its last state
+		if entry.Deleted {
+			delete(d.last, entry.ProductID)
+			continue
+		}
+		event := models.ProductEvent{ProductID: entry.ProductID, Price: entry.Price, Stock: entry.Stock, Currency: entry.Currency}
+		d.last[entry.ProductID] = acceptedEvent{hash: contentHash(event), at: entry.Time}
+		restored++
+	}
+	return restored, nil
+}
+
+// sweepLocked drops expired entries at most once per window; the caller must hold d.mu
+func (d *Deduplicator) sweepLocked(now time.Time) {
+	if now.Sub(d.lastSweep) < d.window {
+		return
+	}
+	for id, last := range d.last {
+		if now.Sub(last.at) >= d.window {
+			delete(d.last, id)
+		}
+	}
+	d.lastSweep = now
+}
+
+// contentHash hashes the fields that make two events identical
+func contentHash(event models.ProductEvent) uint64 {
+	return event.Hash()
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/internal/repositories"
+	"product-service/pkg/queue"
+)
+
+func TestDeduplicator_Window(t *testing.T) {
+	dedup := NewDeduplicator(time.Second)
+	now := time.Now()
+	dedup.now = func() time.Time { return now }
+
+	event := models.ProductEvent{ProductID: "dedup", Price: 9.99, Stock: 3}
+
+	if !dedup.Reserve(event) {
+		t.Error("Expected first event to be new")
+	}
+
+	t.Run("IdenticalWithinWindow", func(t *testing.T) {
+		now = now.Add(500 * time.Millisecond)
+		if dedup.Reserve(event) {
+			t.Error("Expected identical event within the window to be a duplicate")
+		}
+	})
+
+	t.Run("DifferentContent", func(t *testing.T) {
+		changed := event
+		changed.Stock = 4
+		if !dedup.Reserve(changed) {
+			t.Error("Expected event with different stock to be new")
+		}
+	})
+
+	t.Run("IdenticalOutsideWindow", func(t *testing.T) {
+		now = now.Add(2 * time.Second)
+		if !dedup.Reserve(event) {
+			t.Error("Expected identical event outside the window to be new")
+		}
+	})
+
+	t.Run("ReleasedEventIsNew", func(t *testing.T) {
+		released := models.ProductEvent{ProductID: "released", Price: 1.0, Stock: 1}
+		dedup.Reserve(released)
+		dedup.Release(released)
+		if !dedup.Reserve(released) {
+			t.Error("Expected released event to be accepted again")
+		}
+	})
+}
+
+func TestDeduplicator_RevertIsApplied(t *testing.T) {
+	dedup := NewDeduplicator(time.Minute)
+	a := models.ProductEvent{ProductID: "revert", Price: 1.0, Stock: 1}
+	b := models.ProductEvent{ProductID: "revert", Price: 2.0, Stock: 2}
+
+	// A, B, A must leave the product in state A, so the final A is not a duplicate
+	for i, event := range []models.ProductEvent{a, b, a} {
+		if !dedup.Reserve(event) {
+			t.Errorf("Expected event %d to be new", i+1)
+		}
+	}
+	if dedup.Reserve(a) {
+		t.Error("Expected a repeat of the last accepted event to be a duplicate")
+	}
+
+	// Other products have their own last event
+	if !dedup.Reserve(models.ProductEvent{ProductID: "other", Price: 1.0, Stock: 1}) {
+		t.Error("Expected an identical state for another product to be new")
+	}
+}
+
+func TestProductService_Deduplication(t *testing.T) {
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+	service.SetDeduplicator(NewDeduplicator(time.Minute))
+
+	event := models.ProductEvent{ProductID: "dedup", Price: 9.99, Stock: 3}
+	if err := service.ProcessEvent(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := service.ProcessEvent(event); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("Expected ErrDuplicate, got %v", err)
+		}
+	}
+
+	if eventQueue.Len() != 1 {
+		t.Errorf("Expected 1 event enqueued, got %d", eventQueue.Len())
+	}
+	if count := droppedCount(service.Metrics(), DropReasonDuplicate); count != 2 {
+		t.Errorf("Expected 2 duplicate drops, got %d", count)
+	}
+}
+
+func TestProductService_DeduplicationReleasedOnEnqueueFailure(t *testing.T) {
+	eventQueue := NewMockEventQueue(1)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+	service.retryConfig.InitialDelay = time.Millisecond
+	service.SetDeduplicator(NewDeduplicator(time.Minute))
+
+	service.ProcessEvent(models.ProductEvent{ProductID: "filler", Price: 1.0, Stock: 1})
+
+	event := models.ProductEvent{ProductID: "retry", Price: 2.0, Stock: 2}
+	if err := service.ProcessEvent(event); err == nil {
+		t.Fatal("Expected queue full error")
+	}
+
+	eventQueue.Dequeue()
+	if err := service.ProcessEvent(event); err != nil {
+		t.Errorf("Expected retried event to be accepted, got %v", err)
+	}
+	if eventQueue.Len() != 1 {
+		t.Errorf("Expected retried event to be enqueued, got queue length %d", eventQueue.Len())
+	}
+}
+
+func TestProductService_DeduplicationReleasedOnDeadLetter(t *testing.T) {
+	// waitDeadLettered waits for the dead letter queue to hold n events
+	waitDeadLettered := func(t *testing.T, service *ProductService, n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for service.DeadLetterQueue().Len() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d dead-lettered events, got %d", n, service.DeadLetterQueue().Len())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	t.Run("ProcessingFailure", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		service.SetDeduplicator(NewDeduplicator(time.Minute))
+		service.SetEventTransformers(func(event models.ProductEvent) (models.ProductEvent, error) {
+			return event, errors.New("transform failed")
+		})
+		service.Start()
+		defer service.Stop()
+
+		event := models.ProductEvent{ProductID: "fails", Price: 2.0, Stock: 2}
+		if err := service.ProcessEvent(event); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		waitDeadLettered(t, service, 1)
+
+		if err := service.ProcessEvent(event); err != nil {
+			t.Errorf("Expected the dead-lettered event to be accepted again, got %v", err)
+		}
+	})
+
+	t.Run("FailedBatch", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		service.SetDeduplicator(NewDeduplicator(time.Minute))
+		// The transform changes the ID the event is deduplicated by
+		service.SetEventTransformers(LowercaseProductID())
+		bp := queue.NewBatchProcessor(1, time.Hour, 0, 1, func(events []models.ProductEvent) error {
+			return errors.New("batch failed")
+		})
+		defer bp.Stop()
+		service.SetBatchProcessor(bp)
+		service.Start()
+		defer service.Stop()
+
+		event := models.ProductEvent{ProductID: "Batched", Price: 3.0, Stock: 3}
+		if err := service.ProcessEvent(event); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		waitDeadLettered(t, service, 1)
+
+		if failed := service.DeadLetterQueue().List()[0]; failed.Event.ProductID != "Batched" {
+			t.Errorf("Expected the event dead-lettered as accepted, got product %s", failed.Event.ProductID)
+		}
+		if err := service.ProcessEvent(event); err != nil {
+			t.Errorf("Expected the dead-lettered event to be accepted again, got %v", err)
+		}
+	})
+}
+
+func TestDeduplicator_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.wal")
+	event := models.ProductEvent{ProductID: "restart", Price: 9.99, Stock: 3}
+
+	// startService creates a service over a WAL-backed repository, restoring
+	// its deduplication window from the log as startup does
+	startService := func(window time.Duration) (*ProductService, *repositories.WALProductRepository) {
+		repo, err := repositories.NewWALProductRepository(repositories.NewInMemoryProductRepository(), path)
+		if err != nil {
+			t.Fatalf("Failed to open WAL repository: %v", err)
+		}
+		dedup := NewDeduplicator(window)
+		if _, err := dedup.RestoreFromLog(path); err != nil {
+			t.Fatalf("Expected no restore error, got %v", err)
+		}
+		service := NewProductService(repo, NewMockEventQueue(10), 1)
+		service.SetDeduplicator(dedup)
+		return service, repo
+	}
+
+	service, repo := startService(time.Minute)
+	service.Start()
+	if err := service.ProcessEvent(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for service.WorkerPool().Stats().Processed < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	service.Stop()
+	repo.Close()
+
+	t.Run("DuplicateAfterRestart", func(t *testing.T) {
+		restarted, repo := startService(time.Minute)
+		defer repo.Close()
+
+		if err := restarted.ProcessEvent(event); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("Expected ErrDuplicate, got %v", err)
+		}
+		if restarted.Queue().Len() != 0 {
+			t.Errorf("Expected re-submitted event to be deduplicated, got queue length %d", restarted.Queue().Len())
+		}
+		if count := droppedCount(restarted.Metrics(), DropReasonDuplicate); count != 1 {
+			t.Errorf("Expected 1 duplicate drop, got %d", count)
+		}
+
+		changed := event
+		changed.Stock = 4
+		restarted.ProcessEvent(changed)
+		if restarted.Queue().Len() != 1 {
+			t.Errorf("Expected a different update to be accepted, got queue length %d", restarted.Queue().Len())
+		}
+	})
+
+	t.Run("OutsideWindow", func(t *testing.T) {
+		dedup := NewDeduplicator(time.Minute)
+		dedup.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		restored, err := dedup.RestoreFromLog(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if restored != 0 {
+			t.Errorf("Expected no entries restored outside the window, got %d", restored)
+		}
+		if !dedup.Reserve(event) {
+			t.Error("Expected event applied before the window to be new")
+		}
+	})
+
+	t.Run("MissingLog", func(t *testing.T) {
+		restored, err := NewDeduplicator(time.Minute).RestoreFromLog(filepath.Join(t.TempDir(), "missing.wal"))
+		if err != nil || restored != 0 {
+			t.Errorf("Expected a missing log to restore nothing, got %d, %v", restored, err)
+		}
+	})
+}
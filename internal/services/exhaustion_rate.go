@@ -0,0 +1,61 @@
+package services
+
+import "sync"
+
+// metricRetryExhaustionRate reports the share of recent events that exhausted their retries
+const metricRetryExhaustionRate = "retry_exhaustion_rate"
+
+// ExhaustionWindowSize is how many of the latest processing outcomes the exhaustion rate covers
+const ExhaustionWindowSize = 100
+
+// exhaustionWindow tracks whether each of the latest processing outcomes
+// exhausted its retries. Counting outcomes rather than time keeps the rate
+// meaningful at any throughput, and successes push old exhaustions out.
+type exhaustionWindow struct {
+	mu        sync.Mutex
+	outcomes  []bool
+	next      int
+	samples   int
+	exhausted int
+}
+
+// newExhaustionWindow creates a window over the latest size outcomes
+func newExhaustionWindow(size int) *exhaustionWindow {
+	return &exhaustionWindow{outcomes: make([]bool, size)}
+}
+
+// record adds n outcomes, all exhausted or all applied
+func (w *exhaustionWindow) record(exhausted bool, n int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Outcomes beyond the window size would only overwrite each other
+	if n > int64(len(w.outcomes)) {
+		n = int64(len(w.outcomes))
+	}
+	for ; n > 0; n-- {
+		if w.samples == len(w.outcomes) {
+			if w.outcomes[w.next] {
+				w.exhausted--
+			}
+		} else {
+			w.samples++
+		}
+		w.outcomes[w.next] = exhausted
+		if exhausted {
+			w.exhausted++
+		}
+		w.next = (w.next + 1) % len(w.outcomes)
+	}
+}
+
+// rate returns the exhausted share of the outcomes in the window, 0 with none yet
+func (w *exhaustionWindow) rate() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.samples == 0 {
+		return 0
+	}
+	return float64(w.exhausted) / float64(w.samples)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"product-service/internal/models"
+)
+
+func TestExhaustionWindow(t *testing.T) {
+	window := newExhaustionWindow(4)
+	if rate := window.rate(); rate != 0 {
+		t.Errorf("Expected rate 0 with no outcomes, got %v", rate)
+	}
+
+	window.record(true, 1)
+	window.record(false, 1)
+	if rate := window.rate(); rate != 0.5 {
+		t.Errorf("Expected rate 0.5, got %v", rate)
+	}
+
+	// Newer outcomes push the oldest out of the window
+	window.record(true, 2)
+	window.record(false, 1)
+	if rate := window.rate(); rate != 0.5 {
+		t.Errorf("Expected rate 0.5 after the oldest exhaustion rolled out, got %v", rate)
+	}
+	window.record(false, 10)
+	if rate := window.rate(); rate != 0 {
+		t.Errorf("Expected rate 0 after a run of successes, got %v", rate)
+	}
+}
+
+func TestProductService_RetryExhaustionHealth(t *testing.T) {
+	service, _, _ := newChaosTestService(t, 1)
+	service.retryConfig.MaxAttempts = 1
+	service.CircuitBreakers().SetThreshold(1000)
+	service.SetRetryExhaustionThreshold(0.5)
+	pool := service.WorkerPool()
+
+	check := func() models.HealthCheck {
+		t.Helper()
+		status, details := service.checkRetryExhaustion()
+		return models.HealthCheck{Status: status, Details: details}
+	}
+	if result := check(); result.Status != models.HealthStatusHealthy {
+		t.Errorf("Expected healthy before any events, got %s (%s)", result.Status, result.Details)
+	}
+
+	for i := 0; i < 5; i++ {
+		pool.processEvent(models.ProductEvent{ProductID: fmt.Sprintf("fail-%d", i), Price: 1.0, Stock: 1}, 0)
+	}
+	if rate := service.RetryExhaustionRate(); rate != 1 {
+		t.Errorf("Expected every event to exhaust its retries, got rate %v", rate)
+	}
+	if result := check(); result.Status != models.HealthStatusDegraded {
+		t.Errorf("Expected degraded after repeated exhaustions, got %s (%s)", result.Status, result.Details)
+	}
+	if gauge := service.Metrics().Gauge(metricRetryExhaustionRate, "", nil).Value(); gauge != 1 {
+		t.Errorf("Expected the gauge to report 1, got %v", gauge)
+	}
+
+	// Once the downstream recovers, successes push the exhaustions out
+	service.SetChaos(nil)
+	for i := 0; i < 10; i++ {
+		pool.processEvent(models.ProductEvent{ProductID: fmt.Sprintf("ok-%d", i), Price: 1.0, Stock: 1}, 0)
+	}
+	if result := check(); result.Status != models.HealthStatusHealthy {
+		t.Errorf("Expected healthy once processing succeeds again, got %s (%s)", result.Status, result.Details)
+	}
+}
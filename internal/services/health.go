@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/circuitbreaker"
+)
+
+// Names of the subsystem health checks
+const (
+	HealthCheckQueue           = "queue"
+	HealthCheckCircuitBreaker  = "circuit_breaker"
+	HealthCheckWorkers         = "workers"
+	HealthCheckRepository      = "repository"
+	HealthCheckBatchProcessor  = "batch_processor"
+	HealthCheckBacklog         = "backlog"
+	HealthCheckCanary          = "canary"
+	HealthCheckDeadLetter      = "dead_letter_queue"
+	HealthCheckRetryExhaustion = "retry_exhaustion"
+)
+
+// DeadLetterThresholds are the dead letter queue depths at which the service
+// is reported degraded and unhealthy; zero disables a threshold
+type DeadLetterThresholds struct {
+	Degraded  int
+	Unhealthy int
+}
+
+// RepositoryHealthTimeout bounds how long the repository may take to answer a health probe
+const RepositoryHealthTimeout = time.Second
+
+// healthCheckProductID is looked up to probe the repository; it need not exist
+const healthCheckProductID = "__health_check__"
+
+// healthSeverity ranks statuses so the aggregate can take the worst
+var healthSeverity = map[string]int{
+	models.HealthStatusHealthy:   0,
+	models.HealthStatusDegraded:  1,
+	models.HealthStatusUnhealthy: 2,
+}
+
+// healthCheckFunc reports a subsystem status and a human readable detail
+type healthCheckFunc func() (string, string)
+
+// CheckHealth runs every subsystem check concurrently and reports each
+// independently, with the overall status being the worst individual one
+func (s *ProductService) CheckHealth() models.DetailedHealthResponse {
+	checks := map[string]healthCheckFunc{
+		HealthCheckQueue:           s.checkQueue,
+		HealthCheckCircuitBreaker:  s.checkCircuitBreaker,
+		HealthCheckWorkers:         s.checkWorkers,
+		HealthCheckRepository:      s.checkRepository,
+		HealthCheckBatchProcessor:  s.checkBatchProcessor,
+		HealthCheckBacklog:         s.checkBacklog,
+		HealthCheckCanary:          s.checkCanary,
+		HealthCheckDeadLetter:      s.CheckDeadLetter,
+		HealthCheckRetryExhaustion: s.checkRetryExhaustion,
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]models.HealthCheck, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check healthCheckFunc) {
+			defer wg.Done()
+			result := runHealthCheck(check)
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	return models.DetailedHealthResponse{
+		Status: aggregateHealth(results),
+		Checks: results,
+	}
+}
+
+// runHealthCheck times a check, reporting a panicking check as unhealthy
+func runHealthCheck(check healthCheckFunc) (result models.HealthCheck) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			result = models.HealthCheck{Status: models.HealthStatusUnhealthy, Details: fmt.Sprintf("check panicked: %v", r)}
+		}
+		result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	status, details := check()
+	return models.HealthCheck{Status: status, Details: details}
+}
+
+// aggregateHealth returns the worst status among the results
+func aggregateHealth(results map[string]models.HealthCheck) string {
+	worst := models.HealthStatusHealthy
+	for _, result := range results {
+		if healthSeverity[result.Status] > healthSeverity[worst] {
+			worst = result.Status
+		}
+	}
+	return worst
+}
+
+// checkQueue reports the queue depth, degrading once the queue is full
+func (s *ProductService) checkQueue() (string, string) {
+	depth, capacity := s.queue.Len(), s.queue.Cap()
+	details := fmt.Sprintf("depth %d of capacity %d", depth, capacity)
+	if depth >= capacity {
+		return models.HealthStatusDegraded, details
+	}
+	return models.HealthStatusHealthy, details
+}
+
+// checkCircuitBreaker maps the breaker state to a status; a category breaker
+// that is not closed only degrades the service, since other categories still flow
+func (s *ProductService) checkCircuitBreaker() (string, string) {
+	state := s.circuitBreaker.GetState()
+	details := fmt.Sprintf("state %s, %d failures", state, s.circuitBreaker.GetFailureCount())
+
+	group := s.CircuitBreakers()
+	var tripped []string
+	for _, category := range group.Keys() {
+		if group.Get(category).GetState() != circuitbreaker.Closed {
+			tripped = append(tripped, category)
+		}
+	}
+	if len(tripped) > 0 {
+		details += fmt.Sprintf("; categories not closed: %s", strings.Join(tripped, ", "))
+	}
+
+	switch {
+	case state == circuitbreaker.Open:
+		return models.HealthStatusUnhealthy, details
+	case state == circuitbreaker.HalfOpen || len(tripped) > 0:
+		return models.HealthStatusDegraded, details
+	default:
+		return models.HealthStatusHealthy, details
+	}
+}
+
+// checkWorkers compares the running worker goroutines with the configured count
+func (s *ProductService) checkWorkers() (string, string) {
+	alive, expected := s.workerPool.AliveWorkers(), s.workerPool.WorkerCount()
+	details := fmt.Sprintf("%d of %d workers alive", alive, expected)
+	switch {
+	case alive == 0:
+		return models.HealthStatusUnhealthy, details
+	case alive < expected:
+		return models.HealthStatusDegraded, details
+	default:
+		return models.HealthStatusHealthy, details
+	}
+}
+
+// checkRepository probes the repository with a lookup bounded by RepositoryHealthTimeout
+func (s *ProductService) checkRepository() (string, string) {
+	done := make(chan struct{})
+	go func() {
+		s.repository.Get(healthCheckProductID)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return models.HealthStatusHealthy, "responsive"
+	case <-time.After(RepositoryHealthTimeout):
+		return models.HealthStatusUnhealthy, fmt.Sprintf("no response within %v", RepositoryHealthTimeout)
+	}
+}
+
+// checkBatchProcessor reports the events waiting in the batch processor, if one is configured
+func (s *ProductService) checkBatchProcessor() (string, string) {
+	if s.batchProcessor == nil {
+		return models.HealthStatusHealthy, "not configured"
+	}
+	return models.HealthStatusHealthy, fmt.Sprintf("%d pending events, %d batches in flight",
+		s.batchProcessor.GetPendingEvents(), s.batchProcessor.GetInFlightBatches())
+}
+
+// checkBacklog degrades while the slow-consumer alert is firing, if a monitor is configured
+func (s *ProductService) checkBacklog() (string, string) {
+	if s.backlogMonitor == nil {
+		return models.HealthStatusHealthy, "not configured"
+	}
+	if s.backlogMonitor.Alerting() {
+		return models.HealthStatusDegraded, "consumers falling behind"
+	}
+	return models.HealthStatusHealthy, "keeping up"
+}
+
+// checkCanary degrades while the synthetic canary event is not making it through, if a canary is configured
+func (s *ProductService) checkCanary() (string, string) {
+	if s.canary == nil {
+		return models.HealthStatusHealthy, "not configured"
+	}
+	ok, details := s.canary.Status()
+	if !ok {
+		return models.HealthStatusDegraded, details
+	}
+	return models.HealthStatusHealthy, details
+}
+
+// CheckDeadLetter reports the dead letter queue depth, degrading or failing once it
+// reaches the configured thresholds, since a filling queue means events are being lost
+func (s *ProductService) CheckDeadLetter() (string, string) {
+	depth := s.deadLetterQueue.Len()
+	details := fmt.Sprintf("depth %d of capacity %d", depth, s.deadLetterQueue.Cap())
+	switch thresholds := s.dlqThresholds; {
+	case thresholds.Unhealthy > 0 && depth >= thresholds.Unhealthy:
+		return models.HealthStatusUnhealthy, details
+	case thresholds.Degraded > 0 && depth >= thresholds.Degraded:
+		return models.HealthStatusDegraded, details
+	default:
+		return models.HealthStatusHealthy, details
+	}
+}
+
+// checkRetryExhaustion degrades once the share of recent events exhausting their
+// retries rises above the configured threshold, which points at a struggling
+// downstream before the circuit breaker trips
+func (s *ProductService) checkRetryExhaustion() (string, string) {
+	rate := s.RetryExhaustionRate()
+	details := fmt.Sprintf("%.1f%% of the last %d events exhausted their retries", rate*100, ExhaustionWindowSize)
+	if s.exhaustionLimit > 0 && rate > s.exhaustionLimit {
+		return models.HealthStatusDegraded, details
+	}
+	return models.HealthStatusHealthy, details
+}
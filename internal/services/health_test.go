@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestProductService_CheckHealth(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 2)
+	service.Start()
+	defer service.Stop()
+
+	// Give the workers a moment to start
+	time.Sleep(20 * time.Millisecond)
+
+	t.Run("AllChecksReported", func(t *testing.T) {
+		health := service.CheckHealth()
+
+		for _, name := range []string{HealthCheckQueue, HealthCheckCircuitBreaker, HealthCheckWorkers, HealthCheckRepository, HealthCheckBatchProcessor, HealthCheckBacklog, HealthCheckCanary, HealthCheckRetryExhaustion} {
+			check, exists := health.Checks[name]
+			if !exists {
+				t.Errorf("Expected check %s to be reported", name)
+				continue
+			}
+			if check.Status != models.HealthStatusHealthy {
+				t.Errorf("Expected check %s to be healthy, got %s (%s)", name, check.Status, check.Details)
+			}
+		}
+		if health.Status != models.HealthStatusHealthy {
+			t.Errorf("Expected aggregate status healthy, got %s", health.Status)
+		}
+	})
+
+	t.Run("AggregateIsWorstCheck", func(t *testing.T) {
+		service.CircuitBreaker().SetThreshold(1)
+		service.CircuitBreaker().Execute(func() error { return errors.New("dependency down") })
+		defer service.CircuitBreaker().Reset()
+
+		health := service.CheckHealth()
+
+		if health.Checks[HealthCheckCircuitBreaker].Status != models.HealthStatusUnhealthy {
+			t.Errorf("Expected circuit breaker check unhealthy, got %s", health.Checks[HealthCheckCircuitBreaker].Status)
+		}
+		if health.Checks[HealthCheckQueue].Status != models.HealthStatusHealthy {
+			t.Errorf("Expected queue check to stay healthy, got %s", health.Checks[HealthCheckQueue].Status)
+		}
+		if health.Status != models.HealthStatusUnhealthy {
+			t.Errorf("Expected aggregate status unhealthy, got %s", health.Status)
+		}
+	})
+}
+
+func TestAggregateHealth(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		expected string
+	}{
+		{"AllHealthy", []string{models.HealthStatusHealthy, models.HealthStatusHealthy}, models.HealthStatusHealthy},
+		{"OneDegraded", []string{models.HealthStatusHealthy, models.HealthStatusDegraded}, models.HealthStatusDegraded},
+		{"DegradedAndUnhealthy", []string{models.HealthStatusDegraded, models.HealthStatusUnhealthy, models.HealthStatusHealthy}, models.HealthStatusUnhealthy},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := make(map[string]models.HealthCheck)
+			for i, status := range test.statuses {
+				results[string(rune('a'+i))] = models.HealthCheck{Status: status}
+			}
+			if result := aggregateHealth(results); result != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestRunHealthCheck_Panic(t *testing.T) {
+	result := runHealthCheck(func() (string, string) { panic("boom") })
+	if result.Status != models.HealthStatusUnhealthy {
+		t.Errorf("Expected a panicking check to be unhealthy, got %s", result.Status)
+	}
+}
+
+func TestProductService_LastProcessedAndStall(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+	service.SetStallThreshold(time.Second)
+
+	if !service.LastProcessedAt().IsZero() {
+		t.Error("Expected no last processed time before any event")
+	}
+
+	service.Start()
+	before := time.Now()
+	service.ProcessEvent(models.ProductEvent{ProductID: "progress", Price: 1.0, Stock: 1})
+	time.Sleep(50 * time.Millisecond)
+
+	last := service.LastProcessedAt()
+	if last.Before(before) || last.After(time.Now()) {
+		t.Errorf("Expected last processed time after %v, got %v", before, last)
+	}
+	service.Stop()
+
+	// Simulate a stall: events waiting while the last success is long past
+	eventQueue = NewMockEventQueue(10)
+	service = NewProductService(repo, eventQueue, 1)
+	service.SetStallThreshold(time.Second)
+	service.ProcessEvent(models.ProductEvent{ProductID: "stuck", Price: 1.0, Stock: 1})
+
+	atomic.StoreInt64(&service.workerPool.lastProcessed, time.Now().Add(-time.Minute).UnixNano())
+	if !service.Stalled() {
+		t.Error("Expected service to be stalled with queued events and no recent progress")
+	}
+
+	// An empty queue is idle, not stalled
+	eventQueue.Dequeue()
+	if service.Stalled() {
+		t.Error("Expected an empty queue not to count as a stall")
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// metricEventsInFlight reports the number of accepted events not yet done processing
+const metricEventsInFlight = "events_in_flight"
+
+// DropReasonInFlightLimit is recorded when an event is rejected at the in-flight limit
+const DropReasonInFlightLimit = "in_flight_limit"
+
+// ErrInFlightLimitReached is returned for an event arriving while the in-flight limit is reached
+var ErrInFlightLimitReached = errors.New("maximum number of in-flight events reached")
+
+// InFlightLimiter caps the number of events in the system, queued or being
+// processed, independently of the queue capacity
+//
+// A slot is acquired when an event is accepted and released once a worker is
+// done with it, whether it was applied, dead-lettered or shed. In batch mode
+// that is once its batch has been applied or dead-lettered.
+type InFlightLimiter struct {
+	max      int64
+	inFlight int64
+}
+
+// NewInFlightLimiter creates a limiter admitting at most max events at a time
+func NewInFlightLimiter(max int) *InFlightLimiter {
+	return &InFlightLimiter{max: int64(max)}
+}
+
+// Acquire takes a slot for an event, reporting false if the limit is reached
+func (l *InFlightLimiter) Acquire() bool {
+	for {
+		current := atomic.LoadInt64(&l.inFlight)
+		if current >= l.max {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&l.inFlight, current, current+1) {
+			return true
+		}
+	}
+}
+
+// Release frees the slot held by an event that is done processing
+func (l *InFlightLimiter) Release() {
+	atomic.AddInt64(&l.inFlight, -1)
+}
+
+// InFlight returns the number of events currently holding a slot
+func (l *InFlightLimiter) InFlight() int {
+	return int(atomic.LoadInt64(&l.inFlight))
+}
+
+// Max returns the maximum number of in-flight events
+func (l *InFlightLimiter) Max() int {
+	return int(l.max)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/queue"
+)
+
+// gatedProductRepository blocks every update until the gate is closed
+type gatedProductRepository struct {
+	*MockProductRepository
+	gate chan struct{}
+}
+
+func (r *gatedProductRepository) Update(id string, price float64, stock int, currency string) {
+	<-r.gate
+	r.MockProductRepository.Update(id, price, stock, currency)
+}
+
+func TestProductService_InFlightLimit(t *testing.T) {
+	repo := &gatedProductRepository{MockProductRepository: NewMockProductRepository(), gate: make(chan struct{})}
+	service := NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	service.SetInFlightLimiter(NewInFlightLimiter(2))
+	service.Start()
+	defer service.Stop()
+
+	// One event is held by the blocked worker and one waits in the queue
+	for _, id := range []string{"first", "second"} {
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: id, Price: 1.0, Stock: 1}); err != nil {
+			t.Fatalf("Expected %s to be accepted under the cap, got %v", id, err)
+		}
+	}
+
+	t.Run("RejectsAtCap", func(t *testing.T) {
+		err := service.ProcessEvent(models.ProductEvent{ProductID: "third", Price: 1.0, Stock: 1})
+		if !errors.Is(err, ErrInFlightLimitReached) {
+			t.Errorf("Expected ErrInFlightLimitReached, got %v", err)
+		}
+		if service.Queue().Len() >= service.Queue().Cap() {
+			t.Error("Expected the rejection to come from the in-flight cap, not a full queue")
+		}
+		if count := droppedCount(service.Metrics(), DropReasonInFlightLimit); count != 1 {
+			t.Errorf("Expected 1 in-flight limit drop, got %d", count)
+		}
+		if inFlight := service.InFlight(); inFlight != 2 {
+			t.Errorf("Expected 2 events in flight, got %d", inFlight)
+		}
+	})
+
+	t.Run("AcceptsOnceSlotsFree", func(t *testing.T) {
+		close(repo.gate)
+		deadline := time.Now().Add(time.Second)
+		for service.InFlight() > 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if inFlight := service.InFlight(); inFlight != 0 {
+			t.Fatalf("Expected every slot to be released after processing, got %d in flight", inFlight)
+		}
+		if gauge := service.Metrics().Gauge(metricEventsInFlight, "", nil).Value(); gauge != 0 {
+			t.Errorf("Expected events_in_flight gauge 0, got %v", gauge)
+		}
+
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: "third", Price: 1.0, Stock: 1}); err != nil {
+			t.Errorf("Expected the event to be accepted once slots freed, got %v", err)
+		}
+	})
+}
+
+func TestProductService_InFlightLimit_BatchMode(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), queue.NewInMemoryEventQueue(10), 1)
+	service.SetInFlightLimiter(NewInFlightLimiter(2))
+	bp := queue.NewBatchProcessor(10, time.Hour, 0, 1, service.ApplyBatch)
+	service.SetBatchProcessor(bp)
+	service.Start()
+	defer service.Stop()
+
+	for _, id := range []string{"first", "second"} {
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: id, Price: 1.0, Stock: 1}); err != nil {
+			t.Fatalf("Expected %s to be accepted under the cap, got %v", id, err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for bp.GetPendingEvents() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Buffered events are not applied yet, so they still hold their slots
+	if inFlight := service.InFlight(); inFlight != 2 {
+		t.Errorf("Expected buffered events to stay in flight, got %d", inFlight)
+	}
+	if err := service.ProcessEvent(models.ProductEvent{ProductID: "third", Price: 1.0, Stock: 1}); !errors.Is(err, ErrInFlightLimitReached) {
+		t.Errorf("Expected ErrInFlightLimitReached while the batch is pending, got %v", err)
+	}
+
+	if err := bp.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if inFlight := service.InFlight(); inFlight != 0 {
+		t.Errorf("Expected every slot to be released once the batch is applied, got %d", inFlight)
+	}
+}
+
+func TestInFlightLimiter_AcquireRelease(t *testing.T) {
+	limiter := NewInFlightLimiter(1)
+
+	if !limiter.Acquire() {
+		t.Fatal("Expected the first slot to be acquired")
+	}
+	if limiter.Acquire() {
+		t.Error("Expected acquire to fail at the cap")
+	}
+
+	limiter.Release()
+	if !limiter.Acquire() {
+		t.Error("Expected a released slot to be acquired again")
+	}
+	if limiter.InFlight() != 1 || limiter.Max() != 1 {
+		t.Errorf("Expected 1 of 1 in flight, got %d of %d", limiter.InFlight(), limiter.Max())
+	}
+}
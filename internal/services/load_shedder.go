@@ -0,0 +1,103 @@
+package services
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// LoadShedder drops stale low-priority events while the backlog is critically high
+//
+// An event is only ever shed if a newer event for the same product has
+// already been enqueued, so the newest update per product is always applied.
+type LoadShedder struct {
+	mu          sync.Mutex
+	watermark   int
+	probability float64
+	sequence    uint64
+	latest      map[string]uint64
+	random      func() float64
+	logger      *log.Logger
+
+	// Shed-rate reporting
+	logInterval time.Duration
+	lastLog     time.Time
+	considered  int
+	shed        int
+}
+
+// NewLoadShedder creates a load shedder active above the watermark depth,
+// shedding eligible events with the given probability
+func NewLoadShedder(watermark int, probability float64) *LoadShedder {
+	return &LoadShedder{
+		watermark:   watermark,
+		probability: probability,
+		latest:      make(map[string]uint64),
+		random:      rand.Float64,
+		logger:      log.New(os.Stdout, "[SHEDDER] ", log.LstdFlags),
+		logInterval: 10 * time.Second,
+	}
+}
+
+// Track assigns the next sequence number to an event being enqueued
+func (ls *LoadShedder) Track(event *models.ProductEvent) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.sequence++
+	event.Sequence = ls.sequence
+	ls.latest[event.ProductID] = ls.sequence
+}
+
+// Untrack forgets an event that was never enqueued
+func (ls *LoadShedder) Untrack(event models.ProductEvent) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if ls.latest[event.ProductID] == event.Sequence {
+		delete(ls.latest, event.ProductID)
+	}
+}
+
+// ShouldShed decides whether a dequeued event should be dropped given the current depth
+func (ls *LoadShedder) ShouldShed(event models.ProductEvent, depth int) bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	latest, tracked := ls.latest[event.ProductID]
+	if tracked && latest == event.Sequence {
+		// This is the newest event for the product; it is always applied
+		delete(ls.latest, event.ProductID)
+		return false
+	}
+
+	if depth < ls.watermark || event.Priority != models.PriorityLow || !tracked || latest < event.Sequence {
+		return false
+	}
+
+	ls.considered++
+	shed := ls.random() < ls.probability
+	if shed {
+		ls.shed++
+	}
+	ls.logShedRate()
+	return shed
+}
+
+// logShedRate periodically logs how many eligible events were shed; the caller must hold ls.mu
+func (ls *LoadShedder) logShedRate() {
+	now := time.Now()
+	if now.Sub(ls.lastLog) < ls.logInterval {
+		return
+	}
+
+	ls.logger.Printf("Load shedding active: shed %d of %d stale low-priority events (%.1f%%)",
+		ls.shed, ls.considered, float64(ls.shed)/float64(ls.considered)*100)
+	ls.lastLog = now
+	ls.considered = 0
+	ls.shed = 0
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestLoadShedder_ShouldShed(t *testing.T) {
+	shedder := NewLoadShedder(5, 1.0)
+
+	stale := models.ProductEvent{ProductID: "p", Priority: models.PriorityLow}
+	shedder.Track(&stale)
+	high := models.ProductEvent{ProductID: "p", Priority: models.PriorityHigh}
+	shedder.Track(&high)
+	staleHigh := high
+	newest := models.ProductEvent{ProductID: "p", Priority: models.PriorityLow}
+	shedder.Track(&newest)
+
+	// Below the watermark nothing is shed
+	if shedder.ShouldShed(stale, 4) {
+		t.Error("Expected no shedding below the watermark")
+	}
+
+	// Above the watermark, a superseded low-priority event is shed
+	if !shedder.ShouldShed(stale, 10) {
+		t.Error("Expected stale low-priority event to be shed above the watermark")
+	}
+
+	// High-priority events always pass, even when superseded
+	if shedder.ShouldShed(staleHigh, 10) {
+		t.Error("Expected high-priority event to pass")
+	}
+
+	// The newest event for a product is always applied
+	if shedder.ShouldShed(newest, 10) {
+		t.Error("Expected newest low-priority event to pass")
+	}
+}
+
+func TestLoadShedder_Probability(t *testing.T) {
+	shedder := NewLoadShedder(0, 0.5)
+	rolls := []float64{0.1, 0.9}
+	shedder.random = func() float64 {
+		roll := rolls[0]
+		rolls = rolls[1:]
+		return roll
+	}
+
+	first := models.ProductEvent{ProductID: "p", Priority: models.PriorityLow}
+	shedder.Track(&first)
+	second := models.ProductEvent{ProductID: "p", Priority: models.PriorityLow}
+	shedder.Track(&second)
+	shedder.Track(&models.ProductEvent{ProductID: "p"})
+
+	if !shedder.ShouldShed(first, 1) {
+		t.Error("Expected event to be shed when the roll is below the probability")
+	}
+	if shedder.ShouldShed(second, 1) {
+		t.Error("Expected event to pass when the roll is above the probability")
+	}
+}
+
+func TestProductService_LoadShedding(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(100)
+	service := NewProductService(repo, eventQueue, 1)
+	service.SetLoadShedder(NewLoadShedder(10, 1.0))
+
+	// Fill the queue past the watermark before any worker is running
+	for i := 0; i < 30; i++ {
+		service.ProcessEvent(models.ProductEvent{ProductID: "bulk", Price: float64(i), Stock: i, Priority: models.PriorityLow})
+	}
+	for i := 0; i < 5; i++ {
+		service.ProcessEvent(models.ProductEvent{ProductID: "vip", Price: float64(i), Stock: i, Priority: models.PriorityHigh})
+	}
+
+	service.Start()
+	defer service.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && eventQueue.Len() > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	shed := droppedCount(service.Metrics(), DropReasonLoadShed)
+	if shed == 0 {
+		t.Error("Expected some low-priority events to be shed")
+	}
+	if shed >= 30 {
+		t.Errorf("Expected the newest low-priority event to be kept, shed %d of 30", shed)
+	}
+
+	// The newest low-priority value still wins
+	bulk, exists := service.GetProduct("bulk")
+	if !exists || bulk.Price != 29 {
+		t.Errorf("Expected newest bulk update (price 29) to be applied, got %+v", bulk)
+	}
+
+	// High-priority events all pass
+	vip, exists := service.GetProduct("vip")
+	if !exists || vip.Price != 4 {
+		t.Errorf("Expected all high-priority updates to be applied, got %+v", vip)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"product-service/pkg/retry"
+	"product-service/pkg/wal"
+)
+
+// writeReplayLog writes entries appended a minute apart, starting at start
+func writeReplayLog(t *testing.T, start time.Time, productIDs ...string) string {
+	path := filepath.Join(t.TempDir(), "products.wal")
+	var lines strings.Builder
+	for i, productID := range productIDs {
+		fmt.Fprintf(&lines, `{"seq":%d,"product_id":%q,"price":1.5,"stock":%d,"ts":%q}`+"\n",
+			i+1, productID, i+1, start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339))
+	}
+	if err := os.WriteFile(path, []byte(lines.String()), 0644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	return path
+}
+
+// queuedProductIDs drains the queue, returning the product IDs in order
+func queuedProductIDs(service *ProductService) []string {
+	var ids []string
+	for service.Queue().Len() > 0 {
+		event, _ := service.Queue().Dequeue()
+		ids = append(ids, event.ProductID)
+	}
+	return ids
+}
+
+func TestLogReplayer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	path := writeReplayLog(t, start, "a", "b", "c", "d", "e")
+
+	t.Run("ReplayFrom", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		if err := NewLogReplayer(path, service).ReplayFrom(3); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		ids := queuedProductIDs(service)
+		if len(ids) != 3 || ids[0] != "c" || ids[1] != "d" || ids[2] != "e" {
+			t.Errorf("Expected c, d and e re-enqueued, got %v", ids)
+		}
+	})
+
+	t.Run("ReplayFromTime", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		if err := NewLogReplayer(path, service).ReplayFromTime(start.Add(3 * time.Minute)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		ids := queuedProductIDs(service)
+		if len(ids) != 2 || ids[0] != "d" || ids[1] != "e" {
+			t.Errorf("Expected d and e re-enqueued, got %v", ids)
+		}
+	})
+
+	t.Run("CarriesMutation", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		NewLogReplayer(path, service).ReplayFrom(5)
+
+		event, _ := service.Queue().Dequeue()
+		if event.ProductID != "e" || event.Price != 1.5 || event.Stock != 5 {
+			t.Errorf("Expected {e 1.5 5}, got %+v", event)
+		}
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(2), 1)
+		service.SetEnqueueRetry(&retry.RetryConfig{MaxAttempts: 1, Multiplier: 1})
+		if err := NewLogReplayer(path, service).ReplayFrom(1); err == nil {
+			t.Error("Expected an error once the queue is full")
+		}
+		if service.Queue().Len() != 2 {
+			t.Errorf("Expected 2 events enqueued before stopping, got %d", service.Queue().Len())
+		}
+	})
+
+	t.Run("InvalidOffset", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+		if err := NewLogReplayer(path, service).ReplayFrom(-1); err != ErrInvalidOffset {
+			t.Errorf("Expected ErrInvalidOffset, got %v", err)
+		}
+	})
+}
+
+func TestLogReplayer_RecordedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.wal")
+	log, err := wal.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	before := time.Now().Add(-time.Second)
+	log.Append("a", 1.0, 1)
+	log.Append("b", 2.0, 2)
+	log.Close()
+
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+	if err := NewLogReplayer(path, service).ReplayFromTime(before); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ids := queuedProductIDs(service); len(ids) != 2 {
+		t.Errorf("Expected both appended entries replayed by time, got %v", ids)
+	}
+}
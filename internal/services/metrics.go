@@ -0,0 +1,27 @@
+package services
+
+import (
+	"product-service/pkg/metrics"
+)
+
+// Reasons an event can be dropped without being applied
+const (
+	DropReasonQueueFull  = "queue_full"
+	DropReasonValidation = "validation"
+	DropReasonDLQFull    = "dlq_full"
+	DropReasonLoadShed   = "load_shed"
+	DropReasonDuplicate  = "duplicate"
+	DropReasonPurged     = "purged"
+)
+
+// Metric names exposed by the service
+const (
+	metricDroppedTotal  = "dropped_total"
+	metricEventTimeouts = "event_timeouts_total"
+	metricCBRejections  = "circuit_breaker_rejections_total"
+)
+
+// recordDrop increments the dropped-events counter for reason
+func recordDrop(registry *metrics.Registry, reason string) {
+	registry.Counter(metricDroppedTotal, "Events dropped without being applied, by reason", metrics.Labels{"reason": reason}).Inc()
+}
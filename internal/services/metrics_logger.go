@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// MetricsSnapshot is a point-in-time summary of the service's key metrics
+type MetricsSnapshot struct {
+	Processed    int64
+	Failed       int64
+	QueueDepth   int
+	BreakerState string
+	EventsPerSec float64
+}
+
+// String formats the snapshot as space-separated key=value pairs
+func (s MetricsSnapshot) String() string {
+	return fmt.Sprintf("processed=%d failed=%d depth=%d breaker=%s eps=%.2f",
+		s.Processed, s.Failed, s.QueueDepth, s.BreakerState, s.EventsPerSec)
+}
+
+// MetricsLogger periodically logs a metrics summary, giving baseline
+// observability in environments without a metrics scraper
+type MetricsLogger struct {
+	service  *ProductService
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	logger   *log.Logger
+}
+
+// NewMetricsLogger creates a logger summarizing service's metrics every interval
+func NewMetricsLogger(service *ProductService, interval time.Duration) *MetricsLogger {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MetricsLogger{
+		service:  service,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		logger:   log.New(os.Stdout, "[METRICS] ", log.LstdFlags),
+	}
+}
+
+// Start starts logging snapshots
+func (m *MetricsLogger) Start() {
+	m.wg.Add(1)
+	go m.run()
+	m.logger.Printf("Started metrics logging every %v", m.interval)
+}
+
+// Stop stops logging snapshots
+func (m *MetricsLogger) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// run logs a snapshot on every interval until stopped
+func (m *MetricsLogger) run() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.logger.Print(m.Snapshot())
+		}
+	}
+}
+
+// Snapshot collects the current metrics summary; the rate is the one-minute average
+func (m *MetricsLogger) Snapshot() MetricsSnapshot {
+	stats := m.service.WorkerPool().Stats()
+	return MetricsSnapshot{
+		Processed:    stats.Processed,
+		Failed:       stats.Failed,
+		QueueDepth:   m.service.Queue().Len(),
+		BreakerState: m.service.CircuitBreaker().GetState().String(),
+		EventsPerSec: m.service.Throughput().OneMinute,
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestMetricsLogger_Snapshot(t *testing.T) {
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+	pool := service.WorkerPool()
+
+	now := time.Now()
+	pool.throughput.now = func() time.Time { return now }
+	pool.throughput.lastTick = now
+
+	atomic.StoreInt64(&pool.processed, 50)
+	atomic.StoreInt64(&pool.failed, 3)
+	pool.throughput.Mark(50)
+	now = now.Add(rateTickInterval)
+
+	eventQueue.Enqueue(models.ProductEvent{ProductID: "queued-1"})
+	eventQueue.Enqueue(models.ProductEvent{ProductID: "queued-2"})
+
+	service.CircuitBreaker().SetThreshold(1)
+	service.CircuitBreaker().Execute(func() error { return errors.New("dependency down") })
+
+	snapshot := NewMetricsLogger(service, time.Minute).Snapshot()
+
+	expected := MetricsSnapshot{Processed: 50, Failed: 3, QueueDepth: 2, BreakerState: "open", EventsPerSec: 10}
+	if snapshot != expected {
+		t.Errorf("Expected snapshot %+v, got %+v", expected, snapshot)
+	}
+
+	summary := snapshot.String()
+	for _, field := range []string{"processed=50", "failed=3", "depth=2", "breaker=open", "eps=10.00"} {
+		if !strings.Contains(summary, field) {
+			t.Errorf("Expected summary to contain %q, got %q", field, summary)
+		}
+	}
+}
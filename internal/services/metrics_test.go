@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+	"product-service/pkg/queue"
+)
+
+func droppedCount(registry *metrics.Registry, reason string) uint64 {
+	return registry.Counter(metricDroppedTotal, "", metrics.Labels{"reason": reason}).Value()
+}
+
+func TestProductService_DroppedQueueFull(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(1)
+	service := NewProductService(repo, eventQueue, 1)
+	service.retryConfig.InitialDelay = time.Millisecond
+
+	service.ProcessEvent(models.ProductEvent{ProductID: "1", Price: 1.0, Stock: 1})
+	service.ProcessEvent(models.ProductEvent{ProductID: "2", Price: 2.0, Stock: 2})
+
+	if count := droppedCount(service.Metrics(), DropReasonQueueFull); count != 1 {
+		t.Errorf("Expected 1 queue_full drop, got %d", count)
+	}
+}
+
+func TestProductService_DroppedValidation(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(1), 1)
+
+	service.RecordDrop(DropReasonValidation)
+
+	if count := droppedCount(service.Metrics(), DropReasonValidation); count != 1 {
+		t.Errorf("Expected 1 validation drop, got %d", count)
+	}
+}
+
+func TestWorkerPool_DroppedDLQFull(t *testing.T) {
+	repo := &PanickingProductRepository{NewMockProductRepository()}
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+	service.SetDeadLetterQueue(queue.NewDeadLetterQueue(1))
+
+	service.Start()
+	defer service.Stop()
+
+	// The first failure fills the DLQ, the second is lost
+	service.ProcessEvent(models.ProductEvent{ProductID: "1", Price: 1.0, Stock: 1})
+	service.ProcessEvent(models.ProductEvent{ProductID: "2", Price: 2.0, Stock: 2})
+	time.Sleep(100 * time.Millisecond)
+
+	if count := droppedCount(service.Metrics(), DropReasonDLQFull); count != 1 {
+		t.Errorf("Expected 1 dlq_full drop, got %d", count)
+	}
+}
+
+func TestProductService_CircuitBreakerRejections(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+	cb := service.CircuitBreaker()
+	cb.SetThreshold(1)
+	cb.SetTimeout(50 * time.Millisecond)
+
+	rejections := func() uint64 {
+		return service.Metrics().Counter(metricCBRejections, "", nil).Value()
+	}
+
+	cb.Execute(func() error { return errors.New("downstream unavailable") })
+	cb.Execute(func() error { return nil })
+	cb.Execute(func() error { return nil })
+	if rejections() != 2 {
+		t.Errorf("Expected 2 rejections while open, got %d", rejections())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("Expected the half-open probe to succeed, got %v", err)
+	}
+	cb.Execute(func() error { return nil })
+	if rejections() != 2 {
+		t.Errorf("Expected successful probes not to count as rejections, got %d", rejections())
+	}
+}
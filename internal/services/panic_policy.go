@@ -0,0 +1,23 @@
+package services
+
+import "fmt"
+
+// PanicPolicy controls how a worker reacts to a panic while processing an event
+type PanicPolicy string
+
+const (
+	// PanicPolicyRecover absorbs the panic, dead-letters the event and keeps the worker running
+	PanicPolicyRecover PanicPolicy = "recover"
+	// PanicPolicyCrash re-panics so the process exits and the orchestrator restarts it
+	PanicPolicyCrash PanicPolicy = "crash"
+)
+
+// ParsePanicPolicy parses a panic policy from its configuration value
+func ParsePanicPolicy(value string) (PanicPolicy, error) {
+	switch PanicPolicy(value) {
+	case PanicPolicyRecover, PanicPolicyCrash:
+		return PanicPolicy(value), nil
+	default:
+		return "", fmt.Errorf("unknown panic policy %q, expected %q or %q", value, PanicPolicyRecover, PanicPolicyCrash)
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// PanickingProductRepository panics on every update
+type PanickingProductRepository struct {
+	*MockProductRepository
+}
+
+func (p *PanickingProductRepository) Update(id string, price float64, stock int, currency string) {
+	panic("repository exploded")
+}
+
+func TestParsePanicPolicy(t *testing.T) {
+	for _, value := range []string{"recover", "crash"} {
+		policy, err := ParsePanicPolicy(value)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", value, err)
+		}
+		if string(policy) != value {
+			t.Errorf("Expected policy %q, got %q", value, policy)
+		}
+	}
+
+	if _, err := ParsePanicPolicy("ignore"); err == nil {
+		t.Error("Expected error for unknown panic policy")
+	}
+}
+
+func TestWorkerPool_PanicPolicyRecover(t *testing.T) {
+	repo := &PanickingProductRepository{NewMockProductRepository()}
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+	service.SetPanicPolicy(PanicPolicyRecover)
+
+	service.Start()
+	defer service.Stop()
+
+	service.ProcessEvent(models.ProductEvent{ProductID: "panic-1", Price: 1.0, Stock: 1})
+	service.ProcessEvent(models.ProductEvent{ProductID: "panic-2", Price: 2.0, Stock: 2})
+	time.Sleep(100 * time.Millisecond)
+
+	// Both events are dead-lettered, proving the worker survived the first panic
+	failed := service.DeadLetterQueue().List()
+	if len(failed) != 2 {
+		t.Fatalf("Expected 2 dead-lettered events, got %d", len(failed))
+	}
+	if failed[0].Event.ProductID != "panic-1" || failed[1].Event.ProductID != "panic-2" {
+		t.Errorf("Expected panicking events in the DLQ, got %+v", failed)
+	}
+	if failed[0].Error != "panic: repository exploded" {
+		t.Errorf("Expected panic error message, got '%s'", failed[0].Error)
+	}
+	if service.WorkerPool().WorkerCount() != 1 {
+		t.Errorf("Expected worker to keep running, got %d workers", service.WorkerPool().WorkerCount())
+	}
+}
+
+func TestWorkerPool_PanicPolicyCrash(t *testing.T) {
+	repo := &PanickingProductRepository{NewMockProductRepository()}
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+	service.SetPanicPolicy(PanicPolicyCrash)
+
+	// Call processEvent directly so the re-panic can be observed without killing the test binary
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Expected processEvent to re-panic under the crash policy")
+		}
+		if r != "repository exploded" {
+			t.Errorf("Expected original panic value, got %v", r)
+		}
+		if service.DeadLetterQueue().Len() != 0 {
+			t.Errorf("Expected nothing dead-lettered under the crash policy, got %d", service.DeadLetterQueue().Len())
+		}
+	}()
+
+	service.WorkerPool().processEvent(models.ProductEvent{ProductID: "panic", Price: 1.0, Stock: 1}, 0)
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	apperrors "product-service/pkg/errors"
+	"product-service/pkg/queue"
+)
+
+// SlowProductRepository takes longer than the processing timeout to apply an update
+type SlowProductRepository struct {
+	*MockProductRepository
+	delay time.Duration
+}
+
+func (r *SlowProductRepository) Update(id string, price float64, stock int, currency string) {
+	time.Sleep(r.delay)
+	r.MockProductRepository.Update(id, price, stock, currency)
+}
+
+// SlowReadProductRepository takes longer than the processing timeout to read a product
+type SlowReadProductRepository struct {
+	*MockProductRepository
+	delay time.Duration
+}
+
+func (r *SlowReadProductRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	product, exists := r.MockProductRepository.Get(id)
+	return product, exists, nil
+}
+
+func TestWorkerPool_ProcessTimeout(t *testing.T) {
+	repo := &SlowReadProductRepository{MockProductRepository: NewMockProductRepository(), delay: 200 * time.Millisecond}
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+	service.retryConfig.InitialDelay = time.Millisecond
+	dlq := queue.NewDeadLetterQueue(10)
+	service.SetDeadLetterQueue(dlq)
+	service.SetAuditLogger(NewAuditLogger(io.Discard))
+	service.SetProcessTimeout(20 * time.Millisecond)
+
+	pool := service.WorkerPool()
+	err := pool.apply(models.ProductEvent{ProductID: "slow", Price: 1.0, Stock: 1}, 0)
+
+	var classified *apperrors.ClassifiedError
+	if !errors.As(err, &classified) || classified.Type != apperrors.TimeoutError {
+		t.Fatalf("Expected a TimeoutError, got %v", err)
+	}
+
+	pool.processEvent(models.ProductEvent{ProductID: "slow", Price: 1.0, Stock: 1}, 0)
+
+	failed := dlq.List()
+	if len(failed) != 1 {
+		t.Fatalf("Expected timed-out event to be dead-lettered, got %d entries", len(failed))
+	}
+	if failed[0].RetryCount != service.retryConfig.MaxAttempts {
+		t.Errorf("Expected %d attempts before dead-lettering, got %d", service.retryConfig.MaxAttempts, failed[0].RetryCount)
+	}
+	if _, exists := repo.Get("slow"); exists {
+		t.Error("Expected timed-out event not to be applied")
+	}
+	if stats := pool.Stats(); stats.Exhausted != 1 {
+		t.Errorf("Expected 1 exhausted event, got %d", stats.Exhausted)
+	}
+
+	// One timeout from the direct call plus one per retry attempt
+	timeouts := service.Metrics().Counter(metricEventTimeouts, "", nil).Value()
+	if timeouts != uint64(1+service.retryConfig.MaxAttempts) {
+		t.Errorf("Expected %d timeouts, got %d", 1+service.retryConfig.MaxAttempts, timeouts)
+	}
+}
+
+func TestWorkerPool_ProcessTimeout_FastEvent(t *testing.T) {
+	repo := NewMockProductRepository()
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+	service.SetProcessTimeout(time.Second)
+
+	if err := service.WorkerPool().apply(models.ProductEvent{ProductID: "fast", Price: 1.0, Stock: 1}, 0); err != nil {
+		t.Errorf("Expected no error within the timeout, got %v", err)
+	}
+	if _, exists := repo.Get("fast"); !exists {
+		t.Error("Expected event to be applied")
+	}
+}
+
+func TestWorkerPool_ProcessTimeout_Panic(t *testing.T) {
+	repo := &PanickingProductRepository{NewMockProductRepository()}
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+	service.SetProcessTimeout(time.Second)
+
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "exploded") {
+			t.Errorf("Expected the repository panic to reach the worker, got %v", r)
+		}
+	}()
+	service.WorkerPool().apply(models.ProductEvent{ProductID: "boom", Price: 1.0, Stock: 1}, 0)
+}
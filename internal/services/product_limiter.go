@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"product-service/pkg/metrics"
+)
+
+// metricProductCount reports the number of stored products seen by the product limit
+const metricProductCount = "products_stored"
+
+// DropReasonProductLimit is recorded when an event for a new product is rejected at the product limit
+const DropReasonProductLimit = "product_limit"
+
+// ErrProductLimitReached is returned for an event introducing a new product once the limit is reached
+var ErrProductLimitReached = errors.New("maximum number of products reached")
+
+// ProductCounter is implemented by repositories that can report how many products they hold
+type ProductCounter interface {
+	ProductCount() int
+}
+
+// ProductLimiter caps the number of distinct products
+//
+// Events for products that already exist are always admitted. A new product
+// is admitted only while stored products plus new products still waiting in
+// the queue are under the limit, so the cap holds even before those events
+// are applied.
+type ProductLimiter struct {
+	mu      sync.Mutex
+	max     int
+	counter ProductCounter
+	pending map[string]struct{}
+	gauge   *metrics.Gauge
+}
+
+// NewProductLimiter creates a limiter admitting at most max distinct products into counter
+func NewProductLimiter(max int, counter ProductCounter) *ProductLimiter {
+	return &ProductLimiter{
+		max:     max,
+		counter: counter,
+		pending: make(map[string]struct{}),
+	}
+}
+
+// Admit reports whether an event for id may be accepted; exists reports whether a product is already stored
+func (l *ProductLimiter) Admit(id string, exists func(id string) bool) bool {
+	if exists(id) {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.pending[id]; ok {
+		return true
+	}
+	// Pending products that have since been stored are counted by the repository
+	for pendingID := range l.pending {
+		if exists(pendingID) {
+			delete(l.pending, pendingID)
+		}
+	}
+	if l.counter.ProductCount()+len(l.pending) >= l.max {
+		return false
+	}
+	l.pending[id] = struct{}{}
+	return true
+}
+
+// Release frees the slot held by a new product whose event will never be applied
+func (l *ProductLimiter) Release(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.pending, id)
+}
+
+// Stored records that the event holding a new product's slot was applied, so
+// the product is now counted by the repository
+func (l *ProductLimiter) Stored(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.pending[id]; !ok {
+		return
+	}
+	delete(l.pending, id)
+	l.observe()
+}
+
+// Recount refreshes the reported product count after products were removed from the repository
+func (l *ProductLimiter) Recount() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observe()
+}
+
+// SetGauge reports the number of stored products to gauge whenever it changes
+func (l *ProductLimiter) SetGauge(gauge *metrics.Gauge) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gauge = gauge
+	l.observe()
+}
+
+// observe sets the gauge to the number of stored products; the caller holds mu
+func (l *ProductLimiter) observe() {
+	if l.gauge != nil {
+		l.gauge.Set(float64(l.counter.ProductCount()))
+	}
+}
+
+// Count returns the number of stored products
+func (l *ProductLimiter) Count() int {
+	return l.counter.ProductCount()
+}
+
+// Max returns the maximum number of distinct products
+func (l *ProductLimiter) Max() int {
+	return l.max
+}
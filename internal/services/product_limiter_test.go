@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"product-service/internal/models"
+	"product-service/internal/repositories"
+	"product-service/pkg/metrics"
+	"product-service/pkg/queue"
+)
+
+func TestProductService_ProductLimit(t *testing.T) {
+	repo := repositories.NewInMemoryProductRepository()
+	repo.Update("existing", 1.0, 1, "")
+	service := NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+	service.SetProductLimiter(NewProductLimiter(2, repo))
+
+	// The second slot goes to a new product still waiting in the queue
+	if _, err := service.ProcessEventWithTicket(models.ProductEvent{ProductID: "queued", Price: 2.0, Stock: 2}); err != nil {
+		t.Fatalf("Expected new product under the cap to be accepted, got %v", err)
+	}
+
+	t.Run("RejectsNewProduct", func(t *testing.T) {
+		_, err := service.ProcessEventWithTicket(models.ProductEvent{ProductID: "overflow", Price: 3.0, Stock: 3})
+		if !errors.Is(err, ErrProductLimitReached) {
+			t.Errorf("Expected ErrProductLimitReached, got %v", err)
+		}
+		if count := droppedCount(service.Metrics(), DropReasonProductLimit); count != 1 {
+			t.Errorf("Expected 1 product limit drop, got %d", count)
+		}
+	})
+
+	t.Run("AcceptsExistingProduct", func(t *testing.T) {
+		if _, err := service.ProcessEventWithTicket(models.ProductEvent{ProductID: "existing", Price: 4.0, Stock: 4}); err != nil {
+			t.Errorf("Expected update to a stored product to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("AcceptsQueuedProduct", func(t *testing.T) {
+		if _, err := service.ProcessEventWithTicket(models.ProductEvent{ProductID: "queued", Price: 5.0, Stock: 5}); err != nil {
+			t.Errorf("Expected another update to a queued product to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("ReportsCount", func(t *testing.T) {
+		if count := service.ProductCount(); count != 1 {
+			t.Errorf("Expected 1 stored product, got %d", count)
+		}
+		gauge := service.Metrics().Gauge(metricProductCount, "", nil).Value()
+		if gauge != 1 {
+			t.Errorf("Expected products_stored gauge 1, got %v", gauge)
+		}
+	})
+}
+
+func TestProductLimiter_Release(t *testing.T) {
+	repo := repositories.NewInMemoryProductRepository()
+	limiter := NewProductLimiter(1, repo)
+	exists := func(id string) bool {
+		_, ok := repo.Get(id)
+		return ok
+	}
+
+	if !limiter.Admit("first", exists) {
+		t.Fatal("Expected first product to be admitted")
+	}
+	if limiter.Admit("second", exists) {
+		t.Error("Expected second product to be rejected while the first is pending")
+	}
+
+	// A dead-lettered event never reaches the repository, so its slot is freed
+	limiter.Release("first")
+	if !limiter.Admit("second", exists) {
+		t.Error("Expected second product to be admitted after the first was released")
+	}
+
+	// Once stored, the pending product is counted by the repository instead
+	repo.Update("second", 1.0, 1, "")
+	limiter.Stored("second")
+	if limiter.Admit("third", exists) {
+		t.Error("Expected third product to be rejected at the cap")
+	}
+	if !limiter.Admit("second", exists) {
+		t.Error("Expected updates to a stored product to be admitted at the cap")
+	}
+}
+
+func TestProductLimiter_Gauge(t *testing.T) {
+	repo := repositories.NewInMemoryProductRepository()
+	repo.Update("existing", 1.0, 1, "")
+	limiter := NewProductLimiter(10, repo)
+	exists := func(id string) bool {
+		_, ok := repo.Get(id)
+		return ok
+	}
+
+	gauge := metrics.NewRegistry().Gauge(metricProductCount, "", nil)
+	limiter.SetGauge(gauge)
+	if gauge.Value() != 1 {
+		t.Errorf("Expected gauge 1 once set, got %v", gauge.Value())
+	}
+
+	limiter.Admit("new", exists)
+	repo.Update("new", 2.0, 2, "")
+	limiter.Stored("new")
+	if gauge.Value() != 2 {
+		t.Errorf("Expected gauge 2 after a new product was stored, got %v", gauge.Value())
+	}
+
+	repo.Delete("existing")
+	limiter.Recount()
+	if gauge.Value() != 1 {
+		t.Errorf("Expected gauge 1 after a product was removed, got %v", gauge.Value())
+	}
+}
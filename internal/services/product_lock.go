@@ -0,0 +1,43 @@
+package services
+
+import (
+	"sync"
+
+	"product-service/internal/models"
+)
+
+// ProductLock serializes processing of events for the same product
+//
+// Product IDs are hashed onto a fixed number of mutexes, so memory stays
+// bounded however many products are seen. Different products sharing a
+// stripe are serialized too, which only costs parallelism, never correctness.
+type ProductLock struct {
+	stripes []sync.Mutex
+}
+
+// NewProductLock creates a product lock with the given number of stripes, at least one
+func NewProductLock(stripes int) *ProductLock {
+	if stripes < 1 {
+		stripes = 1
+	}
+	return &ProductLock{stripes: make([]sync.Mutex, stripes)}
+}
+
+// Lock blocks until the stripe of product id is held
+func (l *ProductLock) Lock(id string) {
+	l.stripe(id).Lock()
+}
+
+// Unlock releases the stripe of product id
+func (l *ProductLock) Unlock(id string) {
+	l.stripe(id).Unlock()
+}
+
+// Stripes returns the number of stripes
+func (l *ProductLock) Stripes() int {
+	return len(l.stripes)
+}
+
+func (l *ProductLock) stripe(id string) *sync.Mutex {
+	return &l.stripes[models.HashID(id)%uint64(len(l.stripes))]
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// incrementingRepository treats each update's stock as a delta, applied with
+// a non-atomic read-modify-write so unserialized updates overwrite each other
+type incrementingRepository struct {
+	*MockProductRepository
+}
+
+func (r *incrementingRepository) Update(id string, price float64, stock int, currency string) {
+	current := 0
+	if product, exists := r.Get(id); exists {
+		current = product.Stock
+	}
+	time.Sleep(time.Millisecond)
+	r.MockProductRepository.Update(id, price, current+stock, currency)
+}
+
+func TestProductLock_SerializesSameProduct(t *testing.T) {
+	const events = 100
+	repo := &incrementingRepository{MockProductRepository: NewMockProductRepository()}
+	service := NewProductService(repo, NewMockEventQueue(events), 8)
+	service.SetProductLock(NewProductLock(16))
+	service.Start()
+
+	// Each category has its own circuit breaker, so its events run in parallel
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(category string) {
+			defer wg.Done()
+			for j := 0; j < events/4; j++ {
+				event := models.ProductEvent{ProductID: "hot", Category: category, Price: 1.0, Stock: 1}
+				if err := service.ProcessEvent(event); err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+			}
+		}(fmt.Sprintf("category-%d", i))
+	}
+	wg.Wait()
+	service.Stop()
+
+	product, exists := repo.Get("hot")
+	if !exists {
+		t.Fatal("Expected the product to be stored")
+	}
+	if product.Stock != events {
+		t.Errorf("Expected all %d increments applied, got stock %d", events, product.Stock)
+	}
+}
+
+func TestProductLock_Stripes(t *testing.T) {
+	if stripes := NewProductLock(0).Stripes(); stripes != 1 {
+		t.Errorf("Expected a non-positive stripe count to be clamped to 1, got %d", stripes)
+	}
+
+	lock := NewProductLock(64)
+	lock.Lock("a")
+	defer lock.Unlock("a")
+
+	// Find a product on another stripe; it must not wait for "a"
+	other := "b"
+	for i := 0; lock.stripe(other) == lock.stripe("a"); i++ {
+		other = string(rune('b' + i))
+	}
+	done := make(chan struct{})
+	go func() {
+		lock.Lock(other)
+		lock.Unlock(other)
+		close(done)
+	}()
+	<-done
+}
@@ -2,62 +2,643 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"product-service/internal/models"
 	"product-service/pkg/circuitbreaker"
+	apperrors "product-service/pkg/errors"
+	"product-service/pkg/logging"
+	"product-service/pkg/metrics"
 	"product-service/pkg/queue"
+	"product-service/pkg/ratelimit"
 	"product-service/pkg/retry"
+	"product-service/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ProductService handles business logic for products
 type ProductService struct {
-	repository     ProductRepository
-	queue          queue.EventQueue
-	workerPool     *WorkerPool
-	circuitBreaker *circuitbreaker.CircuitBreaker
-	retryConfig    *retry.RetryConfig
+	repository      ProductRepository
+	queue           queue.EventQueue
+	workerPool      *WorkerPool
+	circuitBreaker  *circuitbreaker.CircuitBreaker
+	retryConfig     *retry.RetryConfig
+	enqueueRetry    *retry.RetryConfig
+	deadLetterQueue *queue.DeadLetterQueue
+	metrics         *metrics.Registry
+	loadShedder     *LoadShedder
+	coalescer       *Coalescer
+	admission       *AdmissionPolicy
+	batchPolicy     BatchConflictPolicy
+	batchProcessor  *queue.BatchProcessor
+	deduplicator    *Deduplicator
+	productLimiter  *ProductLimiter
+	inFlightLimiter *InFlightLimiter
+	drainTimeout    time.Duration
+	backlogMonitor  *BacklogMonitor
+	canary          *Canary
+	dlqThresholds   DeadLetterThresholds
+	exhaustionLimit float64
+	stallThreshold  int64 // time.Duration, accessed atomically
+	eventValidator  EventValidator
+	repositorySizer RepositorySizer
+	shardReporter   ShardReporter
+	shardMu         sync.Mutex
+
+	// intakeMu orders enqueues against Stop so nothing is enqueued after draining begins
+	intakeMu sync.RWMutex
+	stopped  bool
+
+	// retryCtx is the root of every enqueue and processing retry; cancelling
+	// it cuts their backoff sleeps short
+	retryCtx     context.Context
+	abortRetries context.CancelFunc
+	abortOnStop  bool
 }
 
+// EventValidator applies deployment-specific rules to an event before it is
+// enqueued; a non-nil error rejects the event as invalid
+type EventValidator func(event models.ProductEvent) error
+
+// EventTransformer rewrites an event in the worker before it is applied; a
+// non-nil error dead-letters the event instead
+type EventTransformer func(event models.ProductEvent) (models.ProductEvent, error)
+
+// DefaultStallThreshold is how long events may wait without any being applied before health degrades
+const DefaultStallThreshold = time.Minute
+
+// ErrDrainTimeout is recorded for events still queued or batched when Stop's drain timeout passes
+var ErrDrainTimeout = errors.New("drain timed out before the event was processed")
+
+// ErrNothingToReplay is returned when the dead letter queue holds no event for a product
+var ErrNothingToReplay = errors.New("no dead-lettered event to replay")
+
+// DefaultDeadLetterQueueSize is the number of failed events retained by default
+const DefaultDeadLetterQueueSize = 1000
+
 // ProductRepository interface for dependency injection
 type ProductRepository interface {
 	Get(id string) (*models.Product, bool)
-	Update(id string, price float64, stock int)
+	// Update stores a product's state; currency is that of its price, empty if unknown
+	Update(id string, price float64, stock int, currency string)
+}
+
+// BatchProductRepository is implemented by repositories that can upsert many events at once
+type BatchProductRepository interface {
+	UpdateBatch(events []models.ProductEvent) []string
+}
+
+// ContextProductRepository is implemented by repositories whose lookups honour context cancellation
+type ContextProductRepository interface {
+	GetContext(ctx context.Context, id string) (*models.Product, bool, error)
 }
 
 // NewProductService creates a new product service
 func NewProductService(repo ProductRepository, eventQueue queue.EventQueue, workers int) *ProductService {
+	retryConfig := retry.DefaultRetryConfig()
 	service := &ProductService{
-		repository:     repo,
-		queue:          eventQueue,
-		circuitBreaker: circuitbreaker.NewCircuitBreaker(5, 60*time.Second),
-		retryConfig:    retry.DefaultRetryConfig(),
+		repository:      repo,
+		queue:           eventQueue,
+		circuitBreaker:  circuitbreaker.NewCircuitBreaker(5, 60*time.Second),
+		retryConfig:     retryConfig,
+		enqueueRetry:    retryConfig.Clone(),
+		deadLetterQueue: queue.NewDeadLetterQueue(DefaultDeadLetterQueueSize),
+		metrics:         metrics.NewRegistry(),
+		batchPolicy:     BatchConflictLastWins,
+		stallThreshold:  int64(DefaultStallThreshold),
+		abortOnStop:     true,
 	}
+	service.retryCtx, service.abortRetries = context.WithCancel(context.Background())
+	service.circuitBreaker.SetFailurePredicate(apperrors.IsDependencyFailure)
 
 	service.workerPool = NewWorkerPool(workers, eventQueue, repo, service.circuitBreaker, service.retryConfig)
+	service.workerPool.SetDeadLetterQueue(service.deadLetterQueue)
+	service.workerPool.SetMetrics(service.metrics)
+	service.workerPool.retryCtx = service.retryCtx
+	service.circuitBreaker.SetRejectionObserver(service.workerPool.observeRejection)
 	return service
 }
 
-// Start starts the product service and worker pool
+// Start starts the product service and worker pool; calling it again is a no-op
 func (s *ProductService) Start() {
 	s.workerPool.Start()
 }
 
-// Stop gracefully stops the product service
+// Ready reports whether Start has launched the workers and Stop has not yet
+// been called, so accepted events will be drained
+func (s *ProductService) Ready() bool {
+	s.intakeMu.RLock()
+	defer s.intakeMu.RUnlock()
+	return !s.stopped && s.workerPool.Running()
+}
+
+// Stop gracefully stops the product service; calling it again is a no-op
+//
+// Intake is closed first, then the workers drain the queue, and in batch
+// mode the final partial batch is flushed, so Stop returns only once every
+// accepted event has been applied. With a drain timeout set, Stop gives up
+// waiting once it passes and dead-letters the events still queued, and any
+// still buffered in the batch processor, instead. The queue itself is closed
+// once the workers are cancelled, releasing any still blocked in a dequeue;
+// intake is already closed by then, so nothing can enqueue onto it.
+//
+// Unless disabled with SetAbortRetriesOnStop, Stop first aborts every retry
+// waiting out its backoff, enqueue and processing alike. Events whose
+// processing is aborted are dead-lettered, and events still queued get a
+// single attempt each while draining.
 func (s *ProductService) Stop() {
-	s.workerPool.Stop()
+	// Enqueues hold intakeMu while retrying, so abort them before taking it
+	if s.abortOnStop {
+		s.abortRetries()
+	}
+
+	s.intakeMu.Lock()
+	alreadyStopped := s.stopped
+	s.stopped = true
+	s.intakeMu.Unlock()
+	if alreadyStopped {
+		return
+	}
+
+	var deadline time.Time
+	if s.drainTimeout > 0 {
+		deadline = time.Now().Add(s.drainTimeout)
+	}
+	drained := s.workerPool.DrainBy(deadline)
+	if !s.workerPool.stopBy(deadline, s.queue.Close) {
+		s.workerPool.logger.Printf("WARNING: workers still busy after drain timeout of %v, shutting down without them", s.drainTimeout)
+	}
+	if !drained {
+		moved := s.workerPool.deadLetterQueued(ErrDrainTimeout)
+		s.workerPool.logger.Printf("WARNING: drain timed out after %v, dead-lettered %d queued events", s.drainTimeout, moved)
+	}
+	if s.batchProcessor != nil {
+		if !s.batchProcessor.StopBy(deadline, s.workerPool.deadLetterBatch) {
+			s.workerPool.logger.Printf("WARNING: batch processor not flushed within drain timeout of %v, dead-lettered its buffered events", s.drainTimeout)
+		}
+	}
 }
 
 // ProcessEvent enqueues a product event for processing with retry
 func (s *ProductService) ProcessEvent(event models.ProductEvent) error {
-	return s.retryConfig.ExecuteWithRetry(func() error {
-		return s.circuitBreaker.Execute(func() error {
-			return s.queue.Enqueue(event)
-		})
+	_, err := s.ProcessEventWithTicket(event)
+	return err
+}
+
+// ProcessEventWithTicket enqueues a product event for processing with retry and
+// returns the queue ticket assigned to it. Once Stop has been called events are
+// rejected with queue.ErrQueueClosed.
+func (s *ProductService) ProcessEventWithTicket(event models.ProductEvent) (int64, error) {
+	s.intakeMu.RLock()
+	defer s.intakeMu.RUnlock()
+	if s.stopped {
+		return 0, queue.ErrQueueClosed
+	}
+
+	if s.admission != nil {
+		if err := s.admission.Admit(event, s.queue.Len()); err != nil {
+			recordDrop(s.metrics, DropReasonAdmission)
+			return 0, err
+		}
+	}
+	if s.deduplicator != nil && !s.deduplicator.Reserve(event) {
+		// The product's last accepted update was identical, so applying this one changes nothing
+		recordDrop(s.metrics, DropReasonDuplicate)
+		return 0, ErrDuplicate
+	}
+	if s.productLimiter != nil && !s.productLimiter.Admit(event.ProductID, s.productExists) {
+		if s.deduplicator != nil {
+			s.deduplicator.Release(event)
+		}
+		recordDrop(s.metrics, DropReasonProductLimit)
+		return 0, ErrProductLimitReached
+	}
+	if s.inFlightLimiter != nil && !s.inFlightLimiter.Acquire() {
+		if s.deduplicator != nil {
+			s.deduplicator.Release(event)
+		}
+		if s.productLimiter != nil {
+			s.productLimiter.Release(event.ProductID)
+		}
+		recordDrop(s.metrics, DropReasonInFlightLimit)
+		return 0, ErrInFlightLimitReached
+	}
+	if s.loadShedder != nil {
+		s.loadShedder.Track(&event)
+	}
+	if s.coalescer != nil {
+		s.coalescer.Track(&event)
+	}
+
+	// A full queue is transient backpressure, not a downstream failure, so the
+	// enqueue is retried on its own schedule and kept out of the circuit breaker
+	var ticket int64
+	err := s.enqueueRetry.ExecuteWithRetryContext(s.retryCtx, func() error {
+		var err error
+		ticket, err = s.queue.EnqueueWithTicket(event)
+		return err
 	})
+	if err != nil && s.loadShedder != nil {
+		s.loadShedder.Untrack(event)
+	}
+	if err != nil && s.coalescer != nil {
+		s.coalescer.Untrack(event)
+	}
+	if err != nil && s.deduplicator != nil {
+		s.deduplicator.Release(event)
+	}
+	if err != nil && s.productLimiter != nil {
+		s.productLimiter.Release(event.ProductID)
+	}
+	if err != nil && s.inFlightLimiter != nil {
+		s.inFlightLimiter.Release()
+	}
+	if errors.Is(err, queue.ErrQueueFull) {
+		recordDrop(s.metrics, DropReasonQueueFull)
+	}
+	return ticket, err
+}
+
+// ProcessEventSync applies an event before returning, bypassing the queue, and
+// returns the resulting product. Processing failures are returned rather than
+// dead-lettered. An event for the same product still waiting in the queue is
+// applied afterwards and may overwrite this one.
+func (s *ProductService) ProcessEventSync(ctx context.Context, event models.ProductEvent) (*models.Product, error) {
+	s.intakeMu.RLock()
+	defer s.intakeMu.RUnlock()
+	if s.stopped {
+		return nil, queue.ErrQueueClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if s.productLimiter != nil {
+		if !s.productLimiter.Admit(event.ProductID, s.productExists) {
+			recordDrop(s.metrics, DropReasonProductLimit)
+			return nil, ErrProductLimitReached
+		}
+		// Once applied the product is counted by the repository
+		defer s.productLimiter.Release(event.ProductID)
+	}
+	if s.inFlightLimiter != nil {
+		if !s.inFlightLimiter.Acquire() {
+			recordDrop(s.metrics, DropReasonInFlightLimit)
+			return nil, ErrInFlightLimitReached
+		}
+		defer s.inFlightLimiter.Release()
+	}
+	if s.workerPool.throttle != nil {
+		if err := s.workerPool.throttle.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	event, err := s.workerPool.transform(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.workerPool.executeSync(event); err != nil {
+		return nil, err
+	}
+	s.workerPool.productStored(event)
+
+	product, exists := s.repository.Get(event.ProductID)
+	if !exists {
+		return nil, fmt.Errorf("product %s not found after processing", event.ProductID)
+	}
+	return product, nil
+}
+
+// SetProductLimiter caps the number of distinct products; events for new
+// products beyond the cap are rejected with ErrProductLimitReached
+func (s *ProductService) SetProductLimiter(limiter *ProductLimiter) {
+	s.productLimiter = limiter
+	s.workerPool.SetProductLimiter(limiter)
+	s.setProductCountGauge()
+}
+
+// setProductCountGauge points the product limiter at the products_stored gauge of the service registry
+func (s *ProductService) setProductCountGauge() {
+	if s.productLimiter != nil && s.metrics != nil {
+		s.productLimiter.SetGauge(s.metrics.Gauge(metricProductCount, "Number of stored products counted against MAX_PRODUCTS", nil))
+	}
+}
+
+// ProductCount returns the number of stored products seen by the product
+// limit, or -1 when no limit is configured
+func (s *ProductService) ProductCount() int {
+	if s.productLimiter == nil {
+		return -1
+	}
+	return s.productLimiter.Count()
+}
+
+// SetInFlightLimiter caps the number of events queued or being processed;
+// events beyond the cap are rejected with ErrInFlightLimitReached
+func (s *ProductService) SetInFlightLimiter(limiter *InFlightLimiter) {
+	s.inFlightLimiter = limiter
+	s.workerPool.SetInFlightLimiter(limiter)
+}
+
+// InFlight refreshes and returns the number of events queued or being
+// processed, or -1 when no in-flight limit is configured
+func (s *ProductService) InFlight() int {
+	if s.inFlightLimiter == nil {
+		return -1
+	}
+	inFlight := s.inFlightLimiter.InFlight()
+	if s.metrics != nil {
+		s.metrics.Gauge(metricEventsInFlight, "Number of accepted events queued or being processed", nil).Set(float64(inFlight))
+	}
+	return inFlight
+}
+
+// productExists reports whether a product is currently stored
+func (s *ProductService) productExists(id string) bool {
+	_, exists := s.repository.Get(id)
+	return exists
+}
+
+// SetDrainTimeout bounds how long Stop waits for the queue to drain and the
+// workers to finish; zero waits for as long as it takes
+func (s *ProductService) SetDrainTimeout(timeout time.Duration) {
+	s.drainTimeout = timeout
+}
+
+// SetThrottle caps event processing to the limiter's rate, for downstreams that are themselves rate limited
+func (s *ProductService) SetThrottle(limiter *ratelimit.Limiter) {
+	s.workerPool.SetThrottle(limiter)
+}
+
+// SetChaos enables chaos mode, injecting delays and failures into event processing
+func (s *ProductService) SetChaos(chaos *ChaosInjector) {
+	s.workerPool.SetChaos(chaos)
+}
+
+// SetAbortRetriesOnStop chooses whether Stop cuts short the backoff of every
+// pending retry, or lets retries play out while draining; enabled by default
+func (s *ProductService) SetAbortRetriesOnStop(enabled bool) {
+	s.abortOnStop = enabled
+}
+
+// SetEnqueueRetry sets how enqueues are retried while the queue is full.
+// The service keeps its own copy, so later changes to rc have no effect.
+func (s *ProductService) SetEnqueueRetry(rc *retry.RetryConfig) {
+	s.enqueueRetry = rc.Clone()
+}
+
+// EnqueueRetry returns a copy of the enqueue retry configuration
+func (s *ProductService) EnqueueRetry() *retry.RetryConfig {
+	return s.enqueueRetry.Clone()
+}
+
+// SetDeduplicator enables skipping of identical events arriving within the deduplicator's window
+func (s *ProductService) SetDeduplicator(deduplicator *Deduplicator) {
+	s.deduplicator = deduplicator
+	s.workerPool.SetDeduplicator(deduplicator)
+}
+
+// SetProcessTimeout bounds how long processing a single event may take; zero disables the bound
+func (s *ProductService) SetProcessTimeout(timeout time.Duration) {
+	s.workerPool.SetProcessTimeout(timeout)
+}
+
+// SetStrictFIFO enables deterministic single-worker processing in enqueue order
+func (s *ProductService) SetStrictFIFO(enabled bool) {
+	s.workerPool.SetStrictFIFO(enabled)
+}
+
+// SetProductLock serializes applying events for the same product across workers
+func (s *ProductService) SetProductLock(lock *ProductLock) {
+	s.workerPool.SetProductLock(lock)
+}
+
+// SetEventValidator sets custom rules checked in addition to the built-in validation
+func (s *ProductService) SetEventValidator(validator EventValidator) {
+	s.eventValidator = validator
+}
+
+// SetEventTransformers sets the transformers applied, in order, to each event before it is applied
+func (s *ProductService) SetEventTransformers(transformers ...EventTransformer) {
+	s.workerPool.SetEventTransformers(transformers...)
+}
+
+// SetAuditLogger records every mutation applied to the repository, including batch writes
+func (s *ProductService) SetAuditLogger(auditLogger *AuditLogger) {
+	s.workerPool.SetAuditLogger(auditLogger)
+}
+
+// ValidateEvent runs the custom event validator, if one is set
+func (s *ProductService) ValidateEvent(event models.ProductEvent) error {
+	if s.eventValidator == nil {
+		return nil
+	}
+	return s.eventValidator(event)
+}
+
+// SetStallThreshold sets how long events may wait without progress before the service is considered stalled
+func (s *ProductService) SetStallThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&s.stallThreshold, int64(threshold))
+}
+
+// LastProcessedAt returns when an event was last applied, or the zero time if none has been
+func (s *ProductService) LastProcessedAt() time.Time {
+	return s.workerPool.LastProcessedAt()
+}
+
+// Stalled reports whether queued events have made no progress within the stall threshold
+func (s *ProductService) Stalled() bool {
+	return s.workerPool.Stalled(time.Duration(atomic.LoadInt64(&s.stallThreshold)))
+}
+
+// SetDeadLetterThresholds sets the dead letter queue depths at which health and readiness degrade
+func (s *ProductService) SetDeadLetterThresholds(thresholds DeadLetterThresholds) {
+	s.dlqThresholds = thresholds
+}
+
+// SetRetryExhaustionThreshold sets the retry exhaustion rate above which health
+// degrades; zero disables the check
+func (s *ProductService) SetRetryExhaustionThreshold(threshold float64) {
+	s.exhaustionLimit = threshold
+}
+
+// SetCanary sets the pipeline canary reported by the health check
+func (s *ProductService) SetCanary(canary *Canary) {
+	s.canary = canary
+}
+
+// SetBacklogMonitor sets the slow-consumer monitor reported by the health check
+func (s *ProductService) SetBacklogMonitor(monitor *BacklogMonitor) {
+	s.backlogMonitor = monitor
+}
+
+// SetLoadShedder enables shedding of stale low-priority events under extreme backlog
+func (s *ProductService) SetLoadShedder(shedder *LoadShedder) {
+	s.loadShedder = shedder
+	s.workerPool.SetLoadShedder(shedder)
+}
+
+// SetAdmissionPolicy sets the policy deciding, from the queue depth, which events may be enqueued
+func (s *ProductService) SetAdmissionPolicy(policy *AdmissionPolicy) {
+	s.admission = policy
+}
+
+// SetCoalescer enables coalescing of rapid updates to the same product
+func (s *ProductService) SetCoalescer(coalescer *Coalescer) {
+	s.coalescer = coalescer
+	s.workerPool.SetCoalescer(coalescer)
+}
+
+// BatchEventOutcome reports what happened to one event passed to EnqueueBatch
+type BatchEventOutcome struct {
+	// Status is models.UpsertCreated or models.UpsertUpdated for an enqueued event
+	Status string
+	// Superseded is set for an event dropped in favour of a later one for the same product
+	Superseded bool
+	// Duplicate is set for an event skipped as identical to the last one accepted for its product
+	Duplicate bool
+	// Err is set for an event that could not be enqueued
+	Err error
+}
+
+// EnqueueBatch enqueues a batch of events as upserts, applying the batch
+// conflict policy to duplicate products, and keeps going past events that
+// cannot be enqueued. It returns one outcome per event, in the order given;
+// only a batch conflict fails the whole batch.
+func (s *ProductService) EnqueueBatch(events []models.ProductEvent) ([]BatchEventOutcome, error) {
+	outcomes := make([]BatchEventOutcome, len(events))
+	last := make(map[string]int, len(events))
+	for i, event := range events {
+		last[event.ProductID] = i
+	}
+	if len(last) < len(events) && s.batchPolicy == BatchConflictReject {
+		return nil, &BatchConflictError{ProductIDs: findDuplicates(events)}
+	}
+
+	for i, event := range events {
+		if last[event.ProductID] != i {
+			outcomes[i].Superseded = true
+			continue
+		}
+
+		status := models.UpsertCreated
+		if _, exists := s.repository.Get(event.ProductID); exists {
+			status = models.UpsertUpdated
+		}
+		err := s.ProcessEvent(event)
+		if errors.Is(err, ErrDuplicate) {
+			outcomes[i].Duplicate = true
+			continue
+		}
+		if err != nil {
+			outcomes[i].Err = err
+			continue
+		}
+		outcomes[i].Status = status
+	}
+	return outcomes, nil
+}
+
+// SetBatchProcessor switches the workers to batch mode: dequeued events are
+// buffered in bp, which should be created with ApplyBatch as its processor.
+// Batches are guarded by a dedicated circuit breaker with the service
+// breaker's threshold and timeout; failed and shed batches are dead-lettered.
+func (s *ProductService) SetBatchProcessor(bp *queue.BatchProcessor) {
+	bp.SetCircuitBreaker(circuitbreaker.NewCircuitBreaker(s.circuitBreaker.GetThreshold(), s.circuitBreaker.GetTimeout()), s.workerPool.deadLetterFailedBatch)
+	s.batchProcessor = bp
+	s.workerPool.SetBatchProcessor(bp)
+}
+
+// BatchProcessor returns the batch processor used in batch mode, or nil
+func (s *ProductService) BatchProcessor() *queue.BatchProcessor {
+	return s.batchProcessor
+}
+
+// ApplyBatch upserts a batch of events into the repository in order, in a
+// single call when the repository supports batch updates. Events are run
+// through the transformers first; those that fail are dead-lettered. The
+// in-flight slots of the batch are released once it is done.
+func (s *ProductService) ApplyBatch(events []models.ProductEvent) error {
+	defer s.workerPool.releaseInFlightBatch(events)
+	events = s.workerPool.transformBatch(events)
+	if len(events) == 0 {
+		return nil
+	}
+
+	auditLogger := s.workerPool.auditLogger
+	var before []*models.Product
+	if auditLogger != nil {
+		before = s.batchBefore(events)
+	}
+
+	if batchRepo, ok := s.repository.(BatchProductRepository); ok {
+		batchRepo.UpdateBatch(events)
+	} else {
+		for _, event := range events {
+			s.repository.Update(event.ProductID, event.Price, event.Stock, event.Currency)
+		}
+	}
+
+	if auditLogger != nil {
+		for i, event := range events {
+			auditLogger.Record(event, before[i])
+		}
+	}
+	for _, event := range events {
+		s.workerPool.productStored(event)
+	}
+	s.workerPool.recordProcessed(int64(len(events)))
+	return nil
+}
+
+// batchBefore returns the state each event of a batch will overwrite, taking
+// earlier events in the batch for the same product into account
+func (s *ProductService) batchBefore(events []models.ProductEvent) []*models.Product {
+	before := make([]*models.Product, len(events))
+	pending := make(map[string]*models.Product)
+	for i, event := range events {
+		if product, ok := pending[event.ProductID]; ok {
+			before[i] = product
+		} else if product, exists := s.repository.Get(event.ProductID); exists {
+			before[i] = product
+		}
+		pending[event.ProductID] = &models.Product{ID: event.ProductID, Price: event.Price, Stock: event.Stock, Currency: event.Currency}
+	}
+	return before
+}
+
+// SetBatchConflictPolicy sets how batches containing duplicate products are handled
+func (s *ProductService) SetBatchConflictPolicy(policy BatchConflictPolicy) {
+	s.batchPolicy = policy
+}
+
+// RecordDrop counts an event dropped by a caller before it reached the service
+func (s *ProductService) RecordDrop(reason string) {
+	recordDrop(s.metrics, reason)
+}
+
+// Metrics returns the registry holding the service metrics
+func (s *ProductService) Metrics() *metrics.Registry {
+	return s.metrics
+}
+
+// SetMetrics replaces the registry the service and its workers record metrics into,
+// so metrics recorded outside the service share one scrape endpoint
+func (s *ProductService) SetMetrics(registry *metrics.Registry) {
+	s.metrics = registry
+	s.workerPool.SetMetrics(registry)
+	s.setProductCountGauge()
 }
 
 // GetProduct retrieves a product by ID
@@ -65,106 +646,954 @@ func (s *ProductService) GetProduct(id string) (*models.Product, bool) {
 	return s.repository.Get(id)
 }
 
+// GetProductContext retrieves a product by ID, returning ctx.Err() as soon as ctx
+// is done. Repositories without context support are waited on in a goroutine so
+// the caller is released promptly even if the lookup itself keeps running.
+func (s *ProductService) GetProductContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	if repo, ok := s.repository.(ContextProductRepository); ok {
+		return repo.GetContext(ctx, id)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	type result struct {
+		product *models.Product
+		exists  bool
+	}
+	done := make(chan result, 1)
+	go func() {
+		product, exists := s.repository.Get(id)
+		done <- result{product, exists}
+	}()
+
+	select {
+	case r := <-done:
+		return r.product, r.exists, nil
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// SetPanicPolicy sets how workers react to a panic while processing an event
+func (s *ProductService) SetPanicPolicy(policy PanicPolicy) {
+	s.workerPool.SetPanicPolicy(policy)
+}
+
+// SetDeadLetterQueue replaces the dead letter queue receiving failed events
+func (s *ProductService) SetDeadLetterQueue(dlq *queue.DeadLetterQueue) {
+	s.deadLetterQueue = dlq
+	s.workerPool.SetDeadLetterQueue(dlq)
+}
+
+// DeadLetterQueue returns the dead letter queue holding failed events
+func (s *ProductService) DeadLetterQueue() *queue.DeadLetterQueue {
+	return s.deadLetterQueue
+}
+
+// PurgeQueue discards every event waiting in the queue without applying it,
+// returning how many were purged. Events already taken by a worker or
+// buffered in the batch processor are not affected.
+func (s *ProductService) PurgeQueue() int {
+	purged := s.workerPool.purgeQueued()
+	s.workerPool.logger.Printf("WARNING: purged %d queued events", purged)
+	return purged
+}
+
+// ReplayDeadLetter re-enqueues the most recently dead-lettered event for
+// productID, returning it with its new ticket. The event stays in the dead
+// letter queue if it cannot be enqueued.
+func (s *ProductService) ReplayDeadLetter(productID string) (models.FailedEvent, int64, error) {
+	failed, ok := s.deadLetterQueue.TakeLatest(productID)
+	if !ok {
+		return models.FailedEvent{}, 0, ErrNothingToReplay
+	}
+
+	ticket, err := s.ProcessEventWithTicket(failed.Event)
+	if err != nil {
+		if restoreErr := s.deadLetterQueue.Restore(failed); restoreErr != nil {
+			s.workerPool.logger.Printf("Lost dead-lettered event for product %s after failed replay: %v", productID, restoreErr)
+		}
+		return failed, 0, err
+	}
+	return failed, ticket, nil
+}
+
+// WorkerPool returns the worker pool processing queued events
+func (s *ProductService) WorkerPool() *WorkerPool {
+	return s.workerPool
+}
+
+// Throughput returns the processed events/sec averages and refreshes their gauges
+func (s *ProductService) Throughput() ThroughputRates {
+	return s.workerPool.Throughput()
+}
+
+// RetryExhaustionRate returns the share of recent events that exhausted their retries and refreshes its gauge
+func (s *ProductService) RetryExhaustionRate() float64 {
+	return s.workerPool.RetryExhaustionRate()
+}
+
+// Queue returns the queue events are buffered in before processing
+func (s *ProductService) Queue() queue.EventQueue {
+	return s.queue
+}
+
+// RetryConfig returns the retry settings applied to each event
+func (s *ProductService) RetryConfig() *retry.RetryConfig {
+	return s.retryConfig
+}
+
+// CircuitBreaker returns the circuit breaker guarding events without a category
+func (s *ProductService) CircuitBreaker() *circuitbreaker.CircuitBreaker {
+	return s.circuitBreaker
+}
+
+// CircuitBreakers returns the per-category circuit breakers guarding event processing
+func (s *ProductService) CircuitBreakers() *circuitbreaker.CircuitBreakerGroup {
+	return s.workerPool.circuitBreakers
+}
+
+// Lifecycle states of a worker pool; a stopped pool cannot be restarted
+const (
+	poolIdle int32 = iota
+	poolRunning
+	poolStopped
+)
+
+// syncWorkerID identifies events processed on the request goroutine rather than by a worker
+const syncWorkerID = -1
+
+// drainPollInterval is how often Drain checks whether the queue has emptied
+const drainPollInterval = 10 * time.Millisecond
+
+// recentFailureLimit is how many of the latest failures the worker pool remembers
+const recentFailureLimit = 10
+
+// WorkerStats counts the outcomes of events handled by the worker pool
+type WorkerStats struct {
+	Processed int64
+	Failed    int64
+	Retries   int64
+	Exhausted int64
+}
+
 // WorkerPool manages a pool of workers for processing events
 type WorkerPool struct {
-	workers        int
-	queue          queue.EventQueue
-	repository     ProductRepository
-	circuitBreaker *circuitbreaker.CircuitBreaker
-	retryConfig    *retry.RetryConfig
-	ctx            context.Context
-	cancel         context.CancelFunc
-	wg             sync.WaitGroup
-	logger         *log.Logger
+	workers         int
+	queue           queue.EventQueue
+	repository      ProductRepository
+	circuitBreakers *circuitbreaker.CircuitBreakerGroup
+	retryConfig     *retry.RetryConfig
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+	logger          *log.Logger
+
+	deadLetterQueue *queue.DeadLetterQueue
+	panicPolicy     PanicPolicy
+	metrics         *metrics.Registry
+	loadShedder     *LoadShedder
+	coalescer       *Coalescer
+	batchProcessor  *queue.BatchProcessor
+	throughput      *RateMeter
+	exhaustions     *exhaustionWindow
+	productLimiter  *ProductLimiter
+	inFlightLimiter *InFlightLimiter
+	deduplicator    *Deduplicator
+	chaos           *ChaosInjector
+	throttle        *ratelimit.Limiter
+	transformers    []EventTransformer
+	auditLogger     *AuditLogger
+	productLock     *ProductLock
+
+	// retryCtx aborts the backoff between processing attempts once done
+	retryCtx context.Context
+
+	// Per-worker cancellation so the pool can be resized while running
+	mu            sync.Mutex
+	workerCancels []context.CancelFunc
+	nextWorkerID  int
+	alive         int64
+	state         int32
+	strictFIFO    bool
+
+	// processTimeout bounds each processing attempt; zero means unbounded
+	processTimeout int64 // time.Duration, accessed atomically
+
+	// Outcome counters and the latest failures, for diagnostics
+	processed      int64
+	lastProcessed  int64
+	activity       *workerActivityRegistry
+	startedAt      int64
+	failed         int64
+	retries        int64
+	exhausted      int64
+	failuresMu     sync.Mutex
+	recentFailures []models.FailedEvent
 }
 
 // NewWorkerPool creates a new worker pool
+// A non-positive worker count would leave events queued forever, so it is
+// clamped to a single worker with a warning.
 func NewWorkerPool(workers int, eventQueue queue.EventQueue, repo ProductRepository, cb *circuitbreaker.CircuitBreaker, rc *retry.RetryConfig) *WorkerPool {
+	logger := log.New(os.Stdout, "[WORKER] ", log.LstdFlags)
+	if workers < 1 {
+		logger.Printf("WARNING: invalid worker count %d, defaulting to 1 worker", workers)
+		workers = 1
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &WorkerPool{
-		workers:        workers,
-		queue:          eventQueue,
-		repository:     repo,
-		circuitBreaker: cb,
-		retryConfig:    rc,
-		ctx:            ctx,
-		cancel:         cancel,
-		logger:         log.New(os.Stdout, "[WORKER] ", log.LstdFlags),
+		workers:         workers,
+		queue:           eventQueue,
+		repository:      repo,
+		circuitBreakers: circuitbreaker.NewCircuitBreakerGroup(cb),
+		retryConfig:     rc,
+		ctx:             ctx,
+		cancel:          cancel,
+		logger:          logger,
+		panicPolicy:     PanicPolicyRecover,
+		metrics:         metrics.NewRegistry(),
+		throughput:      NewRateMeter(),
+		exhaustions:     newExhaustionWindow(ExhaustionWindowSize),
+		retryCtx:        context.Background(),
+		activity:        newWorkerActivityRegistry(),
+	}
+}
+
+// SetLoadShedder sets the load shedder consulted before processing each event
+func (wp *WorkerPool) SetLoadShedder(shedder *LoadShedder) {
+	wp.loadShedder = shedder
+}
+
+// SetCoalescer sets the coalescer consulted before processing each event
+func (wp *WorkerPool) SetCoalescer(coalescer *Coalescer) {
+	wp.coalescer = coalescer
+}
+
+// SetProductLimiter sets the product limit whose slots are released for events that are never applied
+func (wp *WorkerPool) SetProductLimiter(limiter *ProductLimiter) {
+	wp.productLimiter = limiter
+}
+
+// SetDeduplicator sets the deduplicator whose entries are released for events that are never applied
+func (wp *WorkerPool) SetDeduplicator(deduplicator *Deduplicator) {
+	wp.deduplicator = deduplicator
+}
+
+// SetInFlightLimiter sets the in-flight limit whose slots are released as workers finish events
+func (wp *WorkerPool) SetInFlightLimiter(limiter *InFlightLimiter) {
+	wp.inFlightLimiter = limiter
+}
+
+// SetThrottle paces the workers so that together they start events no faster than the limiter allows
+func (wp *WorkerPool) SetThrottle(limiter *ratelimit.Limiter) {
+	wp.throttle = limiter
+}
+
+// SetChaos makes every processing attempt pass through the chaos injector first
+func (wp *WorkerPool) SetChaos(chaos *ChaosInjector) {
+	wp.chaos = chaos
+}
+
+// SetStrictFIFO forces a single worker and disables load shedding so events
+// are applied in exactly the order they were enqueued; call before Start
+func (wp *WorkerPool) SetStrictFIFO(enabled bool) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.strictFIFO = enabled
+	if enabled {
+		wp.workers = 1
 	}
 }
 
-// Start starts all workers
+// SetProductLock serializes applying events for the same product; call before Start
+func (wp *WorkerPool) SetProductLock(lock *ProductLock) {
+	wp.productLock = lock
+}
+
+// SetProcessTimeout bounds each processing attempt; an attempt exceeding it
+// fails with a TimeoutError and is retried or dead-lettered like any failure
+func (wp *WorkerPool) SetProcessTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&wp.processTimeout, int64(timeout))
+}
+
+// SetBatchProcessor makes workers hand events to bp instead of applying them one at a time
+func (wp *WorkerPool) SetBatchProcessor(bp *queue.BatchProcessor) {
+	wp.batchProcessor = bp
+}
+
+// SetAuditLogger sets the audit log recording every mutation the workers apply
+func (wp *WorkerPool) SetAuditLogger(auditLogger *AuditLogger) {
+	wp.auditLogger = auditLogger
+}
+
+// SetEventTransformers sets the transformers applied, in order, to each event before it is applied
+func (wp *WorkerPool) SetEventTransformers(transformers ...EventTransformer) {
+	wp.transformers = transformers
+}
+
+// transform runs an event through the transformers, stopping at the first error
+func (wp *WorkerPool) transform(event models.ProductEvent) (models.ProductEvent, error) {
+	for i, transformer := range wp.transformers {
+		transformed, err := transformer(event)
+		if err != nil {
+			return event, fmt.Errorf("transform %d failed: %w", i+1, err)
+		}
+		event = transformed
+	}
+	return event, nil
+}
+
+// transformBatch transforms each event of a batch, dead-lettering those that
+// fail as they were accepted and returning the rest
+func (wp *WorkerPool) transformBatch(events []models.ProductEvent) []models.ProductEvent {
+	if len(wp.transformers) == 0 {
+		return events
+	}
+	transformed := make([]models.ProductEvent, 0, len(events))
+	for _, event := range events {
+		t, err := wp.transform(event)
+		if err != nil {
+			wp.logger.Printf("Failed to transform batched event for product %s: %v", event.ProductID, err)
+			wp.deadLetter(event, err, 0)
+			continue
+		}
+		transformed = append(transformed, t)
+	}
+	return transformed
+}
+
+// SetMetrics sets the registry the worker pool records metrics into
+func (wp *WorkerPool) SetMetrics(registry *metrics.Registry) {
+	wp.metrics = registry
+}
+
+// SetPanicPolicy sets how workers react to a panic while processing an event
+func (wp *WorkerPool) SetPanicPolicy(policy PanicPolicy) {
+	wp.panicPolicy = policy
+}
+
+// SetDeadLetterQueue sets the dead letter queue receiving failed events
+func (wp *WorkerPool) SetDeadLetterQueue(dlq *queue.DeadLetterQueue) {
+	wp.deadLetterQueue = dlq
+}
+
+// Start starts all workers; it is a no-op if the pool is already running or stopped
 func (wp *WorkerPool) Start() {
+	if !atomic.CompareAndSwapInt32(&wp.state, poolIdle, poolRunning) {
+		wp.logger.Println("Worker pool already started, ignoring Start")
+		return
+	}
+	atomic.StoreInt64(&wp.startedAt, time.Now().UnixNano())
+
+	wp.mu.Lock()
 	for i := 0; i < wp.workers; i++ {
-		wp.wg.Add(1)
-		go wp.worker(i)
+		wp.startWorkerLocked()
 	}
+	wp.mu.Unlock()
 	wp.logger.Printf("Started %d workers", wp.workers)
 }
 
-// Stop gracefully stops all workers
+// Stop gracefully stops all workers; it is a no-op if the pool is already stopped
 func (wp *WorkerPool) Stop() {
+	wp.StopBy(time.Time{})
+}
+
+// StopBy stops all workers, waiting for them to finish their current events
+// until deadline, or indefinitely for a zero deadline. It reports whether
+// they all finished; workers still busy are left to exit on their own.
+func (wp *WorkerPool) StopBy(deadline time.Time) bool {
+	return wp.stopBy(deadline, nil)
+}
+
+// stopBy is StopBy, calling afterCancel, if set, once the workers have been
+// cancelled but before waiting for them to exit
+func (wp *WorkerPool) stopBy(deadline time.Time, afterCancel func()) bool {
+	if !atomic.CompareAndSwapInt32(&wp.state, poolRunning, poolStopped) &&
+		!atomic.CompareAndSwapInt32(&wp.state, poolIdle, poolStopped) {
+		return true
+	}
+
 	wp.logger.Println("Stopping workers...")
 	wp.cancel()
-	wp.wg.Wait()
-	wp.logger.Println("All workers stopped")
+	if afterCancel != nil {
+		afterCancel()
+	}
+	if deadline.IsZero() {
+		wp.wg.Wait()
+		wp.logger.Println("All workers stopped")
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		wp.logger.Println("All workers stopped")
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Running reports whether the pool has been started and not yet stopped
+func (wp *WorkerPool) Running() bool {
+	return atomic.LoadInt32(&wp.state) == poolRunning
+}
+
+// Drain waits until the workers have emptied the queue. It returns at once
+// if no workers are running, since nothing would ever drain it.
+func (wp *WorkerPool) Drain() {
+	wp.DrainBy(time.Time{})
+}
+
+// DrainBy waits like Drain but gives up at deadline, or never for a zero
+// deadline. It reports false if events were still queued when it gave up.
+func (wp *WorkerPool) DrainBy(deadline time.Time) bool {
+	for atomic.LoadInt32(&wp.state) == poolRunning && wp.WorkerCount() > 0 && wp.queue.Len() > 0 {
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+	return true
+}
+
+// deadLetterQueued moves every event still in the queue to the dead letter
+// queue with err, returning how many were moved
+func (wp *WorkerPool) deadLetterQueued(err error) int {
+	moved := 0
+	for wp.queue.Len() > 0 {
+		// A worker finishing up may take the last event first, so never block for long
+		ctx, cancel := context.WithTimeout(context.Background(), drainPollInterval)
+		event, ok := wp.queue.DequeueWithContext(ctx)
+		cancel()
+		if !ok {
+			continue
+		}
+		wp.deadLetter(event, err, 0)
+		wp.releaseInFlight()
+		moved++
+	}
+	return moved
+}
+
+// purgeQueued discards every event still in the queue, counting each as
+// dropped, and returns how many were discarded
+func (wp *WorkerPool) purgeQueued() int {
+	purged := 0
+	for wp.queue.Len() > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), drainPollInterval)
+		event, ok := wp.queue.DequeueWithContext(ctx)
+		cancel()
+		if !ok {
+			continue
+		}
+		recordDrop(wp.metrics, DropReasonPurged)
+		wp.releaseProduct(event)
+		wp.releaseDuplicate(event)
+		wp.releaseInFlight()
+		purged++
+	}
+	return purged
+}
+
+// Resize grows or shrinks the number of running workers. Workers being
+// removed finish the event they are processing before exiting.
+func (wp *WorkerPool) Resize(workers int) {
+	if workers < 0 {
+		workers = 0
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.strictFIFO && workers > 1 {
+		// A second worker could apply events out of order
+		workers = 1
+	}
+
+	current := len(wp.workerCancels)
+	for i := current; i < workers; i++ {
+		wp.startWorkerLocked()
+	}
+	for i := current; i > workers; i-- {
+		wp.workerCancels[i-1]()
+		wp.workerCancels = wp.workerCancels[:i-1]
+	}
+
+	if workers != current {
+		wp.logger.Printf("Resized worker pool from %d to %d workers", current, workers)
+	}
+}
+
+// WorkerCount returns the number of running workers
+func (wp *WorkerPool) WorkerCount() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return len(wp.workerCancels)
+}
+
+// Throughput returns the processed events/sec averages and publishes them as gauges
+func (wp *WorkerPool) Throughput() ThroughputRates {
+	rates := wp.throughput.Rates()
+	for window, rate := range map[string]float64{
+		RateWindow1m:  rates.OneMinute,
+		RateWindow5m:  rates.FiveMinute,
+		RateWindow15m: rates.FifteenMinute,
+	} {
+		wp.metrics.Gauge(metricEventsProcessedRate, "Exponentially weighted processed events per second",
+			metrics.Labels{"window": window}).Set(rate)
+	}
+	return rates
+}
+
+// Stats returns the outcome counters of the worker pool
+func (wp *WorkerPool) Stats() WorkerStats {
+	return WorkerStats{
+		Processed: atomic.LoadInt64(&wp.processed),
+		Failed:    atomic.LoadInt64(&wp.failed),
+		Retries:   atomic.LoadInt64(&wp.retries),
+		Exhausted: atomic.LoadInt64(&wp.exhausted),
+	}
+}
+
+// RecentFailures returns the latest failed events, oldest first
+func (wp *WorkerPool) RecentFailures() []models.FailedEvent {
+	wp.failuresMu.Lock()
+	defer wp.failuresMu.Unlock()
+
+	failures := make([]models.FailedEvent, len(wp.recentFailures))
+	copy(failures, wp.recentFailures)
+	return failures
+}
+
+// recordProcessed counts n successfully applied events
+func (wp *WorkerPool) recordProcessed(n int64) {
+	atomic.AddInt64(&wp.processed, n)
+	atomic.StoreInt64(&wp.lastProcessed, time.Now().UnixNano())
+	wp.throughput.Mark(n)
+	wp.exhaustions.record(false, n)
+}
+
+// recordExhausted counts an event that failed after all its retries
+func (wp *WorkerPool) recordExhausted() {
+	atomic.AddInt64(&wp.exhausted, 1)
+	wp.exhaustions.record(true, 1)
+}
+
+// RetryExhaustionRate returns the share of the latest ExhaustionWindowSize
+// processing outcomes that exhausted their retries, and publishes it as a gauge
+func (wp *WorkerPool) RetryExhaustionRate() float64 {
+	rate := wp.exhaustions.rate()
+	wp.metrics.Gauge(metricRetryExhaustionRate, "Share of recent events that failed after exhausting their retries", nil).Set(rate)
+	return rate
+}
+
+// LastProcessedAt returns when an event was last applied, or the zero time if none has been
+func (wp *WorkerPool) LastProcessedAt() time.Time {
+	return unixNanoTime(atomic.LoadInt64(&wp.lastProcessed))
+}
+
+// Stalled reports whether events are waiting but none has been applied for
+// longer than threshold, counting from Start if nothing was ever applied
+func (wp *WorkerPool) Stalled(threshold time.Duration) bool {
+	if wp.queue.Len() == 0 {
+		return false
+	}
+
+	since := wp.LastProcessedAt()
+	if since.IsZero() {
+		since = unixNanoTime(atomic.LoadInt64(&wp.startedAt))
+	}
+	return !since.IsZero() && time.Since(since) > threshold
+}
+
+// unixNanoTime converts a stored timestamp back to a time, mapping 0 to the zero time
+func unixNanoTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// recordFailure counts a failed event and remembers it among the recent failures
+func (wp *WorkerPool) recordFailure(event models.ProductEvent, err error, retryCount int) {
+	atomic.AddInt64(&wp.failed, 1)
+
+	failed := models.FailedEvent{Event: event, Timestamp: time.Now(), RetryCount: retryCount}
+	if err != nil {
+		failed.Error = err.Error()
+	}
+
+	wp.failuresMu.Lock()
+	defer wp.failuresMu.Unlock()
+	wp.recentFailures = append(wp.recentFailures, failed)
+	if len(wp.recentFailures) > recentFailureLimit {
+		wp.recentFailures = wp.recentFailures[len(wp.recentFailures)-recentFailureLimit:]
+	}
+}
+
+// Activity returns what each running worker is doing, ordered by worker ID.
+// Per-worker counts cover events applied one at a time, not batch flushes.
+func (wp *WorkerPool) Activity() []WorkerActivity {
+	return wp.activity.snapshot()
+}
+
+// AliveWorkers returns the number of worker goroutines currently running
+func (wp *WorkerPool) AliveWorkers() int {
+	return int(atomic.LoadInt64(&wp.alive))
+}
+
+// startWorkerLocked launches a single worker; the caller must hold wp.mu
+func (wp *WorkerPool) startWorkerLocked() {
+	ctx, cancel := context.WithCancel(wp.ctx)
+	wp.workerCancels = append(wp.workerCancels, cancel)
+
+	id := wp.nextWorkerID
+	wp.nextWorkerID++
+
+	wp.wg.Add(1)
+	go wp.worker(ctx, id)
 }
 
 // worker processes events from the queue
-func (wp *WorkerPool) worker(id int) {
+func (wp *WorkerPool) worker(ctx context.Context, id int) {
 	defer wp.wg.Done()
+	atomic.AddInt64(&wp.alive, 1)
+	defer atomic.AddInt64(&wp.alive, -1)
+	wp.activity.register(id)
+	defer wp.activity.unregister(id)
 	wp.logger.Printf("Worker %d started", id)
 
 	for {
-		select {
-		case <-wp.ctx.Done():
-			wp.logger.Printf("Worker %d stopping", id)
-			return
-		default:
-			event, ok := wp.queue.Dequeue()
-			if !ok {
+		event, ok := wp.queue.DequeueWithContext(ctx)
+		if !ok {
+			if ctx.Err() != nil {
+				wp.logger.Printf("Worker %d stopping", id)
+			} else {
 				// Channel closed, exit
 				wp.logger.Printf("Worker %d: queue closed, exiting", id)
-				return
 			}
+			return
+		}
 
-			wp.processEvent(event, id)
+		if wp.throttle != nil {
+			// A worker stopped while waiting still processes the event it holds
+			wp.throttle.Wait(ctx)
 		}
+		wp.processEvent(event, id)
 	}
 }
 
 // processEvent processes a single product event with retry and error handling
 func (wp *WorkerPool) processEvent(event models.ProductEvent, workerID int) {
-	wp.logger.Printf("Worker %d processing event for product %s", workerID, event.ProductID)
+	// Continue the trace of the request that accepted the event
+	_, span := tracing.Tracer().Start(tracing.ExtractEvent(context.Background(), event), "processEvent",
+		trace.WithAttributes(attribute.String("product.id", event.ProductID), attribute.Int("worker.id", workerID)))
+	defer span.End()
 
-	// Process with retry and circuit breaker
-	err := wp.retryConfig.ExecuteWithRetryAndCallback(
-		func() error {
-			return wp.circuitBreaker.Execute(func() error {
-				// Simulate some processing time
-				time.Sleep(10 * time.Millisecond)
+	applied := false
+	wp.activity.begin(workerID, event)
+	defer func() { wp.activity.finish(workerID, applied) }()
+	// A batched event keeps its slot until its batch is applied or dead-lettered
+	batched := false
+	defer func() {
+		if !batched {
+			wp.releaseInFlight()
+		}
+	}()
 
-				// Update the product repository
-				wp.repository.Update(event.ProductID, event.Price, event.Stock)
+	if wp.loadShedder != nil && !wp.strictFIFO && wp.loadShedder.ShouldShed(event, wp.queue.Len()) {
+		recordDrop(wp.metrics, DropReasonLoadShed)
+		wp.releaseProduct(event)
+		return
+	}
+	if wp.coalescer != nil && !wp.strictFIFO && wp.coalescer.Superseded(wp.ctx, event) {
+		recordDrop(wp.metrics, DropReasonCoalesced)
+		wp.releaseProduct(event)
+		return
+	}
 
-				wp.logger.Printf("Worker %d updated product %s: price=%.2f, stock=%d",
-					workerID, event.ProductID, event.Price, event.Stock)
+	// Batched as accepted and transformed as the batch is applied, so a failed
+	// batch is dead-lettered as accepted too
+	if wp.batchProcessor != nil {
+		if err := wp.batchProcessor.AddEvent(event); err != nil {
+			wp.logger.Printf("Worker %d failed to batch event for product %s: %v", workerID, event.ProductID, err)
+			wp.deadLetter(event, err, 0)
+			return
+		}
+		batched = true
+		return
+	}
 
-				return nil
+	// Limits and the dead letter queue keep tracking the event as it was accepted
+	transformed, err := wp.transform(event)
+	if err != nil {
+		wp.logger.Printf("Worker %d failed to transform event for product %s: %v", workerID, event.ProductID, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wp.deadLetter(event, err, 0)
+		return
+	}
+
+	if logging.Enabled(logging.LevelDebug) {
+		wp.logger.Printf("Worker %d processing event for product %s", workerID, event.ProductID)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if wp.panicPolicy == PanicPolicyCrash {
+				wp.logger.Printf("Worker %d panicked processing product %s, crashing: %v", workerID, event.ProductID, r)
+				panic(r)
+			}
+
+			wp.logger.Printf("Worker %d recovered from panic processing product %s: %v\n%s",
+				workerID, event.ProductID, r, debug.Stack())
+			span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", r))
+			wp.deadLetter(event, fmt.Errorf("panic: %v", r), 0)
+		}
+	}()
+
+	err = wp.execute(transformed, workerID)
+	var aborted *retry.AbortedError
+	if errors.As(err, &aborted) {
+		wp.logger.Printf("Worker %d abandoned retrying product %s after %d attempts on shutdown: %v",
+			workerID, event.ProductID, aborted.Attempts, aborted.LastErr)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wp.deadLetter(event, err, aborted.Attempts)
+		return
+	}
+	if err != nil {
+		// Log the final failure
+		wp.logger.Printf("Worker %d failed to process event for product %s after all retries: %v",
+			workerID, event.ProductID, err)
+
+		wp.recordExhausted()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		wp.deadLetter(event, err, wp.retryConfigFor(event).MaxAttempts)
+		return
+	}
+
+	applied = true
+	wp.productStored(event)
+	wp.recordProcessed(1)
+}
+
+// retryConfigFor returns the retry configuration for event, honoring its MaxAttempts override
+func (wp *WorkerPool) retryConfigFor(event models.ProductEvent) *retry.RetryConfig {
+	if event.MaxAttempts <= 0 {
+		return wp.retryConfig
+	}
+	rc := wp.retryConfig.Clone()
+	rc.MaxAttempts = event.MaxAttempts
+	return rc
+}
+
+// execute applies an event with retry and circuit breaker protection
+func (wp *WorkerPool) execute(event models.ProductEvent, workerID int) error {
+	rc := wp.retryConfigFor(event)
+	return rc.ExecuteWithRetryAndCallbackContext(wp.retryCtx,
+		func() error {
+			return wp.circuitBreakers.Execute(event.Category, func() error {
+				if wp.chaos != nil {
+					if err := wp.chaos.Inject(event); err != nil {
+						return err
+					}
+				}
+				return wp.apply(event, workerID)
 			})
 		},
 		func(attempt int, err error) {
+			if attempt < rc.MaxAttempts {
+				atomic.AddInt64(&wp.retries, 1)
+			}
 			wp.logger.Printf("Worker %d attempt %d failed for product %s: %v",
 				workerID, attempt, event.ProductID, err)
 		},
 	)
+}
 
-	if err != nil {
-		// Log the final failure
-		wp.logger.Printf("Worker %d failed to process event for product %s after all retries: %v",
-			workerID, event.ProductID, err)
+// executeSync applies an event on the caller's goroutine with the same retry
+// and circuit breaker protection as the workers, returning a panic as an error
+func (wp *WorkerPool) executeSync(event models.ProductEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			wp.logger.Printf("Recovered from panic synchronously processing product %s: %v", event.ProductID, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if err = wp.execute(event, syncWorkerID); err != nil {
+		var aborted *retry.AbortedError
+		if !errors.As(err, &aborted) {
+			wp.recordExhausted()
+		}
+		return err
+	}
+	wp.recordProcessed(1)
+	return nil
+}
+
+// apply performs the processing step for one event, bounded by the processing
+// timeout if one is set. The deadline is checked before the repository write,
+// so a timed-out step never applies the event behind the retry's back.
+func (wp *WorkerPool) apply(event models.ProductEvent, workerID int) error {
+	timeout := time.Duration(atomic.LoadInt64(&wp.processTimeout))
+	if timeout <= 0 {
+		return wp.applyUnbounded(context.Background(), event, workerID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := wp.applyUnbounded(ctx, event, workerID)
+	if errors.Is(err, context.DeadlineExceeded) {
+		wp.metrics.Counter(metricEventTimeouts, "Event processing attempts that exceeded the processing timeout", nil).Inc()
+		return apperrors.NewTimeoutError(
+			fmt.Sprintf("processing product %s exceeded %v", event.ProductID, timeout), err)
+	}
+	return err
+}
+
+// applyUnbounded updates the repository with the event unless ctx is done first
+func (wp *WorkerPool) applyUnbounded(ctx context.Context, event models.ProductEvent, workerID int) error {
+	// Simulate some processing time
+	timer := time.NewTimer(10 * time.Millisecond)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	}
+
+	// Held across the audit read too, so the recorded previous state is the one overwritten
+	if wp.productLock != nil {
+		wp.productLock.Lock(event.ProductID)
+		defer wp.productLock.Unlock(event.ProductID)
+	}
+
+	// Update the product repository
+	var before *models.Product
+	if wp.auditLogger != nil {
+		var err error
+		if before, err = wp.getContext(ctx, event.ProductID); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	wp.repository.Update(event.ProductID, event.Price, event.Stock, event.Currency)
+	if wp.auditLogger != nil {
+		wp.auditLogger.Record(event, before)
+	}
+
+	if logging.Enabled(logging.LevelDebug) {
+		wp.logger.Printf("Worker %d updated product %s: price=%.2f, stock=%d",
+			workerID, event.ProductID, event.Price, event.Stock)
+	}
+	return nil
+}
+
+// getContext reads a product, honouring ctx when the repository supports it
+func (wp *WorkerPool) getContext(ctx context.Context, id string) (*models.Product, error) {
+	if repo, ok := wp.repository.(ContextProductRepository); ok {
+		product, _, err := repo.GetContext(ctx, id)
+		return product, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	product, _ := wp.repository.Get(id)
+	return product, nil
+}
+
+// deadLetter records a failed event and routes it to the dead letter queue, if one is configured
+func (wp *WorkerPool) deadLetter(event models.ProductEvent, err error, retryCount int) {
+	wp.recordFailure(event, err, retryCount)
+	wp.releaseProduct(event)
+	wp.releaseDuplicate(event)
+
+	if wp.deadLetterQueue == nil {
+		return
+	}
+
+	if dlqErr := wp.deadLetterQueue.Add(event, err, retryCount); dlqErr != nil {
+		wp.logger.Printf("Failed to dead-letter event for product %s: %v", event.ProductID, dlqErr)
+		recordDrop(wp.metrics, DropReasonDLQFull)
+	}
+}
+
+// deadLetterBatch dead-letters a batch abandoned by the batch processor at shutdown
+func (wp *WorkerPool) deadLetterBatch(events []models.ProductEvent) {
+	for _, event := range events {
+		wp.deadLetter(event, ErrDrainTimeout, 0)
+	}
+	wp.releaseInFlightBatch(events)
+}
+
+// deadLetterFailedBatch dead-letters a batch that failed or was shed by the batch circuit breaker
+func (wp *WorkerPool) deadLetterFailedBatch(events []models.ProductEvent, err error) {
+	wp.logger.Printf("Batch of %d events not applied, moving to dead letter queue: %v", len(events), err)
+	for _, event := range events {
+		wp.deadLetter(event, err, 0)
+	}
+	wp.releaseInFlightBatch(events)
+}
+
+// observeRejection counts a processing attempt rejected by the open circuit
+// breaker, warning only on the first rejection after each trip to avoid log spam
+func (wp *WorkerPool) observeRejection(firstSinceOpen bool) {
+	wp.metrics.Counter(metricCBRejections, "Processing attempts rejected while the circuit breaker was open", nil).Inc()
+	if firstSinceOpen && logging.Enabled(logging.LevelWarn) {
+		wp.logger.Println("WARNING: circuit breaker is open, rejecting processing attempts until it half-opens")
+	}
+}
+
+// releaseInFlight frees the in-flight slot held by an event the worker pool is done with
+func (wp *WorkerPool) releaseInFlight() {
+	if wp.inFlightLimiter != nil {
+		wp.inFlightLimiter.Release()
+	}
+}
+
+// releaseInFlightBatch frees the in-flight slots held by a batch the worker pool is done with
+func (wp *WorkerPool) releaseInFlightBatch(events []models.ProductEvent) {
+	for range events {
+		wp.releaseInFlight()
+	}
+}
+
+// releaseProduct frees the product limit slot held by an event that will not be applied
+func (wp *WorkerPool) releaseProduct(event models.ProductEvent) {
+	if wp.productLimiter != nil {
+		wp.productLimiter.Release(event.ProductID)
+	}
+}
+
+// productStored hands the product limit slot held by an applied event over to the repository count
+func (wp *WorkerPool) productStored(event models.ProductEvent) {
+	if wp.productLimiter != nil {
+		wp.productLimiter.Stored(event.ProductID)
+	}
+}
 
-		// In a production system, you would send this to a dead letter queue
-		// or persistent storage for later analysis
+// releaseDuplicate forgets an event that will not be applied as its product's
+// last accepted one, so resending it is not skipped as a duplicate
+func (wp *WorkerPool) releaseDuplicate(event models.ProductEvent) {
+	if wp.deduplicator != nil {
+		wp.deduplicator.Release(event)
 	}
 }
@@ -1,15 +1,26 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"product-service/internal/models"
+	"product-service/pkg/circuitbreaker"
+	"product-service/pkg/queue"
+	"product-service/pkg/ratelimit"
+	"product-service/pkg/retry"
 )
 
 // MockProductRepository for testing
 type MockProductRepository struct {
+	mu       sync.Mutex
 	products map[string]*models.Product
 }
 
@@ -20,15 +31,20 @@ func NewMockProductRepository() *MockProductRepository {
 }
 
 func (m *MockProductRepository) Get(id string) (*models.Product, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	product, exists := m.products[id]
 	return product, exists
 }
 
-func (m *MockProductRepository) Update(id string, price float64, stock int) {
+func (m *MockProductRepository) Update(id string, price float64, stock int, currency string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.products[id] = &models.Product{
-		ID:    id,
-		Price: price,
-		Stock: stock,
+		ID:       id,
+		Price:    price,
+		Stock:    stock,
+		Currency: currency,
 	}
 }
 
@@ -36,6 +52,7 @@ func (m *MockProductRepository) Update(id string, price float64, stock int) {
 type MockEventQueue struct {
 	events chan models.ProductEvent
 	closed bool
+	ticket int64
 }
 
 func NewMockEventQueue(bufferSize int) *MockEventQueue {
@@ -46,14 +63,19 @@ func NewMockEventQueue(bufferSize int) *MockEventQueue {
 }
 
 func (m *MockEventQueue) Enqueue(event models.ProductEvent) error {
+	_, err := m.EnqueueWithTicket(event)
+	return err
+}
+
+func (m *MockEventQueue) EnqueueWithTicket(event models.ProductEvent) (int64, error) {
 	if m.closed {
-		return errors.New("queue is closed")
+		return 0, queue.ErrQueueClosed
 	}
 	select {
 	case m.events <- event:
-		return nil
+		return atomic.AddInt64(&m.ticket, 1), nil
 	default:
-		return errors.New("queue is full")
+		return 0, queue.ErrQueueFull
 	}
 }
 
@@ -66,6 +88,23 @@ func (m *MockEventQueue) Dequeue() (models.ProductEvent, bool) {
 	}
 }
 
+func (m *MockEventQueue) DequeueWithContext(ctx context.Context) (models.ProductEvent, bool) {
+	select {
+	case event, ok := <-m.events:
+		return event, ok
+	case <-ctx.Done():
+		return models.ProductEvent{}, false
+	}
+}
+
+func (m *MockEventQueue) Len() int {
+	return len(m.events)
+}
+
+func (m *MockEventQueue) Cap() int {
+	return cap(m.events)
+}
+
 func (m *MockEventQueue) Close() {
 	close(m.events)
 	m.closed = true
@@ -106,6 +145,80 @@ func TestProductService_ProcessEvent(t *testing.T) {
 	})
 }
 
+func TestProductService_QueueFullDoesNotTripBreaker(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(1), 1)
+	service.SetEnqueueRetry(&retry.RetryConfig{MaxAttempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1})
+
+	if err := service.ProcessEvent(models.ProductEvent{ProductID: "fill", Price: 1.0, Stock: 1}); err != nil {
+		t.Fatalf("Expected no error filling the queue, got %v", err)
+	}
+
+	threshold := service.CircuitBreaker().GetThreshold()
+	for i := 0; i < threshold*3; i++ {
+		err := service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("p%d", i), Price: 1.0, Stock: 1})
+		if !errors.Is(err, queue.ErrQueueFull) {
+			t.Fatalf("Expected ErrQueueFull, got %v", err)
+		}
+	}
+
+	if state := service.CircuitBreaker().GetState(); state != circuitbreaker.Closed {
+		t.Errorf("Expected circuit breaker to stay closed, got %s", state)
+	}
+	if failures := service.CircuitBreaker().GetFailureCount(); failures != 0 {
+		t.Errorf("Expected no breaker failures from backpressure, got %d", failures)
+	}
+}
+
+func TestProductService_IndependentRetryConfigs(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(1), 1)
+
+	service.RetryConfig().MaxAttempts = 7
+	if attempts := service.EnqueueRetry().MaxAttempts; attempts != 3 {
+		t.Errorf("Expected enqueue MaxAttempts to stay 3, got %d", attempts)
+	}
+
+	enqueueRetry := service.EnqueueRetry()
+	enqueueRetry.MaxAttempts = 1
+	service.SetEnqueueRetry(enqueueRetry)
+	enqueueRetry.MaxAttempts = 9
+
+	if attempts := service.EnqueueRetry().MaxAttempts; attempts != 1 {
+		t.Errorf("Expected enqueue MaxAttempts 1, got %d", attempts)
+	}
+	if attempts := service.RetryConfig().MaxAttempts; attempts != 7 {
+		t.Errorf("Expected processing MaxAttempts to stay 7, got %d", attempts)
+	}
+}
+
+func TestWorkerPool_MaxAttemptsOverride(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(10), 1)
+	service.RetryConfig().InitialDelay = time.Millisecond
+	service.RetryConfig().MaxDelay = time.Millisecond
+	service.CircuitBreakers().SetThreshold(100)
+	chaos, _ := NewChaosInjector(1, 0)
+	service.SetChaos(chaos)
+	pool := service.WorkerPool()
+
+	attempts := func(event models.ProductEvent) int64 {
+		before := pool.Stats().Retries
+		if err := pool.execute(event, 0); err == nil {
+			t.Fatalf("Expected every attempt to fail")
+		}
+		// Retries count every failed attempt but the last
+		return pool.Stats().Retries - before + 1
+	}
+
+	if n := attempts(models.ProductEvent{ProductID: "default", Price: 1.0, Stock: 1}); n != 3 {
+		t.Errorf("Expected the default 3 attempts, got %d", n)
+	}
+	if n := attempts(models.ProductEvent{ProductID: "important", Price: 1.0, Stock: 1, MaxAttempts: 6}); n != 6 {
+		t.Errorf("Expected 6 attempts for the override, got %d", n)
+	}
+	if service.RetryConfig().MaxAttempts != 3 {
+		t.Errorf("Expected the shared retry config to stay at 3 attempts, got %d", service.RetryConfig().MaxAttempts)
+	}
+}
+
 func TestProductService_GetProduct(t *testing.T) {
 	repo := NewMockProductRepository()
 	eventQueue := NewMockEventQueue(10)
@@ -113,7 +226,7 @@ func TestProductService_GetProduct(t *testing.T) {
 
 	t.Run("GetProduct_Exists", func(t *testing.T) {
 		// Add a product directly to repository
-		repo.Update("test-product", 99.99, 50)
+		repo.Update("test-product", 99.99, 50, "")
 
 		product, exists := service.GetProduct("test-product")
 		if !exists {
@@ -196,3 +309,525 @@ func TestWorkerPool_StartStop(t *testing.T) {
 		}
 	})
 }
+
+func TestNewProductService_NonPositiveWorkers(t *testing.T) {
+	for _, workers := range []int{0, -2} {
+		repo := NewMockProductRepository()
+		eventQueue := NewMockEventQueue(10)
+		service := NewProductService(repo, eventQueue, workers)
+
+		service.Start()
+
+		if count := service.WorkerPool().WorkerCount(); count != 1 {
+			t.Errorf("Expected %d workers to be clamped to 1, got %d", workers, count)
+		}
+
+		// The clamped worker must actually drain the queue
+		service.ProcessEvent(models.ProductEvent{ProductID: "clamped", Price: 1.0, Stock: 1})
+		time.Sleep(50 * time.Millisecond)
+
+		if _, exists := service.GetProduct("clamped"); !exists {
+			t.Errorf("Expected event to be processed with %d configured workers", workers)
+		}
+
+		service.Stop()
+	}
+}
+
+func TestWorkerPool_BatchMode(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+
+	bp := queue.NewBatchProcessor(10, time.Hour, 10, 1, service.ApplyBatch)
+	defer bp.Stop()
+	service.SetBatchProcessor(bp)
+
+	service.Start()
+	defer service.Stop()
+
+	service.ProcessEvent(models.ProductEvent{ProductID: "batch-mode", Price: 3.0, Stock: 3})
+	time.Sleep(50 * time.Millisecond)
+
+	// Below the batch size the event waits in the batch processor
+	if _, exists := service.GetProduct("batch-mode"); exists {
+		t.Error("Expected event to be buffered until the batch flushes")
+	}
+	if bp.GetPendingEvents() != 1 {
+		t.Errorf("Expected 1 pending event, got %d", bp.GetPendingEvents())
+	}
+
+	bp.Flush()
+	if _, exists := service.GetProduct("batch-mode"); !exists {
+		t.Error("Expected event to be applied after flush")
+	}
+}
+
+func TestWorkerPool_BatchModeCircuitBreaker(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+	service.CircuitBreaker().SetThreshold(2)
+
+	processErr := errors.New("batch store unavailable")
+	bp := queue.NewBatchProcessor(10, time.Hour, 10, 1, func(events []models.ProductEvent) error {
+		return processErr
+	})
+	defer bp.Stop()
+	service.SetBatchProcessor(bp)
+
+	if bp.CircuitBreaker() == nil {
+		t.Fatal("Expected batch processor to be guarded by a circuit breaker")
+	}
+	if bp.CircuitBreaker() == service.CircuitBreaker() {
+		t.Error("Expected batches to use a dedicated circuit breaker")
+	}
+	if threshold := bp.CircuitBreaker().GetThreshold(); threshold != 2 {
+		t.Errorf("Expected batch breaker to inherit threshold 2, got %d", threshold)
+	}
+
+	for i := 0; i < 3; i++ {
+		bp.AddEvent(models.ProductEvent{ProductID: fmt.Sprintf("batch-%d", i), Price: 1.0, Stock: 1})
+		bp.Flush()
+	}
+
+	if state := bp.CircuitBreaker().GetState(); state != circuitbreaker.Open {
+		t.Errorf("Expected batch breaker to open, got %v", state)
+	}
+	if depth := service.DeadLetterQueue().Len(); depth != 3 {
+		t.Fatalf("Expected 3 dead-lettered events, got %d", depth)
+	}
+	failed := service.DeadLetterQueue().List()
+	if failed[2].Error != circuitbreaker.ErrOpen.Error() {
+		t.Errorf("Expected the shed batch to be dead-lettered with %q, got %q", circuitbreaker.ErrOpen, failed[2].Error)
+	}
+}
+
+func TestProductService_IdempotentStartStop(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 2)
+
+	t.Run("StartTwice", func(t *testing.T) {
+		service.Start()
+		service.Start()
+		time.Sleep(20 * time.Millisecond)
+
+		if count := service.WorkerPool().WorkerCount(); count != 2 {
+			t.Errorf("Expected 2 workers after starting twice, got %d", count)
+		}
+		if alive := service.WorkerPool().AliveWorkers(); alive != 2 {
+			t.Errorf("Expected 2 worker goroutines after starting twice, got %d", alive)
+		}
+	})
+
+	t.Run("StopTwice", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("Expected second Stop not to panic, got %v", r)
+			}
+		}()
+
+		service.Stop()
+		service.Stop()
+
+		if alive := service.WorkerPool().AliveWorkers(); alive != 0 {
+			t.Errorf("Expected no workers after stopping, got %d", alive)
+		}
+	})
+
+	t.Run("StartAfterStop", func(t *testing.T) {
+		service.Start()
+		time.Sleep(20 * time.Millisecond)
+
+		if alive := service.WorkerPool().AliveWorkers(); alive != 0 {
+			t.Errorf("Expected a stopped service to stay stopped, got %d workers", alive)
+		}
+	})
+}
+
+func TestProductService_StopFlushesBatchMode(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(100)
+	service := NewProductService(repo, eventQueue, 2)
+
+	// The flush interval never fires, so only Stop can apply the final partial batch
+	bp := queue.NewBatchProcessor(4, time.Hour, 10, 1, service.ApplyBatch)
+	service.SetBatchProcessor(bp)
+	service.Start()
+
+	const events = 10
+	for i := 0; i < events; i++ {
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("stop-%d", i), Price: 1.0, Stock: i}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	service.Stop()
+
+	for i := 0; i < events; i++ {
+		if _, exists := repo.Get(fmt.Sprintf("stop-%d", i)); !exists {
+			t.Errorf("Expected stop-%d to be applied by the time Stop returns", i)
+		}
+	}
+	if eventQueue.Len() != 0 {
+		t.Errorf("Expected queue to be drained, got depth %d", eventQueue.Len())
+	}
+
+	if err := service.ProcessEvent(models.ProductEvent{ProductID: "late", Price: 1.0, Stock: 1}); !errors.Is(err, queue.ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed after Stop, got %v", err)
+	}
+}
+
+func TestProductService_StopDrainTimeout_BatchMode(t *testing.T) {
+	repo := &SlowProductRepository{MockProductRepository: NewMockProductRepository(), delay: 50 * time.Millisecond}
+	eventQueue := NewMockEventQueue(100)
+	service := NewProductService(repo, eventQueue, 1)
+	dlq := queue.NewDeadLetterQueue(100)
+	service.SetDeadLetterQueue(dlq)
+	service.SetDrainTimeout(100 * time.Millisecond)
+
+	// Each batch takes 200ms to apply, so the drain timeout passes with full
+	// batches waiting and a partial batch still buffered
+	bp := queue.NewBatchProcessor(4, time.Hour, 10, 1, service.ApplyBatch)
+	service.SetBatchProcessor(bp)
+	service.Start()
+
+	const events = 10
+	for i := 0; i < events; i++ {
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("buffered-%d", i), Price: 1.0, Stock: i}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	service.Stop()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Stop to give up after the drain timeout, took %v", elapsed)
+	}
+
+	// The batch being applied at the deadline finishes in the background
+	time.Sleep(300 * time.Millisecond)
+
+	deadLettered := make(map[string]bool)
+	for _, entry := range dlq.List() {
+		deadLettered[entry.Event.ProductID] = true
+		if entry.Error != ErrDrainTimeout.Error() {
+			t.Errorf("Expected %q, got %q", ErrDrainTimeout.Error(), entry.Error)
+		}
+	}
+	if len(deadLettered) == 0 {
+		t.Error("Expected the unflushed events to be dead-lettered")
+	}
+	for i := 0; i < events; i++ {
+		id := fmt.Sprintf("buffered-%d", i)
+		if _, applied := repo.Get(id); !applied && !deadLettered[id] {
+			t.Errorf("Expected %s to be applied or dead-lettered", id)
+		}
+	}
+}
+
+func TestProductService_StopDrainTimeout(t *testing.T) {
+	repo := &SlowProductRepository{MockProductRepository: NewMockProductRepository(), delay: 100 * time.Millisecond}
+	eventQueue := NewMockEventQueue(100)
+	service := NewProductService(repo, eventQueue, 1)
+	dlq := queue.NewDeadLetterQueue(100)
+	service.SetDeadLetterQueue(dlq)
+	service.SetDrainTimeout(150 * time.Millisecond)
+	service.Start()
+
+	const events = 10
+	for i := 0; i < events; i++ {
+		if err := service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("slow-%d", i), Price: 1.0, Stock: i}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	start := time.Now()
+	service.Stop()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Stop to give up after the drain timeout, took %v", elapsed)
+	}
+	if eventQueue.Len() != 0 {
+		t.Errorf("Expected the queue to be emptied, got depth %d", eventQueue.Len())
+	}
+
+	failed := dlq.List()
+	if len(failed) == 0 {
+		t.Fatal("Expected the undrained events to be dead-lettered")
+	}
+	for _, entry := range failed {
+		if entry.Error != ErrDrainTimeout.Error() {
+			t.Errorf("Expected %q for %s, got %q", ErrDrainTimeout, entry.Event.ProductID, entry.Error)
+		}
+	}
+
+	// The event in flight at the deadline still completes, so nothing is lost
+	time.Sleep(150 * time.Millisecond)
+	applied := 0
+	for i := 0; i < events; i++ {
+		if _, exists := repo.Get(fmt.Sprintf("slow-%d", i)); exists {
+			applied++
+		}
+	}
+	if applied+len(failed) != events {
+		t.Errorf("Expected every event to be applied or dead-lettered, got %d applied and %d dead-lettered", applied, len(failed))
+	}
+}
+
+// blockingEventQueue dequeues like a bare channel receive, ignoring the
+// context, so only closing the queue releases a blocked worker
+type blockingEventQueue struct {
+	*MockEventQueue
+}
+
+func (q *blockingEventQueue) DequeueWithContext(ctx context.Context) (models.ProductEvent, bool) {
+	event, ok := <-q.events
+	return event, ok
+}
+
+func TestProductService_StopClosesQueue(t *testing.T) {
+	eventQueue := &blockingEventQueue{MockEventQueue: NewMockEventQueue(10)}
+	service := NewProductService(NewMockProductRepository(), eventQueue, 3)
+	service.Start()
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		service.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Stop to release workers blocked on an empty queue")
+	}
+
+	if alive := service.workerPool.AliveWorkers(); alive != 0 {
+		t.Errorf("Expected 0 workers alive after Stop, got %d", alive)
+	}
+	if err := service.ProcessEvent(models.ProductEvent{ProductID: "late", Price: 1.0}); err != queue.ErrQueueClosed {
+		t.Errorf("Expected ErrQueueClosed after Stop, got %v", err)
+	}
+	// Stopping again must not close the queue a second time
+	service.Stop()
+}
+
+func TestProductService_StopAbortsRetries(t *testing.T) {
+	longBackoff := &retry.RetryConfig{MaxAttempts: 5, InitialDelay: 10 * time.Second, MaxDelay: time.Minute, Multiplier: 2}
+
+	t.Run("Processing", func(t *testing.T) {
+		const events = 8
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(events), events)
+		service.retryConfig = longBackoff.Clone()
+		service.workerPool.retryConfig = service.retryConfig
+		service.CircuitBreakers().SetThreshold(1000)
+		chaos, _ := NewChaosInjector(1, 0)
+		service.SetChaos(chaos)
+		dlq := queue.NewDeadLetterQueue(events)
+		service.SetDeadLetterQueue(dlq)
+		service.Start()
+
+		for i := 0; i < events; i++ {
+			if err := service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("retrying-%d", i), Price: 1.0}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		// Let every worker fail its first attempt and start backing off
+		time.Sleep(100 * time.Millisecond)
+
+		start := time.Now()
+		service.Stop()
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected Stop to abort the backoffs, took %v", elapsed)
+		}
+		if dlq.Len() != events {
+			t.Errorf("Expected all %d aborted events to be dead-lettered, got %d", events, dlq.Len())
+		}
+		if stats := service.workerPool.Stats(); stats.Exhausted != 0 {
+			t.Errorf("Expected aborted events not to count as exhausted, got %d", stats.Exhausted)
+		}
+	})
+
+	t.Run("Enqueue", func(t *testing.T) {
+		// Nothing drains the full queue, so every enqueue backs off
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(0), 1)
+		service.SetEnqueueRetry(longBackoff)
+
+		const callers = 10
+		errs := make(chan error, callers)
+		for i := 0; i < callers; i++ {
+			go func(i int) {
+				errs <- service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("blocked-%d", i), Price: 1.0})
+			}(i)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		start := time.Now()
+		service.Stop()
+		for i := 0; i < callers; i++ {
+			select {
+			case err := <-errs:
+				if !errors.Is(err, context.Canceled) || !errors.Is(err, queue.ErrQueueFull) {
+					t.Errorf("Expected an aborted full-queue enqueue, got %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Expected every enqueue retry to return once Stop aborted it")
+			}
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected Stop to abort the backoffs, took %v", elapsed)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		service := NewProductService(NewMockProductRepository(), NewMockEventQueue(0), 1)
+		service.SetEnqueueRetry(&retry.RetryConfig{MaxAttempts: 2, InitialDelay: 200 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2})
+		service.SetAbortRetriesOnStop(false)
+
+		done := make(chan error, 1)
+		go func() { done <- service.ProcessEvent(models.ProductEvent{ProductID: "patient", Price: 1.0}) }()
+		time.Sleep(50 * time.Millisecond)
+		service.Stop()
+
+		var exhausted *retry.ExhaustedError
+		if err := <-done; !errors.As(err, &exhausted) {
+			t.Errorf("Expected the enqueue to play out its retries, got %v", err)
+		}
+	})
+}
+
+// orderRecordingRepository records the order in which products are updated
+type orderRecordingRepository struct {
+	*MockProductRepository
+	orderMu sync.Mutex
+	order   []string
+}
+
+func (r *orderRecordingRepository) Update(id string, price float64, stock int, currency string) {
+	r.orderMu.Lock()
+	r.order = append(r.order, id)
+	r.orderMu.Unlock()
+	r.MockProductRepository.Update(id, price, stock, currency)
+}
+
+func TestProductService_StrictFIFO(t *testing.T) {
+	repo := &orderRecordingRepository{MockProductRepository: NewMockProductRepository()}
+	eventQueue := NewMockEventQueue(100)
+	service := NewProductService(repo, eventQueue, 4)
+	service.SetLoadShedder(NewLoadShedder(0, 1.0))
+	service.SetStrictFIFO(true)
+
+	const events = 20
+	for i := 0; i < events; i++ {
+		service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("fifo-%02d", i), Price: 1.0, Stock: i, Priority: models.PriorityLow})
+	}
+
+	service.Start()
+	service.WorkerPool().Resize(3)
+	if count := service.WorkerPool().WorkerCount(); count != 1 {
+		t.Errorf("Expected strict FIFO to keep a single worker, got %d", count)
+	}
+	service.Stop()
+
+	if len(repo.order) != events {
+		t.Fatalf("Expected %d updates, got %d", events, len(repo.order))
+	}
+	for i, id := range repo.order {
+		if expected := fmt.Sprintf("fifo-%02d", i); id != expected {
+			t.Errorf("Expected update %d to be %s, got %s", i, expected, id)
+		}
+	}
+}
+
+func TestPriceBandValidator(t *testing.T) {
+	validator := PriceBandValidator(1.0, 10.0)
+	for price, valid := range map[float64]bool{0.99: false, 1.0: true, 5.0: true, 10.0: true, 10.01: false} {
+		err := validator(models.ProductEvent{ProductID: "band", Price: price})
+		if (err == nil) != valid {
+			t.Errorf("Expected price %.2f valid=%v, got error %v", price, valid, err)
+		}
+	}
+}
+
+// slowPrefixRepository stalls reads for products whose ID starts with prefix
+type slowPrefixRepository struct {
+	*MockProductRepository
+	prefix string
+}
+
+func (r *slowPrefixRepository) GetContext(ctx context.Context, id string) (*models.Product, bool, error) {
+	if strings.HasPrefix(id, r.prefix) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+	product, exists := r.MockProductRepository.Get(id)
+	return product, exists, nil
+}
+
+func TestProductService_CategoryCircuitBreakers(t *testing.T) {
+	repo := &slowPrefixRepository{MockProductRepository: NewMockProductRepository(), prefix: "supplier-a"}
+	service := NewProductService(repo, NewMockEventQueue(20), 1)
+	service.SetAuditLogger(NewAuditLogger(io.Discard))
+	service.SetProcessTimeout(30 * time.Millisecond)
+	service.RetryConfig().MaxAttempts = 2
+	service.RetryConfig().InitialDelay = time.Millisecond
+	if err := service.CircuitBreakers().SetThreshold(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("supplier-a-%d", i), Price: 1.0, Stock: i, Category: "supplier-a"})
+		service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("supplier-b-%d", i), Price: 1.0, Stock: i, Category: "supplier-b"})
+	}
+	service.Start()
+	service.Stop()
+
+	breakers := service.CircuitBreakers()
+	if state := breakers.Get("supplier-a").GetState(); state != circuitbreaker.Open {
+		t.Errorf("Expected the failing category's breaker to be open, got %s", state)
+	}
+	if state := breakers.Get("supplier-b").GetState(); state != circuitbreaker.Closed {
+		t.Errorf("Expected the healthy category's breaker to stay closed, got %s", state)
+	}
+	if state := service.CircuitBreaker().GetState(); state != circuitbreaker.Closed {
+		t.Errorf("Expected the uncategorized breaker to stay closed, got %s", state)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, exists := repo.Get(fmt.Sprintf("supplier-b-%d", i)); !exists {
+			t.Errorf("Expected supplier-b-%d to be processed while supplier-a was failing", i)
+		}
+	}
+	if service.DeadLetterQueue().Len() != 3 {
+		t.Errorf("Expected the 3 supplier-a events to be dead-lettered, got %d", service.DeadLetterQueue().Len())
+	}
+}
+
+func TestProductService_TargetEPS(t *testing.T) {
+	service := NewProductService(NewMockProductRepository(), NewMockEventQueue(100), 3)
+	throttle, err := ratelimit.NewLimiter(20)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service.SetThrottle(throttle)
+	service.SetDrainTimeout(10 * time.Millisecond)
+
+	// Far more than the target, so unthrottled workers would finish them all within the window
+	for i := 0; i < 100; i++ {
+		service.ProcessEvent(models.ProductEvent{ProductID: fmt.Sprintf("paced-%d", i), Price: 1.0, Stock: i})
+	}
+
+	service.Start()
+	time.Sleep(time.Second)
+	processed := service.WorkerPool().Stats().Processed
+	service.Stop()
+
+	if processed < 15 || processed > 25 {
+		t.Errorf("Expected about 20 events processed in one second at TARGET_EPS 20, got %d", processed)
+	}
+}
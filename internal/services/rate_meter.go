@@ -0,0 +1,119 @@
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateTickInterval is how often the meter folds new events into its averages
+const rateTickInterval = 5 * time.Second
+
+// Rate windows reported by the meter, mirroring Unix load averages
+const (
+	RateWindow1m  = "1m"
+	RateWindow5m  = "5m"
+	RateWindow15m = "15m"
+)
+
+// Metric names for the throughput meter
+const (
+	metricEventsProcessedRate = "events_processed_per_second"
+)
+
+// ThroughputRates holds exponentially weighted events/sec averages
+type ThroughputRates struct {
+	OneMinute     float64 `json:"1m"`
+	FiveMinute    float64 `json:"5m"`
+	FifteenMinute float64 `json:"15m"`
+}
+
+// ewma is a single exponentially weighted moving average of a per-second rate
+type ewma struct {
+	alpha       float64
+	rate        float64
+	initialized bool
+}
+
+// newEWMA creates an average decaying over window, updated every rateTickInterval
+func newEWMA(window time.Duration) *ewma {
+	return &ewma{alpha: 1 - math.Exp(-rateTickInterval.Seconds()/window.Seconds())}
+}
+
+// tick folds count events observed over one interval into the average
+func (e *ewma) tick(count int64) {
+	instant := float64(count) / rateTickInterval.Seconds()
+	if !e.initialized {
+		e.rate = instant
+		e.initialized = true
+		return
+	}
+	e.rate += e.alpha * (instant - e.rate)
+}
+
+// decay applies n idle intervals at once
+func (e *ewma) decay(n int64) {
+	e.rate *= math.Pow(1-e.alpha, float64(n))
+}
+
+// RateMeter measures an event rate over 1, 5 and 15 minute windows
+//
+// Ticks are applied lazily on Mark and Rates, so the meter needs no
+// background goroutine and decays correctly across idle periods.
+type RateMeter struct {
+	mu        sync.Mutex
+	uncounted int64
+	lastTick  time.Time
+	m1        *ewma
+	m5        *ewma
+	m15       *ewma
+	now       func() time.Time
+}
+
+// NewRateMeter creates an empty rate meter
+func NewRateMeter() *RateMeter {
+	meter := &RateMeter{
+		m1:  newEWMA(time.Minute),
+		m5:  newEWMA(5 * time.Minute),
+		m15: newEWMA(15 * time.Minute),
+		now: time.Now,
+	}
+	meter.lastTick = meter.now()
+	return meter
+}
+
+// Mark records n events
+func (m *RateMeter) Mark(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickLocked()
+	m.uncounted += n
+}
+
+// Rates returns the current averages in events per second
+func (m *RateMeter) Rates() ThroughputRates {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickLocked()
+	return ThroughputRates{
+		OneMinute:     m.m1.rate,
+		FiveMinute:    m.m5.rate,
+		FifteenMinute: m.m15.rate,
+	}
+}
+
+// tickLocked applies every interval elapsed since the last tick; the caller must hold m.mu
+func (m *RateMeter) tickLocked() {
+	elapsed := int64(m.now().Sub(m.lastTick) / rateTickInterval)
+	if elapsed <= 0 {
+		return
+	}
+	m.lastTick = m.lastTick.Add(time.Duration(elapsed) * rateTickInterval)
+
+	// Pending events belong to the first elapsed interval; the rest were idle
+	for _, avg := range []*ewma{m.m1, m.m5, m.m15} {
+		avg.tick(m.uncounted)
+		avg.decay(elapsed - 1)
+	}
+	m.uncounted = 0
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestRateMeter_RisesThenDecays(t *testing.T) {
+	now := time.Now()
+	meter := NewRateMeter()
+	meter.now = func() time.Time { return now }
+	meter.lastTick = now
+
+	// 50 events within one tick is 10 events/sec
+	meter.Mark(50)
+	now = now.Add(rateTickInterval)
+
+	peak := meter.Rates()
+	if math.Abs(peak.OneMinute-10) > 0.001 {
+		t.Errorf("Expected 1m rate 10, got %f", peak.OneMinute)
+	}
+
+	now = now.Add(5 * time.Minute)
+	decayed := meter.Rates()
+	if decayed.OneMinute >= peak.OneMinute/10 {
+		t.Errorf("Expected 1m rate to decay below %f, got %f", peak.OneMinute/10, decayed.OneMinute)
+	}
+	// Longer windows decay more slowly
+	if !(decayed.OneMinute < decayed.FiveMinute && decayed.FiveMinute < decayed.FifteenMinute) {
+		t.Errorf("Expected 1m < 5m < 15m after decay, got %+v", decayed)
+	}
+}
+
+func TestWorkerPool_Throughput(t *testing.T) {
+	repo := NewMockProductRepository()
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(repo, eventQueue, 1)
+
+	now := time.Now()
+	meter := service.WorkerPool().throughput
+	meter.mu.Lock()
+	meter.now = func() time.Time { return now }
+	meter.lastTick = now
+	meter.mu.Unlock()
+
+	service.Start()
+	defer service.Stop()
+
+	for i := 0; i < 5; i++ {
+		service.ProcessEvent(models.ProductEvent{ProductID: "burst", Price: float64(i), Stock: i})
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	meter.mu.Lock()
+	now = now.Add(rateTickInterval)
+	meter.mu.Unlock()
+
+	rates := service.Throughput()
+	if math.Abs(rates.OneMinute-1) > 0.001 {
+		t.Errorf("Expected 1m rate 1 after a burst of 5, got %f", rates.OneMinute)
+	}
+	gauge := service.Metrics().Gauge(metricEventsProcessedRate, "", map[string]string{"window": RateWindow1m})
+	if gauge.Value() != rates.OneMinute {
+		t.Errorf("Expected 1m gauge %f, got %f", rates.OneMinute, gauge.Value())
+	}
+
+	meter.mu.Lock()
+	now = now.Add(15 * time.Minute)
+	meter.mu.Unlock()
+
+	if rate := service.Throughput().OneMinute; rate >= 0.01 {
+		t.Errorf("Expected 1m rate to decay when idle, got %f", rate)
+	}
+}
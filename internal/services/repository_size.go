@@ -0,0 +1,37 @@
+package services
+
+// metricRepositorySize reports the approximate memory held by stored products
+const metricRepositorySize = "repository_size_bytes"
+
+// RepositorySizer is implemented by repositories that can estimate the memory their products hold
+type RepositorySizer interface {
+	ProductCount() int
+	SizeBytes() int64
+}
+
+// SetRepositorySizer sets the store whose size is reported by RepositorySize
+func (s *ProductService) SetRepositorySizer(sizer RepositorySizer) {
+	s.repositorySizer = sizer
+}
+
+// RepositorySize refreshes and returns the approximate bytes held by stored
+// products, or -1 when no sizer is configured
+func (s *ProductService) RepositorySize() int64 {
+	if s.repositorySizer == nil {
+		return -1
+	}
+	size := s.repositorySizer.SizeBytes()
+	if s.metrics != nil {
+		s.metrics.Gauge(metricRepositorySize, "Approximate bytes held by stored products, including tombstones", nil).Set(float64(size))
+	}
+	return size
+}
+
+// RepositoryProducts returns the number of products held by the sized store,
+// including tombstones, or -1 when no sizer is configured
+func (s *ProductService) RepositoryProducts() int {
+	if s.repositorySizer == nil {
+		return -1
+	}
+	return s.repositorySizer.ProductCount()
+}
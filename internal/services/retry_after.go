@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"product-service/pkg/circuitbreaker"
+)
+
+// Bounds on the retry hint given to rejected clients
+const (
+	minRetryAfter = time.Second
+	maxRetryAfter = time.Minute
+)
+
+// defaultRetryAfter is suggested while there is no measured throughput to estimate a drain time from
+const defaultRetryAfter = rateTickInterval
+
+// RetryAfter estimates how long a client should wait before retrying an event
+// of the given category that was rejected with err
+//
+// An open circuit breaker suggests the time until it half-opens. Rejections
+// for a full queue or the in-flight limit suggest the time the workers need,
+// at their current throughput, to work off the backlog ahead of the client,
+// and admission refusals the time to drain below the watermark. The product
+// limit only frees up as products are purged, so it suggests the longest wait.
+func (s *ProductService) RetryAfter(category string, err error) time.Duration {
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		return clampRetryAfter(s.CircuitBreakers().Get(category).RetryAfter())
+	}
+	if errors.Is(err, ErrProductLimitReached) {
+		return maxRetryAfter
+	}
+
+	backlog := s.queue.Len()
+	var admissionErr *AdmissionError
+	if errors.As(err, &admissionErr) {
+		// Only the events above the watermark need to drain before the client is admitted
+		backlog = backlog - admissionErr.Watermark + 1
+	} else if errors.Is(err, ErrInFlightLimitReached) && s.inFlightLimiter != nil {
+		backlog = s.inFlightLimiter.InFlight()
+	}
+	return s.EstimatedDrainTime(backlog)
+}
+
+// EstimatedDrainTime estimates how long the workers need to process backlog
+// events at their one-minute average throughput, within the retry hint bounds
+func (s *ProductService) EstimatedDrainTime(backlog int) time.Duration {
+	rate := s.workerPool.Throughput().OneMinute
+	if rate <= 0 {
+		return defaultRetryAfter
+	}
+	return clampRetryAfter(time.Duration(float64(backlog) / rate * float64(time.Second)))
+}
+
+// clampRetryAfter keeps a retry hint between minRetryAfter and maxRetryAfter
+func clampRetryAfter(wait time.Duration) time.Duration {
+	if wait < minRetryAfter {
+		return minRetryAfter
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
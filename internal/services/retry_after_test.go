@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/circuitbreaker"
+	"product-service/pkg/queue"
+)
+
+func TestProductService_RetryAfter(t *testing.T) {
+	eventQueue := NewMockEventQueue(10)
+	service := NewProductService(NewMockProductRepository(), eventQueue, 1)
+
+	t.Run("NoThroughput", func(t *testing.T) {
+		if wait := service.RetryAfter("", queue.ErrQueueFull); wait != defaultRetryAfter {
+			t.Errorf("Expected %v without measured throughput, got %v", defaultRetryAfter, wait)
+		}
+	})
+
+	t.Run("DrainEstimate", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			eventQueue.Enqueue(models.ProductEvent{ProductID: "backlog"})
+		}
+		// 10 events over one 5s tick interval is 2 events/sec
+		now := time.Now()
+		meter := service.workerPool.throughput
+		meter.now = func() time.Time { return now }
+		meter.lastTick = now
+		meter.Mark(10)
+		now = now.Add(rateTickInterval)
+
+		if wait := service.RetryAfter("", queue.ErrQueueFull); wait != 5*time.Second {
+			t.Errorf("Expected 10 queued events at 2/s to take 5s, got %v", wait)
+		}
+	})
+
+	t.Run("ProductLimit", func(t *testing.T) {
+		if wait := service.RetryAfter("", ErrProductLimitReached); wait != maxRetryAfter {
+			t.Errorf("Expected %v at the product limit, got %v", maxRetryAfter, wait)
+		}
+	})
+
+	t.Run("CircuitOpen", func(t *testing.T) {
+		breaker := service.CircuitBreakers().Get("feed")
+		for i := 0; i < breaker.GetThreshold(); i++ {
+			breaker.Execute(func() error { return errors.New("failure") })
+		}
+
+		wait := service.RetryAfter("feed", circuitbreaker.ErrOpen)
+		if wait < 59*time.Second || wait > 60*time.Second {
+			t.Errorf("Expected the time until the breaker half-opens, got %v", wait)
+		}
+	})
+}
+
+func TestClampRetryAfter(t *testing.T) {
+	tests := []struct {
+		wait     time.Duration
+		expected time.Duration
+	}{
+		{0, minRetryAfter},
+		{10 * time.Second, 10 * time.Second},
+		{time.Hour, maxRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := clampRetryAfter(tt.wait); got != tt.expected {
+			t.Errorf("Expected %v for %v, got %v", tt.expected, tt.wait, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// selfCheckProductID is written and read back to probe the repository; it is removed afterwards
+const selfCheckProductID = "__self_check__"
+
+// SelfChecker is implemented by dependencies that can verify they are usable before traffic is served
+type SelfChecker interface {
+	SelfCheck(ctx context.Context) error
+}
+
+// ProductDeleter is implemented by repositories that can remove a product
+type ProductDeleter interface {
+	Delete(id string) bool
+}
+
+// SelfCheck verifies the service's settings, queue backend and repository
+// before it serves traffic. Every failing check is reported, joined into one
+// error, rather than stopping at the first.
+func (s *ProductService) SelfCheck(ctx context.Context) error {
+	var errs []error
+	if err := s.selfCheckConfig(); err != nil {
+		errs = append(errs, fmt.Errorf("config: %w", err))
+	}
+	if err := s.selfCheckQueue(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("queue: %w", err))
+	}
+	if err := s.selfCheckRepository(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("repository: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// selfCheckConfig rejects settings that would leave the service unable to process events
+func (s *ProductService) selfCheckConfig() error {
+	var errs []error
+	if s.retryConfig.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("retry attempts must be positive, got %d", s.retryConfig.MaxAttempts))
+	}
+	if s.enqueueRetry.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("enqueue retry attempts must be positive, got %d", s.enqueueRetry.MaxAttempts))
+	}
+	if s.circuitBreaker.GetThreshold() < 1 {
+		errs = append(errs, fmt.Errorf("circuit breaker threshold must be positive, got %d", s.circuitBreaker.GetThreshold()))
+	}
+	return errors.Join(errs...)
+}
+
+// selfCheckQueue runs the queue backend's own check, then makes sure it can hold events
+func (s *ProductService) selfCheckQueue(ctx context.Context) error {
+	if checker, ok := s.queue.(SelfChecker); ok {
+		if err := checker.SelfCheck(ctx); err != nil {
+			return err
+		}
+	}
+	if s.queue.Cap() < 1 {
+		return fmt.Errorf("capacity must be positive, got %d", s.queue.Cap())
+	}
+	return nil
+}
+
+// selfCheckRepository runs the repository's own check, then probes it. A
+// repository that can delete is probed with a write that must read back;
+// any other is only read, so no probe product is left behind.
+func (s *ProductService) selfCheckRepository(ctx context.Context) error {
+	if checker, ok := s.repository.(SelfChecker); ok {
+		if err := checker.SelfCheck(ctx); err != nil {
+			return err
+		}
+	}
+
+	deleter, canDelete := s.repository.(ProductDeleter)
+	done := make(chan error, 1)
+	go func() {
+		if !canDelete {
+			s.repository.Get(selfCheckProductID)
+			done <- nil
+			return
+		}
+
+		s.repository.Update(selfCheckProductID, 0, 0, "")
+		_, exists := s.repository.Get(selfCheckProductID)
+		deleter.Delete(selfCheckProductID)
+		if !exists {
+			done <- errors.New("probe write could not be read back")
+			return
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("no response: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/internal/repositories"
+	"product-service/pkg/queue"
+)
+
+var errBackendUnreachable = errors.New("backend unreachable")
+
+// UnreachableEventQueue fails its self-check as a remote queue would when it cannot be reached
+type UnreachableEventQueue struct {
+	*MockEventQueue
+}
+
+func (q *UnreachableEventQueue) SelfCheck(ctx context.Context) error {
+	return errBackendUnreachable
+}
+
+// ReadOnlyProductRepository accepts writes but never stores them
+type ReadOnlyProductRepository struct {
+	*MockProductRepository
+}
+
+func (r *ReadOnlyProductRepository) Update(id string, price float64, stock int, currency string) {}
+
+func (r *ReadOnlyProductRepository) Delete(id string) bool { return false }
+
+// HangingProductRepository never answers lookups
+type HangingProductRepository struct {
+	*MockProductRepository
+}
+
+func (r *HangingProductRepository) Get(id string) (*models.Product, bool) {
+	select {}
+}
+
+func TestProductService_SelfCheck(t *testing.T) {
+	t.Run("Healthy", func(t *testing.T) {
+		repo := repositories.NewInMemoryProductRepository()
+		service := NewProductService(repo, queue.NewInMemoryEventQueue(10), 1)
+
+		if err := service.SelfCheck(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, exists := repo.Get(selfCheckProductID); exists {
+			t.Error("Expected the probe product to be removed")
+		}
+	})
+
+	t.Run("AggregatesFailures", func(t *testing.T) {
+		repo := &ReadOnlyProductRepository{NewMockProductRepository()}
+		service := NewProductService(repo, &UnreachableEventQueue{NewMockEventQueue(10)}, 1)
+		service.retryConfig.MaxAttempts = 0
+
+		err := service.SelfCheck(context.Background())
+		if !errors.Is(err, errBackendUnreachable) {
+			t.Errorf("Expected the queue failure, got %v", err)
+		}
+		for _, want := range []string{"config: retry attempts", "queue: backend unreachable", "repository: probe write"} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected %q in the error, got %v", want, err)
+			}
+		}
+	})
+
+	t.Run("TimesOut", func(t *testing.T) {
+		repo := &HangingProductRepository{NewMockProductRepository()}
+		service := NewProductService(repo, NewMockEventQueue(10), 1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := service.SelfCheck(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strconv"
+
+	"product-service/internal/models"
+	"product-service/pkg/metrics"
+)
+
+// Metric names for the per-shard load of a sharded repository
+const (
+	metricShardProducts   = "repository_shard_products"
+	metricShardOperations = "repository_shard_operations_total"
+)
+
+// ShardReporter is implemented by repositories that spread products across shards
+type ShardReporter interface {
+	ShardStats() []models.ShardStats
+}
+
+// SetShardReporter sets the sharded store whose per-shard load is reported by ShardStats
+func (s *ProductService) SetShardReporter(reporter ShardReporter) {
+	s.shardReporter = reporter
+}
+
+// ShardStats refreshes and returns the load on each repository shard, or nil
+// when the repository is not sharded
+func (s *ProductService) ShardStats() []models.ShardStats {
+	if s.shardReporter == nil {
+		return nil
+	}
+	stats := s.shardReporter.ShardStats()
+	if s.metrics == nil {
+		return stats
+	}
+
+	// Counters only move forward, so concurrent refreshes must not both add the same delta
+	s.shardMu.Lock()
+	defer s.shardMu.Unlock()
+	for _, shard := range stats {
+		label := strconv.Itoa(shard.Shard)
+		s.metrics.Gauge(metricShardProducts, "Products held by each repository shard, including tombstones",
+			metrics.Labels{"shard": label}).Set(float64(shard.Products))
+		for operation, count := range map[string]uint64{"get": shard.Gets, "update": shard.Updates, "delete": shard.Deletes} {
+			counter := s.metrics.Counter(metricShardOperations, "Repository operations served by each shard",
+				metrics.Labels{"shard": label, "operation": operation})
+			if count > counter.Value() {
+				counter.Add(count - counter.Value())
+			}
+		}
+	}
+	return stats
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"product-service/internal/repositories"
+	"product-service/pkg/metrics"
+)
+
+func TestProductService_ShardStats(t *testing.T) {
+	repo := repositories.NewShardedProductRepository(4)
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+
+	if stats := service.ShardStats(); stats != nil {
+		t.Errorf("Expected no shard stats without a reporter, got %v", stats)
+	}
+	service.SetShardReporter(repo)
+
+	for i := 0; i < 50; i++ {
+		repo.Update("hot", 1.0, i, "")
+	}
+	for i := 0; i < 10; i++ {
+		repo.Update(fmt.Sprintf("cold-%d", i), 1.0, 1, "")
+	}
+
+	hot := strconv.Itoa(repo.ShardIndex("hot"))
+	updates := func(shard string) uint64 {
+		return service.Metrics().Counter(metricShardOperations, "", metrics.Labels{"shard": shard, "operation": "update"}).Value()
+	}
+
+	service.ShardStats()
+	first := updates(hot)
+	if first < 50 {
+		t.Errorf("Expected at least 50 updates reported for the hot shard, got %d", first)
+	}
+	for shard := 0; shard < 4; shard++ {
+		if label := strconv.Itoa(shard); label != hot && updates(label) >= first {
+			t.Errorf("Expected shard %s to report fewer updates than the hot shard, got %d vs %d", label, updates(label), first)
+		}
+	}
+
+	// A refresh only adds operations served since the previous one
+	repo.Update("hot", 2.0, 1, "")
+	service.ShardStats()
+	service.ShardStats()
+	if got := updates(hot); got != first+1 {
+		t.Errorf("Expected %d updates after one more, got %d", first+1, got)
+	}
+	if products := service.Metrics().Gauge(metricShardProducts, "", metrics.Labels{"shard": hot}).Value(); products < 1 {
+		t.Errorf("Expected the hot shard to report its products, got %v", products)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// TombstoneRepository is a repository that keeps soft-deleted products until they are purged
+type TombstoneRepository interface {
+	PurgeTombstones(cutoff time.Time) int
+}
+
+// TombstoneJanitor periodically hard-deletes products whose soft-delete
+// retention period has passed
+type TombstoneJanitor struct {
+	repository TombstoneRepository
+	retention  time.Duration
+	interval   time.Duration
+	now        func() time.Time
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	logger     *log.Logger
+	limiter    *ProductLimiter
+}
+
+// DefaultTombstonePurgeInterval replaces a non-positive purge interval, which time.NewTicker rejects
+const DefaultTombstonePurgeInterval = time.Minute
+
+// NewTombstoneJanitor creates a janitor purging, every interval, tombstones older than retention
+func NewTombstoneJanitor(repo TombstoneRepository, retention, interval time.Duration) *TombstoneJanitor {
+	if interval <= 0 {
+		interval = DefaultTombstonePurgeInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TombstoneJanitor{
+		repository: repo,
+		retention:  retention,
+		interval:   interval,
+		now:        time.Now,
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     log.New(os.Stdout, "[JANITOR] ", log.LstdFlags),
+	}
+}
+
+// SetProductLimiter sets the product limit recounted after tombstones are purged
+func (j *TombstoneJanitor) SetProductLimiter(limiter *ProductLimiter) {
+	j.limiter = limiter
+}
+
+// Start starts the periodic purge
+func (j *TombstoneJanitor) Start() {
+	j.wg.Add(1)
+	go j.run()
+	j.logger.Printf("Started tombstone janitor every %v with retention %v", j.interval, j.retention)
+}
+
+// Stop stops the periodic purge
+func (j *TombstoneJanitor) Stop() {
+	j.cancel()
+	j.wg.Wait()
+}
+
+// run purges on every interval until stopped
+func (j *TombstoneJanitor) run() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-ticker.C:
+			j.Purge()
+		}
+	}
+}
+
+// Purge hard-deletes every tombstone past its retention and returns how many were removed
+func (j *TombstoneJanitor) Purge() int {
+	purged := j.repository.PurgeTombstones(j.now().Add(-j.retention))
+	if purged > 0 {
+		j.logger.Printf("Purged %d soft-deleted products past their %v retention", purged, j.retention)
+		if j.limiter != nil {
+			j.limiter.Recount()
+		}
+	}
+	return purged
+}
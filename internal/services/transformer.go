@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"product-service/internal/models"
+)
+
+// Names of the built-in transformers accepted by ParseEventTransformers
+const (
+	TransformLowercaseID = "lowercase_id"
+	TransformRoundPrice  = "round_price"
+	TransformMarkup      = "markup"
+)
+
+// LowercaseProductID normalizes product IDs to lowercase
+func LowercaseProductID() EventTransformer {
+	return func(event models.ProductEvent) (models.ProductEvent, error) {
+		event.ProductID = strings.ToLower(event.ProductID)
+		return event, nil
+	}
+}
+
+// RoundPrice rounds prices half away from zero to the given number of decimal places
+func RoundPrice(decimals int) EventTransformer {
+	scale := math.Pow10(decimals)
+	return func(event models.ProductEvent) (models.ProductEvent, error) {
+		event.Price = math.Round(event.Price*scale) / scale
+		return event, nil
+	}
+}
+
+// ApplyMarkup raises prices by percent, e.g. 10 for a 10% markup
+func ApplyMarkup(percent float64) EventTransformer {
+	factor := 1 + percent/100
+	return func(event models.ProductEvent) (models.ProductEvent, error) {
+		event.Price *= factor
+		if math.IsInf(event.Price, 0) {
+			return event, fmt.Errorf("price of product %s overflows after a %.2f%% markup", event.ProductID, percent)
+		}
+		return event, nil
+	}
+}
+
+// FixedPricePlaces rounds prices to the given number of decimal places and
+// rejects those beyond the range of an exact decimal. Fixed price places mode
+// runs it after all other transformers.
+func FixedPricePlaces(places int) EventTransformer {
+	return func(event models.ProductEvent) (models.ProductEvent, error) {
+		price, err := models.DecimalFromFloat(event.Price, places)
+		if err != nil {
+			return event, fmt.Errorf("price of product %s: %w", event.ProductID, err)
+		}
+		event.Price = price.Float64()
+		return event, nil
+	}
+}
+
+// ParseEventTransformers builds a pipeline from specs such as "lowercase_id",
+// "round_price:2" or "markup:10", applied in the order given
+func ParseEventTransformers(specs []string) ([]EventTransformer, error) {
+	transformers := make([]EventTransformer, 0, len(specs))
+	for _, spec := range specs {
+		name, arg, hasArg := strings.Cut(spec, ":")
+		switch name {
+		case TransformLowercaseID:
+			if hasArg {
+				return nil, fmt.Errorf("transform %q takes no argument", name)
+			}
+			transformers = append(transformers, LowercaseProductID())
+		case TransformRoundPrice:
+			decimals := 2
+			if hasArg {
+				var err error
+				if decimals, err = strconv.Atoi(arg); err != nil || decimals < 0 {
+					return nil, fmt.Errorf("transform %q needs a non-negative number of decimal places, got %q", name, arg)
+				}
+			}
+			transformers = append(transformers, RoundPrice(decimals))
+		case TransformMarkup:
+			percent, err := strconv.ParseFloat(arg, 64)
+			if !hasArg || err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) || percent <= -100 {
+				return nil, fmt.Errorf("transform %q needs a percentage above -100, got %q", name, arg)
+			}
+			transformers = append(transformers, ApplyMarkup(percent))
+		default:
+			return nil, fmt.Errorf("unknown event transform %q, expected %q, %q or %q", name, TransformLowercaseID, TransformRoundPrice, TransformMarkup)
+		}
+	}
+	return transformers, nil
+}
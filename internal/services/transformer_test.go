@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+	"product-service/pkg/queue"
+)
+
+func TestProductService_EventTransformers(t *testing.T) {
+	repo := NewMockProductRepository()
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+	dlq := queue.NewDeadLetterQueue(10)
+	service.SetDeadLetterQueue(dlq)
+	service.SetEventTransformers(
+		LowercaseProductID(),
+		RoundPrice(2),
+		func(event models.ProductEvent) (models.ProductEvent, error) {
+			if event.ProductID == "reject-me" {
+				return event, errors.New("rejected by transform")
+			}
+			return event, nil
+		},
+	)
+	service.Start()
+	defer service.Stop()
+
+	service.ProcessEvent(models.ProductEvent{ProductID: "MixedCase-SKU", Price: 19.987, Stock: 3})
+	service.ProcessEvent(models.ProductEvent{ProductID: "REJECT-ME", Price: 1.0, Stock: 1})
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("Applied", func(t *testing.T) {
+		if _, exists := repo.Get("MixedCase-SKU"); exists {
+			t.Error("Expected the product to be stored under its lowercased ID only")
+		}
+		product, exists := repo.Get("mixedcase-sku")
+		if !exists {
+			t.Fatal("Expected the product to be stored under its lowercased ID")
+		}
+		if product.Price != 19.99 {
+			t.Errorf("Expected price rounded to 19.99, got %v", product.Price)
+		}
+	})
+
+	t.Run("ErrorDeadLetters", func(t *testing.T) {
+		if _, exists := repo.Get("reject-me"); exists {
+			t.Error("Expected the rejected event not to be applied")
+		}
+		failed := dlq.List()
+		if len(failed) != 1 {
+			t.Fatalf("Expected 1 dead-lettered event, got %d", len(failed))
+		}
+		if failed[0].Event.ProductID != "REJECT-ME" {
+			t.Errorf("Expected the event to be dead-lettered as accepted, got %s", failed[0].Event.ProductID)
+		}
+		if !strings.Contains(failed[0].Error, "rejected by transform") {
+			t.Errorf("Expected the transform error, got %q", failed[0].Error)
+		}
+	})
+}
+
+func TestParseEventTransformers(t *testing.T) {
+	transformers, err := ParseEventTransformers([]string{"lowercase_id", "markup:10", "round_price"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	event := models.ProductEvent{ProductID: "SKU-1", Price: 9.99}
+	for _, transformer := range transformers {
+		event, _ = transformer(event)
+	}
+	if event.ProductID != "sku-1" || event.Price != 10.99 {
+		t.Errorf("Expected sku-1 at 10.99, got %s at %v", event.ProductID, event.Price)
+	}
+
+	for _, specs := range [][]string{{"uppercase_id"}, {"round_price:-1"}, {"markup"}, {"markup:-100"}, {"lowercase_id:1"}} {
+		if _, err := ParseEventTransformers(specs); err == nil {
+			t.Errorf("Expected an error for %v", specs)
+		}
+	}
+}
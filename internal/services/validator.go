@@ -0,0 +1,17 @@
+package services
+
+import (
+	"fmt"
+
+	"product-service/internal/models"
+)
+
+// PriceBandValidator rejects events whose price falls outside [min, max]
+func PriceBandValidator(min, max float64) EventValidator {
+	return func(event models.ProductEvent) error {
+		if event.Price < min || event.Price > max {
+			return fmt.Errorf("price %.2f is outside the allowed range %.2f to %.2f", event.Price, min, max)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// Worker statuses reported by WorkerPool.Activity
+const (
+	WorkerStatusIdle       = "idle"
+	WorkerStatusProcessing = "processing"
+)
+
+// WorkerActivity is a snapshot of what a single worker is doing
+type WorkerActivity struct {
+	ID        int
+	Status    string
+	Processed int64
+	// CurrentProductID and CurrentSequence identify the event being processed, if any
+	CurrentProductID string
+	CurrentSequence  uint64
+	// LastActivity is when the worker started or last began or finished an event
+	LastActivity time.Time
+}
+
+// workerActivityRegistry tracks the activity of every running worker in a pool
+type workerActivityRegistry struct {
+	mu      sync.Mutex
+	workers map[int]*WorkerActivity
+}
+
+// newWorkerActivityRegistry creates an empty registry
+func newWorkerActivityRegistry() *workerActivityRegistry {
+	return &workerActivityRegistry{workers: make(map[int]*WorkerActivity)}
+}
+
+// register adds an idle worker
+func (r *workerActivityRegistry) register(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workers[id] = &WorkerActivity{ID: id, Status: WorkerStatusIdle, LastActivity: time.Now()}
+}
+
+// unregister removes a worker that has exited
+func (r *workerActivityRegistry) unregister(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.workers, id)
+}
+
+// begin marks the worker as processing event
+func (r *workerActivityRegistry) begin(id int, event models.ProductEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if worker, ok := r.workers[id]; ok {
+		worker.Status = WorkerStatusProcessing
+		worker.CurrentProductID = event.ProductID
+		worker.CurrentSequence = event.Sequence
+		worker.LastActivity = time.Now()
+	}
+}
+
+// finish marks the worker as idle again, counting the event if it was applied
+func (r *workerActivityRegistry) finish(id int, processed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if worker, ok := r.workers[id]; ok {
+		worker.Status = WorkerStatusIdle
+		worker.CurrentProductID = ""
+		worker.CurrentSequence = 0
+		worker.LastActivity = time.Now()
+		if processed {
+			worker.Processed++
+		}
+	}
+}
+
+// snapshot returns a copy of every worker's activity ordered by worker ID
+func (r *workerActivityRegistry) snapshot() []WorkerActivity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	activity := make([]WorkerActivity, 0, len(r.workers))
+	for _, worker := range r.workers {
+		activity = append(activity, *worker)
+	}
+	sort.Slice(activity, func(i, j int) bool { return activity[i].ID < activity[j].ID })
+	return activity
+}
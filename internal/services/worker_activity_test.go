@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"product-service/internal/models"
+)
+
+func TestWorkerPool_Activity(t *testing.T) {
+	repo := &SlowProductRepository{MockProductRepository: NewMockProductRepository(), delay: 200 * time.Millisecond}
+	service := NewProductService(repo, NewMockEventQueue(10), 1)
+	service.Start()
+	defer service.Stop()
+
+	time.Sleep(20 * time.Millisecond)
+	activity := service.WorkerPool().Activity()
+	if len(activity) != 1 || activity[0].Status != WorkerStatusIdle {
+		t.Fatalf("Expected a single idle worker, got %+v", activity)
+	}
+
+	service.ProcessEvent(models.ProductEvent{ProductID: "slow", Price: 1.0, Stock: 1})
+	time.Sleep(50 * time.Millisecond)
+
+	activity = service.WorkerPool().Activity()
+	if activity[0].Status != WorkerStatusProcessing {
+		t.Errorf("Expected worker to be processing, got %s", activity[0].Status)
+	}
+	if activity[0].CurrentProductID != "slow" {
+		t.Errorf("Expected current product slow, got '%s'", activity[0].CurrentProductID)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	activity = service.WorkerPool().Activity()
+	if activity[0].Status != WorkerStatusIdle || activity[0].CurrentProductID != "" {
+		t.Errorf("Expected worker to be idle with no current event, got %+v", activity[0])
+	}
+	if activity[0].Processed != 1 {
+		t.Errorf("Expected 1 processed event, got %d", activity[0].Processed)
+	}
+}
@@ -1,6 +1,7 @@
 package circuitbreaker
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"time"
@@ -15,6 +16,30 @@ const (
 	HalfOpen
 )
 
+// String returns the string representation of the State
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Common circuit breaker errors
+var (
+	ErrInvalidThreshold = errors.New("failure threshold must be positive")
+	ErrInvalidTimeout   = errors.New("timeout must be positive")
+	ErrOpen             = errors.New("circuit breaker is open")
+	ErrInvalidSlowRate  = errors.New("slow call rate must be between 0 and 1")
+	ErrInvalidWindow    = errors.New("slow call window must be positive")
+	ErrInvalidStreak    = errors.New("success streak must be positive")
+)
+
 // CircuitBreaker implements the circuit breaker pattern
 type CircuitBreaker struct {
 	failureThreshold int
@@ -22,7 +47,30 @@ type CircuitBreaker struct {
 	state            State
 	failures         int
 	lastFailureTime  time.Time
+	isFailure        func(error) bool
 	mutex            sync.RWMutex
+
+	// successStreak consecutive successes clear the failure count and close a
+	// half-open breaker; successes counts the current run
+	successStreak int
+	successes     int
+
+	// forced pins the state set by ForceOpen or ForceClosed until Release
+	forced bool
+
+	// Rejections while open; rejectedSinceOpen marks that the current open period already had one
+	rejections        uint64
+	rejectedSinceOpen bool
+	onReject          func(firstSinceOpen bool)
+
+	// Latency tracking: the breaker also opens once slowRate of the last
+	// len(slowWindow) operations took longer than slowThreshold
+	slowThreshold time.Duration
+	slowRate      float64
+	slowWindow    []bool
+	slowNext      int
+	slowSamples   int
+	slowCalls     int
 }
 
 // NewCircuitBreaker creates a new circuit breaker
@@ -32,9 +80,54 @@ func NewCircuitBreaker(failureThreshold int, timeout time.Duration) *CircuitBrea
 		timeout:          timeout,
 		state:            Closed,
 		failures:         0,
+		isFailure:        isNonNil,
+		successStreak:    1,
 	}
 }
 
+// NewCircuitBreakerWithState creates a circuit breaker starting in initial.
+// A breaker starting Open rejects operations until its timeout has passed
+// and then half-opens as usual; use ForceOpen to keep it open indefinitely.
+func NewCircuitBreakerWithState(failureThreshold int, timeout time.Duration, initial State) *CircuitBreaker {
+	cb := NewCircuitBreaker(failureThreshold, timeout)
+	switch initial {
+	case Open:
+		cb.trip()
+	case HalfOpen:
+		cb.state = HalfOpen
+	}
+	return cb
+}
+
+// clone returns a breaker with the same settings and observer as cb. It
+// starts closed unless cb's state is pinned, in which case it is pinned too.
+func (cb *CircuitBreaker) clone() *CircuitBreaker {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	state := Closed
+	if cb.forced {
+		state = cb.state
+	}
+	return &CircuitBreaker{
+		failureThreshold: cb.failureThreshold,
+		timeout:          cb.timeout,
+		state:            state,
+		forced:           cb.forced,
+		lastFailureTime:  cb.lastFailureTime,
+		isFailure:        cb.isFailure,
+		successStreak:    cb.successStreak,
+		onReject:         cb.onReject,
+		slowThreshold:    cb.slowThreshold,
+		slowRate:         cb.slowRate,
+		slowWindow:       make([]bool, len(cb.slowWindow)),
+	}
+}
+
+// isNonNil is the default failure predicate, counting every error
+func isNonNil(err error) bool {
+	return err != nil
+}
+
 // Execute executes an operation with circuit breaker protection
 func (cb *CircuitBreaker) Execute(operation func() error) error {
 	cb.mutex.Lock()
@@ -42,41 +135,138 @@ func (cb *CircuitBreaker) Execute(operation func() error) error {
 
 	// Check if circuit breaker is open
 	if cb.state == Open {
-		if time.Since(cb.lastFailureTime) < cb.timeout {
-			return errors.New("circuit breaker is open")
+		if cb.forced || time.Since(cb.lastFailureTime) < cb.timeout {
+			cb.recordRejection()
+			return ErrOpen
 		}
 		// Timeout has passed, move to half-open state
 		cb.state = HalfOpen
 	}
 
 	// Execute the operation
+	start := time.Now()
 	err := operation()
+	if cb.forced {
+		// A breaker pinned closed ignores outcomes until released
+		return err
+	}
+	slow := cb.recordLatency(time.Since(start))
 
-	if err != nil {
+	if cb.isFailure(err) {
 		cb.recordFailure()
 		return err
 	}
 
-	cb.recordSuccess()
-	return nil
+	// A slow trial call means the dependency has not recovered yet
+	if slow && cb.state == HalfOpen {
+		cb.trip()
+		return err
+	}
+
+	// Errors that are not failures say nothing about the dependency, so they
+	// count toward neither the failures nor the success streak that closes it
+	if err == nil {
+		cb.recordSuccess()
+	}
+	if cb.slowRateExceeded() {
+		cb.trip()
+	}
+	return err
+}
+
+// ExecuteWithTimeout executes an operation with circuit breaker protection,
+// cancelling its context after timeout. An operation running into the
+// timeout is also counted as slow when latency tracking is enabled.
+func (cb *CircuitBreaker) ExecuteWithTimeout(ctx context.Context, timeout time.Duration, operation func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return cb.Execute(func() error {
+		return operation(ctx)
+	})
+}
+
+// recordLatency adds an operation's duration to the rolling window and reports whether it was slow
+func (cb *CircuitBreaker) recordLatency(elapsed time.Duration) bool {
+	if cb.slowThreshold <= 0 {
+		return false
+	}
+
+	slow := elapsed > cb.slowThreshold
+	if cb.slowSamples == len(cb.slowWindow) {
+		if cb.slowWindow[cb.slowNext] {
+			cb.slowCalls--
+		}
+	} else {
+		cb.slowSamples++
+	}
+	cb.slowWindow[cb.slowNext] = slow
+	if slow {
+		cb.slowCalls++
+	}
+	cb.slowNext = (cb.slowNext + 1) % len(cb.slowWindow)
+	return slow
+}
+
+// slowRateExceeded reports whether a full window has at least slowRate slow operations
+func (cb *CircuitBreaker) slowRateExceeded() bool {
+	if cb.slowThreshold <= 0 || cb.slowSamples < len(cb.slowWindow) {
+		return false
+	}
+	return float64(cb.slowCalls)/float64(cb.slowSamples) >= cb.slowRate
+}
+
+// trip opens the breaker, starting a fresh open period
+func (cb *CircuitBreaker) trip() {
+	cb.state = Open
+	cb.successes = 0
+	cb.lastFailureTime = time.Now()
+	cb.rejectedSinceOpen = false
+	cb.resetLatency()
+}
+
+// resetLatency clears the rolling latency window
+func (cb *CircuitBreaker) resetLatency() {
+	for i := range cb.slowWindow {
+		cb.slowWindow[i] = false
+	}
+	cb.slowNext = 0
+	cb.slowSamples = 0
+	cb.slowCalls = 0
 }
 
 // recordFailure records a failure and updates the circuit breaker state
 func (cb *CircuitBreaker) recordFailure() {
 	cb.failures++
+	cb.successes = 0
 	cb.lastFailureTime = time.Now()
 
 	if cb.failures >= cb.failureThreshold {
-		cb.state = Open
+		cb.trip()
 	}
 }
 
-// recordSuccess records a success and resets the circuit breaker if needed
+// recordRejection counts an operation rejected while open and notifies the observer
+func (cb *CircuitBreaker) recordRejection() {
+	cb.rejections++
+	first := !cb.rejectedSinceOpen
+	cb.rejectedSinceOpen = true
+	if cb.onReject != nil {
+		cb.onReject(first)
+	}
+}
+
+// recordSuccess records a success; once the streak is long enough the failure
+// count is cleared and a half-open breaker closes
 func (cb *CircuitBreaker) recordSuccess() {
+	cb.successes++
+	if cb.successes < cb.successStreak {
+		return
+	}
 	if cb.state == HalfOpen {
 		cb.state = Closed
 	}
 	cb.failures = 0
+	cb.successes = 0
 }
 
 // GetState returns the current state of the circuit breaker
@@ -86,6 +276,23 @@ func (cb *CircuitBreaker) GetState() State {
 	return cb.state
 }
 
+// RetryAfter returns how long until an open breaker half-opens, or zero if it is not open
+func (cb *CircuitBreaker) RetryAfter() time.Duration {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if cb.state != Open {
+		return 0
+	}
+	if cb.forced {
+		// No telling when it will be released; suggest one timeout
+		return cb.timeout
+	}
+	if remaining := cb.timeout - time.Since(cb.lastFailureTime); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
 // GetFailureCount returns the current failure count
 func (cb *CircuitBreaker) GetFailureCount() int {
 	cb.mutex.RLock()
@@ -93,10 +300,172 @@ func (cb *CircuitBreaker) GetFailureCount() int {
 	return cb.failures
 }
 
-// Reset resets the circuit breaker to closed state
+// Reset resets the circuit breaker to closed state, releasing any pinned state
 func (cb *CircuitBreaker) Reset() {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
 	cb.state = Closed
+	cb.forced = false
+	cb.failures = 0
+	cb.successes = 0
+	cb.resetLatency()
+}
+
+// ForceOpen opens the breaker and pins it open: every operation is rejected,
+// however long it stays open, until ForceClosed, Release or Reset is called
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.trip()
+	cb.forced = true
+}
+
+// ForceClosed closes the breaker and pins it closed: operations always run
+// and their failures are not counted until Release or Reset is called
+func (cb *CircuitBreaker) ForceClosed() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.state = Closed
+	cb.forced = true
 	cb.failures = 0
+	cb.successes = 0
+	cb.resetLatency()
+}
+
+// Release unpins a state set by ForceOpen or ForceClosed. The breaker keeps
+// its current state; a released open breaker half-opens after its timeout,
+// counted from when it was forced open.
+func (cb *CircuitBreaker) Release() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.forced = false
+}
+
+// IsForced reports whether the state is pinned by ForceOpen or ForceClosed
+func (cb *CircuitBreaker) IsForced() bool {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.forced
+}
+
+// SetThreshold updates the failure threshold used for subsequent trips
+func (cb *CircuitBreaker) SetThreshold(threshold int) error {
+	if threshold <= 0 {
+		return ErrInvalidThreshold
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.failureThreshold = threshold
+	return nil
+}
+
+// SetTimeout updates how long the circuit stays open before moving to half-open
+func (cb *CircuitBreaker) SetTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return ErrInvalidTimeout
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.timeout = timeout
+	return nil
+}
+
+// SetSuccessStreak sets how many consecutive successes it takes to clear
+// the failure count and to close a half-open breaker. With the default of
+// one, any success clears past failures; a longer streak keeps counting
+// failures interleaved with a few successes.
+func (cb *CircuitBreaker) SetSuccessStreak(streak int) error {
+	if streak <= 0 {
+		return ErrInvalidStreak
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.successStreak = streak
+	cb.successes = 0
+	return nil
+}
+
+// GetSuccessStreak returns the number of consecutive successes that reset the failure count
+func (cb *CircuitBreaker) GetSuccessStreak() int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.successStreak
+}
+
+// SetLatencyThreshold makes the breaker also open once at least rate of the
+// last window operations took longer than threshold, even if they succeeded.
+// A zero threshold disables latency tracking.
+func (cb *CircuitBreaker) SetLatencyThreshold(threshold time.Duration, rate float64, window int) error {
+	if threshold > 0 {
+		if rate <= 0 || rate > 1 {
+			return ErrInvalidSlowRate
+		}
+		if window <= 0 {
+			return ErrInvalidWindow
+		}
+	} else {
+		threshold, rate, window = 0, 0, 0
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.slowThreshold = threshold
+	cb.slowRate = rate
+	cb.slowWindow = make([]bool, window)
+	cb.resetLatency()
+	return nil
+}
+
+// GetSlowCallRate returns the fraction of recent operations slower than the latency threshold
+func (cb *CircuitBreaker) GetSlowCallRate() float64 {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if cb.slowSamples == 0 {
+		return 0
+	}
+	return float64(cb.slowCalls) / float64(cb.slowSamples)
+}
+
+// SetFailurePredicate sets which errors count toward tripping the breaker; nil restores the default of every error
+func (cb *CircuitBreaker) SetFailurePredicate(isFailure func(error) bool) {
+	if isFailure == nil {
+		isFailure = isNonNil
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.isFailure = isFailure
+}
+
+// SetRejectionObserver registers a callback run for every operation rejected
+// while open, told whether it is the first rejection since the breaker opened.
+// It runs under the breaker's lock, so it must not call back into the breaker.
+func (cb *CircuitBreaker) SetRejectionObserver(observer func(firstSinceOpen bool)) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.onReject = observer
+}
+
+// GetRejectionCount returns how many operations have been rejected while open
+func (cb *CircuitBreaker) GetRejectionCount() uint64 {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.rejections
+}
+
+// GetThreshold returns the current failure threshold
+func (cb *CircuitBreaker) GetThreshold() int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.failureThreshold
+}
+
+// GetTimeout returns the current open-state timeout
+func (cb *CircuitBreaker) GetTimeout() time.Duration {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.timeout
 }
@@ -1,9 +1,12 @@
 package circuitbreaker
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
+
+	apperrors "product-service/pkg/errors"
 )
 
 func TestCircuitBreaker_NewCircuitBreaker(t *testing.T) {
@@ -23,6 +26,175 @@ func TestCircuitBreaker_NewCircuitBreaker(t *testing.T) {
 	}
 }
 
+func TestCircuitBreaker_NewCircuitBreakerWithState(t *testing.T) {
+	t.Run("Open", func(t *testing.T) {
+		cb := NewCircuitBreakerWithState(3, 20*time.Millisecond, Open)
+		if cb.GetState() != Open {
+			t.Fatalf("Expected initial state Open, got %v", cb.GetState())
+		}
+		if err := cb.Execute(func() error { return nil }); err != ErrOpen {
+			t.Errorf("Expected ErrOpen, got %v", err)
+		}
+
+		// Unlike ForceOpen, the timeout still half-opens it
+		time.Sleep(30 * time.Millisecond)
+		if err := cb.Execute(func() error { return nil }); err != nil {
+			t.Errorf("Expected trial call after timeout to run, got %v", err)
+		}
+		if cb.GetState() != Closed {
+			t.Errorf("Expected state Closed after a successful trial, got %v", cb.GetState())
+		}
+	})
+
+	t.Run("HalfOpen", func(t *testing.T) {
+		cb := NewCircuitBreakerWithState(3, time.Minute, HalfOpen)
+		cb.Execute(func() error { return errors.New("still down") })
+		if cb.GetState() != HalfOpen {
+			t.Errorf("Expected a failed trial below the threshold to stay half-open, got %v", cb.GetState())
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		if cb := NewCircuitBreakerWithState(3, time.Minute, Closed); cb.GetState() != Closed {
+			t.Errorf("Expected initial state Closed, got %v", cb.GetState())
+		}
+	})
+}
+
+func TestCircuitBreaker_SuccessStreak(t *testing.T) {
+	fail := func() error { return errors.New("down") }
+	succeed := func() error { return nil }
+
+	t.Run("DefaultClearsOnAnySuccess", func(t *testing.T) {
+		cb := NewCircuitBreaker(3, time.Minute)
+		cb.Execute(fail)
+		cb.Execute(fail)
+		cb.Execute(succeed)
+		if cb.GetFailureCount() != 0 {
+			t.Errorf("Expected a success to clear failures by default, got %d", cb.GetFailureCount())
+		}
+	})
+
+	t.Run("InterleavedSuccessesKeepPenalty", func(t *testing.T) {
+		cb := NewCircuitBreaker(3, time.Minute)
+		if err := cb.SetSuccessStreak(3); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cb.Execute(fail)
+		cb.Execute(succeed)
+		cb.Execute(fail)
+		cb.Execute(succeed)
+		cb.Execute(succeed)
+		if cb.GetFailureCount() != 2 {
+			t.Fatalf("Expected failures to accumulate across short success runs, got %d", cb.GetFailureCount())
+		}
+
+		cb.Execute(succeed)
+		if cb.GetFailureCount() != 0 {
+			t.Errorf("Expected a streak of 3 successes to clear failures, got %d", cb.GetFailureCount())
+		}
+
+		// A failure then starts the count from scratch
+		cb.Execute(fail)
+		if cb.GetFailureCount() != 1 || cb.GetState() != Closed {
+			t.Errorf("Expected 1 failure and a closed breaker, got %d in %v", cb.GetFailureCount(), cb.GetState())
+		}
+	})
+
+	t.Run("HalfOpenNeedsStreak", func(t *testing.T) {
+		cb := NewCircuitBreakerWithState(5, 10*time.Millisecond, Open)
+		cb.SetSuccessStreak(2)
+		time.Sleep(20 * time.Millisecond)
+
+		cb.Execute(succeed)
+		if cb.GetState() != HalfOpen {
+			t.Fatalf("Expected one trial success to leave the breaker half-open, got %v", cb.GetState())
+		}
+		cb.Execute(succeed)
+		if cb.GetState() != Closed || cb.GetFailureCount() != 0 {
+			t.Errorf("Expected the streak to close the breaker with no failures, got %v with %d", cb.GetState(), cb.GetFailureCount())
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cb := NewCircuitBreaker(3, time.Minute)
+		if err := cb.SetSuccessStreak(0); err != ErrInvalidStreak {
+			t.Errorf("Expected ErrInvalidStreak, got %v", err)
+		}
+		if cb.GetSuccessStreak() != 1 {
+			t.Errorf("Expected streak to remain 1, got %d", cb.GetSuccessStreak())
+		}
+	})
+}
+
+func TestCircuitBreaker_ForceOpen(t *testing.T) {
+	cb := NewCircuitBreaker(1, 10*time.Millisecond)
+	cb.ForceOpen()
+
+	if cb.GetState() != Open || !cb.IsForced() {
+		t.Fatalf("Expected breaker pinned open, got %v (forced %v)", cb.GetState(), cb.IsForced())
+	}
+
+	// Neither the timeout passing nor operations succeeding release it
+	time.Sleep(20 * time.Millisecond)
+	calls := 0
+	for i := 0; i < 5; i++ {
+		err := cb.Execute(func() error {
+			calls++
+			return nil
+		})
+		if err != ErrOpen {
+			t.Errorf("Expected ErrOpen while forced open, got %v", err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Expected no operation to run while forced open, got %d", calls)
+	}
+	if cb.GetState() != Open {
+		t.Errorf("Expected state to stay Open, got %v", cb.GetState())
+	}
+	if cb.RetryAfter() != cb.GetTimeout() {
+		t.Errorf("Expected RetryAfter to suggest one timeout, got %v", cb.RetryAfter())
+	}
+
+	t.Run("ForceClosed", func(t *testing.T) {
+		cb.ForceClosed()
+		if cb.GetState() != Closed || !cb.IsForced() {
+			t.Fatalf("Expected breaker pinned closed, got %v (forced %v)", cb.GetState(), cb.IsForced())
+		}
+
+		// Failures past the threshold do not open a breaker pinned closed
+		for i := 0; i < 3; i++ {
+			if err := cb.Execute(func() error { return errors.New("maintenance") }); err == ErrOpen {
+				t.Fatal("Expected operations to run while forced closed")
+			}
+		}
+		if cb.GetState() != Closed || cb.GetFailureCount() != 0 {
+			t.Errorf("Expected state Closed with no failures counted, got %v with %d", cb.GetState(), cb.GetFailureCount())
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		cb.Release()
+		if cb.IsForced() {
+			t.Error("Expected breaker to be released")
+		}
+		cb.Execute(func() error { return errors.New("down") })
+		if cb.GetState() != Open {
+			t.Errorf("Expected a released breaker to trip again, got %v", cb.GetState())
+		}
+	})
+
+	t.Run("ResetReleases", func(t *testing.T) {
+		cb.ForceOpen()
+		cb.Reset()
+		if cb.IsForced() || cb.GetState() != Closed {
+			t.Errorf("Expected Reset to release and close the breaker, got %v (forced %v)", cb.GetState(), cb.IsForced())
+		}
+	})
+}
+
 func TestCircuitBreaker_Execute_Success(t *testing.T) {
 	cb := NewCircuitBreaker(3, 5*time.Second)
 
@@ -214,3 +386,319 @@ func TestCircuitBreaker_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected state Open after 10 failures, got %v", cb.state)
 	}
 }
+
+func TestCircuitBreaker_SetThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(2, 5*time.Second)
+
+	// One failure under the original threshold
+	cb.Execute(func() error { return errors.New("error 1") })
+
+	// Loosen the breaker mid-operation
+	if err := cb.SetThreshold(4); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The second failure would have tripped the old threshold
+	cb.Execute(func() error { return errors.New("error 2") })
+	cb.Execute(func() error { return errors.New("error 3") })
+
+	if cb.GetState() != Closed {
+		t.Errorf("Expected state Closed under new threshold, got %v", cb.GetState())
+	}
+
+	// The fourth failure reaches the new threshold
+	cb.Execute(func() error { return errors.New("error 4") })
+
+	if cb.GetState() != Open {
+		t.Errorf("Expected state Open after reaching new threshold, got %v", cb.GetState())
+	}
+	if cb.GetThreshold() != 4 {
+		t.Errorf("Expected threshold 4, got %d", cb.GetThreshold())
+	}
+}
+
+func TestCircuitBreaker_SetThreshold_Tighten(t *testing.T) {
+	cb := NewCircuitBreaker(5, 5*time.Second)
+
+	cb.Execute(func() error { return errors.New("error 1") })
+
+	if err := cb.SetThreshold(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cb.Execute(func() error { return errors.New("error 2") })
+
+	if cb.GetState() != Open {
+		t.Errorf("Expected state Open after reaching tightened threshold, got %v", cb.GetState())
+	}
+}
+
+func TestCircuitBreaker_SetThreshold_Invalid(t *testing.T) {
+	cb := NewCircuitBreaker(3, 5*time.Second)
+
+	for _, threshold := range []int{0, -1} {
+		if err := cb.SetThreshold(threshold); err != ErrInvalidThreshold {
+			t.Errorf("Expected ErrInvalidThreshold for %d, got %v", threshold, err)
+		}
+	}
+
+	if cb.GetThreshold() != 3 {
+		t.Errorf("Expected threshold to remain 3, got %d", cb.GetThreshold())
+	}
+}
+
+func TestCircuitBreaker_SetTimeout(t *testing.T) {
+	cb := NewCircuitBreaker(1, 5*time.Second)
+
+	// Open the circuit
+	cb.Execute(func() error { return errors.New("error 1") })
+
+	// Shorten the timeout so the circuit can half-open quickly
+	if err := cb.SetTimeout(50 * time.Millisecond); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	err := cb.Execute(func() error { return nil })
+	if err != nil {
+		t.Errorf("Expected no error after new timeout elapsed, got %v", err)
+	}
+	if cb.GetState() != Closed {
+		t.Errorf("Expected state Closed, got %v", cb.GetState())
+	}
+}
+
+func TestCircuitBreaker_SetTimeout_Invalid(t *testing.T) {
+	cb := NewCircuitBreaker(3, 5*time.Second)
+
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		if err := cb.SetTimeout(timeout); err != ErrInvalidTimeout {
+			t.Errorf("Expected ErrInvalidTimeout for %v, got %v", timeout, err)
+		}
+	}
+
+	if cb.GetTimeout() != 5*time.Second {
+		t.Errorf("Expected timeout to remain 5s, got %v", cb.GetTimeout())
+	}
+}
+
+func TestCircuitBreaker_FailurePredicate(t *testing.T) {
+	cb := NewCircuitBreaker(2, 5*time.Second)
+	cb.SetFailurePredicate(apperrors.IsDependencyFailure)
+
+	t.Run("ValidationErrorsNeverTrip", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			validationErr := apperrors.NewValidationError("invalid price", nil)
+			if err := cb.Execute(func() error { return validationErr }); err != validationErr {
+				t.Errorf("Expected validation error to be returned, got %v", err)
+			}
+		}
+
+		if cb.GetState() != Closed {
+			t.Errorf("Expected state Closed after validation errors, got %v", cb.GetState())
+		}
+		if cb.GetFailureCount() != 0 {
+			t.Errorf("Expected failure count 0, got %d", cb.GetFailureCount())
+		}
+	})
+
+	t.Run("ValidationErrorsAreNeutralWhenHalfOpen", func(t *testing.T) {
+		halfOpen := NewCircuitBreakerWithState(1, 5*time.Second, HalfOpen)
+		halfOpen.SetFailurePredicate(apperrors.IsDependencyFailure)
+		if err := halfOpen.SetSuccessStreak(2); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		halfOpen.Execute(func() error { return nil })
+		halfOpen.Execute(func() error { return apperrors.NewValidationError("invalid price", nil) })
+		if halfOpen.GetState() != HalfOpen {
+			t.Fatalf("Expected a validation error not to close the breaker, got %v", halfOpen.GetState())
+		}
+
+		// The neutral call neither broke nor extended the streak
+		halfOpen.Execute(func() error { return nil })
+		if halfOpen.GetState() != Closed {
+			t.Errorf("Expected the second success to close the breaker, got %v", halfOpen.GetState())
+		}
+	})
+
+	t.Run("SystemErrorsTrip", func(t *testing.T) {
+		cb.Execute(func() error { return apperrors.NewSystemError("repository unavailable", nil) })
+		cb.Execute(func() error { return apperrors.NewSystemError("repository unavailable", nil) })
+
+		if cb.GetState() != Open {
+			t.Errorf("Expected state Open after system errors, got %v", cb.GetState())
+		}
+	})
+}
+
+func TestCircuitBreaker_SetFailurePredicate_NilRestoresDefault(t *testing.T) {
+	cb := NewCircuitBreaker(1, 5*time.Second)
+	cb.SetFailurePredicate(func(error) bool { return false })
+	cb.SetFailurePredicate(nil)
+
+	cb.Execute(func() error { return apperrors.NewValidationError("invalid price", nil) })
+
+	if cb.GetState() != Open {
+		t.Errorf("Expected default predicate to count every error, got state %v", cb.GetState())
+	}
+}
+
+func TestCircuitBreaker_RejectionObserver(t *testing.T) {
+	cb := NewCircuitBreaker(1, 50*time.Millisecond)
+
+	var firsts []bool
+	cb.SetRejectionObserver(func(firstSinceOpen bool) {
+		firsts = append(firsts, firstSinceOpen)
+	})
+
+	cb.Execute(func() error { return errors.New("error") })
+	for i := 0; i < 3; i++ {
+		if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrOpen) {
+			t.Errorf("Expected ErrOpen, got %v", err)
+		}
+	}
+	if cb.GetRejectionCount() != 3 {
+		t.Errorf("Expected 3 rejections, got %d", cb.GetRejectionCount())
+	}
+
+	// A failed half-open probe reopens the breaker, so the next rejection is a first again
+	time.Sleep(60 * time.Millisecond)
+	cb.Execute(func() error { return errors.New("probe failed") })
+	cb.Execute(func() error { return nil })
+
+	expected := []bool{true, false, false, true}
+	if len(firsts) != len(expected) {
+		t.Fatalf("Expected %d observed rejections, got %v", len(expected), firsts)
+	}
+	for i := range expected {
+		if firsts[i] != expected[i] {
+			t.Errorf("Expected rejection %d first=%v, got %v", i, expected[i], firsts[i])
+		}
+	}
+
+	// Successful probes are not rejections
+	time.Sleep(60 * time.Millisecond)
+	cb.Execute(func() error { return nil })
+	cb.Execute(func() error { return nil })
+	if cb.GetRejectionCount() != 4 {
+		t.Errorf("Expected the rejection count to stay 4, got %d", cb.GetRejectionCount())
+	}
+}
+
+func TestCircuitBreaker_RetryAfter(t *testing.T) {
+	cb := NewCircuitBreaker(1, 100*time.Millisecond)
+
+	if wait := cb.RetryAfter(); wait != 0 {
+		t.Errorf("Expected no wait while closed, got %v", wait)
+	}
+
+	cb.Execute(func() error { return errors.New("failure") })
+	if wait := cb.RetryAfter(); wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("Expected a wait of up to 100ms while open, got %v", wait)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if wait := cb.RetryAfter(); wait != 0 {
+		t.Errorf("Expected no wait once the timeout has passed, got %v", wait)
+	}
+}
+
+func TestCircuitBreaker_LatencyThreshold(t *testing.T) {
+	slow := func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+	fast := func() error { return nil }
+
+	t.Run("OpensOnSlowSuccesses", func(t *testing.T) {
+		cb := NewCircuitBreaker(5, time.Minute)
+		if err := cb.SetLatencyThreshold(5*time.Millisecond, 0.5, 4); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for _, operation := range []func() error{fast, slow, fast} {
+			if err := cb.Execute(operation); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if cb.GetState() != Closed {
+			t.Errorf("Expected breaker closed before the window fills, got %s", cb.GetState())
+		}
+
+		cb.Execute(slow)
+		if cb.GetState() != Open {
+			t.Errorf("Expected breaker open at a 50%% slow rate, got %s", cb.GetState())
+		}
+		if cb.GetFailureCount() != 0 {
+			t.Errorf("Expected no failures counted, got %d", cb.GetFailureCount())
+		}
+		if err := cb.Execute(fast); err != ErrOpen {
+			t.Errorf("Expected ErrOpen, got %v", err)
+		}
+	})
+
+	t.Run("StaysClosedBelowRate", func(t *testing.T) {
+		cb := NewCircuitBreaker(5, time.Minute)
+		cb.SetLatencyThreshold(5*time.Millisecond, 0.5, 4)
+
+		for _, operation := range []func() error{slow, fast, fast, fast, fast, slow} {
+			cb.Execute(operation)
+		}
+		if cb.GetState() != Closed {
+			t.Errorf("Expected breaker closed with 1 slow call in the window, got %s", cb.GetState())
+		}
+		if rate := cb.GetSlowCallRate(); rate != 0.25 {
+			t.Errorf("Expected slow call rate 0.25, got %f", rate)
+		}
+	})
+
+	t.Run("SlowTrialReopens", func(t *testing.T) {
+		cb := NewCircuitBreaker(5, 20*time.Millisecond)
+		cb.SetLatencyThreshold(5*time.Millisecond, 1, 1)
+
+		cb.Execute(slow)
+		if cb.GetState() != Open {
+			t.Fatalf("Expected breaker open, got %s", cb.GetState())
+		}
+
+		time.Sleep(30 * time.Millisecond)
+		cb.Execute(slow)
+		if cb.GetState() != Open {
+			t.Errorf("Expected a slow half-open trial to reopen the breaker, got %s", cb.GetState())
+		}
+	})
+
+	t.Run("ExecuteWithTimeout", func(t *testing.T) {
+		cb := NewCircuitBreaker(5, time.Minute)
+		cb.SetFailurePredicate(func(error) bool { return false })
+		cb.SetLatencyThreshold(5*time.Millisecond, 1, 2)
+
+		hang := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		for i := 0; i < 2; i++ {
+			if err := cb.ExecuteWithTimeout(context.Background(), 10*time.Millisecond, hang); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected DeadlineExceeded, got %v", err)
+			}
+		}
+		if cb.GetState() != Open {
+			t.Errorf("Expected timed out operations to open the breaker on latency, got %s", cb.GetState())
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cb := NewCircuitBreaker(5, time.Minute)
+		if err := cb.SetLatencyThreshold(time.Millisecond, 0, 10); err != ErrInvalidSlowRate {
+			t.Errorf("Expected ErrInvalidSlowRate, got %v", err)
+		}
+		if err := cb.SetLatencyThreshold(time.Millisecond, 0.5, 0); err != ErrInvalidWindow {
+			t.Errorf("Expected ErrInvalidWindow, got %v", err)
+		}
+		if err := cb.SetLatencyThreshold(0, 0, 0); err != nil {
+			t.Errorf("Expected disabling to succeed, got %v", err)
+		}
+	})
+}
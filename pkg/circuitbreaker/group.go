@@ -0,0 +1,165 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerGroup keeps an independent circuit breaker per key, so failures
+// under one key trip only that key's breaker. The empty key maps to the base
+// breaker, and breakers for other keys start with the base breaker's settings.
+type CircuitBreakerGroup struct {
+	mu       sync.Mutex
+	base     *CircuitBreaker
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakerGroup creates a group whose keyed breakers are modeled on base
+func NewCircuitBreakerGroup(base *CircuitBreaker) *CircuitBreakerGroup {
+	return &CircuitBreakerGroup{
+		base:     base,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns the breaker for key, creating it on first use
+func (g *CircuitBreakerGroup) Get(key string) *CircuitBreaker {
+	if key == "" {
+		return g.base
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cb, ok := g.breakers[key]
+	if !ok {
+		cb = g.base.clone()
+		g.breakers[key] = cb
+	}
+	return cb
+}
+
+// Execute runs operation under the breaker for key
+func (g *CircuitBreakerGroup) Execute(key string, operation func() error) error {
+	return g.Get(key).Execute(operation)
+}
+
+// Base returns the breaker used for the empty key
+func (g *CircuitBreakerGroup) Base() *CircuitBreaker {
+	return g.base
+}
+
+// Keys returns the keys that have their own breaker, in sorted order
+func (g *CircuitBreakerGroup) Keys() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := make([]string, 0, len(g.breakers))
+	for key := range g.breakers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetThreshold updates the failure threshold of the base breaker and every keyed breaker
+func (g *CircuitBreakerGroup) SetThreshold(threshold int) error {
+	if err := g.base.SetThreshold(threshold); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.SetThreshold(threshold)
+	}
+	return nil
+}
+
+// SetTimeout updates the open-state timeout of the base breaker and every keyed breaker
+func (g *CircuitBreakerGroup) SetTimeout(timeout time.Duration) error {
+	if err := g.base.SetTimeout(timeout); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.SetTimeout(timeout)
+	}
+	return nil
+}
+
+// SetSuccessStreak updates the success streak of the base breaker and every keyed breaker
+func (g *CircuitBreakerGroup) SetSuccessStreak(streak int) error {
+	if err := g.base.SetSuccessStreak(streak); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.SetSuccessStreak(streak)
+	}
+	return nil
+}
+
+// SetLatencyThreshold updates the slow-call settings of the base breaker and every keyed breaker
+func (g *CircuitBreakerGroup) SetLatencyThreshold(threshold time.Duration, rate float64, window int) error {
+	if err := g.base.SetLatencyThreshold(threshold, rate, window); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.SetLatencyThreshold(threshold, rate, window)
+	}
+	return nil
+}
+
+// Reset closes the base breaker and every keyed breaker
+func (g *CircuitBreakerGroup) Reset() {
+	g.base.Reset()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.Reset()
+	}
+}
+
+// ForceOpen pins the base breaker and every keyed breaker open; breakers
+// created for new keys start pinned open as well
+func (g *CircuitBreakerGroup) ForceOpen() {
+	g.base.ForceOpen()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.ForceOpen()
+	}
+}
+
+// ForceClosed pins the base breaker and every keyed breaker closed
+func (g *CircuitBreakerGroup) ForceClosed() {
+	g.base.ForceClosed()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.ForceClosed()
+	}
+}
+
+// Release unpins the base breaker and every keyed breaker
+func (g *CircuitBreakerGroup) Release() {
+	g.base.Release()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, cb := range g.breakers {
+		cb.Release()
+	}
+}
@@ -0,0 +1,87 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerGroup_IsolatesKeys(t *testing.T) {
+	base := NewCircuitBreaker(2, time.Minute)
+	group := NewCircuitBreakerGroup(base)
+
+	for i := 0; i < 2; i++ {
+		group.Execute("failing", func() error { return errors.New("feed unavailable") })
+	}
+
+	if err := group.Execute("failing", func() error { return nil }); !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected the failing key to be rejected, got %v", err)
+	}
+	if err := group.Execute("healthy", func() error { return nil }); err != nil {
+		t.Errorf("Expected the healthy key to keep executing, got %v", err)
+	}
+	if base.GetState() != Closed {
+		t.Errorf("Expected the base breaker to stay closed, got %s", base.GetState())
+	}
+	if group.Get("") != base {
+		t.Error("Expected the empty key to use the base breaker")
+	}
+
+	keys := group.Keys()
+	if len(keys) != 2 || keys[0] != "failing" || keys[1] != "healthy" {
+		t.Errorf("Expected keys [failing healthy], got %v", keys)
+	}
+}
+
+func TestCircuitBreakerGroup_Settings(t *testing.T) {
+	base := NewCircuitBreaker(5, time.Minute)
+	group := NewCircuitBreakerGroup(base)
+
+	existing := group.Get("existing")
+	if existing.GetThreshold() != 5 || existing.GetTimeout() != time.Minute {
+		t.Errorf("Expected a new key to inherit the base settings, got %d/%v", existing.GetThreshold(), existing.GetTimeout())
+	}
+
+	if err := group.SetThreshold(0); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("Expected ErrInvalidThreshold, got %v", err)
+	}
+	group.SetThreshold(3)
+	group.SetTimeout(time.Second)
+	group.SetSuccessStreak(4)
+	for _, cb := range []*CircuitBreaker{base, existing, group.Get("later")} {
+		if cb.GetThreshold() != 3 || cb.GetTimeout() != time.Second {
+			t.Errorf("Expected threshold 3 and timeout 1s, got %d/%v", cb.GetThreshold(), cb.GetTimeout())
+		}
+		if cb.GetSuccessStreak() != 4 {
+			t.Errorf("Expected success streak 4, got %d", cb.GetSuccessStreak())
+		}
+	}
+}
+
+func TestCircuitBreakerGroup_Force(t *testing.T) {
+	group := NewCircuitBreakerGroup(NewCircuitBreaker(3, time.Minute))
+	existing := group.Get("existing")
+
+	group.ForceOpen()
+	created := group.Get("created")
+	for name, cb := range map[string]*CircuitBreaker{"base": group.Base(), "existing": existing, "created": created} {
+		if cb.GetState() != Open || !cb.IsForced() {
+			t.Errorf("Expected %s breaker pinned open, got %v (forced %v)", name, cb.GetState(), cb.IsForced())
+		}
+	}
+
+	group.ForceClosed()
+	if created.GetState() != Closed || !created.IsForced() {
+		t.Errorf("Expected keyed breaker pinned closed, got %v (forced %v)", created.GetState(), created.IsForced())
+	}
+
+	group.Release()
+	for _, key := range []string{"", "existing", "created"} {
+		if group.Get(key).IsForced() {
+			t.Errorf("Expected breaker %q to be released", key)
+		}
+	}
+	if cb := group.Get("after-release"); cb.IsForced() || cb.GetState() != Closed {
+		t.Errorf("Expected new breaker to start closed after release, got %v (forced %v)", cb.GetState(), cb.IsForced())
+	}
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+)
 
 // ErrorType represents the type of error for classification
 type ErrorType int
@@ -69,6 +72,22 @@ func (ce *ClassifiedError) IsSystemError() bool {
 	return ce.Type == SystemError
 }
 
+// IsDependencyFailure reports whether err says something about downstream health.
+// Validation and non-retryable errors describe the request rather than the
+// dependency, so they return false; any other non-nil error, classified or
+// not, returns true.
+func IsDependencyFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var classified *ClassifiedError
+	if stderrors.As(err, &classified) {
+		return classified.Type != ValidationError && classified.Type != NonRetryableError
+	}
+	return true
+}
+
 // NewClassifiedError creates a new classified error
 func NewClassifiedError(errorType ErrorType, message string, cause error) *ClassifiedError {
 	return &ClassifiedError{
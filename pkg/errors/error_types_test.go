@@ -233,3 +233,28 @@ func TestNewNonRetryableErrorWithCause(t *testing.T) {
 		t.Error("Expected ShouldRetry() to return false")
 	}
 }
+
+func TestIsDependencyFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"Nil", nil, false},
+		{"Unclassified", fmt.Errorf("boom"), true},
+		{"Validation", NewValidationError("bad input", nil), false},
+		{"NonRetryable", NewNonRetryableError("not found", nil), false},
+		{"System", NewSystemError("disk full", nil), true},
+		{"Network", NewNetworkError("connection reset", nil), true},
+		{"Timeout", NewTimeoutError("deadline exceeded", nil), true},
+		{"WrappedValidation", fmt.Errorf("handler: %w", NewValidationError("bad input", nil)), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := IsDependencyFailure(test.err); result != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+func TestNew_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := DefaultConfig()
+	cfg.Timeout = 50 * time.Millisecond
+	client := New(cfg)
+
+	start := time.Now()
+	_, err := client.Get(server.URL)
+	elapsed := time.Since(start)
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Expected the request to give up after about 50ms, took %v", elapsed)
+	}
+}
+
+func TestNew_ReusesConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := New(DefaultConfig())
+	get := func() bool {
+		t.Helper()
+		reused := false
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused },
+		}
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		resp, err := client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return reused
+	}
+
+	if get() {
+		t.Error("Expected the first request to open a new connection")
+	}
+	for i := 0; i < 3; i++ {
+		if !get() {
+			t.Errorf("Expected request %d to reuse the pooled connection", i+2)
+		}
+	}
+}
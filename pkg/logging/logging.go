@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is a logging verbosity; messages below the current level are suppressed
+type Level int32
+
+// Supported levels, from most to least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the lowercase name of the level
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+}
+
+// ParseLevel converts a level name into a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+	}
+}
+
+// current is the process-wide level; it can be changed at runtime
+var current = int32(LevelInfo)
+
+// SetLevel changes the process-wide level
+func SetLevel(level Level) {
+	atomic.StoreInt32(&current, int32(level))
+}
+
+// GetLevel returns the process-wide level
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&current))
+}
+
+// Enabled reports whether messages at level should be logged
+func Enabled(level Level) bool {
+	return level >= GetLevel()
+}
@@ -0,0 +1,36 @@
+package logging
+
+import "testing"
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]Level{"debug": LevelDebug, "INFO": LevelInfo, "warning": LevelWarn, " error ": LevelError} {
+		level, err := ParseLevel(name)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", name, err)
+		}
+		if level != expected {
+			t.Errorf("Expected %s for %q, got %s", expected, name, level)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	defer SetLevel(GetLevel())
+
+	SetLevel(LevelWarn)
+	if Enabled(LevelInfo) {
+		t.Error("Expected info to be suppressed at warn level")
+	}
+	if !Enabled(LevelError) {
+		t.Error("Expected error to be logged at warn level")
+	}
+
+	SetLevel(LevelDebug)
+	if !Enabled(LevelDebug) {
+		t.Error("Expected debug to be logged at debug level")
+	}
+}
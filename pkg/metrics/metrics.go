@@ -0,0 +1,380 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Type identifies the kind of a metric family
+type Type string
+
+const (
+	CounterType   Type = "counter"
+	GaugeType     Type = "gauge"
+	HistogramType Type = "histogram"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds suited to request and operation latencies
+var DefaultBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// Labels are the label name/value pairs identifying a series within a family
+type Labels map[string]string
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	value atomic.Uint64
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increments the counter by delta
+func (c *Counter) Add(delta uint64) {
+	c.value.Add(delta)
+}
+
+// Value returns the current counter value
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the gauge to value
+func (g *Gauge) Set(value float64) {
+	g.bits.Store(math.Float64bits(value))
+}
+
+// Add adds delta to the gauge
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := g.bits.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if g.bits.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+// Value returns the current gauge value
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Observe records a single observation
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		if value <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the sum of all observations
+func (h *Histogram) Sum() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// series is a single labelled metric within a family
+type series struct {
+	labels    Labels
+	counter   *Counter
+	gauge     *Gauge
+	histogram *Histogram
+}
+
+// family groups every series sharing a metric name
+type family struct {
+	name   string
+	help   string
+	typ    Type
+	series map[string]*series
+}
+
+// Registry holds all metrics exposed by the service
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+// NewRegistry creates a new, empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// Counter returns the counter for name and labels, creating it if needed
+func (r *Registry) Counter(name, help string, labels Labels) *Counter {
+	s := r.getOrCreate(name, help, CounterType, labels, func(s *series) {
+		s.counter = &Counter{}
+	})
+	return s.counter
+}
+
+// Gauge returns the gauge for name and labels, creating it if needed
+func (r *Registry) Gauge(name, help string, labels Labels) *Gauge {
+	s := r.getOrCreate(name, help, GaugeType, labels, func(s *series) {
+		s.gauge = &Gauge{}
+	})
+	return s.gauge
+}
+
+// Histogram returns the histogram for name and labels, creating it with buckets if needed
+func (r *Registry) Histogram(name, help string, buckets []float64, labels Labels) *Histogram {
+	s := r.getOrCreate(name, help, HistogramType, labels, func(s *series) {
+		sorted := append([]float64(nil), buckets...)
+		sort.Float64s(sorted)
+		s.histogram = &Histogram{
+			buckets: sorted,
+			counts:  make([]uint64, len(sorted)),
+		}
+	})
+	return s.histogram
+}
+
+// getOrCreate looks up a series, initialising it on first use
+func (r *Registry) getOrCreate(name, help string, typ Type, labels Labels, init func(*series)) *series {
+	key := labelKey(labels)
+
+	r.mu.RLock()
+	if f, ok := r.families[name]; ok {
+		if s, ok := f.series[key]; ok && f.typ == typ {
+			r.mu.RUnlock()
+			return s
+		}
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, typ: typ, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+	if f.typ != typ {
+		panic(fmt.Sprintf("metric %s registered as %s, requested as %s", name, f.typ, typ))
+	}
+
+	s, ok := f.series[key]
+	if !ok {
+		copied := make(Labels, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		s = &series{labels: copied}
+		init(s)
+		f.series[key] = s
+	}
+	return s
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.series[key]
+			switch f.typ {
+			case CounterType:
+				fmt.Fprintf(&b, "%s%s %d\n", f.name, formatLabels(s.labels, "", ""), s.counter.Value())
+			case GaugeType:
+				fmt.Fprintf(&b, "%s%s %s\n", f.name, formatLabels(s.labels, "", ""), formatFloat(s.gauge.Value()))
+			case HistogramType:
+				h := s.histogram
+				h.mu.Lock()
+				for i, upper := range h.buckets {
+					fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, formatLabels(s.labels, "le", formatFloat(upper)), h.counts[i])
+				}
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, formatLabels(s.labels, "le", "+Inf"), h.count)
+				fmt.Fprintf(&b, "%s_sum%s %s\n", f.name, formatLabels(s.labels, "", ""), formatFloat(h.sum))
+				fmt.Fprintf(&b, "%s_count%s %d\n", f.name, formatLabels(s.labels, "", ""), h.count)
+				h.mu.Unlock()
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// FamilySnapshot is the JSON form of a metric family
+type FamilySnapshot struct {
+	Name   string           `json:"name"`
+	Help   string           `json:"help"`
+	Type   Type             `json:"type"`
+	Series []SeriesSnapshot `json:"series"`
+}
+
+// SeriesSnapshot is the JSON form of one labelled series. Counters and gauges
+// set Value, which is null for a NaN or infinite gauge; histograms set Histogram.
+type SeriesSnapshot struct {
+	Labels    Labels             `json:"labels,omitempty"`
+	Value     *float64           `json:"value,omitempty"`
+	Histogram *HistogramSnapshot `json:"histogram,omitempty"`
+}
+
+// HistogramSnapshot holds a histogram's cumulative bucket counts, sum and count
+type HistogramSnapshot struct {
+	Buckets []BucketSnapshot `json:"buckets"`
+	Sum     float64          `json:"sum"`
+	Count   uint64           `json:"count"`
+}
+
+// BucketSnapshot counts the observations at or below UpperBound
+type BucketSnapshot struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// Snapshot returns every metric, sorted by name and then by labels
+func (r *Registry) Snapshot() []FamilySnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]FamilySnapshot, 0, len(names))
+	for _, name := range names {
+		f := r.families[name]
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		family := FamilySnapshot{Name: f.name, Help: f.help, Type: f.typ, Series: make([]SeriesSnapshot, 0, len(keys))}
+		for _, key := range keys {
+			s := f.series[key]
+			snapshot := SeriesSnapshot{Labels: s.labels}
+			switch f.typ {
+			case CounterType:
+				value := float64(s.counter.Value())
+				snapshot.Value = &value
+			case GaugeType:
+				if value := s.gauge.Value(); !math.IsNaN(value) && !math.IsInf(value, 0) {
+					snapshot.Value = &value
+				}
+			case HistogramType:
+				h := s.histogram
+				h.mu.Lock()
+				buckets := make([]BucketSnapshot, len(h.buckets))
+				for i, upper := range h.buckets {
+					buckets[i] = BucketSnapshot{UpperBound: upper, Count: h.counts[i]}
+				}
+				snapshot.Histogram = &HistogramSnapshot{Buckets: buckets, Sum: h.sum, Count: h.count}
+				h.mu.Unlock()
+			}
+			family.Series = append(family.Series, snapshot)
+		}
+		families = append(families, family)
+	}
+	return families
+}
+
+// WriteJSON writes every metric as a JSON document of the form {"metrics": [...]}
+func (r *Registry) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(struct {
+		Metrics []FamilySnapshot `json:"metrics"`
+	}{r.Snapshot()})
+}
+
+// labelKey builds a stable key identifying a label set
+func labelKey(labels Labels) string {
+	return formatLabels(labels, "", "")
+}
+
+// formatLabels renders labels (plus an optional extra label) as {k="v",...}
+func formatLabels(labels Labels, extraName, extraValue string) string {
+	if len(labels) == 0 && extraName == "" {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extraName, extraValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat renders a float the way Prometheus expects
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return fmt.Sprintf("%g", value)
+	}
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_Counter(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.Counter("events_total", "Total events", Labels{"reason": "a"})
+	c.Inc()
+	c.Add(2)
+
+	// The same name and labels return the same counter
+	if r.Counter("events_total", "Total events", Labels{"reason": "a"}).Value() != 3 {
+		t.Errorf("Expected counter value 3, got %d", c.Value())
+	}
+
+	// Different labels are a different series
+	if r.Counter("events_total", "Total events", Labels{"reason": "b"}).Value() != 0 {
+		t.Error("Expected a new series for different labels")
+	}
+}
+
+func TestRegistry_Gauge(t *testing.T) {
+	r := NewRegistry()
+
+	g := r.Gauge("queue_depth", "Queue depth", nil)
+	g.Set(5)
+	g.Add(-2)
+
+	if g.Value() != 3 {
+		t.Errorf("Expected gauge value 3, got %f", g.Value())
+	}
+}
+
+func TestRegistry_Histogram(t *testing.T) {
+	r := NewRegistry()
+
+	h := r.Histogram("latency_seconds", "Latency", []float64{0.1, 1}, nil)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	if h.Count() != 3 {
+		t.Errorf("Expected count 3, got %d", h.Count())
+	}
+	if h.Sum() != 2.55 {
+		t.Errorf("Expected sum 2.55, got %f", h.Sum())
+	}
+}
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	r := NewRegistry()
+
+	r.Counter("dropped_total", "Dropped events", Labels{"reason": "queue_full"}).Add(2)
+	r.Gauge("queue_depth", "Queue depth", nil).Set(7)
+	r.Histogram("latency_seconds", "Latency", []float64{0.1, 1}, Labels{"op": "get"}).Observe(0.5)
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	output := b.String()
+
+	expected := []string{
+		"# TYPE dropped_total counter",
+		`dropped_total{reason="queue_full"} 2`,
+		"# TYPE queue_depth gauge",
+		"queue_depth 7",
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{op="get",le="0.1"} 0`,
+		`latency_seconds_bucket{op="get",le="1"} 1`,
+		`latency_seconds_bucket{op="get",le="+Inf"} 1`,
+		`latency_seconds_sum{op="get"} 0.5`,
+		`latency_seconds_count{op="get"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestRegistry_WriteJSON(t *testing.T) {
+	r := NewRegistry()
+
+	r.Counter("dropped_total", "Dropped events", Labels{"reason": "queue_full"}).Add(2)
+	r.Gauge("queue_depth", "Queue depth", nil).Set(7)
+	r.Gauge("ratio", "Undefined ratio", nil).Set(math.NaN())
+	r.Histogram("latency_seconds", "Latency", []float64{0.1, 1}, Labels{"op": "get"}).Observe(0.5)
+
+	var b strings.Builder
+	if err := r.WriteJSON(&b); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var document struct {
+		Metrics []FamilySnapshot `json:"metrics"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &document); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, b.String())
+	}
+
+	families := make(map[string]FamilySnapshot)
+	var names []string
+	for _, family := range document.Metrics {
+		families[family.Name] = family
+		names = append(names, family.Name)
+	}
+	if strings.Join(names, ",") != "dropped_total,latency_seconds,queue_depth,ratio" {
+		t.Errorf("Expected families sorted by name, got %v", names)
+	}
+
+	dropped := families["dropped_total"]
+	if dropped.Type != CounterType || dropped.Help != "Dropped events" || len(dropped.Series) != 1 {
+		t.Fatalf("Expected one dropped_total counter series, got %+v", dropped)
+	}
+	if series := dropped.Series[0]; series.Labels["reason"] != "queue_full" || series.Value == nil || *series.Value != 2 {
+		t.Errorf("Expected queue_full count 2, got %+v", series)
+	}
+
+	if depth := families["queue_depth"].Series[0]; depth.Value == nil || *depth.Value != 7 {
+		t.Errorf("Expected queue depth 7, got %+v", depth)
+	}
+	if ratio := families["ratio"].Series[0]; ratio.Value != nil {
+		t.Errorf("Expected a NaN gauge to be null, got %v", *ratio.Value)
+	}
+
+	histogram := families["latency_seconds"].Series[0].Histogram
+	if histogram == nil {
+		t.Fatal("Expected histogram data")
+	}
+	if histogram.Count != 1 || histogram.Sum != 0.5 || len(histogram.Buckets) != 2 {
+		t.Errorf("Expected one 0.5 observation in 2 buckets, got %+v", histogram)
+	}
+	if histogram.Buckets[0].Count != 0 || histogram.Buckets[1].UpperBound != 1 || histogram.Buckets[1].Count != 1 {
+		t.Errorf("Expected cumulative bucket counts 0 and 1, got %+v", histogram.Buckets)
+	}
+}
+
+func TestRegistry_TypeMismatchPanics(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("metric", "help", nil)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic when re-registering a counter as a gauge")
+		}
+	}()
+	r.Gauge("metric", "help", nil)
+}
+
+func TestRegistry_ConcurrentAccess(t *testing.T) {
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Counter("concurrent_total", "Concurrent", Labels{"kind": "x"}).Inc()
+		}()
+	}
+	wg.Wait()
+
+	if r.Counter("concurrent_total", "Concurrent", Labels{"kind": "x"}).Value() != 50 {
+		t.Errorf("Expected 50, got %d", r.Counter("concurrent_total", "Concurrent", Labels{"kind": "x"}).Value())
+	}
+}
@@ -1,114 +1,476 @@
 package queue
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
 
 	"product-service/internal/models"
+	"product-service/pkg/circuitbreaker"
 )
 
 // BatchProcessor handles batch processing of events for high throughput
+//
+// Full batches are handed to the processor goroutine over a buffered channel
+// holding at most maxInFlight batches. When that buffer is exhausted, flushing
+// blocks instead of dropping the batch, so a slow processor applies
+// backpressure all the way up to AddEvent callers. Batches are sent without
+// holding the mutex, so a blocked flush stalls only its own caller and those
+// queued behind it: batches are always sent in the order they were taken.
 type BatchProcessor struct {
 	batchSize     int
 	flushInterval time.Duration
+	maxInFlight   int
+	concurrency   int
 	events        []models.ProductEvent
+	maxBytes      int
+	pendingBytes  int
 	mutex         sync.Mutex
 	flushChan     chan []models.ProductEvent
 	stopChan      chan struct{}
+	stopOnce      sync.Once
+	stopped       bool
+	sending       sync.WaitGroup
+	wg            sync.WaitGroup
 	processor     BatchProcessorFunc
+
+	// Every taken batch gets the next ticket, under mutex, and is sent only
+	// once the batch before it has been; sent and finished count the batches
+	// handed to the processing goroutines and those they are done with
+	nextTicket uint64
+	sendMu     sync.Mutex
+	sendCond   *sync.Cond
+	nowSending uint64
+	sent       int
+	finished   int
+
+	// breaker, when set, guards the processor; batches that fail or are
+	// shed by the open breaker are handed to onFailure
+	breaker   *circuitbreaker.CircuitBreaker
+	onFailure func(events []models.ProductEvent, err error)
+
+	// abandonChan is closed when StopBy gives up waiting; batches not yet
+	// applied are then handed to onAbandon instead of the processor
+	abandonChan chan struct{}
+	abandonOnce sync.Once
+	stateMu     sync.Mutex
+	abandoned   bool
+	onAbandon   func(events []models.ProductEvent)
+	applying    int
+	exited      int
+	flusherDone chan struct{}
 }
 
+// stopPollInterval is how often StopBy checks whether idle processing goroutines have exited
+const stopPollInterval = time.Millisecond
+
+// DefaultBatchFlushInterval replaces a non-positive flush interval, which time.NewTicker rejects
+const DefaultBatchFlushInterval = time.Second
+
 // BatchProcessorFunc defines the function signature for processing batches
 type BatchProcessorFunc func(events []models.ProductEvent) error
 
 // NewBatchProcessor creates a new batch processor
-func NewBatchProcessor(batchSize int, flushInterval time.Duration, processor BatchProcessorFunc) *BatchProcessor {
+//
+// maxInFlight bounds how many full batches may wait for the processor before
+// AddEvent starts blocking; a value of zero hands batches over synchronously.
+// concurrency sets how many goroutines apply batches in parallel.
+// A batchSize below one is clamped to one and a non-positive flushInterval
+// falls back to DefaultBatchFlushInterval, so misconfiguration cannot panic.
+func NewBatchProcessor(batchSize int, flushInterval time.Duration, maxInFlight int, concurrency int, processor BatchProcessorFunc) *BatchProcessor {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = DefaultBatchFlushInterval
+	}
+	if maxInFlight < 0 {
+		maxInFlight = 0
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
 	bp := &BatchProcessor{
 		batchSize:     batchSize,
 		flushInterval: flushInterval,
+		maxInFlight:   maxInFlight,
+		concurrency:   concurrency,
 		events:        make([]models.ProductEvent, 0, batchSize),
-		flushChan:     make(chan []models.ProductEvent, 10),
+		flushChan:     make(chan []models.ProductEvent, maxInFlight),
 		stopChan:      make(chan struct{}),
 		processor:     processor,
+		abandonChan:   make(chan struct{}),
+		flusherDone:   make(chan struct{}),
 	}
+	bp.sendCond = sync.NewCond(&bp.sendMu)
 
-	// Start the batch processing goroutine
-	go bp.processBatches()
+	// Start the periodic flusher and the batch processing goroutines
+	bp.wg.Add(1 + concurrency)
+	go bp.flushPeriodically()
+	for i := 0; i < concurrency; i++ {
+		go bp.processBatches()
+	}
 
 	return bp
 }
 
+// SetMaxBatchBytes caps the estimated serialized size of a batch, flushing
+// before an event would push it over the limit; zero disables the cap
+func (bp *BatchProcessor) SetMaxBatchBytes(maxBytes int) {
+	bp.mutex.Lock()
+	defer bp.mutex.Unlock()
+	bp.maxBytes = maxBytes
+}
+
+// SetCircuitBreaker guards the processor with cb. Repeated batch failures
+// open the breaker, after which further batches are shed without calling the
+// processor. Every batch that fails or is shed is passed to onFailure along
+// with the error, so it can be dead-lettered.
+func (bp *BatchProcessor) SetCircuitBreaker(cb *circuitbreaker.CircuitBreaker, onFailure func(events []models.ProductEvent, err error)) {
+	bp.stateMu.Lock()
+	defer bp.stateMu.Unlock()
+	bp.breaker = cb
+	bp.onFailure = onFailure
+}
+
+// CircuitBreaker returns the breaker guarding the processor, or nil
+func (bp *BatchProcessor) CircuitBreaker() *circuitbreaker.CircuitBreaker {
+	bp.stateMu.Lock()
+	defer bp.stateMu.Unlock()
+	return bp.breaker
+}
+
+// process applies a batch through the circuit breaker, if any
+func (bp *BatchProcessor) process(events []models.ProductEvent) error {
+	breaker := bp.CircuitBreaker()
+	if breaker == nil {
+		return bp.processor(events)
+	}
+	return breaker.Execute(func() error {
+		return bp.processor(events)
+	})
+}
+
+// fail hands a batch that failed or was shed to the failure handler, if any
+func (bp *BatchProcessor) fail(events []models.ProductEvent, err error) {
+	bp.stateMu.Lock()
+	handler := bp.onFailure
+	bp.stateMu.Unlock()
+	if handler != nil {
+		handler(events, err)
+	}
+}
+
+// EstimateEventSize returns the size of an event serialized as JSON
+func EstimateEventSize(event models.ProductEvent) int {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
 // AddEvent adds an event to the batch
 func (bp *BatchProcessor) AddEvent(event models.ProductEvent) error {
 	bp.mutex.Lock()
-	defer bp.mutex.Unlock()
+	if bp.stopped {
+		bp.mutex.Unlock()
+		return ErrBatchProcessorStopped
+	}
+
+	var batches []takenBatch
+	size := 0
+	if bp.maxBytes > 0 {
+		size = EstimateEventSize(event)
+		// Flush first if this event would push the batch over the byte limit
+		if len(bp.events) > 0 && bp.pendingBytes+size > bp.maxBytes {
+			batches = append(batches, bp.takeBatch())
+		}
+	}
 
 	bp.events = append(bp.events, event)
+	bp.pendingBytes += size
 
-	// Flush if batch is full
-	if len(bp.events) >= bp.batchSize {
-		return bp.flushBatch()
+	// Flush if batch is full, by count or by size
+	if len(bp.events) >= bp.batchSize || (bp.maxBytes > 0 && bp.pendingBytes >= bp.maxBytes) {
+		batches = append(batches, bp.takeBatch())
 	}
+	bp.mutex.Unlock()
 
+	for _, batch := range batches {
+		bp.sendBatch(batch)
+	}
 	return nil
 }
 
-// flushBatch flushes the current batch
+// flushBatch sends the buffered events to the processing goroutines,
+// blocking while the in-flight buffer is full
 func (bp *BatchProcessor) flushBatch() error {
+	bp.mutex.Lock()
+	batch := bp.takeBatch()
+	bp.mutex.Unlock()
+
+	bp.sendBatch(batch)
+	return nil
+}
+
+// takenBatch is a batch removed from the buffer, with its place in the send order
+type takenBatch struct {
+	events []models.ProductEvent
+	ticket uint64
+}
+
+// takeBatch removes the buffered events for sending, with no events if there
+// are none. The caller must hold bp.mutex and pass the batch to sendBatch once
+// unlocked.
+func (bp *BatchProcessor) takeBatch() takenBatch {
 	if len(bp.events) == 0 {
-		return nil
+		return takenBatch{}
 	}
 
 	// Create a copy of the events to send
-	eventsToProcess := make([]models.ProductEvent, len(bp.events))
-	copy(eventsToProcess, bp.events)
+	events := make([]models.ProductEvent, len(bp.events))
+	copy(events, bp.events)
 
 	// Clear the current batch
 	bp.events = bp.events[:0]
+	bp.pendingBytes = 0
+
+	// Registered under the mutex so the final flush can wait for it before closing flushChan
+	bp.sending.Add(1)
+	ticket := bp.nextTicket
+	bp.nextTicket++
+	return takenBatch{events: events, ticket: ticket}
+}
+
+// sendBatch hands a batch from takeBatch to the processing goroutines once
+// every batch taken before it has been, blocking while the in-flight buffer is full
+func (bp *BatchProcessor) sendBatch(batch takenBatch) {
+	if batch.events == nil {
+		return
+	}
+	defer bp.sending.Done()
+	bp.waitTurn(batch.ticket)
+	defer bp.endTurn()
+
+	bp.sendMu.Lock()
+	bp.sent++
+	bp.sendMu.Unlock()
 
-	// Send to processing channel
+	// Never drop a batch, but once StopBy has given up waiting hand it to the
+	// abandon handler instead of blocking
 	select {
-	case bp.flushChan <- eventsToProcess:
+	case bp.flushChan <- batch.events:
+	case <-bp.abandonChan:
+		bp.abandon(batch.events)
+		bp.finishBatch()
+	}
+}
+
+// waitTurn blocks until every batch taken before ticket has been sent
+func (bp *BatchProcessor) waitTurn(ticket uint64) {
+	bp.sendMu.Lock()
+	defer bp.sendMu.Unlock()
+	for bp.nowSending != ticket {
+		bp.sendCond.Wait()
+	}
+}
+
+// endTurn lets the next batch taken be sent
+func (bp *BatchProcessor) endTurn() {
+	bp.sendMu.Lock()
+	defer bp.sendMu.Unlock()
+	bp.nowSending++
+	bp.sendCond.Broadcast()
+}
+
+// finishBatch records that a sent batch has been processed or abandoned
+func (bp *BatchProcessor) finishBatch() {
+	bp.sendMu.Lock()
+	defer bp.sendMu.Unlock()
+	bp.finished++
+	bp.sendCond.Broadcast()
+}
+
+// waitFinished blocks until every batch sent so far has been processed or abandoned
+func (bp *BatchProcessor) waitFinished() {
+	bp.sendMu.Lock()
+	defer bp.sendMu.Unlock()
+	for bp.finished < bp.sent {
+		bp.sendCond.Wait()
+	}
+}
+
+// abandon hands events that will not be processed to the abandon handler, if any
+func (bp *BatchProcessor) abandon(events []models.ProductEvent) {
+	bp.stateMu.Lock()
+	handler := bp.onAbandon
+	bp.stateMu.Unlock()
+	if handler != nil {
+		handler(events)
+	}
+}
+
+// Flush synchronously processes the events currently buffered, returning the
+// processor's error, or circuitbreaker.ErrOpen if the breaker shed them; such
+// events go to the failure handler as well. They are processed in order, once
+// the full batches taken before them have been, and before any taken after.
+func (bp *BatchProcessor) Flush() error {
+	bp.mutex.Lock()
+	if bp.stopped {
+		bp.mutex.Unlock()
+		return ErrBatchProcessorStopped
+	}
+	batch := bp.takeBatch()
+	bp.mutex.Unlock()
+
+	if batch.events == nil {
 		return nil
-	default:
-		return ErrBatchProcessorFull
 	}
+	defer bp.sending.Done()
+	bp.waitTurn(batch.ticket)
+	defer bp.endTurn()
+	bp.waitFinished()
+
+	if err := bp.process(batch.events); err != nil {
+		bp.fail(batch.events, err)
+		return err
+	}
+	return nil
 }
 
-// processBatches processes batches from the flush channel
-func (bp *BatchProcessor) processBatches() {
+// flushPeriodically flushes partial batches on every tick and once more on stop
+func (bp *BatchProcessor) flushPeriodically() {
+	defer bp.wg.Done()
+	defer close(bp.flusherDone)
+
 	ticker := time.NewTicker(bp.flushInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case events := <-bp.flushChan:
-			if err := bp.processor(events); err != nil {
-				// Log error or send to dead letter queue
-				// In production, you would have proper error handling here
-			}
 		case <-ticker.C:
 			// Periodic flush
-			bp.mutex.Lock()
-			if len(bp.events) > 0 {
-				bp.flushBatch()
-			}
-			bp.mutex.Unlock()
+			bp.flushBatch()
 		case <-bp.stopChan:
 			// Flush remaining events before stopping
 			bp.mutex.Lock()
-			if len(bp.events) > 0 {
-				bp.flushBatch()
-			}
+			bp.stopped = true
+			batch := bp.takeBatch()
 			bp.mutex.Unlock()
+			bp.sendBatch(batch)
+
+			// Once stopped no new send can start, so close after those in progress
+			bp.sending.Wait()
+			close(bp.flushChan)
 			return
 		}
 	}
 }
 
-// Stop stops the batch processor
+// processBatches processes batches from the flush channel until it is closed.
+// Every processing goroutine keeps draining until the final flush has been
+// consumed, so Stop only returns once all buffered events are processed.
+func (bp *BatchProcessor) processBatches() {
+	defer bp.wg.Done()
+
+	defer func() {
+		bp.stateMu.Lock()
+		bp.exited++
+		bp.stateMu.Unlock()
+	}()
+
+	for events := range bp.flushChan {
+		bp.stateMu.Lock()
+		if bp.abandoned {
+			bp.stateMu.Unlock()
+			bp.abandon(events)
+			bp.finishBatch()
+			continue
+		}
+		bp.applying++
+		bp.stateMu.Unlock()
+
+		if err := bp.process(events); err != nil {
+			bp.fail(events, err)
+		}
+
+		bp.stateMu.Lock()
+		bp.applying--
+		bp.stateMu.Unlock()
+		bp.finishBatch()
+	}
+}
+
+// Stop stops the batch processor, waiting until every buffered event has been processed
 func (bp *BatchProcessor) Stop() {
-	close(bp.stopChan)
+	bp.stopOnce.Do(func() {
+		close(bp.stopChan)
+	})
+	bp.wg.Wait()
+}
+
+// StopBy stops the batch processor like Stop, but waits only until deadline
+// for buffered events to be processed; a zero deadline waits indefinitely.
+//
+// If the deadline passes first, every event not yet handed to the processor,
+// whether still buffered or in a full batch waiting for a goroutine, is passed
+// to abandon instead, and StopBy returns false once that is done. Batches the
+// processor is already applying are left to finish in the background.
+func (bp *BatchProcessor) StopBy(deadline time.Time, abandon func(events []models.ProductEvent)) bool {
+	bp.stopOnce.Do(func() {
+		close(bp.stopChan)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		bp.wg.Wait()
+		close(done)
+	}()
+
+	if deadline.IsZero() {
+		<-done
+		return true
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+	}
+
+	bp.stateMu.Lock()
+	bp.abandoned = true
+	bp.onAbandon = abandon
+	bp.stateMu.Unlock()
+	bp.abandonOnce.Do(func() {
+		close(bp.abandonChan)
+	})
+
+	// The final flush can no longer block, so the flusher closes flushChan
+	// promptly. Whatever is left in it is abandoned here, alongside any
+	// goroutine not busy applying, and those goroutines then exit.
+	<-bp.flusherDone
+	for events := range bp.flushChan {
+		bp.abandon(events)
+		bp.finishBatch()
+	}
+	for {
+		bp.stateMu.Lock()
+		settled := bp.exited+bp.applying >= bp.concurrency
+		bp.stateMu.Unlock()
+		if settled {
+			return false
+		}
+		select {
+		case <-done:
+			return false
+		case <-time.After(stopPollInterval):
+		}
+	}
 }
 
 // GetBatchSize returns the current batch size
@@ -124,3 +486,18 @@ func (bp *BatchProcessor) GetPendingEvents() int {
 	defer bp.mutex.Unlock()
 	return len(bp.events)
 }
+
+// GetInFlightBatches returns the number of full batches waiting for the processor
+func (bp *BatchProcessor) GetInFlightBatches() int {
+	return len(bp.flushChan)
+}
+
+// GetConcurrency returns the number of batch processing goroutines
+func (bp *BatchProcessor) GetConcurrency() int {
+	return bp.concurrency
+}
+
+// GetMaxInFlight returns the configured in-flight batch capacity
+func (bp *BatchProcessor) GetMaxInFlight() int {
+	return bp.maxInFlight
+}
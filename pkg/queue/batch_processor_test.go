@@ -2,16 +2,19 @@ package queue
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"product-service/internal/models"
+	"product-service/pkg/circuitbreaker"
 )
 
 func TestBatchProcessor_NewBatchProcessor(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
-	processor := NewBatchProcessor(5, 100*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(5, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		processedBatches = append(processedBatches, events)
 		return nil
 	})
@@ -31,7 +34,7 @@ func TestBatchProcessor_AddEvent_SingleEvent(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
 	var mu sync.Mutex
 
-	processor := NewBatchProcessor(5, 100*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(5, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		mu.Lock()
 		processedBatches = append(processedBatches, events)
 		mu.Unlock()
@@ -62,7 +65,7 @@ func TestBatchProcessor_AddEvent_BatchSizeReached(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
 	var mu sync.Mutex
 
-	processor := NewBatchProcessor(3, 1*time.Second, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(3, 1*time.Second, 10, 1, func(events []models.ProductEvent) error {
 		mu.Lock()
 		processedBatches = append(processedBatches, events)
 		mu.Unlock()
@@ -95,7 +98,7 @@ func TestBatchProcessor_AddEvent_FlushInterval(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
 	var mu sync.Mutex
 
-	processor := NewBatchProcessor(10, 50*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(10, 50*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		mu.Lock()
 		processedBatches = append(processedBatches, events)
 		mu.Unlock()
@@ -126,7 +129,7 @@ func TestBatchProcessor_AddEvent_ConcurrentAccess(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
 	var mu sync.Mutex
 
-	processor := NewBatchProcessor(10, 100*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(10, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		mu.Lock()
 		processedBatches = append(processedBatches, events)
 		mu.Unlock()
@@ -171,7 +174,7 @@ func TestBatchProcessor_AddEvent_ConcurrentAccess(t *testing.T) {
 }
 
 func TestBatchProcessor_AddEvent_ProcessorError(t *testing.T) {
-	processor := NewBatchProcessor(1, 100*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(1, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		return errors.New("processing error")
 	})
 
@@ -193,7 +196,7 @@ func TestBatchProcessor_Stop(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
 	var mu sync.Mutex
 
-	processor := NewBatchProcessor(10, 100*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(10, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		mu.Lock()
 		processedBatches = append(processedBatches, events)
 		mu.Unlock()
@@ -229,7 +232,7 @@ func TestBatchProcessor_FlushBatch(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
 	var mu sync.Mutex
 
-	processor := NewBatchProcessor(5, 100*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(5, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		mu.Lock()
 		processedBatches = append(processedBatches, events)
 		mu.Unlock()
@@ -266,7 +269,7 @@ func TestBatchProcessor_EmptyFlush(t *testing.T) {
 	processedBatches := make([][]models.ProductEvent, 0)
 	var mu sync.Mutex
 
-	processor := NewBatchProcessor(5, 100*time.Millisecond, func(events []models.ProductEvent) error {
+	processor := NewBatchProcessor(5, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
 		mu.Lock()
 		processedBatches = append(processedBatches, events)
 		mu.Unlock()
@@ -288,3 +291,539 @@ func TestBatchProcessor_EmptyFlush(t *testing.T) {
 	}
 	mu.Unlock()
 }
+
+func TestBatchProcessor_MaxInFlight_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	totalProcessed := 0
+
+	// A processor that stalls until released, so batches pile up in flight
+	processor := NewBatchProcessor(2, 1*time.Second, 1, 1, func(events []models.ProductEvent) error {
+		<-release
+		mu.Lock()
+		totalProcessed += len(events)
+		mu.Unlock()
+		return nil
+	})
+
+	if cap(processor.flushChan) != 1 {
+		t.Fatalf("Expected in-flight capacity 1, got %d", cap(processor.flushChan))
+	}
+	if processor.GetMaxInFlight() != 1 {
+		t.Errorf("Expected max in-flight 1, got %d", processor.GetMaxInFlight())
+	}
+
+	// Produce more batches than the processor and buffer can hold
+	numEvents := 20
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < numEvents; i++ {
+			event := models.ProductEvent{ProductID: string(rune('a' + i)), Price: float64(i), Stock: i}
+			if err := processor.AddEvent(event); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+	}()
+
+	// The producer must block rather than drop once the buffer is exhausted
+	select {
+	case <-done:
+		t.Fatal("Expected AddEvent to block while the in-flight buffer is full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if inFlight := processor.GetInFlightBatches(); inFlight > 1 {
+		t.Errorf("Expected at most 1 in-flight batch, got %d", inFlight)
+	}
+
+	// Release the processor and let everything drain
+	close(release)
+	<-done
+	processor.Stop()
+
+	mu.Lock()
+	if totalProcessed != numEvents {
+		t.Errorf("Expected %d processed events with no drops, got %d", numEvents, totalProcessed)
+	}
+	mu.Unlock()
+}
+
+func TestBatchProcessor_BlockedFlushDoesNotHoldLock(t *testing.T) {
+	release := make(chan struct{})
+	processor := NewBatchProcessor(2, time.Hour, 1, 1, func(events []models.ProductEvent) error {
+		<-release
+		return nil
+	})
+
+	// The first batch stalls the processor, the second fills the buffer and the third blocks its sender
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		for i := 0; i < 6; i++ {
+			processor.AddEvent(models.ProductEvent{ProductID: fmt.Sprintf("product-%d", i), Price: 1.0, Stock: i})
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	added := make(chan struct{})
+	go func() {
+		defer close(added)
+		processor.AddEvent(models.ProductEvent{ProductID: "partial", Price: 1.0, Stock: 1})
+		processor.GetPendingEvents()
+	}()
+
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("Expected buffering an event not to wait for the blocked flush")
+	}
+	if pending := processor.GetPendingEvents(); pending != 1 {
+		t.Errorf("Expected 1 pending event, got %d", pending)
+	}
+
+	close(release)
+	<-blocked
+	processor.Stop()
+}
+
+func TestBatchProcessor_SendsInTakeOrder(t *testing.T) {
+	var mu sync.Mutex
+	var processed []int
+	release := make(chan struct{})
+	processor := NewBatchProcessor(1, time.Hour, 0, 1, func(events []models.ProductEvent) error {
+		<-release
+		mu.Lock()
+		processed = append(processed, events[0].Stock)
+		mu.Unlock()
+		return nil
+	})
+
+	// The first batch stalls the processor, so every later one is taken and
+	// then waits to be sent; each is taken before the next sender starts
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			processor.AddEvent(models.ProductEvent{ProductID: fmt.Sprintf("product-%d", i), Price: 1.0, Stock: i})
+		}(i)
+		for taken := false; !taken; {
+			processor.mutex.Lock()
+			taken = processor.nextTicket == uint64(i+1)
+			processor.mutex.Unlock()
+		}
+	}
+
+	close(release)
+	wg.Wait()
+	processor.Stop()
+
+	for i, stock := range processed {
+		if stock != i {
+			t.Fatalf("Expected batches processed in the order taken, got %v", processed)
+		}
+	}
+}
+
+func TestBatchProcessor_FlushAfterQueuedBatches(t *testing.T) {
+	var mu sync.Mutex
+	var processed []string
+	release := make(chan struct{})
+	processor := NewBatchProcessor(2, time.Hour, 0, 1, func(events []models.ProductEvent) error {
+		<-release
+		mu.Lock()
+		for _, event := range events {
+			processed = append(processed, event.ProductID)
+		}
+		mu.Unlock()
+		return nil
+	})
+	defer processor.Stop()
+
+	// a and b stall the processor, c and d wait to be sent and e stays buffered
+	go func() {
+		for _, id := range []string{"a", "b", "c", "d"} {
+			processor.AddEvent(models.ProductEvent{ProductID: id, Price: 1.0, Stock: 1})
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	processor.AddEvent(models.ProductEvent{ProductID: "e", Price: 1.0, Stock: 1})
+
+	flushed := make(chan error)
+	go func() { flushed <- processor.Flush() }()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err := <-flushed; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(processed, ""); got != "abcde" {
+		t.Errorf("Expected the flushed event after the queued batches, got %s", got)
+	}
+}
+
+func TestBatchProcessor_AddEvent_AfterStop(t *testing.T) {
+	processor := NewBatchProcessor(5, 100*time.Millisecond, 10, 1, func(events []models.ProductEvent) error {
+		return nil
+	})
+
+	processor.Stop()
+
+	err := processor.AddEvent(models.ProductEvent{ProductID: "late", Price: 1.0, Stock: 1})
+	if err != ErrBatchProcessorStopped {
+		t.Errorf("Expected ErrBatchProcessorStopped, got %v", err)
+	}
+}
+
+func TestBatchProcessor_Concurrency(t *testing.T) {
+	var mu sync.Mutex
+	running := 0
+	maxRunning := 0
+	totalProcessed := 0
+
+	// A slow processor that records how many batches run at the same time
+	processor := NewBatchProcessor(2, 1*time.Second, 10, 4, func(events []models.ProductEvent) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		totalProcessed += len(events)
+		mu.Unlock()
+		return nil
+	})
+
+	if processor.GetConcurrency() != 4 {
+		t.Errorf("Expected concurrency 4, got %d", processor.GetConcurrency())
+	}
+
+	// 8 full batches plus a final partial batch of 1
+	numEvents := 17
+	for i := 0; i < numEvents; i++ {
+		event := models.ProductEvent{ProductID: string(rune('a' + i)), Price: float64(i), Stock: i}
+		if err := processor.AddEvent(event); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	processor.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxRunning < 2 {
+		t.Errorf("Expected batches to be processed in parallel, max concurrent was %d", maxRunning)
+	}
+	if maxRunning > 4 {
+		t.Errorf("Expected at most 4 concurrent batches, got %d", maxRunning)
+	}
+	if totalProcessed != numEvents {
+		t.Errorf("Expected all %d events processed on Stop, got %d", numEvents, totalProcessed)
+	}
+}
+
+func TestBatchProcessor_Flush(t *testing.T) {
+	var mu sync.Mutex
+	var processed []models.ProductEvent
+
+	processor := NewBatchProcessor(10, time.Hour, 10, 1, func(events []models.ProductEvent) error {
+		mu.Lock()
+		processed = append(processed, events...)
+		mu.Unlock()
+		return nil
+	})
+	defer processor.Stop()
+
+	// Stay below the batch size so nothing flushes on its own
+	for i := 0; i < 3; i++ {
+		processor.AddEvent(models.ProductEvent{ProductID: "flush", Price: float64(i), Stock: i})
+	}
+
+	if err := processor.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Flush is synchronous, so the events must already be processed
+	mu.Lock()
+	count := len(processed)
+	mu.Unlock()
+	if count != 3 {
+		t.Errorf("Expected 3 events processed immediately, got %d", count)
+	}
+	if processor.GetPendingEvents() != 0 {
+		t.Errorf("Expected no pending events, got %d", processor.GetPendingEvents())
+	}
+}
+
+func TestBatchProcessor_Flush_Error(t *testing.T) {
+	processErr := errors.New("downstream unavailable")
+	processor := NewBatchProcessor(10, time.Hour, 10, 1, func(events []models.ProductEvent) error {
+		return processErr
+	})
+
+	processor.AddEvent(models.ProductEvent{ProductID: "flush", Price: 1.0, Stock: 1})
+	if err := processor.Flush(); err != processErr {
+		t.Errorf("Expected processor error, got %v", err)
+	}
+
+	processor.Stop()
+	if err := processor.Flush(); err != ErrBatchProcessorStopped {
+		t.Errorf("Expected ErrBatchProcessorStopped after stop, got %v", err)
+	}
+}
+
+func TestBatchProcessor_CircuitBreaker(t *testing.T) {
+	processErr := errors.New("downstream unavailable")
+	var mu sync.Mutex
+	calls := 0
+	var failed [][]models.ProductEvent
+	var failErrs []error
+
+	processor := NewBatchProcessor(1, time.Hour, 0, 1, func(events []models.ProductEvent) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return processErr
+	})
+	defer processor.Stop()
+
+	cb := circuitbreaker.NewCircuitBreaker(3, time.Hour)
+	processor.SetCircuitBreaker(cb, func(events []models.ProductEvent, err error) {
+		mu.Lock()
+		failed = append(failed, events)
+		failErrs = append(failErrs, err)
+		mu.Unlock()
+	})
+	if processor.CircuitBreaker() != cb {
+		t.Error("Expected the configured circuit breaker to be returned")
+	}
+
+	for i := 0; i < 5; i++ {
+		processor.AddEvent(models.ProductEvent{ProductID: "failing", Price: 1.0, Stock: 1})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		done := len(failed) == 5
+		mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	if cb.GetState() != circuitbreaker.Open {
+		t.Errorf("Expected breaker to open after repeated batch failures, got %v", cb.GetState())
+	}
+	if calls != 3 {
+		t.Errorf("Expected processor to be called 3 times before the breaker opened, got %d", calls)
+	}
+	if len(failed) != 5 {
+		mu.Unlock()
+		t.Fatalf("Expected all 5 batches handed to the failure handler, got %d", len(failed))
+	}
+	for i, err := range failErrs {
+		if i < 3 && err != processErr {
+			t.Errorf("Expected batch %d to fail with the processor error, got %v", i, err)
+		}
+		if i >= 3 && !errors.Is(err, circuitbreaker.ErrOpen) {
+			t.Errorf("Expected batch %d to be shed by the open breaker, got %v", i, err)
+		}
+	}
+	mu.Unlock()
+
+	t.Run("FlushShed", func(t *testing.T) {
+		processor.mutex.Lock()
+		processor.events = append(processor.events, models.ProductEvent{ProductID: "buffered", Price: 1.0, Stock: 1})
+		processor.mutex.Unlock()
+
+		if err := processor.Flush(); !errors.Is(err, circuitbreaker.ErrOpen) {
+			t.Errorf("Expected Flush to be shed with ErrOpen, got %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if calls != 3 {
+			t.Errorf("Expected the processor not to be called while open, got %d calls", calls)
+		}
+		if len(failed) != 6 {
+			t.Errorf("Expected the shed flush to reach the failure handler, got %d batches", len(failed))
+		}
+	})
+}
+
+func TestBatchProcessor_MaxBatchBytes(t *testing.T) {
+	processedBatches := make([][]models.ProductEvent, 0)
+	var mu sync.Mutex
+
+	processor := NewBatchProcessor(100, time.Hour, 10, 1, func(events []models.ProductEvent) error {
+		mu.Lock()
+		processedBatches = append(processedBatches, events)
+		mu.Unlock()
+		return nil
+	})
+	defer processor.Stop()
+
+	large := func(i int) models.ProductEvent {
+		return models.ProductEvent{ProductID: strings.Repeat("x", 100) + string(rune('a'+i)), Price: 1.0, Stock: i}
+	}
+	eventSize := EstimateEventSize(large(0))
+	// Room for two large events but not three
+	processor.SetMaxBatchBytes(eventSize*3 - 1)
+
+	for i := 0; i < 3; i++ {
+		if err := processor.AddEvent(large(i)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	if len(processedBatches) != 1 {
+		t.Fatalf("Expected a size-based flush well before the count of 100, got %d batches", len(processedBatches))
+	}
+	if len(processedBatches[0]) != 2 {
+		t.Errorf("Expected the first 2 events in the flushed batch, got %d", len(processedBatches[0]))
+	}
+	mu.Unlock()
+
+	if pending := processor.GetPendingEvents(); pending != 1 {
+		t.Errorf("Expected the third event to start a new batch, got %d pending", pending)
+	}
+}
+
+func TestBatchProcessor_MaxBatchBytes_OversizedEvent(t *testing.T) {
+	processed := make(chan int, 10)
+	processor := NewBatchProcessor(100, time.Hour, 10, 1, func(events []models.ProductEvent) error {
+		processed <- len(events)
+		return nil
+	})
+	defer processor.Stop()
+	processor.SetMaxBatchBytes(10)
+
+	// An event larger than the limit is flushed on its own rather than held back
+	processor.AddEvent(models.ProductEvent{ProductID: "oversized", Price: 1.0, Stock: 1})
+
+	select {
+	case n := <-processed:
+		if n != 1 {
+			t.Errorf("Expected a batch of 1, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected oversized event to be flushed immediately")
+	}
+}
+
+func TestBatchProcessor_InvalidSettings(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		t.Run(interval.String(), func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Expected no panic for interval %v, got %v", interval, r)
+				}
+			}()
+
+			processor := NewBatchProcessor(0, interval, 10, 1, func(events []models.ProductEvent) error {
+				return nil
+			})
+			defer processor.Stop()
+
+			if processor.flushInterval != DefaultBatchFlushInterval {
+				t.Errorf("Expected flush interval %v, got %v", DefaultBatchFlushInterval, processor.flushInterval)
+			}
+			if processor.batchSize != 1 {
+				t.Errorf("Expected batch size clamped to 1, got %d", processor.batchSize)
+			}
+		})
+	}
+}
+
+func TestBatchProcessor_StopBy(t *testing.T) {
+	t.Run("FlushedInTime", func(t *testing.T) {
+		var mu sync.Mutex
+		processed := 0
+		processor := NewBatchProcessor(10, time.Hour, 10, 1, func(events []models.ProductEvent) error {
+			mu.Lock()
+			processed += len(events)
+			mu.Unlock()
+			return nil
+		})
+
+		for i := 0; i < 3; i++ {
+			processor.AddEvent(models.ProductEvent{ProductID: "in-time", Price: 1.0, Stock: i})
+		}
+
+		abandoned := 0
+		if !processor.StopBy(time.Now().Add(time.Second), func(events []models.ProductEvent) {
+			abandoned += len(events)
+		}) {
+			t.Error("Expected StopBy to flush before the deadline")
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if processed != 3 || abandoned != 0 {
+			t.Errorf("Expected 3 processed and 0 abandoned, got %d and %d", processed, abandoned)
+		}
+	})
+
+	t.Run("DeadlinePassed", func(t *testing.T) {
+		release := make(chan struct{})
+		var mu sync.Mutex
+		processed := 0
+		processor := NewBatchProcessor(2, time.Hour, 10, 1, func(events []models.ProductEvent) error {
+			<-release
+			mu.Lock()
+			processed += len(events)
+			mu.Unlock()
+			return nil
+		})
+
+		// Two full batches, the first held by the processor, plus a partial one
+		const total = 5
+		for i := 0; i < total; i++ {
+			if err := processor.AddEvent(models.ProductEvent{ProductID: "late", Price: 1.0, Stock: i}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		var abandonedMu sync.Mutex
+		abandoned := 0
+		start := time.Now()
+		flushed := processor.StopBy(time.Now().Add(50*time.Millisecond), func(events []models.ProductEvent) {
+			abandonedMu.Lock()
+			abandoned += len(events)
+			abandonedMu.Unlock()
+		})
+		if flushed {
+			t.Error("Expected StopBy to report the deadline passing")
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected StopBy to return soon after the deadline, took %v", elapsed)
+		}
+
+		close(release)
+		processor.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		abandonedMu.Lock()
+		defer abandonedMu.Unlock()
+		if abandoned != 3 {
+			t.Errorf("Expected 3 abandoned events, got %d", abandoned)
+		}
+		if processed+abandoned != total {
+			t.Errorf("Expected every event to be processed or abandoned, got %d processed and %d abandoned", processed, abandoned)
+		}
+	})
+}
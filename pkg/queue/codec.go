@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"product-service/internal/models"
+)
+
+// Codec serializes events for queue backends that store them outside memory
+type Codec interface {
+	Marshal(event models.ProductEvent) ([]byte, error)
+	Unmarshal(data []byte) (models.ProductEvent, error)
+}
+
+// JSONCodec encodes events as JSON, keeping them human readable
+//
+// JSON cannot represent NaN or infinite prices, so Marshal returns an error
+// for them; such events are rejected by validation before reaching a queue.
+type JSONCodec struct{}
+
+// jsonRecord carries the service-assigned fields, which the API encoding omits
+type jsonRecord struct {
+	models.ProductEvent
+	Sequence    uint64 `json:"sequence,omitempty"`
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+// Marshal encodes an event as JSON
+func (JSONCodec) Marshal(event models.ProductEvent) ([]byte, error) {
+	return json.Marshal(jsonRecord{
+		ProductEvent: event,
+		Sequence:     event.Sequence,
+		TraceParent:  event.TraceParent,
+		TraceState:   event.TraceState,
+	})
+}
+
+// Unmarshal decodes an event from JSON
+func (JSONCodec) Unmarshal(data []byte) (models.ProductEvent, error) {
+	var record jsonRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return models.ProductEvent{}, err
+	}
+	record.ProductEvent.Sequence = record.Sequence
+	record.ProductEvent.TraceParent = record.TraceParent
+	record.ProductEvent.TraceState = record.TraceState
+	return record.ProductEvent, nil
+}
+
+// GobCodec encodes events with encoding/gob, which is more compact and
+// preserves every float64 value exactly, including NaN and infinities
+type GobCodec struct{}
+
+// Marshal encodes an event with gob; each payload is self-describing
+func (GobCodec) Marshal(event models.ProductEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(event); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes an event encoded with gob
+func (GobCodec) Unmarshal(data []byte) (models.ProductEvent, error) {
+	var event models.ProductEvent
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&event); err != nil {
+		return models.ProductEvent{}, err
+	}
+	return event, nil
+}
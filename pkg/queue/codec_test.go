@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"math"
+	"testing"
+
+	"product-service/internal/models"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	events := []models.ProductEvent{
+		{ProductID: "simple", Price: 10.5, Stock: 3},
+		{ProductID: "produit-été-商品-🚀", Price: 1.0, Stock: 1, Currency: "EUR"},
+		{ProductID: "max", Price: math.MaxFloat64, Stock: math.MaxInt32},
+		{ProductID: "min", Price: -math.MaxFloat64, Stock: math.MinInt32},
+		{ProductID: "tiny", Price: math.SmallestNonzeroFloat64, Stock: 0},
+		{ProductID: "full", Price: 0.1, Stock: 7, Currency: "JPY", Priority: models.PriorityHigh, SchemaVersion: 2, Sequence: 42,
+			TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", TraceState: "vendor=value"},
+	}
+
+	codecs := map[string]Codec{
+		"json": JSONCodec{},
+		"gob":  GobCodec{},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			for _, event := range events {
+				data, err := codec.Marshal(event)
+				if err != nil {
+					t.Fatalf("Expected no error marshaling %s, got %v", event.ProductID, err)
+				}
+
+				decoded, err := codec.Unmarshal(data)
+				if err != nil {
+					t.Fatalf("Expected no error unmarshaling %s, got %v", event.ProductID, err)
+				}
+				if decoded != event {
+					t.Errorf("Expected %+v, got %+v", event, decoded)
+				}
+			}
+		})
+	}
+}
+
+func TestCodec_NonFinitePrices(t *testing.T) {
+	for _, price := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		event := models.ProductEvent{ProductID: "non-finite", Price: price, Stock: 1}
+
+		if _, err := (JSONCodec{}).Marshal(event); err == nil {
+			t.Errorf("Expected JSON to reject price %v", price)
+		}
+
+		data, err := (GobCodec{}).Marshal(event)
+		if err != nil {
+			t.Fatalf("Expected gob to encode price %v, got %v", price, err)
+		}
+		decoded, err := (GobCodec{}).Unmarshal(data)
+		if err != nil {
+			t.Fatalf("Expected gob to decode price %v, got %v", price, err)
+		}
+		if math.IsNaN(price) != math.IsNaN(decoded.Price) || (!math.IsNaN(price) && decoded.Price != price) {
+			t.Errorf("Expected price %v, got %v", price, decoded.Price)
+		}
+	}
+}
+
+func TestCodec_UnmarshalInvalid(t *testing.T) {
+	for name, codec := range map[string]Codec{"json": JSONCodec{}, "gob": GobCodec{}} {
+		if _, err := codec.Unmarshal([]byte("not an event")); err == nil {
+			t.Errorf("Expected %s codec to reject garbage input", name)
+		}
+	}
+}
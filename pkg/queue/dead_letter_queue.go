@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"product-service/internal/models"
+)
+
+// DeadLetterQueue holds events that failed processing for later inspection or replay
+type DeadLetterQueue struct {
+	mu       sync.Mutex
+	events   []models.FailedEvent
+	capacity int
+}
+
+// NewDeadLetterQueue creates a new dead letter queue holding at most capacity events
+func NewDeadLetterQueue(capacity int) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		events:   make([]models.FailedEvent, 0),
+		capacity: capacity,
+	}
+}
+
+// Add records a failed event, returning ErrDeadLetterQueueFull if there is no room
+func (dlq *DeadLetterQueue) Add(event models.ProductEvent, err error, retryCount int) error {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	if len(dlq.events) >= dlq.capacity {
+		return ErrDeadLetterQueueFull
+	}
+
+	failed := models.FailedEvent{
+		Event:      event,
+		Timestamp:  time.Now(),
+		RetryCount: retryCount,
+	}
+	if err != nil {
+		failed.Error = err.Error()
+	}
+
+	dlq.events = append(dlq.events, failed)
+	return nil
+}
+
+// Restore puts back a failed event previously taken from the queue, keeping its original details
+func (dlq *DeadLetterQueue) Restore(failed models.FailedEvent) error {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	if len(dlq.events) >= dlq.capacity {
+		return ErrDeadLetterQueueFull
+	}
+	dlq.events = append(dlq.events, failed)
+	return nil
+}
+
+// TakeLatest removes and returns the most recently failed event for productID
+func (dlq *DeadLetterQueue) TakeLatest(productID string) (models.FailedEvent, bool) {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	for i := len(dlq.events) - 1; i >= 0; i-- {
+		if dlq.events[i].Event.ProductID == productID {
+			failed := dlq.events[i]
+			dlq.events = append(dlq.events[:i], dlq.events[i+1:]...)
+			return failed, true
+		}
+	}
+	return models.FailedEvent{}, false
+}
+
+// List returns a copy of the dead-lettered events in the order they failed
+func (dlq *DeadLetterQueue) List() []models.FailedEvent {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	events := make([]models.FailedEvent, len(dlq.events))
+	copy(events, dlq.events)
+	return events
+}
+
+// Drain removes and returns every dead-lettered event
+func (dlq *DeadLetterQueue) Drain() []models.FailedEvent {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	events := dlq.events
+	dlq.events = make([]models.FailedEvent, 0)
+	return events
+}
+
+// Len returns the number of dead-lettered events
+func (dlq *DeadLetterQueue) Len() int {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+	return len(dlq.events)
+}
+
+// Cap returns the capacity of the dead letter queue
+func (dlq *DeadLetterQueue) Cap() int {
+	return dlq.capacity
+}
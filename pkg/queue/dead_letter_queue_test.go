@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+
+	"product-service/internal/models"
+)
+
+func TestDeadLetterQueue_Add(t *testing.T) {
+	dlq := NewDeadLetterQueue(10)
+
+	event := models.ProductEvent{ProductID: "failed", Price: 1.0, Stock: 1}
+	if err := dlq.Add(event, errors.New("downstream error"), 3); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if dlq.Len() != 1 {
+		t.Errorf("Expected length 1, got %d", dlq.Len())
+	}
+
+	failed := dlq.List()[0]
+	if failed.Event.ProductID != "failed" {
+		t.Errorf("Expected product ID 'failed', got '%s'", failed.Event.ProductID)
+	}
+	if failed.Error != "downstream error" {
+		t.Errorf("Expected error 'downstream error', got '%s'", failed.Error)
+	}
+	if failed.RetryCount != 3 {
+		t.Errorf("Expected retry count 3, got %d", failed.RetryCount)
+	}
+	if failed.Timestamp.IsZero() {
+		t.Error("Expected timestamp to be set")
+	}
+}
+
+func TestDeadLetterQueue_Full(t *testing.T) {
+	dlq := NewDeadLetterQueue(1)
+
+	dlq.Add(models.ProductEvent{ProductID: "1"}, errors.New("error"), 0)
+
+	err := dlq.Add(models.ProductEvent{ProductID: "2"}, errors.New("error"), 0)
+	if err != ErrDeadLetterQueueFull {
+		t.Errorf("Expected ErrDeadLetterQueueFull, got %v", err)
+	}
+}
+
+func TestDeadLetterQueue_Drain(t *testing.T) {
+	dlq := NewDeadLetterQueue(10)
+
+	dlq.Add(models.ProductEvent{ProductID: "1"}, errors.New("error"), 0)
+	dlq.Add(models.ProductEvent{ProductID: "2"}, errors.New("error"), 0)
+
+	drained := dlq.Drain()
+	if len(drained) != 2 {
+		t.Errorf("Expected 2 drained events, got %d", len(drained))
+	}
+	if drained[0].Event.ProductID != "1" || drained[1].Event.ProductID != "2" {
+		t.Errorf("Expected events drained in order, got %+v", drained)
+	}
+	if dlq.Len() != 0 {
+		t.Errorf("Expected empty queue after drain, got %d", dlq.Len())
+	}
+}
+
+func TestDeadLetterQueue_TakeLatest(t *testing.T) {
+	dlq := NewDeadLetterQueue(10)
+
+	dlq.Add(models.ProductEvent{ProductID: "1", Stock: 1}, errors.New("error"), 0)
+	dlq.Add(models.ProductEvent{ProductID: "2", Stock: 2}, errors.New("error"), 0)
+	dlq.Add(models.ProductEvent{ProductID: "1", Stock: 3}, errors.New("error"), 0)
+
+	failed, ok := dlq.TakeLatest("1")
+	if !ok {
+		t.Fatal("Expected an event for product 1")
+	}
+	if failed.Event.Stock != 3 {
+		t.Errorf("Expected the most recent event with stock 3, got %d", failed.Event.Stock)
+	}
+	if dlq.Len() != 2 {
+		t.Errorf("Expected 2 events left, got %d", dlq.Len())
+	}
+
+	if _, ok := dlq.TakeLatest("missing"); ok {
+		t.Error("Expected no event for an unknown product")
+	}
+
+	if err := dlq.Restore(failed); err != nil {
+		t.Fatalf("Expected no error restoring, got %v", err)
+	}
+	if restored, _ := dlq.TakeLatest("1"); restored.Timestamp != failed.Timestamp {
+		t.Errorf("Expected the restored event to keep its timestamp, got %v", restored.Timestamp)
+	}
+}
@@ -4,9 +4,29 @@ import "errors"
 
 // Common queue errors
 var (
-	ErrQueueFull          = errors.New("queue is full")
-	ErrQueueClosed        = errors.New("queue is closed")
-	ErrBatchProcessorFull = errors.New("batch processor is full")
-	ErrInvalidEvent       = errors.New("invalid event")
-	ErrEventTooLarge      = errors.New("event too large")
+	ErrQueueFull             = errors.New("queue is full")
+	ErrQueueClosed           = errors.New("queue is closed")
+	ErrBatchProcessorFull    = errors.New("batch processor is full")
+	ErrBatchProcessorStopped = errors.New("batch processor is stopped")
+	ErrInvalidEvent          = errors.New("invalid event")
+	ErrEventTooLarge         = errors.New("event too large")
+	ErrDeadLetterQueueFull   = errors.New("dead letter queue is full")
+	ErrUnknownBackend        = errors.New("unknown queue backend")
+	ErrInvalidQueueSize      = errors.New("invalid queue size")
 )
+
+// QueueFullError is returned when an event is rejected because the queue is at capacity
+type QueueFullError struct {
+	Depth    int
+	Capacity int
+}
+
+// Error implements the error interface
+func (e *QueueFullError) Error() string {
+	return ErrQueueFull.Error()
+}
+
+// Is reports whether the target is ErrQueueFull so callers can match the sentinel
+func (e *QueueFullError) Is(target error) bool {
+	return target == ErrQueueFull
+}
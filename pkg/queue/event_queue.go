@@ -1,7 +1,8 @@
 package queue
 
 import (
-	"fmt"
+	"context"
+	"sync"
 
 	"product-service/internal/models"
 )
@@ -9,39 +10,162 @@ import (
 // EventQueue interface defines the contract for event queuing
 type EventQueue interface {
 	Enqueue(event models.ProductEvent) error
+	EnqueueWithTicket(event models.ProductEvent) (int64, error)
 	Dequeue() (models.ProductEvent, bool)
+	DequeueWithContext(ctx context.Context) (models.ProductEvent, bool)
+	Len() int
+	Cap() int
 	Close()
 }
 
-// InMemoryEventQueue implements EventQueue using buffered channels
+// Peeker is implemented by queues that can list their buffered events without consuming them
+type Peeker interface {
+	// Peek returns up to max buffered events in the order they will be dequeued
+	Peek(max int) []models.ProductEvent
+}
+
+// InMemoryEventQueue implements EventQueue using a fixed-size ring buffer
 type InMemoryEventQueue struct {
-	events chan models.ProductEvent
+	mu     sync.Mutex
+	events []models.ProductEvent
+	head   int
+	size   int
+	ticket int64
+	closed bool
+
+	// available is closed and replaced whenever an event arrives or the queue
+	// is closed, waking every blocked dequeue to try again
+	available chan struct{}
 }
 
 // NewInMemoryEventQueue creates a new in-memory event queue with specified buffer size
 func NewInMemoryEventQueue(bufferSize int) EventQueue {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
 	return &InMemoryEventQueue{
-		events: make(chan models.ProductEvent, bufferSize),
+		events:    make([]models.ProductEvent, bufferSize),
+		available: make(chan struct{}),
 	}
 }
 
 // Enqueue adds an event to the queue
 func (q *InMemoryEventQueue) Enqueue(event models.ProductEvent) error {
-	select {
-	case q.events <- event:
-		return nil
-	default:
-		return fmt.Errorf("queue is full")
+	_, err := q.EnqueueWithTicket(event)
+	return err
+}
+
+// EnqueueWithTicket adds an event to the queue and returns its ticket, a sequence
+// number starting at 1 that increases in the order events enter the queue
+func (q *InMemoryEventQueue) EnqueueWithTicket(event models.ProductEvent) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return 0, ErrQueueClosed
+	}
+	if q.size == len(q.events) {
+		return 0, &QueueFullError{Depth: q.size, Capacity: len(q.events)}
 	}
+
+	q.events[(q.head+q.size)%len(q.events)] = event
+	q.size++
+	q.ticket++
+	q.wakeLocked()
+	return q.ticket, nil
 }
 
-// Dequeue retrieves an event from the queue
+// Dequeue retrieves an event from the queue without waiting, returning false
+// if the queue is empty
 func (q *InMemoryEventQueue) Dequeue() (models.ProductEvent, bool) {
-	event, ok := <-q.events
-	return event, ok
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.popLocked()
+}
+
+// DequeueWithContext retrieves an event from the queue, waiting for one to
+// arrive. It returns false if the context is cancelled first, or once the
+// queue is closed and empty.
+func (q *InMemoryEventQueue) DequeueWithContext(ctx context.Context) (models.ProductEvent, bool) {
+	for {
+		q.mu.Lock()
+		if event, ok := q.popLocked(); ok {
+			q.mu.Unlock()
+			return event, true
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return models.ProductEvent{}, false
+		}
+		available := q.available
+		q.mu.Unlock()
+
+		select {
+		case <-available:
+		case <-ctx.Done():
+			return models.ProductEvent{}, false
+		}
+	}
+}
+
+// popLocked removes the oldest event; the caller must hold q.mu
+func (q *InMemoryEventQueue) popLocked() (models.ProductEvent, bool) {
+	if q.size == 0 {
+		return models.ProductEvent{}, false
+	}
+
+	event := q.events[q.head]
+	// Clear the slot so the event can be garbage collected
+	q.events[q.head] = models.ProductEvent{}
+	q.head = (q.head + 1) % len(q.events)
+	q.size--
+	return event, true
+}
+
+// wakeLocked wakes every blocked dequeue; the caller must hold q.mu
+func (q *InMemoryEventQueue) wakeLocked() {
+	close(q.available)
+	q.available = make(chan struct{})
 }
 
-// Close closes the event queue
+// Peek returns up to max buffered events in dequeue order without removing
+// them; a max of zero or less returns them all
+func (q *InMemoryEventQueue) Peek(max int) []models.ProductEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := q.size
+	if max > 0 && max < count {
+		count = max
+	}
+	events := make([]models.ProductEvent, count)
+	for i := range events {
+		events[i] = q.events[(q.head+i)%len(q.events)]
+	}
+	return events
+}
+
+// Len returns the number of events currently buffered in the queue
+func (q *InMemoryEventQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Cap returns the capacity of the queue
+func (q *InMemoryEventQueue) Cap() int {
+	return len(q.events)
+}
+
+// Close stops the queue accepting events; events already buffered can still
+// be dequeued. Calling it again is a no-op.
 func (q *InMemoryEventQueue) Close() {
-	close(q.events)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.wakeLocked()
 }
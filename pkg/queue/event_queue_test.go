@@ -1,8 +1,12 @@
 package queue
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"product-service/internal/models"
 )
@@ -186,3 +190,177 @@ func TestInMemoryEventQueue_EventIntegrity(t *testing.T) {
 		t.Errorf("Expected Stock %d, got %d", originalEvent.Stock, dequeuedEvent.Stock)
 	}
 }
+
+func TestInMemoryEventQueue_QueueFullError(t *testing.T) {
+	q := NewInMemoryEventQueue(2)
+
+	q.Enqueue(models.ProductEvent{ProductID: "1", Price: 1.0, Stock: 1})
+	q.Enqueue(models.ProductEvent{ProductID: "2", Price: 2.0, Stock: 2})
+
+	err := q.Enqueue(models.ProductEvent{ProductID: "3", Price: 3.0, Stock: 3})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Expected errors.Is(err, ErrQueueFull), got %v", err)
+	}
+
+	var fullErr *QueueFullError
+	if !errors.As(err, &fullErr) {
+		t.Fatalf("Expected *QueueFullError, got %T", err)
+	}
+	if fullErr.Depth != 2 {
+		t.Errorf("Expected depth 2, got %d", fullErr.Depth)
+	}
+	if fullErr.Capacity != 2 {
+		t.Errorf("Expected capacity 2, got %d", fullErr.Capacity)
+	}
+}
+
+func TestInMemoryEventQueue_DequeueWithContext(t *testing.T) {
+	q := NewInMemoryEventQueue(10)
+
+	q.Enqueue(models.ProductEvent{ProductID: "1", Price: 1.0, Stock: 1})
+
+	event, ok := q.DequeueWithContext(context.Background())
+	if !ok || event.ProductID != "1" {
+		t.Errorf("Expected to dequeue event '1', got %+v (ok=%v)", event, ok)
+	}
+
+	// An empty queue returns once the context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, ok = q.DequeueWithContext(ctx)
+	if ok {
+		t.Error("Expected no event after context cancellation")
+	}
+}
+
+func TestInMemoryEventQueue_LenCap(t *testing.T) {
+	q := NewInMemoryEventQueue(5)
+
+	q.Enqueue(models.ProductEvent{ProductID: "1", Price: 1.0, Stock: 1})
+	q.Enqueue(models.ProductEvent{ProductID: "2", Price: 2.0, Stock: 2})
+
+	if q.Len() != 2 {
+		t.Errorf("Expected length 2, got %d", q.Len())
+	}
+	if q.Cap() != 5 {
+		t.Errorf("Expected capacity 5, got %d", q.Cap())
+	}
+}
+
+func TestInMemoryEventQueue_EnqueueWithTicket(t *testing.T) {
+	const producers = 10
+	const perProducer = 50
+	q := NewInMemoryEventQueue(producers * perProducer)
+
+	var mu sync.Mutex
+	tickets := make(map[string]int64)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(producer int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				id := fmt.Sprintf("%d-%d", producer, i)
+				ticket, err := q.EnqueueWithTicket(models.ProductEvent{ProductID: id})
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+					return
+				}
+				mu.Lock()
+				tickets[id] = ticket
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	// Tickets must be unique and increase in the order events leave the queue
+	seen := make(map[int64]bool)
+	var last int64
+	for i := 0; i < producers*perProducer; i++ {
+		event, _ := q.Dequeue()
+		ticket := tickets[event.ProductID]
+		if seen[ticket] {
+			t.Fatalf("Duplicate ticket %d", ticket)
+		}
+		seen[ticket] = true
+		if ticket <= last {
+			t.Fatalf("Expected ticket greater than %d, got %d", last, ticket)
+		}
+		last = ticket
+	}
+
+	if last != producers*perProducer {
+		t.Errorf("Expected last ticket %d, got %d", producers*perProducer, last)
+	}
+}
+
+func TestInMemoryEventQueue_EnqueueWithTicket_Full(t *testing.T) {
+	q := NewInMemoryEventQueue(1)
+
+	if ticket, _ := q.EnqueueWithTicket(models.ProductEvent{ProductID: "1"}); ticket != 1 {
+		t.Errorf("Expected first ticket 1, got %d", ticket)
+	}
+
+	ticket, err := q.EnqueueWithTicket(models.ProductEvent{ProductID: "2"})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	if ticket != 0 {
+		t.Errorf("Expected no ticket for a rejected event, got %d", ticket)
+	}
+}
+
+func TestInMemoryEventQueue_Peek(t *testing.T) {
+	q := NewInMemoryEventQueue(3)
+	peeker, ok := q.(Peeker)
+	if !ok {
+		t.Fatal("Expected the in-memory queue to implement Peeker")
+	}
+
+	// Wrap around the end of the ring buffer
+	q.Enqueue(models.ProductEvent{ProductID: "0"})
+	q.Enqueue(models.ProductEvent{ProductID: "1"})
+	q.Dequeue()
+	q.Enqueue(models.ProductEvent{ProductID: "2"})
+	q.Enqueue(models.ProductEvent{ProductID: "3"})
+
+	events := peeker.Peek(0)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	for i, event := range events {
+		if expected := fmt.Sprint(i + 1); event.ProductID != expected {
+			t.Errorf("Expected event %d to be %s, got %s", i, expected, event.ProductID)
+		}
+	}
+	if limited := peeker.Peek(2); len(limited) != 2 || limited[1].ProductID != "2" {
+		t.Errorf("Expected the first 2 events, got %+v", limited)
+	}
+	if q.Len() != 3 {
+		t.Errorf("Expected peeking not to remove events, got length %d", q.Len())
+	}
+}
+
+func TestInMemoryEventQueue_CloseWakesDequeue(t *testing.T) {
+	q := NewInMemoryEventQueue(1)
+
+	done := make(chan bool)
+	go func() {
+		_, ok := q.DequeueWithContext(context.Background())
+		done <- ok
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Expected no event from a closed, empty queue")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Close to wake the blocked dequeue")
+	}
+}
@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"fmt"
+	"strings"
+
+	"product-service/internal/config"
+)
+
+// Queue backends selectable with QUEUE_BACKEND
+const (
+	BackendMemory = "memory"
+)
+
+// NewEventQueue creates the event queue backend selected by cfg.QueueBackend,
+// sized by cfg.QueueSize. An empty backend selects the in-memory queue.
+// Sizes above cfg.MaxQueueSize are rejected before anything is allocated.
+func NewEventQueue(cfg *config.Config) (EventQueue, error) {
+	if err := validateQueueSize(cfg.QueueSize, cfg.MaxQueueSize); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.QueueBackend)) {
+	case "", BackendMemory:
+		return NewInMemoryEventQueue(cfg.QueueSize), nil
+	default:
+		return nil, fmt.Errorf("%w %q, expected %q", ErrUnknownBackend, cfg.QueueBackend, BackendMemory)
+	}
+}
+
+// validateQueueSize checks a queue size against the configured maximum; a
+// maximum of zero or less leaves the size unbounded
+func validateQueueSize(size, max int) error {
+	if size < 0 {
+		return fmt.Errorf("%w: QUEUE_SIZE must not be negative, got %d", ErrInvalidQueueSize, size)
+	}
+	if max > 0 && size > max {
+		return fmt.Errorf("%w: QUEUE_SIZE %d exceeds MAX_QUEUE_SIZE %d; lower QUEUE_SIZE, or raise MAX_QUEUE_SIZE if the memory is really available",
+			ErrInvalidQueueSize, size, max)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"product-service/internal/config"
+)
+
+func TestNewEventQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+	}{
+		{"Memory", "memory"},
+		{"CaseInsensitive", "Memory"},
+		{"DefaultWhenEmpty", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := NewEventQueue(&config.Config{QueueBackend: test.backend, QueueSize: 5})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, ok := q.(*InMemoryEventQueue); !ok {
+				t.Errorf("Expected *InMemoryEventQueue, got %T", q)
+			}
+			if q.Cap() != 5 {
+				t.Errorf("Expected capacity 5, got %d", q.Cap())
+			}
+		})
+	}
+
+	t.Run("UnknownBackend", func(t *testing.T) {
+		q, err := NewEventQueue(&config.Config{QueueBackend: "carrier-pigeon", QueueSize: 5})
+		if !errors.Is(err, ErrUnknownBackend) {
+			t.Errorf("Expected ErrUnknownBackend, got %v", err)
+		}
+		if q != nil {
+			t.Errorf("Expected no queue, got %T", q)
+		}
+	})
+	t.Run("QueueSizeOverMax", func(t *testing.T) {
+		q, err := NewEventQueue(&config.Config{QueueBackend: "memory", QueueSize: 100000000000, MaxQueueSize: 1000000})
+		if !errors.Is(err, ErrInvalidQueueSize) {
+			t.Fatalf("Expected ErrInvalidQueueSize, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "QUEUE_SIZE 100000000000 exceeds MAX_QUEUE_SIZE 1000000") {
+			t.Errorf("Expected the sizes in the error, got %q", err.Error())
+		}
+		if q != nil {
+			t.Errorf("Expected no queue, got %T", q)
+		}
+	})
+
+	t.Run("NegativeQueueSize", func(t *testing.T) {
+		if _, err := NewEventQueue(&config.Config{QueueSize: -1}); !errors.Is(err, ErrInvalidQueueSize) {
+			t.Errorf("Expected ErrInvalidQueueSize, got %v", err)
+		}
+	})
+}
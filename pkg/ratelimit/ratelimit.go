@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrInvalidRate is returned for a rate that is not a positive, finite number
+var ErrInvalidRate = errors.New("rate must be a positive number")
+
+// Limiter paces operations to a steady rate
+//
+// Each call to Wait reserves the next slot, spaced 1/rate apart, so bursts
+// are smoothed out rather than let through and followed by a pause. Time
+// spent idle is not banked, so a quiet period never allows a burst later.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewLimiter creates a limiter allowing perSecond operations per second
+func NewLimiter(perSecond float64) (*Limiter, error) {
+	interval, err := intervalFor(perSecond)
+	if err != nil {
+		return nil, err
+	}
+	return &Limiter{interval: interval}, nil
+}
+
+// intervalFor converts a rate into the spacing between operations
+func intervalFor(perSecond float64) (time.Duration, error) {
+	if perSecond <= 0 || math.IsNaN(perSecond) || math.IsInf(perSecond, 0) {
+		return 0, ErrInvalidRate
+	}
+	interval := time.Duration(float64(time.Second) / perSecond)
+	if interval < 1 {
+		interval = 1
+	}
+	return interval, nil
+}
+
+// Wait blocks until the next slot, returning ctx.Err() if ctx is done first.
+// The slot is consumed either way, so cancelled callers still count toward the rate.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetRate changes the rate for slots reserved from now on
+func (l *Limiter) SetRate(perSecond float64) error {
+	interval, err := intervalFor(perSecond)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interval = interval
+	return nil
+}
+
+// Rate returns the current rate in operations per second
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return float64(time.Second) / float64(l.interval)
+}
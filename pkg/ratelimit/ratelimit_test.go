@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewLimiter_InvalidRate(t *testing.T) {
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := NewLimiter(rate); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("Expected ErrInvalidRate for %v, got %v", rate, err)
+		}
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	limiter, err := NewLimiter(100)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// The first slot is immediate and the other ten are 10ms apart
+	if elapsed := time.Since(start); elapsed < 95*time.Millisecond || elapsed > 300*time.Millisecond {
+		t.Errorf("Expected 11 waits at 100/sec to take about 100ms, took %v", elapsed)
+	}
+}
+
+func TestLimiter_WaitCancelled(t *testing.T) {
+	limiter, _ := NewLimiter(1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestLimiter_SetRate(t *testing.T) {
+	limiter, _ := NewLimiter(1)
+
+	if err := limiter.SetRate(-5); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("Expected ErrInvalidRate, got %v", err)
+	}
+	if err := limiter.SetRate(50); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rate := limiter.Rate(); rate != 50 {
+		t.Errorf("Expected rate 50, got %v", rate)
+	}
+}
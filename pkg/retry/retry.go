@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -13,6 +14,39 @@ type RetryConfig struct {
 	Multiplier   float64
 }
 
+// ExhaustedError is returned when an operation fails on every attempt
+type ExhaustedError struct {
+	Attempts int
+	LastErr  error
+}
+
+// Error implements the error interface
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("operation failed after %d attempts", e.Attempts)
+}
+
+// Unwrap returns the error from the final attempt
+func (e *ExhaustedError) Unwrap() error {
+	return e.LastErr
+}
+
+// AbortedError is returned when the context is done while waiting to retry an operation
+type AbortedError struct {
+	Attempts int
+	Err      error
+	LastErr  error
+}
+
+// Error implements the error interface
+func (e *AbortedError) Error() string {
+	return fmt.Sprintf("operation aborted after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the context error and the error from the final attempt
+func (e *AbortedError) Unwrap() []error {
+	return []error{e.Err, e.LastErr}
+}
+
 // DefaultRetryConfig returns a sensible default retry configuration
 func DefaultRetryConfig() *RetryConfig {
 	return &RetryConfig{
@@ -23,35 +57,39 @@ func DefaultRetryConfig() *RetryConfig {
 	}
 }
 
-// ExecuteWithRetry executes an operation with exponential backoff retry
-func (r *RetryConfig) ExecuteWithRetry(operation func() error) error {
-	delay := r.InitialDelay
-
-	for attempt := 1; attempt <= r.MaxAttempts; attempt++ {
-		if err := operation(); err == nil {
-			return nil
-		}
-
-		if attempt == r.MaxAttempts {
-			return fmt.Errorf("operation failed after %d attempts", r.MaxAttempts)
-		}
+// Clone returns an independent copy of the configuration
+func (r *RetryConfig) Clone() *RetryConfig {
+	clone := *r
+	return &clone
+}
 
-		time.Sleep(delay)
-		delay = time.Duration(float64(delay) * r.Multiplier)
-		if delay > r.MaxDelay {
-			delay = r.MaxDelay
-		}
-	}
+// ExecuteWithRetry executes an operation with exponential backoff retry.
+// The backoff is local to the call: every call starts again from
+// InitialDelay, so no penalty carries over from earlier failing operations.
+func (r *RetryConfig) ExecuteWithRetry(operation func() error) error {
+	return r.ExecuteWithRetryContext(context.Background(), operation)
+}
 
-	return nil
+// ExecuteWithRetryContext is ExecuteWithRetry, giving up with an AbortedError
+// as soon as ctx is done instead of waiting for the next attempt
+func (r *RetryConfig) ExecuteWithRetryContext(ctx context.Context, operation func() error) error {
+	return r.ExecuteWithRetryAndCallbackContext(ctx, operation, nil)
 }
 
 // ExecuteWithRetryAndCallback executes an operation with retry and calls a callback on each failure
 func (r *RetryConfig) ExecuteWithRetryAndCallback(operation func() error, onFailure func(attempt int, err error)) error {
+	return r.ExecuteWithRetryAndCallbackContext(context.Background(), operation, onFailure)
+}
+
+// ExecuteWithRetryAndCallbackContext is ExecuteWithRetryAndCallback, giving
+// up with an AbortedError as soon as ctx is done instead of waiting for the
+// next attempt. An attempt already running is never interrupted.
+func (r *RetryConfig) ExecuteWithRetryAndCallbackContext(ctx context.Context, operation func() error, onFailure func(attempt int, err error)) error {
 	delay := r.InitialDelay
 
 	for attempt := 1; attempt <= r.MaxAttempts; attempt++ {
-		if err := operation(); err == nil {
+		err := operation()
+		if err == nil {
 			return nil
 		}
 
@@ -60,10 +98,16 @@ func (r *RetryConfig) ExecuteWithRetryAndCallback(operation func() error, onFail
 		}
 
 		if attempt == r.MaxAttempts {
-			return fmt.Errorf("operation failed after %d attempts", r.MaxAttempts)
+			return &ExhaustedError{Attempts: r.MaxAttempts, LastErr: err}
 		}
 
-		time.Sleep(delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return &AbortedError{Attempts: attempt, Err: ctx.Err(), LastErr: err}
+		}
 		delay = time.Duration(float64(delay) * r.Multiplier)
 		if delay > r.MaxDelay {
 			delay = r.MaxDelay
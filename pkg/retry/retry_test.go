@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -251,3 +252,122 @@ func TestRetryConfig_MaxDelayCap(t *testing.T) {
 		t.Errorf("Expected elapsed time <= %v, got %v", maxReasonableTime, elapsed)
 	}
 }
+
+func TestRetryConfig_ExhaustedError_Unwrap(t *testing.T) {
+	config := &RetryConfig{
+		MaxAttempts:  2,
+		InitialDelay: 1 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+
+	sentinel := errors.New("downstream unavailable")
+	err := config.ExecuteWithRetry(func() error {
+		return sentinel
+	})
+
+	if !errors.Is(err, sentinel) {
+		t.Errorf("Expected exhausted error to wrap the last attempt's error, got %v", err)
+	}
+
+	var exhausted *ExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("Expected *ExhaustedError, got %T", err)
+	}
+	if exhausted.Attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", exhausted.Attempts)
+	}
+}
+
+func TestRetryConfig_Clone(t *testing.T) {
+	original := DefaultRetryConfig()
+	clone := original.Clone()
+
+	if *clone != *original {
+		t.Errorf("Expected clone %+v to equal original %+v", *clone, *original)
+	}
+
+	clone.MaxAttempts = 10
+	clone.InitialDelay = time.Second
+	if original.MaxAttempts != 3 {
+		t.Errorf("Expected original MaxAttempts to stay 3, got %d", original.MaxAttempts)
+	}
+	if original.InitialDelay != 100*time.Millisecond {
+		t.Errorf("Expected original InitialDelay to stay 100ms, got %v", original.InitialDelay)
+	}
+
+	original.Multiplier = 4
+	if clone.Multiplier != 2.0 {
+		t.Errorf("Expected clone Multiplier to stay 2.0, got %f", clone.Multiplier)
+	}
+}
+
+func TestRetryConfig_DelayResetsBetweenCalls(t *testing.T) {
+	config := &RetryConfig{
+		MaxAttempts:  3,
+		InitialDelay: 20 * time.Millisecond,
+		MaxDelay:     time.Second,
+		Multiplier:   10,
+	}
+
+	// failThen returns an operation failing failures times before succeeding
+	failThen := func(failures int) func() error {
+		return func() error {
+			if failures > 0 {
+				failures--
+				return errors.New("transient")
+			}
+			return nil
+		}
+	}
+
+	// Escalate the backoff to 200ms within one call
+	if err := config.ExecuteWithRetry(failThen(2)); err != nil {
+		t.Fatalf("Expected success on the third attempt, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		config.ExecuteWithRetry(failThen(0))
+	}
+
+	// The next failure waits the initial delay again, not the escalated one
+	start := time.Now()
+	if err := config.ExecuteWithRetry(failThen(1)); err != nil {
+		t.Fatalf("Expected success on retry, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 150*time.Millisecond {
+		t.Errorf("Expected the backoff to restart from 20ms, took %v", elapsed)
+	}
+}
+
+func TestRetryConfig_ExecuteWithRetryContext_Aborted(t *testing.T) {
+	config := &RetryConfig{
+		MaxAttempts:  5,
+		InitialDelay: 10 * time.Second,
+		MaxDelay:     time.Minute,
+		Multiplier:   2.0,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	testErr := errors.New("test error")
+	attempts := 0
+	start := time.Now()
+	err := config.ExecuteWithRetryContext(ctx, func() error {
+		attempts++
+		return testErr
+	})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the backoff to be cut short, took %v", elapsed)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+	var aborted *AbortedError
+	if !errors.As(err, &aborted) || aborted.Attempts != 1 {
+		t.Fatalf("Expected an AbortedError after 1 attempt, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, testErr) {
+		t.Errorf("Expected the error to wrap both the cancellation and the last failure, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"product-service/internal/models"
+)
+
+// TracerName identifies spans created by this service
+const TracerName = "product-service"
+
+// Exporters that can be selected with Setup
+const (
+	ExporterNone   = ""
+	ExporterStdout = "stdout"
+)
+
+// W3C trace context header names carried on events
+const (
+	headerTraceParent = "traceparent"
+	headerTraceState  = "tracestate"
+)
+
+// Setup installs a global tracer provider for the named exporter and returns
+// a function flushing it on shutdown. With ExporterNone the global no-op
+// provider is left in place, so instrumentation costs next to nothing.
+func Setup(exporter string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		var err error
+		if spanExporter, err = stdouttrace.New(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the service tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// eventCarrier exposes an event's trace fields as a propagation carrier
+type eventCarrier struct {
+	event *models.ProductEvent
+}
+
+// Get returns the value stored for key
+func (c eventCarrier) Get(key string) string {
+	switch key {
+	case headerTraceParent:
+		return c.event.TraceParent
+	case headerTraceState:
+		return c.event.TraceState
+	default:
+		return ""
+	}
+}
+
+// Set stores the value for key; keys other than the trace context headers are ignored
+func (c eventCarrier) Set(key, value string) {
+	switch key {
+	case headerTraceParent:
+		c.event.TraceParent = value
+	case headerTraceState:
+		c.event.TraceState = value
+	}
+}
+
+// Keys lists the keys the carrier holds
+func (c eventCarrier) Keys() []string {
+	return []string{headerTraceParent, headerTraceState}
+}
+
+// InjectEvent records the span context of ctx on the event so a worker can continue the trace
+func InjectEvent(ctx context.Context, event *models.ProductEvent) {
+	otel.GetTextMapPropagator().Inject(ctx, eventCarrier{event: event})
+}
+
+// ExtractEvent returns a context carrying the span context recorded on the event
+func ExtractEvent(ctx context.Context, event models.ProductEvent) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, eventCarrier{event: &event})
+}
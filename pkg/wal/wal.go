@@ -0,0 +1,243 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Common write-ahead log errors
+var (
+	ErrOutOfOrder = errors.New("write-ahead log entries are out of order")
+	ErrClosed     = errors.New("write-ahead log is closed")
+	ErrTornTail   = errors.New("write-ahead log ends with a partially written entry")
+)
+
+// logger reports recoveries made while opening a log
+var logger = log.New(os.Stdout, "[WAL] ", log.LstdFlags)
+
+// Entry is a single applied product mutation recorded in the log
+type Entry struct {
+	Sequence  uint64  `json:"seq"`
+	ProductID string  `json:"product_id"`
+	Price     float64 `json:"price"`
+	Stock     int     `json:"stock"`
+	Currency  string  `json:"currency,omitempty"`
+	// Deleted marks the removal of the product rather than an update
+	Deleted bool `json:"deleted,omitempty"`
+	// Time is when the entry was appended; entries written before it was recorded have none
+	Time time.Time `json:"ts"`
+}
+
+// WriteAheadLog is an append-only, totally ordered log of applied mutations
+//
+// Each entry is written as a JSON line and synced to disk before Append
+// returns, so callers can apply the mutation in memory only once it is durable.
+type WriteAheadLog struct {
+	mu       sync.Mutex
+	file     *os.File
+	sequence uint64
+}
+
+// Open opens (or creates) the log at path, resuming the sequence from any existing entries
+//
+// A final line that cannot be parsed is what a crash mid-append leaves behind,
+// so it is truncated away with a warning; corruption before it is an error.
+func Open(path string) (*WriteAheadLog, error) {
+	entries, size, err := read(path)
+	if errors.Is(err, ErrTornTail) {
+		if err := os.Truncate(path, size); err != nil {
+			return nil, fmt.Errorf("failed to truncate write-ahead log: %w", err)
+		}
+		logger.Printf("Warning: truncated partially written entry at offset %d of %s", size, path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+
+	// A last entry missing only its newline is complete, but the next append must not join it
+	if info, err := file.Stat(); err == nil && info.Size() < size {
+		if _, err := file.Write([]byte("\n")); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to terminate write-ahead log: %w", err)
+		}
+	}
+
+	w := &WriteAheadLog{file: file}
+	if len(entries) > 0 {
+		w.sequence = entries[len(entries)-1].Sequence
+	}
+	return w, nil
+}
+
+// Append durably records a mutation and returns the entry with its assigned sequence
+func (w *WriteAheadLog) Append(productID string, price float64, stock int) (Entry, error) {
+	return w.AppendWithCurrency(productID, price, stock, "")
+}
+
+// AppendWithCurrency durably records a mutation including the price currency
+func (w *WriteAheadLog) AppendWithCurrency(productID string, price float64, stock int, currency string) (Entry, error) {
+	return w.append(Entry{ProductID: productID, Price: price, Stock: stock, Currency: currency})
+}
+
+// AppendDelete durably records the removal of a product
+func (w *WriteAheadLog) AppendDelete(productID string) (Entry, error) {
+	return w.append(Entry{ProductID: productID, Deleted: true})
+}
+
+// append assigns the next sequence and append time to entry and durably records it
+func (w *WriteAheadLog) append(entry Entry) (Entry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return Entry{}, ErrClosed
+	}
+
+	entry.Sequence = w.sequence + 1
+	entry.Time = time.Now().UTC()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to encode write-ahead log entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := w.file.Write(line); err != nil {
+		return Entry{}, fmt.Errorf("failed to write write-ahead log entry: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return Entry{}, fmt.Errorf("failed to sync write-ahead log: %w", err)
+	}
+
+	w.sequence = entry.Sequence
+	return entry, nil
+}
+
+// Sequence returns the sequence number of the last appended entry
+func (w *WriteAheadLog) Sequence() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sequence
+}
+
+// Sync flushes the log file to disk, failing if the log is closed or the disk is unwritable
+func (w *WriteAheadLog) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return ErrClosed
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync write-ahead log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying log file
+func (w *WriteAheadLog) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// ReadAll reads every entry in the log at path, verifying they are in sequence order
+func ReadAll(path string) ([]Entry, error) {
+	entries, _, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// read reads every entry in the log at path along with the size of the
+// well-formed prefix. An unparsable final line is reported as ErrTornTail,
+// alongside the entries before it.
+func read(path string) ([]Entry, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var (
+		entries []Entry
+		size    int64
+		torn    error
+	)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			if torn == nil {
+				size++
+			}
+			continue
+		}
+		if torn != nil {
+			return nil, 0, torn
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Only corruption if another entry follows
+			torn = fmt.Errorf("invalid write-ahead log entry on line %d: %w", line, err)
+			continue
+		}
+		if len(entries) > 0 && entry.Sequence <= entries[len(entries)-1].Sequence {
+			return nil, 0, fmt.Errorf("%w: sequence %d follows %d", ErrOutOfOrder, entry.Sequence, entries[len(entries)-1].Sequence)
+		}
+		entries = append(entries, entry)
+		size += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read write-ahead log: %w", err)
+	}
+	if torn != nil {
+		return entries, size, fmt.Errorf("%w: %v", ErrTornTail, torn)
+	}
+
+	return entries, size, nil
+}
+
+// ReadFrom reads the entries in the log at path from sequence onward
+func ReadFrom(path string, sequence uint64) ([]Entry, error) {
+	return readMatching(path, func(entry Entry) bool { return entry.Sequence >= sequence })
+}
+
+// ReadSince reads the entries in the log at path appended at or after since;
+// entries without a recorded time are skipped
+func ReadSince(path string, since time.Time) ([]Entry, error) {
+	return readMatching(path, func(entry Entry) bool { return !entry.Time.IsZero() && !entry.Time.Before(since) })
+}
+
+// readMatching reads every entry in the log at path and keeps those matching keep
+func readMatching(path string, keep func(Entry) bool) ([]Entry, error) {
+	entries, err := ReadAll(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []Entry
+	for _, entry := range entries {
+		if keep(entry) {
+			matching = append(matching, entry)
+		}
+	}
+	return matching, nil
+}
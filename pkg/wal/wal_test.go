@@ -0,0 +1,195 @@
+package wal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteAheadLog_AppendAndReadAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	w, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error opening log, got %v", err)
+	}
+
+	w.Append("a", 1.5, 10)
+	w.Append("b", 2.5, 20)
+	w.Append("a", 3.5, 30)
+	w.Close()
+
+	entries, err := ReadAll(path)
+	if err != nil {
+		t.Fatalf("Expected no error reading log, got %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	for i, entry := range entries {
+		if entry.Sequence != uint64(i+1) {
+			t.Errorf("Expected sequence %d, got %d", i+1, entry.Sequence)
+		}
+	}
+	if entries[2].ProductID != "a" || entries[2].Price != 3.5 || entries[2].Stock != 30 {
+		t.Errorf("Expected last entry {a 3.5 30}, got %+v", entries[2])
+	}
+}
+
+func TestWriteAheadLog_ResumesSequence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	w, _ := Open(path)
+	w.Append("a", 1.0, 1)
+	w.Append("b", 2.0, 2)
+	w.Close()
+
+	// Reopening continues from the last recorded sequence
+	w, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error reopening log, got %v", err)
+	}
+	defer w.Close()
+
+	if w.Sequence() != 2 {
+		t.Errorf("Expected resumed sequence 2, got %d", w.Sequence())
+	}
+
+	entry, err := w.Append("c", 3.0, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entry.Sequence != 3 {
+		t.Errorf("Expected sequence 3, got %d", entry.Sequence)
+	}
+}
+
+func TestWriteAheadLog_AppendAfterClose(t *testing.T) {
+	w, _ := Open(filepath.Join(t.TempDir(), "events.wal"))
+	w.Close()
+
+	if _, err := w.Append("a", 1.0, 1); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestReadAll_OutOfOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+	content := `{"seq":1,"product_id":"a","price":1,"stock":1}
+{"seq":3,"product_id":"b","price":2,"stock":2}
+{"seq":2,"product_id":"c","price":3,"stock":3}
+`
+	os.WriteFile(path, []byte(content), 0644)
+
+	_, err := ReadAll(path)
+	if !errors.Is(err, ErrOutOfOrder) {
+		t.Errorf("Expected ErrOutOfOrder, got %v", err)
+	}
+}
+
+func TestReadAll_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+	os.WriteFile(path, []byte("not json\n"), 0644)
+
+	if _, err := ReadAll(path); err == nil {
+		t.Error("Expected error reading corrupt log")
+	}
+}
+
+func TestReadFromAndSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+	w, _ := Open(path)
+	w.Append("a", 1.0, 1)
+	w.Append("b", 2.0, 2)
+	mid := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	w.Append("c", 3.0, 3)
+	w.Close()
+
+	entries, err := ReadFrom(path, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 2 || entries[0].ProductID != "b" || entries[1].ProductID != "c" {
+		t.Errorf("Expected entries b and c from sequence 2, got %+v", entries)
+	}
+
+	entries, err = ReadSince(path, mid)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 1 || entries[0].ProductID != "c" {
+		t.Errorf("Expected only entry c since the midpoint, got %+v", entries)
+	}
+}
+
+func TestWriteAheadLog_TruncatesTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+
+	w, _ := Open(path)
+	w.Append("a", 1.0, 1)
+	w.Append("b", 2.0, 2)
+	w.Close()
+
+	// A crash mid-append leaves half a JSON line behind
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`{"seq":3,"product_id":"c","pri`)
+	file.Close()
+
+	if _, err := ReadAll(path); !errors.Is(err, ErrTornTail) {
+		t.Errorf("Expected ErrTornTail before reopening, got %v", err)
+	}
+
+	w, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected torn tail to be recovered, got %v", err)
+	}
+	if w.Sequence() != 2 {
+		t.Errorf("Expected resumed sequence 2, got %d", w.Sequence())
+	}
+	if _, err := w.Append("c", 3.0, 3); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	w.Close()
+
+	entries, err := ReadAll(path)
+	if err != nil {
+		t.Fatalf("Expected no error reading recovered log, got %v", err)
+	}
+	if len(entries) != 3 || entries[2].ProductID != "c" || entries[2].Sequence != 3 {
+		t.Errorf("Expected 3 entries ending with c at sequence 3, got %+v", entries)
+	}
+}
+
+func TestWriteAheadLog_CorruptBeforeTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+	content := `{"seq":1,"product_id":"a","price":1,"stock":1}
+not json
+{"seq":2,"product_id":"b","price":2,"stock":2}
+`
+	os.WriteFile(path, []byte(content), 0644)
+
+	if _, err := Open(path); err == nil || errors.Is(err, ErrTornTail) {
+		t.Errorf("Expected corruption before the last line to fail, got %v", err)
+	}
+}
+
+func TestWriteAheadLog_TerminatesUnterminatedEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.wal")
+	os.WriteFile(path, []byte(`{"seq":1,"product_id":"a","price":1,"stock":1}`), 0644)
+
+	w, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	w.Append("b", 2.0, 2)
+	w.Close()
+
+	entries, err := ReadAll(path)
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected 2 entries, got %d (%v)", len(entries), err)
+	}
+}